@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports (interfaces: CalendarImportService)
+//
+// Generated by this command:
+//
+//	mockgen -destination=../../adapters/mock/calendar_import_service_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports CalendarImportService
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	reflect "reflect"
+	time "time"
+
+	types "github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	model "github.com/mattermost/mattermost/server/public/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCalendarImportService is a mock of CalendarImportService interface.
+type MockCalendarImportService struct {
+	ctrl     *gomock.Controller
+	recorder *MockCalendarImportServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockCalendarImportServiceMockRecorder is the mock recorder for MockCalendarImportService.
+type MockCalendarImportServiceMockRecorder struct {
+	mock *MockCalendarImportService
+}
+
+// NewMockCalendarImportService creates a new mock instance.
+func NewMockCalendarImportService(ctrl *gomock.Controller) *MockCalendarImportService {
+	mock := &MockCalendarImportService{ctrl: ctrl}
+	mock.recorder = &MockCalendarImportServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCalendarImportService) EXPECT() *MockCalendarImportServiceMockRecorder {
+	return m.recorder
+}
+
+// Import mocks base method.
+func (m *MockCalendarImportService) Import(userID, channelID string, lead time.Duration, data []byte) (*types.ImportReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Import", userID, channelID, lead, data)
+	ret0, _ := ret[0].(*types.ImportReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Import indicates an expected call of Import.
+func (mr *MockCalendarImportServiceMockRecorder) Import(userID, channelID, lead, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Import", reflect.TypeOf((*MockCalendarImportService)(nil).Import), userID, channelID, lead, data)
+}
+
+// ImportAttachments mocks base method.
+func (m *MockCalendarImportService) ImportAttachments(post *model.Post) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ImportAttachments", post)
+}
+
+// ImportAttachments indicates an expected call of ImportAttachments.
+func (mr *MockCalendarImportServiceMockRecorder) ImportAttachments(post any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportAttachments", reflect.TypeOf((*MockCalendarImportService)(nil).ImportAttachments), post)
+}
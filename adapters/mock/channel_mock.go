@@ -67,3 +67,61 @@ func (mr *MockChannelServiceMockRecorder) MakeChannelLink(info any) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MakeChannelLink", reflect.TypeOf((*MockChannelService)(nil).MakeChannelLink), info)
 }
+
+// ResolveDestination mocks base method.
+func (m *MockChannelService) ResolveDestination(teamID, userID, channelName string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveDestination", teamID, userID, channelName)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveDestination indicates an expected call of ResolveDestination.
+func (mr *MockChannelServiceMockRecorder) ResolveDestination(teamID, userID, channelName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveDestination", reflect.TypeOf((*MockChannelService)(nil).ResolveDestination), teamID, userID, channelName)
+}
+
+// ResolveUserChannel mocks base method.
+func (m *MockChannelService) ResolveUserChannel(userID, channelName string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveUserChannel", userID, channelName)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveUserChannel indicates an expected call of ResolveUserChannel.
+func (mr *MockChannelServiceMockRecorder) ResolveUserChannel(userID, channelName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveUserChannel", reflect.TypeOf((*MockChannelService)(nil).ResolveUserChannel), userID, channelName)
+}
+
+// VerifyCanPost mocks base method.
+func (m *MockChannelService) VerifyCanPost(channelID, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyCanPost", channelID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyCanPost indicates an expected call of VerifyCanPost.
+func (mr *MockChannelServiceMockRecorder) VerifyCanPost(channelID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyCanPost", reflect.TypeOf((*MockChannelService)(nil).VerifyCanPost), channelID, userID)
+}
+
+// VerifyMembership mocks base method.
+func (m *MockChannelService) VerifyMembership(channelID, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyMembership", channelID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyMembership indicates an expected call of VerifyMembership.
+func (mr *MockChannelServiceMockRecorder) VerifyMembership(channelID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyMembership", reflect.TypeOf((*MockChannelService)(nil).VerifyMembership), channelID, userID)
+}
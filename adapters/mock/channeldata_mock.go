@@ -55,6 +55,51 @@ func (mr *MockChannelDataServiceMockRecorder) Get(channelID any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockChannelDataService)(nil).Get), channelID)
 }
 
+// GetByName mocks base method.
+func (m *MockChannelDataService) GetByName(teamID, channelName string, includeDeleted bool) (*model.Channel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByName", teamID, channelName, includeDeleted)
+	ret0, _ := ret[0].(*model.Channel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByName indicates an expected call of GetByName.
+func (mr *MockChannelDataServiceMockRecorder) GetByName(teamID, channelName, includeDeleted any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByName", reflect.TypeOf((*MockChannelDataService)(nil).GetByName), teamID, channelName, includeDeleted)
+}
+
+// GetDirect mocks base method.
+func (m *MockChannelDataService) GetDirect(userID1, userID2 string) (*model.Channel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDirect", userID1, userID2)
+	ret0, _ := ret[0].(*model.Channel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDirect indicates an expected call of GetDirect.
+func (mr *MockChannelDataServiceMockRecorder) GetDirect(userID1, userID2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDirect", reflect.TypeOf((*MockChannelDataService)(nil).GetDirect), userID1, userID2)
+}
+
+// GetMember mocks base method.
+func (m *MockChannelDataService) GetMember(channelID, userID string) (*model.ChannelMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMember", channelID, userID)
+	ret0, _ := ret[0].(*model.ChannelMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMember indicates an expected call of GetMember.
+func (mr *MockChannelDataServiceMockRecorder) GetMember(channelID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMember", reflect.TypeOf((*MockChannelDataService)(nil).GetMember), channelID, userID)
+}
+
 // ListMembers mocks base method.
 func (m *MockChannelDataService) ListMembers(channelID string, page, perPage int) ([]*model.ChannelMember, error) {
 	m.ctrl.T.Helper()
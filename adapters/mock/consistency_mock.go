@@ -0,0 +1,56 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports (interfaces: ConsistencyChecker)
+//
+// Generated by this command:
+//
+//	mockgen -destination=../../adapters/mock/consistency_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports ConsistencyChecker
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	reflect "reflect"
+
+	types "github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockConsistencyChecker is a mock of ConsistencyChecker interface.
+type MockConsistencyChecker struct {
+	ctrl     *gomock.Controller
+	recorder *MockConsistencyCheckerMockRecorder
+	isgomock struct{}
+}
+
+// MockConsistencyCheckerMockRecorder is the mock recorder for MockConsistencyChecker.
+type MockConsistencyCheckerMockRecorder struct {
+	mock *MockConsistencyChecker
+}
+
+// NewMockConsistencyChecker creates a new mock instance.
+func NewMockConsistencyChecker(ctrl *gomock.Controller) *MockConsistencyChecker {
+	mock := &MockConsistencyChecker{ctrl: ctrl}
+	mock.recorder = &MockConsistencyCheckerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockConsistencyChecker) EXPECT() *MockConsistencyCheckerMockRecorder {
+	return m.recorder
+}
+
+// Repair mocks base method.
+func (m *MockConsistencyChecker) Repair() (*types.ConsistencyReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Repair")
+	ret0, _ := ret[0].(*types.ConsistencyReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Repair indicates an expected call of Repair.
+func (mr *MockConsistencyCheckerMockRecorder) Repair() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Repair", reflect.TypeOf((*MockConsistencyChecker)(nil).Repair))
+}
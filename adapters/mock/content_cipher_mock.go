@@ -0,0 +1,100 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports (interfaces: ContentCipher)
+//
+// Generated by this command:
+//
+//	mockgen -destination=../../adapters/mock/content_cipher_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports ContentCipher
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	reflect "reflect"
+
+	types "github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockContentCipher is a mock of ContentCipher interface.
+type MockContentCipher struct {
+	ctrl     *gomock.Controller
+	recorder *MockContentCipherMockRecorder
+	isgomock struct{}
+}
+
+// MockContentCipherMockRecorder is the mock recorder for MockContentCipher.
+type MockContentCipherMockRecorder struct {
+	mock *MockContentCipher
+}
+
+// NewMockContentCipher creates a new mock instance.
+func NewMockContentCipher(ctrl *gomock.Controller) *MockContentCipher {
+	mock := &MockContentCipher{ctrl: ctrl}
+	mock.recorder = &MockContentCipherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockContentCipher) EXPECT() *MockContentCipherMockRecorder {
+	return m.recorder
+}
+
+// Decrypt mocks base method.
+func (m *MockContentCipher) Decrypt(enc *types.EncryptedContent) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Decrypt", enc)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Decrypt indicates an expected call of Decrypt.
+func (mr *MockContentCipherMockRecorder) Decrypt(enc any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Decrypt", reflect.TypeOf((*MockContentCipher)(nil).Decrypt), enc)
+}
+
+// Encrypt mocks base method.
+func (m *MockContentCipher) Encrypt(plaintext string) (*types.EncryptedContent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Encrypt", plaintext)
+	ret0, _ := ret[0].(*types.EncryptedContent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Encrypt indicates an expected call of Encrypt.
+func (mr *MockContentCipherMockRecorder) Encrypt(plaintext any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Encrypt", reflect.TypeOf((*MockContentCipher)(nil).Encrypt), plaintext)
+}
+
+// KeyID mocks base method.
+func (m *MockContentCipher) KeyID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KeyID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// KeyID indicates an expected call of KeyID.
+func (mr *MockContentCipherMockRecorder) KeyID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KeyID", reflect.TypeOf((*MockContentCipher)(nil).KeyID))
+}
+
+// Rewrap mocks base method.
+func (m *MockContentCipher) Rewrap(enc *types.EncryptedContent) (*types.EncryptedContent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rewrap", enc)
+	ret0, _ := ret[0].(*types.EncryptedContent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rewrap indicates an expected call of Rewrap.
+func (mr *MockContentCipherMockRecorder) Rewrap(enc any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rewrap", reflect.TypeOf((*MockContentCipher)(nil).Rewrap), enc)
+}
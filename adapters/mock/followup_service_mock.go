@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports (interfaces: FollowupService)
+//
+// Generated by this command:
+//
+//	mockgen -destination=../../adapters/mock/followup_service_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports FollowupService
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	reflect "reflect"
+
+	model "github.com/mattermost/mattermost/server/public/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFollowupService is a mock of FollowupService interface.
+type MockFollowupService struct {
+	ctrl     *gomock.Controller
+	recorder *MockFollowupServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockFollowupServiceMockRecorder is the mock recorder for MockFollowupService.
+type MockFollowupServiceMockRecorder struct {
+	mock *MockFollowupService
+}
+
+// NewMockFollowupService creates a new mock instance.
+func NewMockFollowupService(ctrl *gomock.Controller) *MockFollowupService {
+	mock := &MockFollowupService{ctrl: ctrl}
+	mock.recorder = &MockFollowupServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFollowupService) EXPECT() *MockFollowupServiceMockRecorder {
+	return m.recorder
+}
+
+// Build mocks base method.
+func (m *MockFollowupService) Build(args *model.CommandArgs, text string) *model.CommandResponse {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Build", args, text)
+	ret0, _ := ret[0].(*model.CommandResponse)
+	return ret0
+}
+
+// Build indicates an expected call of Build.
+func (mr *MockFollowupServiceMockRecorder) Build(args, text any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Build", reflect.TypeOf((*MockFollowupService)(nil).Build), args, text)
+}
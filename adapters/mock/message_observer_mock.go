@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports (interfaces: MessageObserver)
+//
+// Generated by this command:
+//
+//	mockgen -destination=../../adapters/mock/message_observer_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports MessageObserver
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	reflect "reflect"
+
+	types "github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMessageObserver is a mock of MessageObserver interface.
+type MockMessageObserver struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessageObserverMockRecorder
+	isgomock struct{}
+}
+
+// MockMessageObserverMockRecorder is the mock recorder for MockMessageObserver.
+type MockMessageObserverMockRecorder struct {
+	mock *MockMessageObserver
+}
+
+// NewMockMessageObserver creates a new mock instance.
+func NewMockMessageObserver(ctrl *gomock.Controller) *MockMessageObserver {
+	mock := &MockMessageObserver{ctrl: ctrl}
+	mock.recorder = &MockMessageObserverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMessageObserver) EXPECT() *MockMessageObserverMockRecorder {
+	return m.recorder
+}
+
+// MessageScheduled mocks base method.
+func (m *MockMessageObserver) MessageScheduled(msg *types.ScheduledMessage) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "MessageScheduled", msg)
+}
+
+// MessageScheduled indicates an expected call of MessageScheduled.
+func (mr *MockMessageObserverMockRecorder) MessageScheduled(msg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MessageScheduled", reflect.TypeOf((*MockMessageObserver)(nil).MessageScheduled), msg)
+}
+
+// MessageUnscheduled mocks base method.
+func (m *MockMessageObserver) MessageUnscheduled(msgID string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "MessageUnscheduled", msgID)
+}
+
+// MessageUnscheduled indicates an expected call of MessageUnscheduled.
+func (mr *MockMessageObserverMockRecorder) MessageUnscheduled(msgID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MessageUnscheduled", reflect.TypeOf((*MockMessageObserver)(nil).MessageUnscheduled), msgID)
+}
@@ -0,0 +1,125 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports (interfaces: Metrics)
+//
+// Generated by this command:
+//
+//	mockgen -destination=../../adapters/mock/metrics_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports Metrics
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMetrics is a mock of Metrics interface.
+type MockMetrics struct {
+	ctrl     *gomock.Controller
+	recorder *MockMetricsMockRecorder
+	isgomock struct{}
+}
+
+// MockMetricsMockRecorder is the mock recorder for MockMetrics.
+type MockMetricsMockRecorder struct {
+	mock *MockMetrics
+}
+
+// NewMockMetrics creates a new mock instance.
+func NewMockMetrics(ctrl *gomock.Controller) *MockMetrics {
+	mock := &MockMetrics{ctrl: ctrl}
+	mock.recorder = &MockMetricsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMetrics) EXPECT() *MockMetricsMockRecorder {
+	return m.recorder
+}
+
+// IncDeferred mocks base method.
+func (m *MockMetrics) IncDeferred() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "IncDeferred")
+}
+
+// IncDeferred indicates an expected call of IncDeferred.
+func (mr *MockMetricsMockRecorder) IncDeferred() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncDeferred", reflect.TypeOf((*MockMetrics)(nil).IncDeferred))
+}
+
+// IncDelivered mocks base method.
+func (m *MockMetrics) IncDelivered() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "IncDelivered")
+}
+
+// IncDelivered indicates an expected call of IncDelivered.
+func (mr *MockMetricsMockRecorder) IncDelivered() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncDelivered", reflect.TypeOf((*MockMetrics)(nil).IncDelivered))
+}
+
+// IncDeliveryFailed mocks base method.
+func (m *MockMetrics) IncDeliveryFailed() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "IncDeliveryFailed")
+}
+
+// IncDeliveryFailed indicates an expected call of IncDeliveryFailed.
+func (mr *MockMetricsMockRecorder) IncDeliveryFailed() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncDeliveryFailed", reflect.TypeOf((*MockMetrics)(nil).IncDeliveryFailed))
+}
+
+// IncKVError mocks base method.
+func (m *MockMetrics) IncKVError(op string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "IncKVError", op)
+}
+
+// IncKVError indicates an expected call of IncKVError.
+func (mr *MockMetricsMockRecorder) IncKVError(op any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncKVError", reflect.TypeOf((*MockMetrics)(nil).IncKVError), op)
+}
+
+// ObserveLateness mocks base method.
+func (m *MockMetrics) ObserveLateness(d time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ObserveLateness", d)
+}
+
+// ObserveLateness indicates an expected call of ObserveLateness.
+func (mr *MockMetricsMockRecorder) ObserveLateness(d any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ObserveLateness", reflect.TypeOf((*MockMetrics)(nil).ObserveLateness), d)
+}
+
+// ObserveTickDuration mocks base method.
+func (m *MockMetrics) ObserveTickDuration(d time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ObserveTickDuration", d)
+}
+
+// ObserveTickDuration indicates an expected call of ObserveTickDuration.
+func (mr *MockMetricsMockRecorder) ObserveTickDuration(d any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ObserveTickDuration", reflect.TypeOf((*MockMetrics)(nil).ObserveTickDuration), d)
+}
+
+// SetPendingMessages mocks base method.
+func (m *MockMetrics) SetPendingMessages(n int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetPendingMessages", n)
+}
+
+// SetPendingMessages indicates an expected call of SetPendingMessages.
+func (mr *MockMetricsMockRecorder) SetPendingMessages(n any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPendingMessages", reflect.TypeOf((*MockMetrics)(nil).SetPendingMessages), n)
+}
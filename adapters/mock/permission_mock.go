@@ -0,0 +1,69 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports (interfaces: PermissionService)
+//
+// Generated by this command:
+//
+//	mockgen -destination=../../adapters/mock/permission_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports PermissionService
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	reflect "reflect"
+
+	model "github.com/mattermost/mattermost/server/public/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPermissionService is a mock of PermissionService interface.
+type MockPermissionService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPermissionServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockPermissionServiceMockRecorder is the mock recorder for MockPermissionService.
+type MockPermissionServiceMockRecorder struct {
+	mock *MockPermissionService
+}
+
+// NewMockPermissionService creates a new mock instance.
+func NewMockPermissionService(ctrl *gomock.Controller) *MockPermissionService {
+	mock := &MockPermissionService{ctrl: ctrl}
+	mock.recorder = &MockPermissionServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPermissionService) EXPECT() *MockPermissionServiceMockRecorder {
+	return m.recorder
+}
+
+// HasPermissionTo mocks base method.
+func (m *MockPermissionService) HasPermissionTo(userID string, permission *model.Permission) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasPermissionTo", userID, permission)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// HasPermissionTo indicates an expected call of HasPermissionTo.
+func (mr *MockPermissionServiceMockRecorder) HasPermissionTo(userID, permission any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasPermissionTo", reflect.TypeOf((*MockPermissionService)(nil).HasPermissionTo), userID, permission)
+}
+
+// HasPermissionToChannel mocks base method.
+func (m *MockPermissionService) HasPermissionToChannel(userID, channelID string, permission *model.Permission) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasPermissionToChannel", userID, channelID, permission)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// HasPermissionToChannel indicates an expected call of HasPermissionToChannel.
+func (mr *MockPermissionServiceMockRecorder) HasPermissionToChannel(userID, channelID, permission any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasPermissionToChannel", reflect.TypeOf((*MockPermissionService)(nil).HasPermissionToChannel), userID, channelID, permission)
+}
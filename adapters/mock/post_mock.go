@@ -68,6 +68,36 @@ func (mr *MockPostServiceMockRecorder) DM(botID, userID, post any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DM", reflect.TypeOf((*MockPostService)(nil).DM), botID, userID, post)
 }
 
+// GetPost mocks base method.
+func (m *MockPostService) GetPost(postID string) (*model.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPost", postID)
+	ret0, _ := ret[0].(*model.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPost indicates an expected call of GetPost.
+func (mr *MockPostServiceMockRecorder) GetPost(postID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPost", reflect.TypeOf((*MockPostService)(nil).GetPost), postID)
+}
+
+// GetPostThread mocks base method.
+func (m *MockPostService) GetPostThread(postID string) (*model.PostList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostThread", postID)
+	ret0, _ := ret[0].(*model.PostList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostThread indicates an expected call of GetPostThread.
+func (mr *MockPostServiceMockRecorder) GetPostThread(postID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostThread", reflect.TypeOf((*MockPostService)(nil).GetPostThread), postID)
+}
+
 // SendEphemeralPost mocks base method.
 func (m *MockPostService) SendEphemeralPost(userID string, post *model.Post) {
 	m.ctrl.T.Helper()
@@ -53,3 +53,17 @@ func (mr *MockScheduleServiceMockRecorder) Build(args, text any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Build", reflect.TypeOf((*MockScheduleService)(nil).Build), args, text)
 }
+
+// BuildForward mocks base method.
+func (m *MockScheduleService) BuildForward(args *model.CommandArgs, text string) *model.CommandResponse {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuildForward", args, text)
+	ret0, _ := ret[0].(*model.CommandResponse)
+	return ret0
+}
+
+// BuildForward indicates an expected call of BuildForward.
+func (mr *MockScheduleServiceMockRecorder) BuildForward(args, text any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildForward", reflect.TypeOf((*MockScheduleService)(nil).BuildForward), args, text)
+}
@@ -11,6 +11,7 @@ package mock
 
 import (
 	reflect "reflect"
+	time "time"
 
 	types "github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
 	gomock "go.uber.org/mock/gomock"
@@ -40,6 +41,72 @@ func (m *MockScheduler) EXPECT() *MockSchedulerMockRecorder {
 	return m.recorder
 }
 
+// LastTick mocks base method.
+func (m *MockScheduler) LastTick() time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LastTick")
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// LastTick indicates an expected call of LastTick.
+func (mr *MockSchedulerMockRecorder) LastTick() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastTick", reflect.TypeOf((*MockScheduler)(nil).LastTick))
+}
+
+// MessageScheduled mocks base method.
+func (m *MockScheduler) MessageScheduled(msg *types.ScheduledMessage) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "MessageScheduled", msg)
+}
+
+// MessageScheduled indicates an expected call of MessageScheduled.
+func (mr *MockSchedulerMockRecorder) MessageScheduled(msg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MessageScheduled", reflect.TypeOf((*MockScheduler)(nil).MessageScheduled), msg)
+}
+
+// MessageUnscheduled mocks base method.
+func (m *MockScheduler) MessageUnscheduled(msgID string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "MessageUnscheduled", msgID)
+}
+
+// MessageUnscheduled indicates an expected call of MessageUnscheduled.
+func (mr *MockSchedulerMockRecorder) MessageUnscheduled(msgID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MessageUnscheduled", reflect.TypeOf((*MockScheduler)(nil).MessageUnscheduled), msgID)
+}
+
+// NextTick mocks base method.
+func (m *MockScheduler) NextTick() time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NextTick")
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// NextTick indicates an expected call of NextTick.
+func (mr *MockSchedulerMockRecorder) NextTick() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NextTick", reflect.TypeOf((*MockScheduler)(nil).NextTick))
+}
+
+// Policy mocks base method.
+func (m *MockScheduler) Policy() types.DeliveryPolicy {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Policy")
+	ret0, _ := ret[0].(types.DeliveryPolicy)
+	return ret0
+}
+
+// Policy indicates an expected call of Policy.
+func (mr *MockSchedulerMockRecorder) Policy() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Policy", reflect.TypeOf((*MockScheduler)(nil).Policy))
+}
+
 // SendNow mocks base method.
 func (m *MockScheduler) SendNow(msg *types.ScheduledMessage) error {
 	m.ctrl.T.Helper()
@@ -54,6 +121,18 @@ func (mr *MockSchedulerMockRecorder) SendNow(msg any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendNow", reflect.TypeOf((*MockScheduler)(nil).SendNow), msg)
 }
 
+// SetPolicy mocks base method.
+func (m *MockScheduler) SetPolicy(policy types.DeliveryPolicy) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetPolicy", policy)
+}
+
+// SetPolicy indicates an expected call of SetPolicy.
+func (mr *MockSchedulerMockRecorder) SetPolicy(policy any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPolicy", reflect.TypeOf((*MockScheduler)(nil).SetPolicy), policy)
+}
+
 // Start mocks base method.
 func (m *MockScheduler) Start() {
 	m.ctrl.T.Helper()
@@ -67,9 +146,11 @@ func (mr *MockSchedulerMockRecorder) Start() *gomock.Call {
 }
 
 // Stop mocks base method.
-func (m *MockScheduler) Stop() {
+func (m *MockScheduler) Stop() error {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "Stop")
+	ret := m.ctrl.Call(m, "Stop")
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
 // Stop indicates an expected call of Stop.
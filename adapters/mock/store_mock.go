@@ -11,6 +11,7 @@ package mock
 
 import (
 	reflect "reflect"
+	time "time"
 
 	types "github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
 	gomock "go.uber.org/mock/gomock"
@@ -54,6 +55,35 @@ func (mr *MockStoreMockRecorder) CleanupMessageFromUserIndex(userID, msgID any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CleanupMessageFromUserIndex", reflect.TypeOf((*MockStore)(nil).CleanupMessageFromUserIndex), userID, msgID)
 }
 
+// DeferScheduledMessage mocks base method.
+func (m *MockStore) DeferScheduledMessage(msgID string, until time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeferScheduledMessage", msgID, until)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeferScheduledMessage indicates an expected call of DeferScheduledMessage.
+func (mr *MockStoreMockRecorder) DeferScheduledMessage(msgID, until any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeferScheduledMessage", reflect.TypeOf((*MockStore)(nil).DeferScheduledMessage), msgID, until)
+}
+
+// DeleteCalendarToken mocks base method.
+func (m *MockStore) DeleteCalendarToken(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCalendarToken", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCalendarToken indicates an expected call of DeleteCalendarToken.
+func (mr *MockStoreMockRecorder) DeleteCalendarToken(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCalendarToken", reflect.TypeOf((*MockStore)(nil).DeleteCalendarToken), userID)
+}
+
 // DeleteScheduledMessage mocks base method.
 func (m *MockStore) DeleteScheduledMessage(userID, msgID string) error {
 	m.ctrl.T.Helper()
@@ -68,6 +98,20 @@ func (mr *MockStoreMockRecorder) DeleteScheduledMessage(userID, msgID any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteScheduledMessage", reflect.TypeOf((*MockStore)(nil).DeleteScheduledMessage), userID, msgID)
 }
 
+// DeleteTemplate mocks base method.
+func (m *MockStore) DeleteTemplate(userID, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTemplate", userID, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTemplate indicates an expected call of DeleteTemplate.
+func (mr *MockStoreMockRecorder) DeleteTemplate(userID, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTemplate", reflect.TypeOf((*MockStore)(nil).DeleteTemplate), userID, name)
+}
+
 // GenerateMessageID mocks base method.
 func (m *MockStore) GenerateMessageID() string {
 	m.ctrl.T.Helper()
@@ -82,6 +126,51 @@ func (mr *MockStoreMockRecorder) GenerateMessageID() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateMessageID", reflect.TypeOf((*MockStore)(nil).GenerateMessageID))
 }
 
+// GetCalendarToken mocks base method.
+func (m *MockStore) GetCalendarToken(userID string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCalendarToken", userID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCalendarToken indicates an expected call of GetCalendarToken.
+func (mr *MockStoreMockRecorder) GetCalendarToken(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCalendarToken", reflect.TypeOf((*MockStore)(nil).GetCalendarToken), userID)
+}
+
+// GetCalendarTokenOwner mocks base method.
+func (m *MockStore) GetCalendarTokenOwner(token string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCalendarTokenOwner", token)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCalendarTokenOwner indicates an expected call of GetCalendarTokenOwner.
+func (mr *MockStoreMockRecorder) GetCalendarTokenOwner(token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCalendarTokenOwner", reflect.TypeOf((*MockStore)(nil).GetCalendarTokenOwner), token)
+}
+
+// GetReceiptPreference mocks base method.
+func (m *MockStore) GetReceiptPreference(userID string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReceiptPreference", userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReceiptPreference indicates an expected call of GetReceiptPreference.
+func (mr *MockStoreMockRecorder) GetReceiptPreference(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReceiptPreference", reflect.TypeOf((*MockStore)(nil).GetReceiptPreference), userID)
+}
+
 // GetScheduledMessage mocks base method.
 func (m *MockStore) GetScheduledMessage(msgID string) (*types.ScheduledMessage, error) {
 	m.ctrl.T.Helper()
@@ -97,6 +186,21 @@ func (mr *MockStoreMockRecorder) GetScheduledMessage(msgID any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduledMessage", reflect.TypeOf((*MockStore)(nil).GetScheduledMessage), msgID)
 }
 
+// GetTemplate mocks base method.
+func (m *MockStore) GetTemplate(userID, name string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTemplate", userID, name)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTemplate indicates an expected call of GetTemplate.
+func (mr *MockStoreMockRecorder) GetTemplate(userID, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTemplate", reflect.TypeOf((*MockStore)(nil).GetTemplate), userID, name)
+}
+
 // ListScheduledMessages mocks base method.
 func (m *MockStore) ListScheduledMessages() ([]*types.ScheduledMessage, error) {
 	m.ctrl.T.Helper()
@@ -112,6 +216,36 @@ func (mr *MockStoreMockRecorder) ListScheduledMessages() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduledMessages", reflect.TypeOf((*MockStore)(nil).ListScheduledMessages))
 }
 
+// ListTemplates mocks base method.
+func (m *MockStore) ListTemplates(userID string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTemplates", userID)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTemplates indicates an expected call of ListTemplates.
+func (mr *MockStoreMockRecorder) ListTemplates(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTemplates", reflect.TypeOf((*MockStore)(nil).ListTemplates), userID)
+}
+
+// ListUserIndexes mocks base method.
+func (m *MockStore) ListUserIndexes() (map[string][]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserIndexes")
+	ret0, _ := ret[0].(map[string][]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserIndexes indicates an expected call of ListUserIndexes.
+func (mr *MockStoreMockRecorder) ListUserIndexes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserIndexes", reflect.TypeOf((*MockStore)(nil).ListUserIndexes))
+}
+
 // ListUserMessageIDs mocks base method.
 func (m *MockStore) ListUserMessageIDs(userID string) ([]string, error) {
 	m.ctrl.T.Helper()
@@ -127,6 +261,65 @@ func (mr *MockStoreMockRecorder) ListUserMessageIDs(userID any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserMessageIDs", reflect.TypeOf((*MockStore)(nil).ListUserMessageIDs), userID)
 }
 
+// MarkScheduledMessageWarned mocks base method.
+func (m *MockStore) MarkScheduledMessageWarned(msgID string, at time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkScheduledMessageWarned", msgID, at)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkScheduledMessageWarned indicates an expected call of MarkScheduledMessageWarned.
+func (mr *MockStoreMockRecorder) MarkScheduledMessageWarned(msgID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkScheduledMessageWarned", reflect.TypeOf((*MockStore)(nil).MarkScheduledMessageWarned), msgID, at)
+}
+
+// NeedsReencryption mocks base method.
+func (m *MockStore) NeedsReencryption() (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NeedsReencryption")
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NeedsReencryption indicates an expected call of NeedsReencryption.
+func (mr *MockStoreMockRecorder) NeedsReencryption() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NeedsReencryption", reflect.TypeOf((*MockStore)(nil).NeedsReencryption))
+}
+
+// ReencryptMessages mocks base method.
+func (m *MockStore) ReencryptMessages() (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReencryptMessages")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReencryptMessages indicates an expected call of ReencryptMessages.
+func (mr *MockStoreMockRecorder) ReencryptMessages() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReencryptMessages", reflect.TypeOf((*MockStore)(nil).ReencryptMessages))
+}
+
+// RestoreMessageToUserIndex mocks base method.
+func (m *MockStore) RestoreMessageToUserIndex(userID, msgID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreMessageToUserIndex", userID, msgID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreMessageToUserIndex indicates an expected call of RestoreMessageToUserIndex.
+func (mr *MockStoreMockRecorder) RestoreMessageToUserIndex(userID, msgID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreMessageToUserIndex", reflect.TypeOf((*MockStore)(nil).RestoreMessageToUserIndex), userID, msgID)
+}
+
 // SaveScheduledMessage mocks base method.
 func (m *MockStore) SaveScheduledMessage(userID string, msg *types.ScheduledMessage) error {
 	m.ctrl.T.Helper()
@@ -140,3 +333,76 @@ func (mr *MockStoreMockRecorder) SaveScheduledMessage(userID, msg any) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveScheduledMessage", reflect.TypeOf((*MockStore)(nil).SaveScheduledMessage), userID, msg)
 }
+
+// SaveTemplate mocks base method.
+func (m *MockStore) SaveTemplate(userID, name, content string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveTemplate", userID, name, content)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveTemplate indicates an expected call of SaveTemplate.
+func (mr *MockStoreMockRecorder) SaveTemplate(userID, name, content any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveTemplate", reflect.TypeOf((*MockStore)(nil).SaveTemplate), userID, name, content)
+}
+
+// ScanScheduledMessages mocks base method.
+func (m *MockStore) ScanScheduledMessages() ([]*types.ScheduledMessage, []string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScanScheduledMessages")
+	ret0, _ := ret[0].([]*types.ScheduledMessage)
+	ret1, _ := ret[1].([]string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ScanScheduledMessages indicates an expected call of ScanScheduledMessages.
+func (mr *MockStoreMockRecorder) ScanScheduledMessages() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanScheduledMessages", reflect.TypeOf((*MockStore)(nil).ScanScheduledMessages))
+}
+
+// SetCalendarToken mocks base method.
+func (m *MockStore) SetCalendarToken(userID, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCalendarToken", userID, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCalendarToken indicates an expected call of SetCalendarToken.
+func (mr *MockStoreMockRecorder) SetCalendarToken(userID, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCalendarToken", reflect.TypeOf((*MockStore)(nil).SetCalendarToken), userID, token)
+}
+
+// SetReceiptPreference mocks base method.
+func (m *MockStore) SetReceiptPreference(userID string, enabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetReceiptPreference", userID, enabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetReceiptPreference indicates an expected call of SetReceiptPreference.
+func (mr *MockStoreMockRecorder) SetReceiptPreference(userID, enabled any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReceiptPreference", reflect.TypeOf((*MockStore)(nil).SetReceiptPreference), userID, enabled)
+}
+
+// SnoozeScheduledMessage mocks base method.
+func (m *MockStore) SnoozeScheduledMessage(msgID string, postAt time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SnoozeScheduledMessage", msgID, postAt)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SnoozeScheduledMessage indicates an expected call of SnoozeScheduledMessage.
+func (mr *MockStoreMockRecorder) SnoozeScheduledMessage(msgID, postAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SnoozeScheduledMessage", reflect.TypeOf((*MockStore)(nil).SnoozeScheduledMessage), msgID, postAt)
+}
@@ -13,6 +13,7 @@ import (
 	reflect "reflect"
 
 	model "github.com/mattermost/mattermost/server/public/model"
+	pluginapi "github.com/mattermost/mattermost/server/public/pluginapi"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -54,3 +55,23 @@ func (mr *MockTeamServiceMockRecorder) Get(teamID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockTeamService)(nil).Get), teamID)
 }
+
+// List mocks base method.
+func (m *MockTeamService) List(options ...pluginapi.TeamListOption) ([]*model.Team, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "List", varargs...)
+	ret0, _ := ret[0].([]*model.Team)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockTeamServiceMockRecorder) List(options ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockTeamService)(nil).List), varargs...)
+}
@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports (interfaces: TemplateService)
+//
+// Generated by this command:
+//
+//	mockgen -destination=../../adapters/mock/template_service_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports TemplateService
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	reflect "reflect"
+
+	model "github.com/mattermost/mattermost/server/public/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockTemplateService is a mock of TemplateService interface.
+type MockTemplateService struct {
+	ctrl     *gomock.Controller
+	recorder *MockTemplateServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockTemplateServiceMockRecorder is the mock recorder for MockTemplateService.
+type MockTemplateServiceMockRecorder struct {
+	mock *MockTemplateService
+}
+
+// NewMockTemplateService creates a new mock instance.
+func NewMockTemplateService(ctrl *gomock.Controller) *MockTemplateService {
+	mock := &MockTemplateService{ctrl: ctrl}
+	mock.recorder = &MockTemplateServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTemplateService) EXPECT() *MockTemplateServiceMockRecorder {
+	return m.recorder
+}
+
+// Build mocks base method.
+func (m *MockTemplateService) Build(userID, text string) *model.CommandResponse {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Build", userID, text)
+	ret0, _ := ret[0].(*model.CommandResponse)
+	return ret0
+}
+
+// Build indicates an expected call of Build.
+func (mr *MockTemplateServiceMockRecorder) Build(userID, text any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Build", reflect.TypeOf((*MockTemplateService)(nil).Build), userID, text)
+}
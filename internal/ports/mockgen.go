@@ -15,3 +15,18 @@ package ports
 //go:generate mockgen -destination=../../adapters/mock/scheduler_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports Scheduler
 //go:generate mockgen -destination=../../adapters/mock/list_service_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports ListService
 //go:generate mockgen -destination=../../adapters/mock/schedule_service_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports ScheduleService
+//go:generate mockgen -destination=../../adapters/mock/template_service_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports TemplateService
+//go:generate mockgen -destination=../../adapters/mock/file_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports FileService
+//go:generate mockgen -destination=../../adapters/mock/export_service_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports ExportService
+//go:generate mockgen -destination=../../adapters/mock/import_service_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports ImportService
+//go:generate mockgen -destination=../../adapters/mock/config_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports ConfigService
+//go:generate mockgen -destination=../../adapters/mock/calendar_service_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports CalendarService
+//go:generate mockgen -destination=../../adapters/mock/calendar_import_service_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports CalendarImportService
+//go:generate mockgen -destination=../../adapters/mock/receipt_service_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports ReceiptService
+//go:generate mockgen -destination=../../adapters/mock/followup_service_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports FollowupService
+//go:generate mockgen -destination=../../adapters/mock/permission_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports PermissionService
+//go:generate mockgen -destination=../../adapters/mock/metrics_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports Metrics
+//go:generate mockgen -destination=../../adapters/mock/admin_service_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports AdminService
+//go:generate mockgen -destination=../../adapters/mock/consistency_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports ConsistencyChecker
+//go:generate mockgen -destination=../../adapters/mock/content_cipher_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports ContentCipher
+//go:generate mockgen -destination=../../adapters/mock/message_observer_mock.go -package=mock github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports MessageObserver
@@ -1,6 +1,9 @@
 package ports
 
 import (
+	"io"
+	"time"
+
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/clock"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
 	"github.com/mattermost/mattermost/server/public/model"
@@ -18,10 +21,15 @@ type Logger interface {
 // Clock aliases the clock interface for shared use.
 type Clock = clock.Clock
 
+// TimerClock aliases the clock interface that also creates timers.
+type TimerClock = clock.TimerClock
+
 // PostService abstracts Mattermost post operations.
 type PostService interface {
 	CreatePost(post *model.Post) error
 	DM(botID, userID string, post *model.Post) error
+	GetPostThread(postID string) (*model.PostList, error)
+	GetPost(postID string) (*model.Post, error)
 	UpdateEphemeralPost(userID string, post *model.Post)
 	SendEphemeralPost(userID string, post *model.Post)
 }
@@ -38,17 +46,25 @@ type ChannelInfo struct {
 type ChannelService interface {
 	GetInfoOrUnknown(channelID string) *ChannelInfo
 	MakeChannelLink(info *ChannelInfo) string
+	ResolveDestination(teamID, userID, channelName string) (string, error)
+	ResolveUserChannel(userID, channelName string) (string, error)
+	VerifyMembership(channelID, userID string) error
+	VerifyCanPost(channelID, userID string) error
 }
 
 // ChannelDataService provides channel data access.
 type ChannelDataService interface {
 	Get(channelID string) (*model.Channel, error)
+	GetByName(teamID, channelName string, includeDeleted bool) (*model.Channel, error)
+	GetDirect(userID1, userID2 string) (*model.Channel, error)
+	GetMember(channelID, userID string) (*model.ChannelMember, error)
 	ListMembers(channelID string, page, perPage int) ([]*model.ChannelMember, error)
 }
 
 // TeamService provides team data access.
 type TeamService interface {
 	Get(teamID string) (*model.Team, error)
+	List(options ...pluginapi.TeamListOption) ([]*model.Team, error)
 }
 
 // SlashCommandService registers slash commands.
@@ -59,6 +75,26 @@ type SlashCommandService interface {
 // UserService fetches user data.
 type UserService interface {
 	Get(userID string) (*model.User, error)
+	GetByUsername(username string) (*model.User, error)
+}
+
+// PermissionService checks user permissions.
+type PermissionService interface {
+	HasPermissionTo(userID string, permission *model.Permission) bool
+	HasPermissionToChannel(userID, channelID string, permission *model.Permission) bool
+}
+
+// ConfigService reads the server configuration.
+type ConfigService interface {
+	GetConfig() *model.Config
+}
+
+// FileService uploads and reads post attachments.
+type FileService interface {
+	Upload(content io.Reader, fileName, channelID string) (*model.FileInfo, error)
+	Get(fileID string) (io.Reader, error)
+	GetInfo(fileID string) (*model.FileInfo, error)
+	CopyInfos(fileIDs []string, userID string) ([]string, error)
 }
 
 // KVService abstracts key-value storage.
@@ -89,17 +125,68 @@ type Store interface {
 	SaveScheduledMessage(userID string, msg *types.ScheduledMessage) error
 	DeleteScheduledMessage(userID string, msgID string) error
 	CleanupMessageFromUserIndex(userID string, msgID string) error
+	RestoreMessageToUserIndex(userID string, msgID string) error
 	GetScheduledMessage(msgID string) (*types.ScheduledMessage, error)
 	ListScheduledMessages() ([]*types.ScheduledMessage, error)
+	// ScanScheduledMessages is ListScheduledMessages that also returns the
+	// IDs of stored messages that could not be read, so callers can tell
+	// them apart from messages that do not exist.
+	ScanScheduledMessages() ([]*types.ScheduledMessage, []string, error)
 	ListUserMessageIDs(userID string) ([]string, error)
 	GenerateMessageID() string
+	SaveTemplate(userID, name, content string) error
+	GetTemplate(userID, name string) (string, error)
+	ListTemplates(userID string) (map[string]string, error)
+	DeleteTemplate(userID, name string) error
+	GetCalendarToken(userID string) (string, error)
+	GetCalendarTokenOwner(token string) (string, error)
+	SetCalendarToken(userID, token string) error
+	DeleteCalendarToken(userID string) error
+	GetReceiptPreference(userID string) (bool, error)
+	SetReceiptPreference(userID string, enabled bool) error
+	ListUserIndexes() (map[string][]string, error)
+	ReencryptMessages() (int, error)
+	NeedsReencryption() (bool, error)
+	DeferScheduledMessage(msgID string, until time.Time) (bool, error)
+	MarkScheduledMessageWarned(msgID string, at time.Time) (bool, error)
+	SnoozeScheduledMessage(msgID string, postAt time.Time) (bool, error)
+}
+
+// ContentCipher seals scheduled message content at rest.
+type ContentCipher interface {
+	KeyID() string
+	Encrypt(plaintext string) (*types.EncryptedContent, error)
+	Decrypt(enc *types.EncryptedContent) (string, error)
+	Rewrap(enc *types.EncryptedContent) (*types.EncryptedContent, error)
+}
+
+// Metrics records scheduler and store metrics.
+type Metrics interface {
+	SetPendingMessages(n int)
+	IncDelivered()
+	IncDeliveryFailed()
+	ObserveLateness(d time.Duration)
+	ObserveTickDuration(d time.Duration)
+	IncKVError(op string)
+	IncDeferred()
+}
+
+// MessageObserver is told when scheduled messages are saved or deleted.
+type MessageObserver interface {
+	MessageScheduled(msg *types.ScheduledMessage)
+	MessageUnscheduled(msgID string)
 }
 
 // Scheduler manages scheduled message delivery.
 type Scheduler interface {
+	MessageObserver
 	Start()
-	Stop()
+	Stop() error
+	SetPolicy(policy types.DeliveryPolicy)
+	Policy() types.DeliveryPolicy
 	SendNow(msg *types.ScheduledMessage) error
+	LastTick() time.Time
+	NextTick() time.Time
 }
 
 // ListService builds scheduled message lists.
@@ -107,7 +194,57 @@ type ListService interface {
 	Build(userID string) *model.CommandResponse
 }
 
+// TemplateService manages saved message templates.
+type TemplateService interface {
+	Build(userID, text string) *model.CommandResponse
+}
+
+// ExportService exports a user's scheduled messages to a file.
+type ExportService interface {
+	Build(userID, format string) *model.CommandResponse
+}
+
+// ImportService imports scheduled messages from an exported file.
+type ImportService interface {
+	Import(userID, format string, data []byte) (*types.ImportReport, error)
+}
+
+// CalendarImportService schedules event reminders from iCalendar files.
+type CalendarImportService interface {
+	Import(userID, channelID string, lead time.Duration, data []byte) (*types.ImportReport, error)
+	ImportAttachments(post *model.Post)
+}
+
+// CalendarService manages per-user calendar feeds.
+type CalendarService interface {
+	Build(userID, text string) *model.CommandResponse
+	Feed(token string) ([]byte, error)
+}
+
+// ReceiptService manages per-user delivery receipt preferences.
+type ReceiptService interface {
+	Build(userID, text string) *model.CommandResponse
+}
+
+// FollowupService sets follow-up reminders for threads.
+type FollowupService interface {
+	Build(args *model.CommandArgs, text string) *model.CommandResponse
+}
+
+// ConsistencyChecker finds and repairs drift between stored messages and
+// user indexes.
+type ConsistencyChecker interface {
+	Repair() (*types.ConsistencyReport, error)
+}
+
+// AdminService reports plugin health to system admins.
+type AdminService interface {
+	Build(userID, text string) *model.CommandResponse
+	Status() (*types.AdminStatus, error)
+}
+
 // ScheduleService schedules new messages.
 type ScheduleService interface {
 	Build(args *model.CommandArgs, text string) *model.CommandResponse
+	BuildForward(args *model.CommandArgs, text string) *model.CommandResponse
 }
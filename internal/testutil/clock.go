@@ -1,10 +1,144 @@
 // Package testutil provides helpers for tests.
 package testutil
 
-import "time"
+import (
+	"sync"
+	"time"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/clock"
+)
 
 // FakeClock is a controllable clock for tests.
 type FakeClock struct{ NowTime time.Time }
 
 // Now returns the configured time.
 func (f FakeClock) Now() time.Time { return f.NowTime }
+
+// ManualClock is a TimerClock for tests that only moves when Advance is
+// called. Timers and tickers fire as Advance passes their deadlines.
+type ManualClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*manualWaiter
+}
+
+// NewManualClock returns a ManualClock starting at now.
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+// Now returns the current fake time.
+func (m *ManualClock) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+// Advance moves the clock forward by d and fires every timer and ticker
+// that falls due. Like a real ticker, a ticker fires at most once per call.
+func (m *ManualClock) Advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = m.now.Add(d)
+	for _, w := range m.waiters {
+		w.fireIfDue(m.now)
+	}
+}
+
+// Waiters returns how many timers and tickers are waiting to fire, so a test
+// can tell when the code under test has started waiting.
+func (m *ManualClock) Waiters() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, w := range m.waiters {
+		if w.active {
+			n++
+		}
+	}
+	return n
+}
+
+// NewTimer creates a timer that fires once the clock reaches now+d.
+func (m *ManualClock) NewTimer(d time.Duration) clock.Timer {
+	return m.add(d, 0)
+}
+
+// NewTicker creates a ticker that fires every d of fake time.
+func (m *ManualClock) NewTicker(d time.Duration) clock.Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	return &manualTicker{m.add(d, d)}
+}
+
+func (m *ManualClock) add(d, period time.Duration) *manualWaiter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w := &manualWaiter{clock: m, c: make(chan time.Time, 1), period: period}
+	w.schedule(m.now, d)
+	m.waiters = append(m.waiters, w)
+	return w
+}
+
+type manualWaiter struct {
+	clock  *ManualClock
+	c      chan time.Time
+	at     time.Time
+	period time.Duration
+	active bool
+}
+
+func (w *manualWaiter) schedule(now time.Time, d time.Duration) {
+	w.at = now.Add(d)
+	w.active = true
+	w.fireIfDue(now)
+}
+
+func (w *manualWaiter) fireIfDue(now time.Time) {
+	if !w.active || w.at.After(now) {
+		return
+	}
+	select {
+	case w.c <- now:
+	default:
+	}
+	if w.period == 0 {
+		w.active = false
+		return
+	}
+	for !w.at.After(now) {
+		w.at = w.at.Add(w.period)
+	}
+}
+
+func (w *manualWaiter) C() <-chan time.Time { return w.c }
+
+func (w *manualWaiter) Stop() bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	wasActive := w.active
+	w.active = false
+	return wasActive
+}
+
+func (w *manualWaiter) Reset(d time.Duration) bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	wasActive := w.active
+	w.schedule(w.clock.now, d)
+	return wasActive
+}
+
+type manualTicker struct{ w *manualWaiter }
+
+func (t *manualTicker) C() <-chan time.Time { return t.w.C() }
+
+func (t *manualTicker) Stop() { t.w.Stop() }
+
+func (t *manualTicker) Reset(d time.Duration) {
+	t.w.clock.mu.Lock()
+	defer t.w.clock.mu.Unlock()
+	t.w.period = d
+	t.w.schedule(t.w.clock.now, d)
+}
@@ -0,0 +1,77 @@
+package testutil
+
+import (
+	"testing"
+	"time"
+)
+
+var manualStart = time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+func fired(c <-chan time.Time) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestManualClock_Timer(t *testing.T) {
+	clk := NewManualClock(manualStart)
+	timer := clk.NewTimer(time.Minute)
+
+	clk.Advance(59 * time.Second)
+	if fired(timer.C()) {
+		t.Fatal("timer fired early")
+	}
+	clk.Advance(time.Second)
+	if !fired(timer.C()) {
+		t.Fatal("timer did not fire when due")
+	}
+	if clk.Waiters() != 0 || timer.Stop() {
+		t.Fatal("fired timer is still active")
+	}
+	if !clk.Now().Equal(manualStart.Add(time.Minute)) {
+		t.Fatalf("Now() = %v", clk.Now())
+	}
+}
+
+func TestManualClock_TimerStopAndReset(t *testing.T) {
+	clk := NewManualClock(manualStart)
+	timer := clk.NewTimer(time.Second)
+
+	if !timer.Stop() {
+		t.Fatal("Stop() = false for an active timer")
+	}
+	clk.Advance(time.Minute)
+	if fired(timer.C()) {
+		t.Fatal("stopped timer fired")
+	}
+	timer.Reset(time.Second)
+	clk.Advance(time.Second)
+	if !fired(timer.C()) {
+		t.Fatal("reset timer did not fire")
+	}
+	if !fired(clk.NewTimer(0).C()) {
+		t.Fatal("zero duration timer did not fire immediately")
+	}
+}
+
+func TestManualClock_Ticker(t *testing.T) {
+	clk := NewManualClock(manualStart)
+	ticker := clk.NewTicker(time.Minute)
+
+	clk.Advance(3 * time.Minute)
+	if !fired(ticker.C()) || fired(ticker.C()) {
+		t.Fatal("ticker should fire once per Advance")
+	}
+	clk.Advance(time.Minute)
+	if !fired(ticker.C()) {
+		t.Fatal("ticker did not fire on the next period")
+	}
+	ticker.Stop()
+	clk.Advance(time.Hour)
+	if fired(ticker.C()) || clk.Waiters() != 0 {
+		t.Fatal("stopped ticker fired")
+	}
+}
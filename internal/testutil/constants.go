@@ -15,3 +15,8 @@ func SchedKey(id string) string {
 func IndexKey(userID string) string {
 	return fmt.Sprintf("%s%s", constants.UserIndexPrefix, userID)
 }
+
+// TemplateKey builds a user template key for tests.
+func TemplateKey(userID string) string {
+	return fmt.Sprintf("%s%s", constants.TemplatePrefix, userID)
+}
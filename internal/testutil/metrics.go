@@ -0,0 +1,27 @@
+package testutil
+
+import "time"
+
+// FakeMetrics is a no-op metrics recorder for tests.
+type FakeMetrics struct{}
+
+// SetPendingMessages is a no-op.
+func (FakeMetrics) SetPendingMessages(int) {}
+
+// IncDelivered is a no-op.
+func (FakeMetrics) IncDelivered() {}
+
+// IncDeliveryFailed is a no-op.
+func (FakeMetrics) IncDeliveryFailed() {}
+
+// ObserveLateness is a no-op.
+func (FakeMetrics) ObserveLateness(time.Duration) {}
+
+// ObserveTickDuration is a no-op.
+func (FakeMetrics) ObserveTickDuration(time.Duration) {}
+
+// IncKVError is a no-op.
+func (FakeMetrics) IncKVError(string) {}
+
+// IncDeferred is a no-op.
+func (FakeMetrics) IncDeferred() {}
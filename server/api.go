@@ -5,10 +5,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/command"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/formatter"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/metrics"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/transfer"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
 	"github.com/gorilla/mux"
 	"github.com/mattermost/mattermost/server/public/model"
@@ -18,10 +23,21 @@ import (
 func (p *Plugin) ServeHTTP(_ *plugin.Context, w http.ResponseWriter, r *http.Request) {
 	p.logger.Debug("Received HTTP request", "method", r.Method, "url", r.URL.String())
 	router := mux.NewRouter()
-	router.Use(p.MattermostAuthorizationRequired)
+	// Calendar clients cannot send Mattermost credentials, so the feed is
+	// authenticated by its unguessable per-user token instead.
+	router.HandleFunc(constants.CalendarRoutePrefix+"{token}"+constants.CalendarFileExtension, p.CalendarFeed).Methods(http.MethodGet)
 	api := router.PathPrefix("/api/v1").Subrouter()
+	api.Use(p.MattermostAuthorizationRequired)
 	api.HandleFunc("/delete", p.UserDeleteMessage).Methods(http.MethodPost)
 	api.HandleFunc("/send", p.UserSendMessage).Methods(http.MethodPost)
+	api.HandleFunc("/snooze", p.UserSnoozeMessage).Methods(http.MethodPost)
+	api.HandleFunc("/import", p.UserImportMessages).Methods(http.MethodPost)
+	api.HandleFunc("/import/calendar", p.UserImportCalendar).Methods(http.MethodPost)
+	admin := api.PathPrefix("").Subrouter()
+	admin.Use(p.SystemAdminRequired)
+	admin.HandleFunc("/metrics", p.ServeMetrics).Methods(http.MethodGet)
+	admin.HandleFunc("/admin/status", p.AdminStatus).Methods(http.MethodGet)
+	admin.HandleFunc("/admin/repair", p.AdminRepair).Methods(http.MethodPost)
 	router.ServeHTTP(w, r)
 }
 
@@ -39,6 +55,19 @@ func (p *Plugin) MattermostAuthorizationRequired(next http.Handler) http.Handler
 	})
 }
 
+func (p *Plugin) SystemAdminRequired(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Header.Get(constants.HTTPHeaderMattermostUserID)
+		p.logger.Debug("Checking system admin permission", "user_id", userID, "url", r.URL.String())
+		if !p.permissions.HasPermissionTo(userID, model.PermissionManageSystem) {
+			p.logger.Warn("Authorization failed: user is not a system admin", "user_id", userID, "url", r.URL.String())
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (p *Plugin) UserDeleteMessage(w http.ResponseWriter, r *http.Request) {
 	userID := r.Header.Get(constants.HTTPHeaderMattermostUserID)
 	p.logger.Debug("Handling UserDeleteMessage request", "user_id", userID)
@@ -54,12 +83,15 @@ func (p *Plugin) UserDeleteMessage(w http.ResponseWriter, r *http.Request) {
 
 	p.logger.Debug("Calling command layer UserDeleteMessage", "user_id", userID, "message_id", msgID)
 	deletedMsg, err := p.Command.UserDeleteMessage(userID, msgID)
-	args := &model.CommandArgs{
-		UserId: userID,
+	fromWarning := isWarningAction(req)
+	if !fromWarning {
+		args := &model.CommandArgs{
+			UserId: userID,
+		}
+		p.logger.Debug("Building updated ephemeral list", "user_id", userID)
+		updatedList := p.Command.BuildEphemeralList(args)
+		p.updateEphemeralPostWithList(userID, req.PostId, req.ChannelId, updatedList)
 	}
-	p.logger.Debug("Building updated ephemeral list", "user_id", userID)
-	updatedList := p.Command.BuildEphemeralList(args)
-	p.updateEphemeralPostWithList(userID, req.PostId, req.ChannelId, updatedList)
 	if err != nil {
 		p.logger.Error("Command layer failed to delete message", "user_id", userID, "message_id", msgID, "error", err)
 		http.Error(w, fmt.Sprintf("Failed to delete message: %v", err), http.StatusInternalServerError)
@@ -67,7 +99,11 @@ func (p *Plugin) UserDeleteMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	p.logger.Info("Successfully deleted message via command layer", "user_id", userID, "message_id", msgID)
-	p.sendDeletionConfirmation(userID, req.ChannelId, deletedMsg)
+	if fromWarning {
+		p.updateWarningPost(w, userID, formatter.FormatWarningCancelled(p.destinationLinks(deletedMsg)))
+	} else {
+		p.sendDeletionConfirmation(userID, req.ChannelId, deletedMsg)
+	}
 
 	p.logger.Debug("UserDeleteMessage request completed successfully", "user_id", userID, "message_id", msgID)
 }
@@ -87,10 +123,13 @@ func (p *Plugin) UserSendMessage(w http.ResponseWriter, r *http.Request) {
 
 	p.logger.Debug("Calling command layer UserSendMessage", "user_id", userID, "message_id", msgID)
 	msg, err := p.Command.UserSendMessage(userID, msgID)
+	fromWarning := isWarningAction(req)
 	if err != nil {
 		p.logger.Error("Command layer failed to validate send", "user_id", userID, "message_id", msgID, "error", err)
-		updatedList := p.Command.BuildEphemeralList(&model.CommandArgs{UserId: userID})
-		p.updateEphemeralPostWithList(userID, req.PostId, req.ChannelId, updatedList)
+		if !fromWarning {
+			updatedList := p.Command.BuildEphemeralList(&model.CommandArgs{UserId: userID})
+			p.updateEphemeralPostWithList(userID, req.PostId, req.ChannelId, updatedList)
+		}
 		http.Error(w, fmt.Sprintf("Failed to send message: %v", err), http.StatusInternalServerError)
 		p.sendSendError(userID, req.ChannelId, msgID, err)
 		return
@@ -99,9 +138,11 @@ func (p *Plugin) UserSendMessage(w http.ResponseWriter, r *http.Request) {
 	p.logger.Debug("Calling scheduler SendNow", "user_id", userID, "message_id", msgID)
 	sendErr := p.Scheduler.SendNow(msg)
 
-	p.logger.Debug("Building updated ephemeral list", "user_id", userID)
-	updatedList := p.Command.BuildEphemeralList(&model.CommandArgs{UserId: userID})
-	p.updateEphemeralPostWithList(userID, req.PostId, req.ChannelId, updatedList)
+	if !fromWarning {
+		p.logger.Debug("Building updated ephemeral list", "user_id", userID)
+		updatedList := p.Command.BuildEphemeralList(&model.CommandArgs{UserId: userID})
+		p.updateEphemeralPostWithList(userID, req.PostId, req.ChannelId, updatedList)
+	}
 
 	if sendErr != nil {
 		p.logger.Error("Failed to send message via scheduler", "user_id", userID, "message_id", msgID, "error", sendErr)
@@ -111,11 +152,163 @@ func (p *Plugin) UserSendMessage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	p.logger.Info("Successfully sent message via scheduler", "user_id", userID, "message_id", msgID)
-	p.sendSendConfirmation(userID, req.ChannelId, msg)
+	if fromWarning {
+		p.updateWarningPost(w, userID, formatter.FormatWarningSent(p.destinationLinks(msg)))
+	} else {
+		p.sendSendConfirmation(userID, req.ChannelId, msg)
+	}
 
 	p.logger.Debug("UserSendMessage request completed successfully", "user_id", userID, "message_id", msgID)
 }
 
+func (p *Plugin) UserSnoozeMessage(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get(constants.HTTPHeaderMattermostUserID)
+	p.logger.Debug("Handling UserSnoozeMessage request", "user_id", userID)
+
+	req, msgID, err := parseActionRequest(p, r, "snooze")
+	if err != nil {
+		p.logger.Error("Failed to parse snooze request", "user_id", userID, "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p.logger.Debug("Calling command layer UserSnoozeMessage", "user_id", userID, "message_id", msgID)
+	msg, err := p.Command.UserSnoozeMessage(userID, msgID)
+	if err != nil {
+		p.logger.Error("Command layer failed to snooze message", "user_id", userID, "message_id", msgID, "error", err)
+		http.Error(w, fmt.Sprintf("Failed to snooze message: %v", err), http.StatusInternalServerError)
+		p.sendSnoozeError(userID, req.ChannelId, msgID, err)
+		return
+	}
+	p.Scheduler.MessageScheduled(msg)
+
+	p.logger.Info("Successfully snoozed message", "user_id", userID, "message_id", msgID, "post_at", msg.PostAt)
+	loc, err := time.LoadLocation(msg.Timezone)
+	if err != nil {
+		p.logger.Warn("Failed to load timezone for snooze update, falling back to UTC", "user_id", userID, "message_id", msg.ID, "timezone", msg.Timezone, "error", err)
+		loc = time.UTC
+	}
+	p.updateWarningPost(w, userID, formatter.FormatWarningSnoozed(msg.PostAt.In(loc), p.destinationLinks(msg)))
+}
+
+func (p *Plugin) UserImportMessages(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get(constants.HTTPHeaderMattermostUserID)
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = transfer.FormatJSON
+	}
+	p.logger.Debug("Handling UserImportMessages request", "user_id", userID, "format", format)
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, constants.MaxImportBytes))
+	if err != nil {
+		p.logger.Error("Failed to read import request body", "user_id", userID, "error", err)
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	report, err := p.Importer.Import(userID, format, data)
+	if err != nil {
+		p.logger.Error("Failed to import messages", "user_id", userID, "format", format, "error", err)
+		http.Error(w, fmt.Sprintf("Failed to import messages: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	p.logger.Info("Imported scheduled messages", "user_id", userID, "imported", report.Imported, "rejected", report.Rejected)
+	w.Header().Set(constants.HTTPHeaderContentType, constants.ContentTypeJSON)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		p.logger.Error("Failed to write import report", "user_id", userID, "error", err)
+	}
+}
+
+func (p *Plugin) UserImportCalendar(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get(constants.HTTPHeaderMattermostUserID)
+	channelID := r.URL.Query().Get("channel_id")
+	p.logger.Debug("Handling UserImportCalendar request", "user_id", userID, "channel_id", channelID)
+
+	lead, err := command.ParseLeadTime(r.URL.Query().Get("lead"))
+	if err != nil {
+		p.logger.Debug("Invalid lead time in calendar import request", "user_id", userID, "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, constants.MaxImportBytes))
+	if err != nil {
+		p.logger.Error("Failed to read calendar import request body", "user_id", userID, "error", err)
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	report, err := p.CalendarImporter.Import(userID, channelID, lead, data)
+	if err != nil {
+		p.logger.Error("Failed to import calendar", "user_id", userID, "channel_id", channelID, "error", err)
+		http.Error(w, fmt.Sprintf("Failed to import calendar: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	p.logger.Info("Imported calendar reminders", "user_id", userID, "imported", report.Imported, "rejected", report.Rejected)
+	w.Header().Set(constants.HTTPHeaderContentType, constants.ContentTypeJSON)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		p.logger.Error("Failed to write calendar import report", "user_id", userID, "error", err)
+	}
+}
+
+func (p *Plugin) ServeMetrics(w http.ResponseWriter, _ *http.Request) {
+	p.logger.Debug("Handling ServeMetrics request")
+	w.Header().Set(constants.HTTPHeaderContentType, metrics.ContentType)
+	if _, err := p.metrics.WriteTo(w); err != nil {
+		p.logger.Error("Failed to write metrics", "error", err)
+	}
+}
+
+func (p *Plugin) AdminStatus(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get(constants.HTTPHeaderMattermostUserID)
+	p.logger.Debug("Handling AdminStatus request", "user_id", userID)
+	status, err := p.Admin.Status()
+	if err != nil {
+		p.logger.Error("Failed to build admin status", "user_id", userID, "error", err)
+		http.Error(w, fmt.Sprintf("Failed to build status: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(constants.HTTPHeaderContentType, constants.ContentTypeJSON)
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		p.logger.Error("Failed to write admin status", "user_id", userID, "error", err)
+	}
+}
+
+func (p *Plugin) AdminRepair(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get(constants.HTTPHeaderMattermostUserID)
+	p.logger.Info("Handling AdminRepair request", "user_id", userID)
+	report, err := p.Consistency.Repair()
+	if err != nil {
+		p.logger.Error("Failed to run consistency repair", "user_id", userID, "error", err)
+		http.Error(w, fmt.Sprintf("Failed to run repair: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(constants.HTTPHeaderContentType, constants.ContentTypeJSON)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		p.logger.Error("Failed to write consistency report", "user_id", userID, "error", err)
+	}
+}
+
+func (p *Plugin) CalendarFeed(w http.ResponseWriter, r *http.Request) {
+	p.logger.Debug("Handling CalendarFeed request", "remote_addr", r.RemoteAddr)
+	feed, err := p.Calendar.Feed(mux.Vars(r)["token"])
+	if errors.Is(err, command.ErrCalendarTokenNotFound) {
+		p.logger.Debug("Calendar feed not found")
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		p.logger.Error("Failed to render calendar feed", "error", err)
+		http.Error(w, "Failed to render calendar feed", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(constants.HTTPHeaderContentType, constants.ContentTypeCalendar)
+	if _, err := w.Write(feed); err != nil {
+		p.logger.Error("Failed to write calendar feed", "error", err)
+	}
+}
+
 func (p *Plugin) buildEphemeralListUpdate(userID, postID, channelID string, updatedList *model.CommandResponse) *model.Post {
 	p.logger.Debug("Building ephemeral post update structure", "user_id", userID, "post_id", postID, "channel_id", channelID)
 	post := &model.Post{
@@ -136,47 +329,60 @@ func (p *Plugin) buildEphemeralListUpdate(userID, postID, channelID string, upda
 }
 
 func parseDeleteRequest(p *Plugin, r *http.Request) (*model.PostActionIntegrationRequest, string, error) {
-	p.logger.Debug("Decoding JSON body for delete request")
+	return parseActionRequest(p, r, "delete")
+}
+
+func parseSendRequest(p *Plugin, r *http.Request) (*model.PostActionIntegrationRequest, string, error) {
+	return parseActionRequest(p, r, "send")
+}
+
+// parseActionRequest decodes an interactive button request and checks its
+// context names action and a message ID.
+func parseActionRequest(p *Plugin, r *http.Request, action string) (*model.PostActionIntegrationRequest, string, error) {
+	p.logger.Debug("Decoding JSON body for action request", "action", action)
 	var req model.PostActionIntegrationRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		p.logger.Error("Failed to decode JSON body", "error", err)
 		return nil, "", fmt.Errorf("invalid request body: %w", err)
 	}
 
-	p.logger.Debug("Validating delete request context", "context", req.Context)
-	action, actionOk := req.Context["action"].(string)
+	p.logger.Debug("Validating action request context", "action", action, "context", req.Context)
+	gotAction, actionOk := req.Context["action"].(string)
 	msgID, idOk := req.Context["id"].(string)
 
-	if !actionOk || action != "delete" || !idOk || msgID == "" {
-		err := errors.New("invalid delete request context: missing or invalid action/id")
-		p.logger.Error("Delete request context validation failed", "error", err, "action", action, "action_ok", actionOk, "msg_id", msgID, "id_ok", idOk)
+	if !actionOk || gotAction != action || !idOk || msgID == "" {
+		err := fmt.Errorf("invalid %s request context: missing or invalid action/id", action)
+		p.logger.Error("Action request context validation failed", "error", err, "action", gotAction, "action_ok", actionOk, "msg_id", msgID, "id_ok", idOk)
 		return nil, "", err
 	}
 
-	p.logger.Debug("Delete request parsed and validated successfully", "action", action, "message_id", msgID)
+	p.logger.Debug("Action request parsed and validated successfully", "action", action, "message_id", msgID)
 	return &req, msgID, nil
 }
 
-func parseSendRequest(p *Plugin, r *http.Request) (*model.PostActionIntegrationRequest, string, error) {
-	p.logger.Debug("Decoding JSON body for send request")
-	var req model.PostActionIntegrationRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		p.logger.Error("Failed to decode JSON body", "error", err)
-		return nil, "", fmt.Errorf("invalid request body: %w", err)
-	}
-
-	p.logger.Debug("Validating send request context", "context", req.Context)
-	action, actionOk := req.Context["action"].(string)
-	msgID, idOk := req.Context["id"].(string)
+// isWarningAction reports whether a button request came from a heads-up DM
+// rather than the scheduled message list.
+func isWarningAction(req *model.PostActionIntegrationRequest) bool {
+	source, _ := req.Context["source"].(string)
+	return source == constants.ActionSourceWarning
+}
 
-	if !actionOk || action != "send" || !idOk || msgID == "" {
-		err := errors.New("invalid send request context: missing or invalid action/id")
-		p.logger.Error("Send request context validation failed", "error", err, "action", action, "action_ok", actionOk, "msg_id", msgID, "id_ok", idOk)
-		return nil, "", err
+// updateWarningPost answers a heads-up button by replacing the heads-up
+// with outcome and removing its buttons.
+func (p *Plugin) updateWarningPost(w http.ResponseWriter, userID string, outcome string) {
+	p.logger.Debug("Updating heads-up post", "user_id", userID)
+	resp := &model.PostActionIntegrationResponse{
+		Update: &model.Post{
+			Message: outcome,
+			Props: map[string]any{
+				"attachments": []*model.MessageAttachment{},
+			},
+		},
+	}
+	w.Header().Set(constants.HTTPHeaderContentType, constants.ContentTypeJSON)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		p.logger.Error("Failed to write heads-up update", "user_id", userID, "error", err)
 	}
-
-	p.logger.Debug("Send request parsed and validated successfully", "action", action, "message_id", msgID)
-	return &req, msgID, nil
 }
 
 func (p *Plugin) updateEphemeralPostWithList(userID string, postID string, channelID string, updatedList *model.CommandResponse) {
@@ -195,7 +401,7 @@ func (p *Plugin) sendDeletionConfirmation(userID string, channelID string, delet
 	}
 	humanTime := deletedMsg.PostAt.In(loc).Format(constants.TimeLayout)
 	p.logger.Debug("Formatted time for confirmation message", "user_id", userID, "message_id", deletedMsg.ID, "formatted_time", humanTime, "location", loc.String())
-	channelInfo := p.Channel.MakeChannelLink(p.Channel.GetInfoOrUnknown(deletedMsg.ChannelID))
+	channelInfo := p.destinationLinks(deletedMsg)
 	confirmation := &model.Post{
 		UserId:    userID,
 		ChannelId: channelID,
@@ -206,6 +412,15 @@ func (p *Plugin) sendDeletionConfirmation(userID string, channelID string, delet
 	p.logger.Debug("Successfully sent ephemeral deletion confirmation", "user_id", userID, "channel_id", channelID, "message_id", deletedMsg.ID)
 }
 
+func (p *Plugin) destinationLinks(msg *types.ScheduledMessage) string {
+	destinations := msg.Destinations()
+	links := make([]string, 0, len(destinations))
+	for _, channelID := range destinations {
+		links = append(links, p.Channel.MakeChannelLink(p.Channel.GetInfoOrUnknown(channelID)))
+	}
+	return formatter.FormatDestinationList(links)
+}
+
 func (p *Plugin) sendDeletionError(userID string, channelID string, msgID string, err error) {
 	p.logger.Debug("Preparing deletion error message", "user_id", userID, "channel_id", channelID, "message_id", msgID, "error", err)
 	alert := &model.Post{
@@ -227,7 +442,7 @@ func (p *Plugin) sendSendConfirmation(userID string, channelID string, msg *type
 	}
 	humanTime := msg.PostAt.In(loc).Format(constants.TimeLayout)
 	p.logger.Debug("Formatted time for confirmation message", "user_id", userID, "message_id", msg.ID, "formatted_time", humanTime, "location", loc.String())
-	channelInfo := p.Channel.MakeChannelLink(p.Channel.GetInfoOrUnknown(msg.ChannelID))
+	channelInfo := p.destinationLinks(msg)
 	confirmation := &model.Post{
 		UserId:    userID,
 		ChannelId: channelID,
@@ -249,3 +464,14 @@ func (p *Plugin) sendSendError(userID string, channelID string, msgID string, er
 	p.poster.SendEphemeralPost(userID, alert)
 	p.logger.Debug("Successfully sent ephemeral send error", "user_id", userID, "channel_id", channelID, "message_id", msgID)
 }
+
+func (p *Plugin) sendSnoozeError(userID string, channelID string, msgID string, err error) {
+	p.logger.Debug("Preparing snooze error message", "user_id", userID, "channel_id", channelID, "message_id", msgID, "error", err)
+	alert := &model.Post{
+		UserId:    userID,
+		ChannelId: channelID,
+		Message:   fmt.Sprintf("%s Could not snooze message: %v", constants.EmojiError, err),
+	}
+	p.poster.SendEphemeralPost(userID, alert)
+	p.logger.Debug("Successfully sent ephemeral snooze error", "user_id", userID, "channel_id", channelID, "message_id", msgID)
+}
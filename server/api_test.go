@@ -14,7 +14,10 @@ import (
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/adapters/mock"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/testutil"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/command"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/formatter"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/metrics"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/stretchr/testify/assert"
@@ -27,6 +30,7 @@ var expectedAttachments = []*model.MessageAttachment{{Text: "dummy attachment da
 type mockCommand struct {
 	UserDeleteMessageFunc  func(userID, msgID string) (*types.ScheduledMessage, error)
 	UserSendMessageFunc    func(userID, msgID string) (*types.ScheduledMessage, error)
+	UserSnoozeMessageFunc  func(userID, msgID string) (*types.ScheduledMessage, error)
 	BuildEphemeralListFunc func(args *model.CommandArgs) *model.CommandResponse
 }
 
@@ -46,6 +50,12 @@ func (m *mockCommand) UserSendMessage(userID, msgID string) (*types.ScheduledMes
 	}
 	panic("UserSendMessageFunc not set")
 }
+func (m *mockCommand) UserSnoozeMessage(userID, msgID string) (*types.ScheduledMessage, error) {
+	if m.UserSnoozeMessageFunc != nil {
+		return m.UserSnoozeMessageFunc(userID, msgID)
+	}
+	panic("UserSnoozeMessageFunc not set")
+}
 func (m *mockCommand) BuildEphemeralList(args *model.CommandArgs) *model.CommandResponse {
 	if m.BuildEphemeralListFunc != nil {
 		return m.BuildEphemeralListFunc(args)
@@ -655,6 +665,515 @@ func TestServeHTTP_Send_HappyPath_NormalTimezone(t *testing.T) {
 	assert.Equal(t, "", rr.Body.String())
 }
 
+func createWarningRequest(t *testing.T, userID, channelID, action, msgID string) *http.Request {
+	t.Helper()
+	reqBody := model.PostActionIntegrationRequest{
+		PostId:    "warning-post",
+		ChannelId: channelID,
+		Context: map[string]any{
+			"action": action,
+			"id":     msgID,
+			"source": constants.ActionSourceWarning,
+		},
+	}
+	b, err := json.Marshal(reqBody)
+	require.NoError(t, err)
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/"+action, bytes.NewReader(b))
+	r.Header.Set(constants.HTTPHeaderMattermostUserID, userID)
+	return r
+}
+
+func decodeWarningUpdate(t *testing.T, rr *httptest.ResponseRecorder) *model.Post {
+	t.Helper()
+	var resp model.PostActionIntegrationResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	require.NotNil(t, resp.Update)
+	assert.Empty(t, resp.Update.Props["attachments"], "buttons are removed")
+	return resp.Update
+}
+
+func TestServeHTTP_Send_FromWarning(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, channelMock, schedulerMock, cmdMock := setupPluginForAPI(t, ctrl)
+
+	msg := &types.ScheduledMessage{ID: "m1", UserID: "u1", ChannelID: "c1", PostAt: time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC), Timezone: "UTC"}
+	cmdMock.UserSendMessageFunc = func(_, _ string) (*types.ScheduledMessage, error) { return msg, nil }
+	schedulerMock.EXPECT().SendNow(msg).Return(nil)
+	channelMock.EXPECT().GetInfoOrUnknown("c1").Return(&ports.ChannelInfo{ChannelID: "c1"})
+	channelMock.EXPECT().MakeChannelLink(gomock.Any()).Return("in channel: ~town-square")
+
+	rr := httptest.NewRecorder()
+	p.ServeHTTP(nil, rr, createWarningRequest(t, "u1", "dm1", "send", "m1"))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, formatter.FormatWarningSent("in channel: ~town-square"), decodeWarningUpdate(t, rr).Message)
+}
+
+func TestServeHTTP_Delete_FromWarning(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, channelMock, _, cmdMock := setupPluginForAPI(t, ctrl)
+
+	msg := &types.ScheduledMessage{ID: "m1", UserID: "u1", ChannelID: "c1", Timezone: "UTC"}
+	cmdMock.UserDeleteMessageFunc = func(_, _ string) (*types.ScheduledMessage, error) { return msg, nil }
+	channelMock.EXPECT().GetInfoOrUnknown("c1").Return(&ports.ChannelInfo{ChannelID: "c1"})
+	channelMock.EXPECT().MakeChannelLink(gomock.Any()).Return("in channel: ~town-square")
+
+	rr := httptest.NewRecorder()
+	p.ServeHTTP(nil, rr, createWarningRequest(t, "u1", "dm1", "delete", "m1"))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, formatter.FormatWarningCancelled("in channel: ~town-square"), decodeWarningUpdate(t, rr).Message)
+}
+
+func TestServeHTTP_Snooze_HappyPath(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, channelMock, schedulerMock, cmdMock := setupPluginForAPI(t, ctrl)
+
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	snoozed := &types.ScheduledMessage{ID: "m1", UserID: "u1", ChannelID: "c1", PostAt: time.Date(2025, 1, 2, 15, 15, 0, 0, time.UTC), Timezone: "America/New_York"}
+	cmdMock.UserSnoozeMessageFunc = func(u, id string) (*types.ScheduledMessage, error) {
+		assert.Equal(t, "u1", u)
+		assert.Equal(t, "m1", id)
+		return snoozed, nil
+	}
+	schedulerMock.EXPECT().MessageScheduled(snoozed)
+	channelMock.EXPECT().GetInfoOrUnknown("c1").Return(&ports.ChannelInfo{ChannelID: "c1"})
+	channelMock.EXPECT().MakeChannelLink(gomock.Any()).Return("in channel: ~town-square")
+
+	rr := httptest.NewRecorder()
+	p.ServeHTTP(nil, rr, createWarningRequest(t, "u1", "dm1", "snooze", "m1"))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, formatter.FormatWarningSnoozed(snoozed.PostAt.In(loc), "in channel: ~town-square"), decodeWarningUpdate(t, rr).Message)
+}
+
+func TestServeHTTP_Snooze_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, postMock, _, _, cmdMock := setupPluginForAPI(t, ctrl)
+
+	cmdMock.UserSnoozeMessageFunc = func(_, _ string) (*types.ScheduledMessage, error) {
+		return nil, errors.New("message m1 was already sent or changed")
+	}
+	postMock.EXPECT().SendEphemeralPost("u1", gomock.Any()).Do(func(_ string, post *model.Post) {
+		assert.Equal(t, "dm1", post.ChannelId)
+		assert.Equal(t, constants.EmojiError+" Could not snooze message: message m1 was already sent or changed", post.Message)
+	})
+
+	rr := httptest.NewRecorder()
+	p.ServeHTTP(nil, rr, createWarningRequest(t, "u1", "dm1", "snooze", "m1"))
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func TestServeHTTP_Import_HappyPath(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, _, _, _ := setupPluginForAPI(t, ctrl)
+	importerMock := mock.NewMockImportService(ctrl)
+	p.Importer = importerMock
+
+	body := "id,channel_id\n"
+	report := &types.ImportReport{
+		Imported: 1,
+		Rejected: 1,
+		Rows: []types.ImportRowResult{
+			{Row: 1, MessageID: "new1"},
+			{Row: 2, Error: "scheduled time is in the past"},
+		},
+	}
+	importerMock.EXPECT().Import("u1", "csv", []byte(body)).Return(report, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/import?format=csv", strings.NewReader(body))
+	req.Header.Set(constants.HTTPHeaderMattermostUserID, "u1")
+	rr := httptest.NewRecorder()
+
+	p.ServeHTTP(nil, rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, constants.ContentTypeJSON, rr.Header().Get(constants.HTTPHeaderContentType))
+	var got types.ImportReport
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.Equal(t, *report, got)
+}
+
+func TestServeHTTP_Import_DefaultsToJSON(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, _, _, _ := setupPluginForAPI(t, ctrl)
+	importerMock := mock.NewMockImportService(ctrl)
+	p.Importer = importerMock
+
+	importerMock.EXPECT().Import("u1", "json", []byte("[]")).Return(&types.ImportReport{Rows: []types.ImportRowResult{}}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/import", strings.NewReader("[]"))
+	req.Header.Set(constants.HTTPHeaderMattermostUserID, "u1")
+	rr := httptest.NewRecorder()
+
+	p.ServeHTTP(nil, rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestServeHTTP_Import_InvalidFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, _, _, _ := setupPluginForAPI(t, ctrl)
+	importerMock := mock.NewMockImportService(ctrl)
+	p.Importer = importerMock
+
+	importerMock.EXPECT().Import("u1", "json", []byte("{bad")).Return(nil, errors.New("invalid JSON export"))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/import?format=json", strings.NewReader("{bad"))
+	req.Header.Set(constants.HTTPHeaderMattermostUserID, "u1")
+	rr := httptest.NewRecorder()
+
+	p.ServeHTTP(nil, rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "invalid JSON export")
+}
+
+func TestServeHTTP_ImportCalendar_HappyPath(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, _, _, _ := setupPluginForAPI(t, ctrl)
+	importerMock := mock.NewMockCalendarImportService(ctrl)
+	p.CalendarImporter = importerMock
+
+	body := "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"
+	report := &types.ImportReport{Imported: 1, Rows: []types.ImportRowResult{{Row: 1, MessageID: "new1"}}}
+	importerMock.EXPECT().Import("u1", "c1", 30*time.Minute, []byte(body)).Return(report, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/import/calendar?channel_id=c1&lead=30m", strings.NewReader(body))
+	req.Header.Set(constants.HTTPHeaderMattermostUserID, "u1")
+	rr := httptest.NewRecorder()
+
+	p.ServeHTTP(nil, rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, constants.ContentTypeJSON, rr.Header().Get(constants.HTTPHeaderContentType))
+	var got types.ImportReport
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.Equal(t, *report, got)
+}
+
+func TestServeHTTP_ImportCalendar_DefaultLeadTime(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, _, _, _ := setupPluginForAPI(t, ctrl)
+	importerMock := mock.NewMockCalendarImportService(ctrl)
+	p.CalendarImporter = importerMock
+
+	importerMock.EXPECT().Import("u1", "c1", 15*time.Minute, gomock.Any()).Return(&types.ImportReport{Rows: []types.ImportRowResult{}}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/import/calendar?channel_id=c1", strings.NewReader(""))
+	req.Header.Set(constants.HTTPHeaderMattermostUserID, "u1")
+	rr := httptest.NewRecorder()
+
+	p.ServeHTTP(nil, rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestServeHTTP_ImportCalendar_InvalidLeadTime(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, _, _, _ := setupPluginForAPI(t, ctrl)
+	p.CalendarImporter = mock.NewMockCalendarImportService(ctrl)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/import/calendar?channel_id=c1&lead=soon", strings.NewReader(""))
+	req.Header.Set(constants.HTTPHeaderMattermostUserID, "u1")
+	rr := httptest.NewRecorder()
+
+	p.ServeHTTP(nil, rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "invalid lead time")
+}
+
+func TestServeHTTP_ImportCalendar_InvalidFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, _, _, _ := setupPluginForAPI(t, ctrl)
+	importerMock := mock.NewMockCalendarImportService(ctrl)
+	p.CalendarImporter = importerMock
+
+	importerMock.EXPECT().Import("u1", "c1", 15*time.Minute, []byte("[]")).Return(nil, errors.New("not an iCalendar file"))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/import/calendar?channel_id=c1", strings.NewReader("[]"))
+	req.Header.Set(constants.HTTPHeaderMattermostUserID, "u1")
+	rr := httptest.NewRecorder()
+
+	p.ServeHTTP(nil, rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "not an iCalendar file")
+}
+
+func TestServeHTTP_ImportCalendar_AuthFail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, _, _, _ := setupPluginForAPI(t, ctrl)
+	p.CalendarImporter = mock.NewMockCalendarImportService(ctrl)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/import/calendar?channel_id=c1", strings.NewReader(""))
+	rr := httptest.NewRecorder()
+
+	p.ServeHTTP(nil, rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestServeHTTP_CalendarFeed_HappyPath(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, _, _, _ := setupPluginForAPI(t, ctrl)
+	calendarMock := mock.NewMockCalendarService(ctrl)
+	p.Calendar = calendarMock
+
+	calendarMock.EXPECT().Feed("tok").Return([]byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"), nil)
+
+	// No Mattermost user header: calendar clients cannot authenticate.
+	req := httptest.NewRequest(http.MethodGet, "/calendar/tok.ics", nil)
+	rr := httptest.NewRecorder()
+
+	p.ServeHTTP(nil, rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, constants.ContentTypeCalendar, rr.Header().Get(constants.HTTPHeaderContentType))
+	assert.Equal(t, "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n", rr.Body.String())
+}
+
+func TestServeHTTP_CalendarFeed_UnknownToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, _, _, _ := setupPluginForAPI(t, ctrl)
+	calendarMock := mock.NewMockCalendarService(ctrl)
+	p.Calendar = calendarMock
+
+	calendarMock.EXPECT().Feed("nope").Return(nil, command.ErrCalendarTokenNotFound)
+
+	req := httptest.NewRequest(http.MethodGet, "/calendar/nope.ics", nil)
+	rr := httptest.NewRecorder()
+
+	p.ServeHTTP(nil, rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestServeHTTP_CalendarFeed_Failure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, _, _, _ := setupPluginForAPI(t, ctrl)
+	calendarMock := mock.NewMockCalendarService(ctrl)
+	p.Calendar = calendarMock
+
+	calendarMock.EXPECT().Feed("tok").Return(nil, errors.New("kv down"))
+
+	req := httptest.NewRequest(http.MethodGet, "/calendar/tok.ics", nil)
+	rr := httptest.NewRecorder()
+
+	p.ServeHTTP(nil, rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func TestServeHTTP_Metrics_Admin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, _, _, _ := setupPluginForAPI(t, ctrl)
+	permissionMock := mock.NewMockPermissionService(ctrl)
+	p.permissions = permissionMock
+	p.metrics = metrics.New()
+	p.metrics.IncDelivered()
+
+	permissionMock.EXPECT().HasPermissionTo("admin", model.PermissionManageSystem).Return(true)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/metrics", nil)
+	req.Header.Set(constants.HTTPHeaderMattermostUserID, "admin")
+	rr := httptest.NewRecorder()
+
+	p.ServeHTTP(nil, rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, metrics.ContentType, rr.Header().Get(constants.HTTPHeaderContentType))
+	assert.Contains(t, rr.Body.String(), "scheduled_messages_delivered_total 1\n")
+}
+
+func TestServeHTTP_Metrics_NotAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, _, _, _ := setupPluginForAPI(t, ctrl)
+	permissionMock := mock.NewMockPermissionService(ctrl)
+	p.permissions = permissionMock
+	p.metrics = metrics.New()
+
+	permissionMock.EXPECT().HasPermissionTo("user1", model.PermissionManageSystem).Return(false)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/metrics", nil)
+	req.Header.Set(constants.HTTPHeaderMattermostUserID, "user1")
+	rr := httptest.NewRecorder()
+
+	p.ServeHTTP(nil, rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestServeHTTP_Metrics_AuthFail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, _, _, _ := setupPluginForAPI(t, ctrl)
+	p.permissions = mock.NewMockPermissionService(ctrl)
+	p.metrics = metrics.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/metrics", nil)
+	rr := httptest.NewRecorder()
+
+	p.ServeHTTP(nil, rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestServeHTTP_AdminStatus_HappyPath(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, _, _, _ := setupPluginForAPI(t, ctrl)
+	permissionMock := mock.NewMockPermissionService(ctrl)
+	adminMock := mock.NewMockAdminService(ctrl)
+	p.permissions = permissionMock
+	p.Admin = adminMock
+
+	lastTick := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	permissionMock.EXPECT().HasPermissionTo("admin", model.PermissionManageSystem).Return(true)
+	adminMock.EXPECT().Status().Return(&types.AdminStatus{BotID: "bot", LastTick: lastTick, DueMessages: 3}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/status", nil)
+	req.Header.Set(constants.HTTPHeaderMattermostUserID, "admin")
+	rr := httptest.NewRecorder()
+
+	p.ServeHTTP(nil, rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, constants.ContentTypeJSON, rr.Header().Get(constants.HTTPHeaderContentType))
+	var got types.AdminStatus
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.Equal(t, "bot", got.BotID)
+	assert.True(t, got.LastTick.Equal(lastTick))
+	assert.Equal(t, 3, got.DueMessages)
+}
+
+func TestServeHTTP_AdminStatus_NotAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, _, _, _ := setupPluginForAPI(t, ctrl)
+	permissionMock := mock.NewMockPermissionService(ctrl)
+	p.permissions = permissionMock
+	p.Admin = mock.NewMockAdminService(ctrl)
+
+	permissionMock.EXPECT().HasPermissionTo("user1", model.PermissionManageSystem).Return(false)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/status", nil)
+	req.Header.Set(constants.HTTPHeaderMattermostUserID, "user1")
+	rr := httptest.NewRecorder()
+
+	p.ServeHTTP(nil, rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestServeHTTP_AdminStatus_Failure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, _, _, _ := setupPluginForAPI(t, ctrl)
+	permissionMock := mock.NewMockPermissionService(ctrl)
+	adminMock := mock.NewMockAdminService(ctrl)
+	p.permissions = permissionMock
+	p.Admin = adminMock
+
+	permissionMock.EXPECT().HasPermissionTo("admin", model.PermissionManageSystem).Return(true)
+	adminMock.EXPECT().Status().Return(nil, errors.New("kv down"))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/status", nil)
+	req.Header.Set(constants.HTTPHeaderMattermostUserID, "admin")
+	rr := httptest.NewRecorder()
+
+	p.ServeHTTP(nil, rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func TestServeHTTP_AdminRepair_HappyPath(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, _, _, _ := setupPluginForAPI(t, ctrl)
+	permissionMock := mock.NewMockPermissionService(ctrl)
+	checkerMock := mock.NewMockConsistencyChecker(ctrl)
+	p.permissions = permissionMock
+	p.Consistency = checkerMock
+
+	report := &types.ConsistencyReport{Messages: 2, Indexes: 1, Repaired: []types.ConsistencyIssue{
+		{Kind: constants.ConsistencyIssueOrphanedIndexEntry, UserID: "u1", MessageID: "gone"},
+	}, Failed: []types.ConsistencyIssue{}}
+	permissionMock.EXPECT().HasPermissionTo("admin", model.PermissionManageSystem).Return(true)
+	checkerMock.EXPECT().Repair().Return(report, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/repair", nil)
+	req.Header.Set(constants.HTTPHeaderMattermostUserID, "admin")
+	rr := httptest.NewRecorder()
+
+	p.ServeHTTP(nil, rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var got types.ConsistencyReport
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.Equal(t, *report, got)
+}
+
+func TestServeHTTP_AdminRepair_NotAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, _, _, _ := setupPluginForAPI(t, ctrl)
+	permissionMock := mock.NewMockPermissionService(ctrl)
+	p.permissions = permissionMock
+	p.Consistency = mock.NewMockConsistencyChecker(ctrl)
+
+	permissionMock.EXPECT().HasPermissionTo("user1", model.PermissionManageSystem).Return(false)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/repair", nil)
+	req.Header.Set(constants.HTTPHeaderMattermostUserID, "user1")
+	rr := httptest.NewRecorder()
+
+	p.ServeHTTP(nil, rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestServeHTTP_AdminRepair_Failure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, _, _, _, _ := setupPluginForAPI(t, ctrl)
+	permissionMock := mock.NewMockPermissionService(ctrl)
+	checkerMock := mock.NewMockConsistencyChecker(ctrl)
+	p.permissions = permissionMock
+	p.Consistency = checkerMock
+
+	permissionMock.EXPECT().HasPermissionTo("admin", model.PermissionManageSystem).Return(true)
+	checkerMock.EXPECT().Repair().Return(nil, errors.New("kv down"))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/repair", nil)
+	req.Header.Set(constants.HTTPHeaderMattermostUserID, "admin")
+	rr := httptest.NewRecorder()
+
+	p.ServeHTTP(nil, rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
 func TestUpdateEphemeralPostWithList(t *testing.T) { // TC-4.1
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -8,14 +8,16 @@ import (
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
 	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
 )
 
 // Channel provides channel lookup and formatting helpers.
 type Channel struct {
-	logger     ports.Logger
-	channelAPI ports.ChannelDataService
-	teamAPI    ports.TeamService
-	userAPI    ports.UserService
+	logger      ports.Logger
+	channelAPI  ports.ChannelDataService
+	teamAPI     ports.TeamService
+	userAPI     ports.UserService
+	permissions ports.PermissionService
 }
 
 // New constructs a Channel service.
@@ -24,13 +26,15 @@ func New(
 	channelAPI ports.ChannelDataService,
 	teamAPI ports.TeamService,
 	userAPI ports.UserService,
+	permissions ports.PermissionService,
 ) *Channel {
 	logger.Debug("Creating new Channel service")
 	return &Channel{
-		logger:     logger,
-		channelAPI: channelAPI,
-		teamAPI:    teamAPI,
-		userAPI:    userAPI,
+		logger:      logger,
+		channelAPI:  channelAPI,
+		teamAPI:     teamAPI,
+		userAPI:     userAPI,
+		permissions: permissions,
 	}
 }
 
@@ -122,6 +126,99 @@ func (c *Channel) MakeChannelLink(channelInfo *ports.ChannelInfo) string {
 	return fmt.Sprintf("in channel: %s", channelInfo.ChannelLink)
 }
 
+// ResolveDestination looks up a team channel by name and confirms the user can
+// post in it.
+func (c *Channel) ResolveDestination(teamID, userID, channelName string) (string, error) {
+	name := strings.TrimPrefix(strings.ToLower(channelName), "~")
+	c.logger.Debug("Resolving destination channel", "team_id", teamID, "user_id", userID, "channel_name", name)
+	channel, err := c.channelAPI.GetByName(teamID, name, false)
+	if err != nil {
+		c.logger.Warn("Failed to find channel by name", "team_id", teamID, "channel_name", name, "error", err)
+		return "", fmt.Errorf("channel ~%s not found", name)
+	}
+	if err := c.checkCanPost(channel, userID, "~"+name); err != nil {
+		return "", err
+	}
+	c.logger.Debug("Resolved destination channel", "channel_name", name, "channel_id", channel.Id)
+	return channel.Id, nil
+}
+
+// ResolveUserChannel looks up a channel by name across the user's teams and
+// confirms the user can post in it. It is used where no team context exists, such
+// as direct messages to the bot.
+func (c *Channel) ResolveUserChannel(userID, channelName string) (string, error) {
+	name := strings.TrimPrefix(strings.ToLower(channelName), "~")
+	c.logger.Debug("Resolving channel across user's teams", "user_id", userID, "channel_name", name)
+	teams, err := c.teamAPI.List(pluginapi.FilterTeamsByUser(userID))
+	if err != nil {
+		c.logger.Error("Failed to list user's teams", "user_id", userID, "error", err)
+		return "", fmt.Errorf("failed to list your teams: %w", err)
+	}
+	var matches []*model.Channel
+	for _, team := range teams {
+		if channel, err := c.channelAPI.GetByName(team.Id, name, false); err == nil {
+			c.logger.Debug("Found channel in team", "team_id", team.Id, "channel_name", name, "channel_id", channel.Id)
+			matches = append(matches, channel)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		c.logger.Warn("Channel not found in any of the user's teams", "user_id", userID, "channel_name", name)
+		return "", fmt.Errorf("channel ~%s not found", name)
+	case 1:
+	default:
+		c.logger.Warn("Channel name is ambiguous across teams", "user_id", userID, "channel_name", name, "matches", len(matches))
+		return "", fmt.Errorf("channel ~%s exists in more than one of your teams", name)
+	}
+	if err := c.checkCanPost(matches[0], userID, "~"+name); err != nil {
+		return "", err
+	}
+	c.logger.Debug("Resolved channel across user's teams", "channel_name", name, "channel_id", matches[0].Id)
+	return matches[0].Id, nil
+}
+
+// VerifyMembership confirms the user is a member of the channel.
+func (c *Channel) VerifyMembership(channelID, userID string) error {
+	c.logger.Debug("Verifying channel membership", "channel_id", channelID, "user_id", userID)
+	if _, err := c.channelAPI.GetMember(channelID, userID); err != nil {
+		c.logger.Warn("User is not a member of channel", "channel_id", channelID, "user_id", userID, "error", err)
+		return fmt.Errorf("you are not a member of channel %s", channelID)
+	}
+	c.logger.Debug("Verified channel membership", "channel_id", channelID, "user_id", userID)
+	return nil
+}
+
+// VerifyCanPost confirms the channel is not archived and the user is a member
+// with permission to post in it.
+func (c *Channel) VerifyCanPost(channelID, userID string) error {
+	c.logger.Debug("Verifying user can post in channel", "channel_id", channelID, "user_id", userID)
+	channel, err := c.channelAPI.Get(channelID)
+	if err != nil {
+		c.logger.Warn("Failed to get channel", "channel_id", channelID, "error", err)
+		return fmt.Errorf("channel %s not found", channelID)
+	}
+	return c.checkCanPost(channel, userID, "channel "+channelID)
+}
+
+// checkCanPost returns an error naming the channel as label when the user
+// cannot post in it.
+func (c *Channel) checkCanPost(channel *model.Channel, userID, label string) error {
+	if channel.DeleteAt != 0 {
+		c.logger.Warn("Channel is archived", "channel_id", channel.Id, "user_id", userID)
+		return fmt.Errorf("%s is archived", label)
+	}
+	if _, err := c.channelAPI.GetMember(channel.Id, userID); err != nil {
+		c.logger.Warn("User is not a member of channel", "channel_id", channel.Id, "user_id", userID, "error", err)
+		return fmt.Errorf("you are not a member of %s", label)
+	}
+	if !c.permissions.HasPermissionToChannel(userID, channel.Id, model.PermissionCreatePost) {
+		c.logger.Warn("User cannot post in channel", "channel_id", channel.Id, "user_id", userID)
+		return fmt.Errorf("you do not have permission to post in %s", label)
+	}
+	c.logger.Debug("Verified user can post in channel", "channel_id", channel.Id, "user_id", userID)
+	return nil
+}
+
 func (c *Channel) mapMembersToUsernames(members []*model.ChannelMember) ([]string, error) {
 	c.logger.Debug("Mapping channel members to usernames", "member_count", len(members))
 	var usernames []string
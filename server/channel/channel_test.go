@@ -17,20 +17,22 @@ func newTestChannel(t *testing.T) (*Channel,
 	*mock.MockChannelDataService,
 	*mock.MockTeamService,
 	*mock.MockUserService,
+	*mock.MockPermissionService,
 	*gomock.Controller,
 ) {
 	ctrl := gomock.NewController(t)
 	chData := mock.NewMockChannelDataService(ctrl)
 	teamSvc := mock.NewMockTeamService(ctrl)
 	userSvc := mock.NewMockUserService(ctrl)
+	perms := mock.NewMockPermissionService(ctrl)
 
-	ch := New(testutil.FakeLogger{}, chData, teamSvc, userSvc)
-	return ch, chData, teamSvc, userSvc, ctrl
+	ch := New(testutil.FakeLogger{}, chData, teamSvc, userSvc, perms)
+	return ch, chData, teamSvc, userSvc, perms, ctrl
 }
 
 func TestGetInfo(t *testing.T) {
 	t.Run("channel get error", func(t *testing.T) {
-		ch, chData, _, _, ctrl := newTestChannel(t)
+		ch, chData, _, _, _, ctrl := newTestChannel(t)
 		defer ctrl.Finish()
 
 		chID := "bad"
@@ -46,7 +48,7 @@ func TestGetInfo(t *testing.T) {
 	})
 
 	t.Run("direct / group happy path", func(t *testing.T) {
-		ch, chData, _, userSvc, ctrl := newTestChannel(t)
+		ch, chData, _, userSvc, _, ctrl := newTestChannel(t)
 		defer ctrl.Finish()
 
 		channelID := "chan1"
@@ -81,7 +83,7 @@ func TestGetInfo(t *testing.T) {
 	})
 
 	t.Run("private channel happy path", func(t *testing.T) {
-		ch, chData, teamSvc, _, ctrl := newTestChannel(t)
+		ch, chData, teamSvc, _, _, ctrl := newTestChannel(t)
 		defer ctrl.Finish()
 
 		channelID := "private1"
@@ -118,7 +120,7 @@ func TestGetInfo(t *testing.T) {
 	})
 
 	t.Run("group happy path", func(t *testing.T) {
-		ch, chData, _, userSvc, ctrl := newTestChannel(t)
+		ch, chData, _, userSvc, _, ctrl := newTestChannel(t)
 		defer ctrl.Finish()
 
 		channelID := "group1"
@@ -155,7 +157,7 @@ func TestGetInfo(t *testing.T) {
 	})
 
 	t.Run("direct path list members error", func(t *testing.T) {
-		ch, chData, _, _, ctrl := newTestChannel(t)
+		ch, chData, _, _, _, ctrl := newTestChannel(t)
 		defer ctrl.Finish()
 
 		channelID := "chan_lst_err"
@@ -178,7 +180,7 @@ func TestGetInfo(t *testing.T) {
 	})
 
 	t.Run("direct path user lookup error", func(t *testing.T) {
-		ch, chData, _, userSvc, ctrl := newTestChannel(t)
+		ch, chData, _, userSvc, _, ctrl := newTestChannel(t)
 		defer ctrl.Finish()
 
 		channelID := "chan_user_err"
@@ -205,7 +207,7 @@ func TestGetInfo(t *testing.T) {
 	})
 
 	t.Run("public/private happy path", func(t *testing.T) {
-		ch, chData, teamSvc, _, ctrl := newTestChannel(t)
+		ch, chData, teamSvc, _, _, ctrl := newTestChannel(t)
 		defer ctrl.Finish()
 
 		channelID := "public1"
@@ -241,7 +243,7 @@ func TestGetInfo(t *testing.T) {
 	})
 
 	t.Run("public path team fetch error", func(t *testing.T) {
-		ch, chData, teamSvc, _, ctrl := newTestChannel(t)
+		ch, chData, teamSvc, _, _, ctrl := newTestChannel(t)
 		defer ctrl.Finish()
 
 		channelID := "public_err"
@@ -269,7 +271,7 @@ func TestGetInfo(t *testing.T) {
 
 func TestGetInfoOrUnknown(t *testing.T) {
 	t.Run("success forwards info", func(t *testing.T) {
-		ch, chData, teamSvc, _, ctrl := newTestChannel(t)
+		ch, chData, teamSvc, _, _, ctrl := newTestChannel(t)
 		defer ctrl.Finish()
 
 		channelID := "public_success"
@@ -302,7 +304,7 @@ func TestGetInfoOrUnknown(t *testing.T) {
 	})
 
 	t.Run("failure returns UnknownChannel", func(t *testing.T) {
-		ch, chData, _, _, ctrl := newTestChannel(t)
+		ch, chData, _, _, _, ctrl := newTestChannel(t)
 		defer ctrl.Finish()
 
 		chData.EXPECT().Get("bad").Return(nil, errors.New("boom")).Times(1)
@@ -315,7 +317,7 @@ func TestGetInfoOrUnknown(t *testing.T) {
 }
 
 func TestUnknownChannel(t *testing.T) {
-	ch, _, _, _, ctrl := newTestChannel(t)
+	ch, _, _, _, _, ctrl := newTestChannel(t)
 	defer ctrl.Finish()
 
 	uc := ch.UnknownChannel()
@@ -325,7 +327,7 @@ func TestUnknownChannel(t *testing.T) {
 }
 
 func TestMakeChannelLink(t *testing.T) {
-	ch, _, _, _, ctrl := newTestChannel(t)
+	ch, _, _, _, _, ctrl := newTestChannel(t)
 	defer ctrl.Finish()
 
 	tests := []struct {
@@ -386,3 +388,239 @@ func TestMakeChannelLink(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveDestination(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		ch, chData, _, _, perms, ctrl := newTestChannel(t)
+		defer ctrl.Finish()
+
+		chData.EXPECT().GetByName("team1", "town-square", false).Return(&model.Channel{Id: "chan1"}, nil).Times(1)
+		chData.EXPECT().GetMember("chan1", "user1").Return(&model.ChannelMember{ChannelId: "chan1", UserId: "user1"}, nil).Times(1)
+		perms.EXPECT().HasPermissionToChannel("user1", "chan1", model.PermissionCreatePost).Return(true).Times(1)
+
+		got, err := ch.ResolveDestination("team1", "user1", "~Town-Square")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "chan1" {
+			t.Fatalf("expected chan1, got %q", got)
+		}
+	})
+
+	t.Run("channel not found", func(t *testing.T) {
+		ch, chData, _, _, _, ctrl := newTestChannel(t)
+		defer ctrl.Finish()
+
+		chData.EXPECT().GetByName("team1", "missing", false).Return(nil, errors.New("not found")).Times(1)
+
+		_, err := ch.ResolveDestination("team1", "user1", "~missing")
+		if err == nil || err.Error() != "channel ~missing not found" {
+			t.Fatalf("expected not found error, got %v", err)
+		}
+	})
+
+	t.Run("user not a member", func(t *testing.T) {
+		ch, chData, _, _, _, ctrl := newTestChannel(t)
+		defer ctrl.Finish()
+
+		chData.EXPECT().GetByName("team1", "private", false).Return(&model.Channel{Id: "chan2"}, nil).Times(1)
+		chData.EXPECT().GetMember("chan2", "user1").Return(nil, errors.New("no member")).Times(1)
+
+		_, err := ch.ResolveDestination("team1", "user1", "~private")
+		if err == nil || err.Error() != "you are not a member of ~private" {
+			t.Fatalf("expected membership error, got %v", err)
+		}
+	})
+
+	t.Run("no permission to post", func(t *testing.T) {
+		ch, chData, _, _, perms, ctrl := newTestChannel(t)
+		defer ctrl.Finish()
+
+		chData.EXPECT().GetByName("team1", "announcements", false).Return(&model.Channel{Id: "chan3"}, nil).Times(1)
+		chData.EXPECT().GetMember("chan3", "user1").Return(&model.ChannelMember{ChannelId: "chan3", UserId: "user1"}, nil).Times(1)
+		perms.EXPECT().HasPermissionToChannel("user1", "chan3", model.PermissionCreatePost).Return(false).Times(1)
+
+		_, err := ch.ResolveDestination("team1", "user1", "~announcements")
+		if err == nil || err.Error() != "you do not have permission to post in ~announcements" {
+			t.Fatalf("expected permission error, got %v", err)
+		}
+	})
+
+	t.Run("archived channel", func(t *testing.T) {
+		ch, chData, _, _, _, ctrl := newTestChannel(t)
+		defer ctrl.Finish()
+
+		chData.EXPECT().GetByName("team1", "old", false).Return(&model.Channel{Id: "chan4", DeleteAt: 1}, nil).Times(1)
+
+		_, err := ch.ResolveDestination("team1", "user1", "~old")
+		if err == nil || err.Error() != "~old is archived" {
+			t.Fatalf("expected archived error, got %v", err)
+		}
+	})
+}
+
+func TestResolveUserChannel(t *testing.T) {
+	teams := []*model.Team{{Id: "team1"}, {Id: "team2"}}
+
+	t.Run("found in one team", func(t *testing.T) {
+		ch, chData, teamSvc, _, perms, ctrl := newTestChannel(t)
+		defer ctrl.Finish()
+
+		teamSvc.EXPECT().List(gomock.Any()).Return(teams, nil).Times(1)
+		chData.EXPECT().GetByName("team1", "releases", false).Return(nil, errors.New("not found")).Times(1)
+		chData.EXPECT().GetByName("team2", "releases", false).Return(&model.Channel{Id: "chan2"}, nil).Times(1)
+		chData.EXPECT().GetMember("chan2", "user1").Return(&model.ChannelMember{ChannelId: "chan2", UserId: "user1"}, nil).Times(1)
+		perms.EXPECT().HasPermissionToChannel("user1", "chan2", model.PermissionCreatePost).Return(true).Times(1)
+
+		got, err := ch.ResolveUserChannel("user1", "~Releases")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "chan2" {
+			t.Fatalf("expected chan2, got %q", got)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		ch, chData, teamSvc, _, _, ctrl := newTestChannel(t)
+		defer ctrl.Finish()
+
+		teamSvc.EXPECT().List(gomock.Any()).Return(teams, nil).Times(1)
+		chData.EXPECT().GetByName(gomock.Any(), "missing", false).Return(nil, errors.New("not found")).Times(2)
+
+		_, err := ch.ResolveUserChannel("user1", "~missing")
+		if err == nil || err.Error() != "channel ~missing not found" {
+			t.Fatalf("expected not found error, got %v", err)
+		}
+	})
+
+	t.Run("ambiguous", func(t *testing.T) {
+		ch, chData, teamSvc, _, _, ctrl := newTestChannel(t)
+		defer ctrl.Finish()
+
+		teamSvc.EXPECT().List(gomock.Any()).Return(teams, nil).Times(1)
+		chData.EXPECT().GetByName("team1", "town-square", false).Return(&model.Channel{Id: "chan1"}, nil).Times(1)
+		chData.EXPECT().GetByName("team2", "town-square", false).Return(&model.Channel{Id: "chan2"}, nil).Times(1)
+
+		_, err := ch.ResolveUserChannel("user1", "town-square")
+		if err == nil || err.Error() != "channel ~town-square exists in more than one of your teams" {
+			t.Fatalf("expected ambiguity error, got %v", err)
+		}
+	})
+
+	t.Run("not a member", func(t *testing.T) {
+		ch, chData, teamSvc, _, _, ctrl := newTestChannel(t)
+		defer ctrl.Finish()
+
+		teamSvc.EXPECT().List(gomock.Any()).Return(teams[:1], nil).Times(1)
+		chData.EXPECT().GetByName("team1", "private", false).Return(&model.Channel{Id: "chan1"}, nil).Times(1)
+		chData.EXPECT().GetMember("chan1", "user1").Return(nil, errors.New("no member")).Times(1)
+
+		_, err := ch.ResolveUserChannel("user1", "~private")
+		if err == nil || err.Error() != "you are not a member of ~private" {
+			t.Fatalf("expected membership error, got %v", err)
+		}
+	})
+
+	t.Run("team list error", func(t *testing.T) {
+		ch, _, teamSvc, _, _, ctrl := newTestChannel(t)
+		defer ctrl.Finish()
+
+		teamSvc.EXPECT().List(gomock.Any()).Return(nil, errors.New("boom")).Times(1)
+
+		if _, err := ch.ResolveUserChannel("user1", "~releases"); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestVerifyMembership(t *testing.T) {
+	t.Run("member", func(t *testing.T) {
+		ch, chData, _, _, _, ctrl := newTestChannel(t)
+		defer ctrl.Finish()
+
+		chData.EXPECT().GetMember("chan1", "user1").Return(&model.ChannelMember{ChannelId: "chan1", UserId: "user1"}, nil).Times(1)
+
+		if err := ch.VerifyMembership("chan1", "user1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("not a member", func(t *testing.T) {
+		ch, chData, _, _, _, ctrl := newTestChannel(t)
+		defer ctrl.Finish()
+
+		chData.EXPECT().GetMember("chan1", "user1").Return(nil, errors.New("not found")).Times(1)
+
+		err := ch.VerifyMembership("chan1", "user1")
+		if err == nil || err.Error() != "you are not a member of channel chan1" {
+			t.Fatalf("expected membership error, got %v", err)
+		}
+	})
+}
+
+func TestVerifyCanPost(t *testing.T) {
+	t.Run("can post", func(t *testing.T) {
+		ch, chData, _, _, perms, ctrl := newTestChannel(t)
+		defer ctrl.Finish()
+
+		chData.EXPECT().Get("chan1").Return(&model.Channel{Id: "chan1"}, nil).Times(1)
+		chData.EXPECT().GetMember("chan1", "user1").Return(&model.ChannelMember{ChannelId: "chan1", UserId: "user1"}, nil).Times(1)
+		perms.EXPECT().HasPermissionToChannel("user1", "chan1", model.PermissionCreatePost).Return(true).Times(1)
+
+		if err := ch.VerifyCanPost("chan1", "user1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("channel not found", func(t *testing.T) {
+		ch, chData, _, _, _, ctrl := newTestChannel(t)
+		defer ctrl.Finish()
+
+		chData.EXPECT().Get("chan1").Return(nil, errors.New("not found")).Times(1)
+
+		err := ch.VerifyCanPost("chan1", "user1")
+		if err == nil || err.Error() != "channel chan1 not found" {
+			t.Fatalf("expected not found error, got %v", err)
+		}
+	})
+
+	t.Run("archived", func(t *testing.T) {
+		ch, chData, _, _, _, ctrl := newTestChannel(t)
+		defer ctrl.Finish()
+
+		chData.EXPECT().Get("chan1").Return(&model.Channel{Id: "chan1", DeleteAt: 1}, nil).Times(1)
+
+		err := ch.VerifyCanPost("chan1", "user1")
+		if err == nil || err.Error() != "channel chan1 is archived" {
+			t.Fatalf("expected archived error, got %v", err)
+		}
+	})
+
+	t.Run("not a member", func(t *testing.T) {
+		ch, chData, _, _, _, ctrl := newTestChannel(t)
+		defer ctrl.Finish()
+
+		chData.EXPECT().Get("chan1").Return(&model.Channel{Id: "chan1"}, nil).Times(1)
+		chData.EXPECT().GetMember("chan1", "user1").Return(nil, errors.New("not found")).Times(1)
+
+		err := ch.VerifyCanPost("chan1", "user1")
+		if err == nil || err.Error() != "you are not a member of channel chan1" {
+			t.Fatalf("expected membership error, got %v", err)
+		}
+	})
+
+	t.Run("no permission to post", func(t *testing.T) {
+		ch, chData, _, _, perms, ctrl := newTestChannel(t)
+		defer ctrl.Finish()
+
+		chData.EXPECT().Get("chan1").Return(&model.Channel{Id: "chan1"}, nil).Times(1)
+		chData.EXPECT().GetMember("chan1", "user1").Return(&model.ChannelMember{ChannelId: "chan1", UserId: "user1"}, nil).Times(1)
+		perms.EXPECT().HasPermissionToChannel("user1", "chan1", model.PermissionCreatePost).Return(false).Times(1)
+
+		err := ch.VerifyCanPost("chan1", "user1")
+		if err == nil || err.Error() != "you do not have permission to post in channel chan1" {
+			t.Fatalf("expected permission error, got %v", err)
+		}
+	})
+}
@@ -8,13 +8,51 @@ type Clock interface {
 	Now() time.Time
 }
 
+// Timer delivers the time on C once, after a duration, as time.Timer does.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker delivers the time on C every period, as time.Ticker does.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+	Reset(d time.Duration)
+}
+
+// TimerClock is a Clock that can also wait, so code that sleeps until a
+// given time can be driven by a fake clock in tests.
+type TimerClock interface {
+	Clock
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
 type realClock struct{}
 
 func (realClock) Now() time.Time {
 	return time.Now()
 }
 
-// NewReal returns a Clock backed by time.Now.
-func NewReal() Clock {
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTimer struct{ *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+type realTicker struct{ *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.Ticker.C }
+
+// NewReal returns a TimerClock backed by the time package.
+func NewReal() TimerClock {
 	return realClock{}
 }
@@ -18,3 +18,29 @@ func TestRealClock_Now(t *testing.T) {
 		t.Fatalf("Now() = %v, expected between %v and %v", got, before, after)
 	}
 }
+
+func TestRealClock_Timer(t *testing.T) {
+	timer := clock.NewReal().NewTimer(time.Millisecond)
+
+	select {
+	case <-timer.C():
+	case <-time.After(time.Second):
+		t.Fatal("timer did not fire")
+	}
+	if timer.Stop() {
+		t.Fatal("Stop() = true after the timer fired")
+	}
+}
+
+func TestRealClock_Ticker(t *testing.T) {
+	ticker := clock.NewReal().NewTicker(time.Millisecond)
+	defer ticker.Stop()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-ticker.C():
+		case <-time.After(time.Second):
+			t.Fatalf("ticker did not fire (tick %d)", i+1)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/consistency"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/formatter"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// AdminService reports scheduler health and stored data consistency to
+// system admins.
+type AdminService struct {
+	logger          ports.Logger
+	store           ports.Store
+	scheduler       ports.Scheduler
+	checker         ports.ConsistencyChecker
+	permissions     ports.PermissionService
+	cipher          ports.ContentCipher
+	botID           string
+	clock           ports.Clock
+	maxUserMessages int
+}
+
+// NewAdminService constructs an AdminService.
+func NewAdminService(
+	logger ports.Logger,
+	store ports.Store,
+	scheduler ports.Scheduler,
+	checker ports.ConsistencyChecker,
+	permissions ports.PermissionService,
+	cipher ports.ContentCipher,
+	botID string,
+	clk ports.Clock,
+	maxUserMessages int,
+) *AdminService {
+	logger.Debug("Creating new AdminService")
+	return &AdminService{
+		logger:          logger,
+		store:           store,
+		scheduler:       scheduler,
+		checker:         checker,
+		permissions:     permissions,
+		cipher:          cipher,
+		botID:           botID,
+		clock:           clk,
+		maxUserMessages: maxUserMessages,
+	}
+}
+
+// Build runs an admin subcommand. Only system admins may use it.
+func (a *AdminService) Build(userID, text string) *model.CommandResponse {
+	action := strings.ToLower(strings.TrimSpace(text))
+	a.logger.Debug("Handling admin subcommand", "user_id", userID, "action", action)
+	if !a.permissions.HasPermissionTo(userID, model.PermissionManageSystem) {
+		a.logger.Warn("Non-admin user attempted admin subcommand", "user_id", userID, "action", action)
+		return errorResponse(formatter.FormatAdminError(errors.New(constants.AdminErrPermission)))
+	}
+	switch action {
+	case constants.AdminActionStatus:
+		status, err := a.Status()
+		if err != nil {
+			return errorResponse(formatter.FormatAdminError(err))
+		}
+		return ephemeralResponse(formatter.FormatAdminStatus(status))
+	case constants.AdminActionRepair:
+		a.logger.Info("Admin requested consistency repair", "user_id", userID)
+		report, err := a.checker.Repair()
+		if err != nil {
+			return errorResponse(formatter.FormatAdminError(err))
+		}
+		return ephemeralResponse(formatter.FormatConsistencyReport(report))
+	default:
+		return errorResponse(formatter.FormatAdminError(errors.New(constants.AdminErrInvalidFormat)))
+	}
+}
+
+// Status gathers the scheduler tick times, message and index drift counts,
+// and the settings currently in effect.
+func (a *AdminService) Status() (*types.AdminStatus, error) {
+	a.logger.Debug("Building admin status report")
+	msgs, unreadable, err := a.store.ScanScheduledMessages()
+	if err != nil {
+		a.logger.Error("Failed to list scheduled messages for admin status", "error", err)
+		return nil, fmt.Errorf("failed to list scheduled messages: %w", err)
+	}
+	indexes, err := a.store.ListUserIndexes()
+	if err != nil {
+		a.logger.Error("Failed to list user indexes for admin status", "error", err)
+		return nil, fmt.Errorf("failed to list user indexes: %w", err)
+	}
+
+	now := a.clock.Now()
+	due := 0
+	for _, msg := range msgs {
+		if !msg.PostAt.After(now) {
+			due++
+		}
+	}
+	orphaned, missing := 0, 0
+	for _, issue := range consistency.Find(msgs, unreadable, indexes) {
+		if issue.Kind == constants.ConsistencyIssueOrphanedIndexEntry {
+			orphaned++
+		} else {
+			missing++
+		}
+	}
+
+	status := &types.AdminStatus{
+		BotID:                a.botID,
+		LastTick:             a.scheduler.LastTick(),
+		NextTick:             a.scheduler.NextTick(),
+		ScheduledMessages:    len(msgs),
+		DueMessages:          due,
+		UnreadableMessages:   len(unreadable),
+		OrphanedIndexEntries: orphaned,
+		MissingIndexEntries:  missing,
+		Settings:             a.settings(a.scheduler.Policy()),
+	}
+	a.logger.Debug("Built admin status report", "scheduled", status.ScheduledMessages, "due", due, "unreadable", len(unreadable), "orphaned", orphaned, "missing", missing)
+	return status, nil
+}
+
+// settings reports the limits the plugin is running with and the live
+// encryption and delivery settings from the plugin configuration.
+func (a *AdminService) settings(policy types.DeliveryPolicy) types.AdminSettings {
+	keyID := a.cipher.KeyID()
+	a.logger.Debug("Reading live settings for admin status", "encryption_key_id", keyID, "late_policy", policy.LatePolicy, "attribution", policy.Attribution, "scheduled_indicator", policy.ScheduledIndicator)
+	return types.AdminSettings{
+		MaxUserMessages:           a.maxUserMessages,
+		MaxMessageBytes:           constants.MaxMessageBytes,
+		MaxImportBytes:            constants.MaxImportBytes,
+		MaxFetchScheduledMessages: constants.MaxFetchScheduledMessages,
+		EncryptionEnabled:         keyID != "",
+		EncryptionKeyID:           keyID,
+		LatePolicy:                policy.LatePolicy,
+		LateThresholdMinutes:      int(policy.LateThreshold / time.Minute),
+		MaxChannelPostsPerMinute:  policy.ChannelRateLimit,
+		MaxPostsPerMinute:         policy.GlobalRateLimit,
+		DefaultAttribution:        policy.Attribution,
+		ShowScheduledIndicator:    policy.ScheduledIndicator,
+	}
+}
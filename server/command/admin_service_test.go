@@ -0,0 +1,186 @@
+package command
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/adapters/mock"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/testutil"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/formatter"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// testDeliveryPolicy differs from every default so the status shows the
+// live settings rather than defaults.
+var testDeliveryPolicy = types.DeliveryPolicy{
+	LatePolicy:         constants.LatePolicyAnnotate,
+	LateThreshold:      20 * time.Minute,
+	ChannelRateLimit:   3,
+	GlobalRateLimit:    30,
+	Attribution:        constants.AttributionBot,
+	ScheduledIndicator: true,
+}
+
+type adminMocks struct {
+	store       *mock.MockStore
+	scheduler   *mock.MockScheduler
+	checker     *mock.MockConsistencyChecker
+	permissions *mock.MockPermissionService
+	cipher      *mock.MockContentCipher
+}
+
+func setupAdminServiceTest(t *testing.T) (*AdminService, *adminMocks) {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	mocks := &adminMocks{
+		store:       mock.NewMockStore(ctrl),
+		scheduler:   mock.NewMockScheduler(ctrl),
+		checker:     mock.NewMockConsistencyChecker(ctrl),
+		permissions: mock.NewMockPermissionService(ctrl),
+		cipher:      mock.NewMockContentCipher(ctrl),
+	}
+	service := NewAdminService(&testutil.FakeLogger{}, mocks.store, mocks.scheduler, mocks.checker, mocks.permissions, mocks.cipher, testBotID, testutil.FakeClock{NowTime: testNow}, testMaxUserMsgs)
+	require.NotNil(t, service)
+	return service, mocks
+}
+
+func expectAdminStatusData(mocks *adminMocks) {
+	mocks.store.EXPECT().ScanScheduledMessages().Return([]*types.ScheduledMessage{
+		createTestMessage("due", testUserID, testChannelID, "late", "UTC", testNow.Add(-2*time.Minute)),
+		createTestMessage("now", testUserID, testChannelID, "on time", "UTC", testNow),
+		createTestMessage("later", "other", testChannelID, "later", "UTC", testNow.Add(time.Hour)),
+	}, []string{"sealed"}, nil)
+	mocks.store.EXPECT().ListUserIndexes().Return(map[string][]string{
+		testUserID: {"due", "gone", "sealed"},
+		"other":    {"later", "lost"},
+	}, nil)
+	mocks.scheduler.EXPECT().LastTick().Return(testNow.Add(-time.Minute))
+	mocks.scheduler.EXPECT().NextTick().Return(testNow.Add(time.Minute))
+	mocks.scheduler.EXPECT().Policy().Return(testDeliveryPolicy)
+	mocks.cipher.EXPECT().KeyID().Return("key-1")
+}
+
+func TestAdminStatus(t *testing.T) {
+	service, mocks := setupAdminServiceTest(t)
+	expectAdminStatusData(mocks)
+
+	status, err := service.Status()
+
+	require.NoError(t, err)
+	assert.Equal(t, &types.AdminStatus{
+		BotID:                testBotID,
+		LastTick:             testNow.Add(-time.Minute),
+		NextTick:             testNow.Add(time.Minute),
+		ScheduledMessages:    3,
+		DueMessages:          2,
+		UnreadableMessages:   1,
+		OrphanedIndexEntries: 2,
+		MissingIndexEntries:  1,
+		Settings: types.AdminSettings{
+			MaxUserMessages:           testMaxUserMsgs,
+			MaxMessageBytes:           constants.MaxMessageBytes,
+			MaxImportBytes:            constants.MaxImportBytes,
+			MaxFetchScheduledMessages: constants.MaxFetchScheduledMessages,
+			EncryptionEnabled:         true,
+			EncryptionKeyID:           "key-1",
+			LatePolicy:                constants.LatePolicyAnnotate,
+			LateThresholdMinutes:      20,
+			MaxChannelPostsPerMinute:  3,
+			MaxPostsPerMinute:         30,
+			DefaultAttribution:        constants.AttributionBot,
+			ShowScheduledIndicator:    true,
+		},
+	}, status)
+}
+
+func TestAdminStatus_StoreErrors(t *testing.T) {
+	t.Run("messages", func(t *testing.T) {
+		service, mocks := setupAdminServiceTest(t)
+		mocks.store.EXPECT().ScanScheduledMessages().Return(nil, nil, errors.New("kv down"))
+
+		_, err := service.Status()
+
+		assert.EqualError(t, err, "failed to list scheduled messages: kv down")
+	})
+	t.Run("indexes", func(t *testing.T) {
+		service, mocks := setupAdminServiceTest(t)
+		mocks.store.EXPECT().ScanScheduledMessages().Return(nil, nil, nil)
+		mocks.store.EXPECT().ListUserIndexes().Return(nil, errors.New("kv down"))
+
+		_, err := service.Status()
+
+		assert.EqualError(t, err, "failed to list user indexes: kv down")
+	})
+}
+
+func TestAdminBuild_Status(t *testing.T) {
+	service, mocks := setupAdminServiceTest(t)
+	mocks.permissions.EXPECT().HasPermissionTo(testUserID, model.PermissionManageSystem).Return(true)
+	expectAdminStatusData(mocks)
+
+	resp := service.Build(testUserID, " status ")
+
+	require.NotNil(t, resp)
+	assert.Equal(t, model.CommandResponseTypeEphemeral, resp.ResponseType)
+	assert.Contains(t, resp.Text, constants.AdminStatusHeader)
+	assert.Contains(t, resp.Text, "| Due but unsent | 2 |")
+	assert.Contains(t, resp.Text, "| Orphaned index entries | 2 |")
+	assert.Contains(t, resp.Text, "| Messages missing from index | 1 |")
+}
+
+func TestAdminBuild_NotAdmin(t *testing.T) {
+	service, mocks := setupAdminServiceTest(t)
+	mocks.permissions.EXPECT().HasPermissionTo(testUserID, model.PermissionManageSystem).Return(false)
+
+	resp := service.Build(testUserID, "status")
+
+	assert.Equal(t, formatter.FormatAdminError(errors.New(constants.AdminErrPermission)), resp.Text)
+}
+
+func TestAdminBuild_InvalidAction(t *testing.T) {
+	service, mocks := setupAdminServiceTest(t)
+	mocks.permissions.EXPECT().HasPermissionTo(testUserID, model.PermissionManageSystem).Return(true)
+
+	resp := service.Build(testUserID, "reboot")
+
+	assert.Equal(t, formatter.FormatAdminError(errors.New(constants.AdminErrInvalidFormat)), resp.Text)
+}
+
+func TestAdminBuild_StatusError(t *testing.T) {
+	service, mocks := setupAdminServiceTest(t)
+	mocks.permissions.EXPECT().HasPermissionTo(testUserID, model.PermissionManageSystem).Return(true)
+	mocks.store.EXPECT().ScanScheduledMessages().Return(nil, nil, errors.New("kv down"))
+
+	resp := service.Build(testUserID, "status")
+
+	assert.Equal(t, formatter.FormatAdminError(errors.New("failed to list scheduled messages: kv down")), resp.Text)
+}
+
+func TestAdminBuild_Repair(t *testing.T) {
+	service, mocks := setupAdminServiceTest(t)
+	report := &types.ConsistencyReport{Messages: 2, Indexes: 1, Repaired: []types.ConsistencyIssue{
+		{Kind: constants.ConsistencyIssueMissingIndexEntry, UserID: testUserID, MessageID: "m1"},
+	}}
+	mocks.permissions.EXPECT().HasPermissionTo(testUserID, model.PermissionManageSystem).Return(true)
+	mocks.checker.EXPECT().Repair().Return(report, nil)
+
+	resp := service.Build(testUserID, "repair")
+
+	assert.Equal(t, formatter.FormatConsistencyReport(report), resp.Text)
+}
+
+func TestAdminBuild_RepairError(t *testing.T) {
+	service, mocks := setupAdminServiceTest(t)
+	mocks.permissions.EXPECT().HasPermissionTo(testUserID, model.PermissionManageSystem).Return(true)
+	mocks.checker.EXPECT().Repair().Return(nil, errors.New("kv down"))
+
+	resp := service.Build(testUserID, "repair")
+
+	assert.Equal(t, formatter.FormatAdminError(errors.New("kv down")), resp.Text)
+}
@@ -0,0 +1,294 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/formatter"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/ical"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// CalendarImportService schedules reminder messages for the events in
+// iCalendar files, either uploaded through the API or sent to the bot as a
+// direct message attachment.
+type CalendarImportService struct {
+	logger          ports.Logger
+	store           ports.Store
+	channel         ports.ChannelService
+	channelAPI      ports.ChannelDataService
+	files           ports.FileService
+	poster          ports.PostService
+	userAPI         ports.UserService
+	botID           string
+	clock           ports.Clock
+	maxUserMessages int
+}
+
+// NewCalendarImportService constructs a CalendarImportService.
+func NewCalendarImportService(
+	logger ports.Logger,
+	store ports.Store,
+	channel ports.ChannelService,
+	channelAPI ports.ChannelDataService,
+	files ports.FileService,
+	poster ports.PostService,
+	userAPI ports.UserService,
+	botID string,
+	clk ports.Clock,
+	maxUserMessages int,
+) *CalendarImportService {
+	logger.Debug("Creating new CalendarImportService")
+	return &CalendarImportService{
+		logger:          logger,
+		store:           store,
+		channel:         channel,
+		channelAPI:      channelAPI,
+		files:           files,
+		poster:          poster,
+		userAPI:         userAPI,
+		botID:           botID,
+		clock:           clk,
+		maxUserMessages: maxUserMessages,
+	}
+}
+
+// ParseLeadTime reads how long before an event its reminder is posted, as a
+// duration ("15m", "1h30m") or a number of minutes ("15"). An empty value
+// gives the default lead time.
+func ParseLeadTime(text string) (time.Duration, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if text == "" {
+		return constants.DefaultCalendarLeadMinutes * time.Minute, nil
+	}
+	var lead time.Duration
+	if minutes, err := strconv.Atoi(text); err == nil {
+		lead = time.Duration(minutes) * time.Minute
+	} else if lead, err = time.ParseDuration(text); err != nil {
+		return 0, fmt.Errorf("invalid lead time %q. Use a duration like 15m or 1h30m", text)
+	}
+	if lead < 0 || lead > constants.MaxCalendarLeadMinutes*time.Minute {
+		return 0, fmt.Errorf("lead time must be between 0 and %s", formatter.FormatLeadTime(constants.MaxCalendarLeadMinutes*time.Minute))
+	}
+	return lead, nil
+}
+
+// Import schedules a reminder in channelID, lead before each upcoming
+// occurrence of the file's events. Events are rejected individually; an error
+// is only returned when the file or channel cannot be used at all.
+func (c *CalendarImportService) Import(userID, channelID string, lead time.Duration, data []byte) (*types.ImportReport, error) {
+	c.logger.Info("Importing calendar events for user", "user_id", userID, "channel_id", channelID, "lead", lead, "bytes", len(data))
+	if channelID == "" {
+		return nil, errors.New("missing channel_id")
+	}
+	if err := c.channel.VerifyCanPost(channelID, userID); err != nil {
+		return nil, err
+	}
+
+	tz := userTimezone(c.logger, c.userAPI, userID)
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		c.logger.Warn("Failed to load timezone location, proceeding with UTC", "user_id", userID, "timezone", tz, "error", err)
+		loc = time.UTC
+	}
+	events, err := ical.Parse(data, loc)
+	if err != nil {
+		c.logger.Warn("Failed to parse calendar file", "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	ids, err := c.store.ListUserMessageIDs(userID)
+	if err != nil {
+		c.logger.Error("Failed to list user message IDs for calendar import", "user_id", userID, "error", err)
+		return nil, fmt.Errorf("failed to check message count: %w", err)
+	}
+	count := len(ids)
+	now := c.clock.Now()
+	// Events starting within the lead time are still expanded so they are
+	// reported as too late rather than silently dropped.
+	until := now.Add(lead).AddDate(0, 0, constants.CalendarImportHorizonDays)
+	byEvent := make(map[int][]ical.Instance)
+	for _, inst := range ical.Expand(events, now, until) {
+		byEvent[inst.Event.Number] = append(byEvent[inst.Event.Number], inst)
+	}
+	c.logger.Debug("Expanded calendar events", "user_id", userID, "events", len(events), "with_occurrences", len(byEvent), "until", until)
+
+	report := &types.ImportReport{Rows: []types.ImportRowResult{}}
+	reject := func(row int, err error) {
+		c.logger.Debug("Rejected calendar event", "user_id", userID, "event", row, "error", err)
+		report.Rows = append(report.Rows, types.ImportRowResult{Row: row, Error: err.Error()})
+		report.Rejected++
+	}
+	for _, e := range events {
+		if e.Err != nil {
+			reject(e.Number, e.Err)
+			continue
+		}
+		instances := byEvent[e.Number]
+		if len(instances) == 0 {
+			reject(e.Number, fmt.Errorf("no occurrences in the next %d days", constants.CalendarImportHorizonDays))
+			continue
+		}
+		for _, inst := range instances {
+			msgID, err := c.scheduleReminder(userID, channelID, loc, lead, inst, count, now)
+			if err != nil {
+				reject(e.Number, err)
+				continue
+			}
+			report.Rows = append(report.Rows, types.ImportRowResult{Row: e.Number, MessageID: msgID})
+			report.Imported++
+			count++
+		}
+	}
+	c.logger.Info("Finished importing calendar events", "user_id", userID, "imported", report.Imported, "rejected", report.Rejected)
+	return report, nil
+}
+
+func (c *CalendarImportService) scheduleReminder(userID, channelID string, loc *time.Location, lead time.Duration, inst ical.Instance, count int, now time.Time) (string, error) {
+	postAt := inst.Start.Add(-lead)
+	if !postAt.After(now) {
+		return "", fmt.Errorf("reminder time %s is in the past", postAt.In(loc).Format(constants.TimeLayout))
+	}
+	content := formatter.FormatCalendarReminder(inst.Event.Summary, lead)
+	if err := checkMaxMessageBytes(c.logger, content); err != nil {
+		return "", err
+	}
+	if count >= c.maxUserMessages {
+		return "", fmt.Errorf("cannot schedule more than %d messages (current: %d)", c.maxUserMessages, count)
+	}
+
+	msg := &types.ScheduledMessage{
+		ID:             c.store.GenerateMessageID(),
+		UserID:         userID,
+		ChannelID:      channelID,
+		PostAt:         postAt.UTC(),
+		MessageContent: content,
+		Timezone:       loc.String(),
+		Literal:        true,
+	}
+	if inst.Event.Rule != nil || !inst.Event.RecurrenceID.IsZero() {
+		msg.Occurrence = inst.Number
+		msg.RecurrenceID = inst.Event.UID
+	}
+	if err := c.store.SaveScheduledMessage(userID, msg); err != nil {
+		c.logger.Error("Failed to save calendar reminder", "user_id", userID, "event", inst.Event.Number, "error", err)
+		return "", fmt.Errorf("failed to save message: %w", err)
+	}
+	c.logger.Debug("Scheduled calendar reminder", "user_id", userID, "event", inst.Event.Number, "occurrence", inst.Number, "message_id", msg.ID, "post_at", msg.PostAt)
+	return msg.ID, nil
+}
+
+// ImportAttachments imports the .ics files a user sends the bot in a direct
+// message. The message text names the reminder channel and, optionally, the
+// lead time, e.g. "~releases 30m". Results are sent back as a direct message.
+func (c *CalendarImportService) ImportAttachments(post *model.Post) {
+	if post.UserId == c.botID || len(post.FileIds) == 0 {
+		return
+	}
+	if !c.isBotDirectMessage(post) {
+		return
+	}
+	infos := c.calendarFiles(post.FileIds)
+	if len(infos) == 0 {
+		c.logger.Debug("Direct message to bot has no calendar files", "user_id", post.UserId, "post_id", post.Id)
+		return
+	}
+	c.logger.Debug("Importing calendar files from direct message", "user_id", post.UserId, "post_id", post.Id, "files", len(infos))
+
+	channelID, lead, err := c.parseAttachmentText(post.UserId, post.Message)
+	if err != nil {
+		for _, info := range infos {
+			c.reply(post.UserId, formatter.FormatCalendarImportError(info.Name, err))
+		}
+		return
+	}
+	channelLink := c.channel.MakeChannelLink(c.channel.GetInfoOrUnknown(channelID))
+	for _, info := range infos {
+		report, err := c.importFile(post.UserId, channelID, lead, info)
+		if err != nil {
+			c.reply(post.UserId, formatter.FormatCalendarImportError(info.Name, err))
+			continue
+		}
+		c.reply(post.UserId, formatter.FormatCalendarImportReport(info.Name, channelLink, report))
+	}
+}
+
+func (c *CalendarImportService) isBotDirectMessage(post *model.Post) bool {
+	ch, err := c.channelAPI.Get(post.ChannelId)
+	if err != nil {
+		c.logger.Warn("Failed to get channel of post with attachments", "channel_id", post.ChannelId, "error", err)
+		return false
+	}
+	return ch.Type == model.ChannelTypeDirect && ch.Name == model.GetDMNameFromIds(post.UserId, c.botID)
+}
+
+func (c *CalendarImportService) calendarFiles(fileIDs []string) []*model.FileInfo {
+	var infos []*model.FileInfo
+	for _, id := range fileIDs {
+		info, err := c.files.GetInfo(id)
+		if err != nil {
+			c.logger.Warn("Failed to get file info", "file_id", id, "error", err)
+			continue
+		}
+		if strings.EqualFold("."+info.Extension, constants.CalendarFileExtension) {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+func (c *CalendarImportService) parseAttachmentText(userID, text string) (string, time.Duration, error) {
+	var channelName, leadText string
+	for _, field := range strings.Fields(text) {
+		switch {
+		case strings.HasPrefix(field, "~") && channelName == "":
+			channelName = field
+		case !strings.HasPrefix(field, "~") && leadText == "":
+			leadText = field
+		default:
+			return "", 0, errors.New(constants.CalendarImportErrUsage)
+		}
+	}
+	if channelName == "" {
+		return "", 0, errors.New(constants.CalendarImportErrUsage)
+	}
+	lead, err := ParseLeadTime(leadText)
+	if err != nil {
+		return "", 0, err
+	}
+	channelID, err := c.channel.ResolveUserChannel(userID, channelName)
+	if err != nil {
+		return "", 0, err
+	}
+	return channelID, lead, nil
+}
+
+func (c *CalendarImportService) importFile(userID, channelID string, lead time.Duration, info *model.FileInfo) (*types.ImportReport, error) {
+	if info.Size > constants.MaxImportBytes {
+		return nil, fmt.Errorf("file is larger than %d bytes", constants.MaxImportBytes)
+	}
+	content, err := c.files.Get(info.Id)
+	if err != nil {
+		c.logger.Error("Failed to read calendar file", "user_id", userID, "file_id", info.Id, "error", err)
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	data, err := io.ReadAll(io.LimitReader(content, constants.MaxImportBytes))
+	if err != nil {
+		c.logger.Error("Failed to read calendar file", "user_id", userID, "file_id", info.Id, "error", err)
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return c.Import(userID, channelID, lead, data)
+}
+
+func (c *CalendarImportService) reply(userID, text string) {
+	if err := c.poster.DM(c.botID, userID, &model.Post{Message: text}); err != nil {
+		c.logger.Error("Failed to send calendar import result", "user_id", userID, "error", err)
+	}
+}
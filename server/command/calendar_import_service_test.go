@@ -0,0 +1,367 @@
+package command
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/adapters/mock"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/testutil"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/formatter"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const testBotID = "bot"
+
+type calendarImportMocks struct {
+	store      *mock.MockStore
+	channel    *mock.MockChannelService
+	channelAPI *mock.MockChannelDataService
+	files      *mock.MockFileService
+	poster     *mock.MockPostService
+	users      *mock.MockUserService
+}
+
+func setupCalendarImportServiceTest(t *testing.T) (*CalendarImportService, *calendarImportMocks) {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	mocks := &calendarImportMocks{
+		store:      mock.NewMockStore(ctrl),
+		channel:    mock.NewMockChannelService(ctrl),
+		channelAPI: mock.NewMockChannelDataService(ctrl),
+		files:      mock.NewMockFileService(ctrl),
+		poster:     mock.NewMockPostService(ctrl),
+		users:      mock.NewMockUserService(ctrl),
+	}
+	service := NewCalendarImportService(
+		&testutil.FakeLogger{},
+		mocks.store,
+		mocks.channel,
+		mocks.channelAPI,
+		mocks.files,
+		mocks.poster,
+		mocks.users,
+		testBotID,
+		testutil.FakeClock{NowTime: testNow},
+		testMaxUserMsgs,
+	)
+	require.NotNil(t, service)
+	return service, mocks
+}
+
+func testICS(lines ...string) []byte {
+	all := append([]string{"BEGIN:VCALENDAR", "VERSION:2.0"}, lines...)
+	return []byte(strings.Join(append(all, "END:VCALENDAR"), "\r\n"))
+}
+
+func (m *calendarImportMocks) expectUserTimezone(tz string) {
+	m.users.EXPECT().Get(testUserID).Return(&model.User{Timezone: model.StringMap{"manualTimezone": tz}}, nil)
+}
+
+func (m *calendarImportMocks) expectSaves(t *testing.T, saved *[]*types.ScheduledMessage) {
+	t.Helper()
+	next := 0
+	m.store.EXPECT().GenerateMessageID().DoAndReturn(func() string {
+		next++
+		return "msg" + string(rune('0'+next))
+	}).AnyTimes()
+	m.store.EXPECT().SaveScheduledMessage(testUserID, gomock.Any()).DoAndReturn(func(_ string, msg *types.ScheduledMessage) error {
+		*saved = append(*saved, msg)
+		return nil
+	}).AnyTimes()
+}
+
+func TestParseLeadTime(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":      15 * time.Minute,
+		"30":    30 * time.Minute,
+		"0":     0,
+		"1h30m": 90 * time.Minute,
+		" 2H ":  2 * time.Hour,
+	}
+	for input, expected := range tests {
+		got, err := ParseLeadTime(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, got, input)
+	}
+
+	_, err := ParseLeadTime("soon")
+	assert.EqualError(t, err, `invalid lead time "soon". Use a duration like 15m or 1h30m`)
+	_, err = ParseLeadTime("-5m")
+	assert.EqualError(t, err, "lead time must be between 0 and 7 days")
+	_, err = ParseLeadTime("169h")
+	assert.Error(t, err)
+}
+
+func TestCalendarImport_SchedulesRemindersWithTZIDAndRecurrence(t *testing.T) {
+	service, mocks := setupCalendarImportServiceTest(t)
+	var saved []*types.ScheduledMessage
+	data := testICS(
+		"BEGIN:VEVENT",
+		"UID:release",
+		`DTSTART;TZID=America/New_York:20240116T090000`,
+		"SUMMARY:Release 2.1",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:standup",
+		"DTSTART:20240108T150000Z",
+		"RRULE:FREQ=WEEKLY;COUNT=3",
+		"SUMMARY:Standup",
+		"END:VEVENT",
+	)
+
+	mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(nil)
+	mocks.expectUserTimezone("Europe/Berlin")
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return(nil, nil)
+	mocks.expectSaves(t, &saved)
+
+	report, err := service.Import(testUserID, testChannelID, 15*time.Minute, data)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Imported)
+	assert.Equal(t, 0, report.Rejected)
+	require.Len(t, saved, 3)
+
+	assert.Equal(t, time.Date(2024, 1, 16, 13, 45, 0, 0, time.UTC), saved[0].PostAt)
+	assert.Equal(t, formatter.FormatCalendarReminder("Release 2.1", 15*time.Minute), saved[0].MessageContent)
+	assert.Equal(t, "Europe/Berlin", saved[0].Timezone)
+	assert.Equal(t, testChannelID, saved[0].ChannelID)
+	assert.Zero(t, saved[0].Occurrence)
+	assert.Empty(t, saved[0].RecurrenceID)
+
+	// The first standup (Jan 8) is already past; numbering follows the series.
+	assert.Equal(t, time.Date(2024, 1, 15, 14, 45, 0, 0, time.UTC), saved[1].PostAt)
+	assert.Equal(t, 2, saved[1].Occurrence)
+	assert.Equal(t, time.Date(2024, 1, 22, 14, 45, 0, 0, time.UTC), saved[2].PostAt)
+	assert.Equal(t, 3, saved[2].Occurrence)
+	assert.Equal(t, "standup", saved[1].RecurrenceID)
+	assert.Equal(t, "standup", saved[2].RecurrenceID)
+	assert.Equal(t, []types.ImportRowResult{
+		{Row: 1, MessageID: "msg1"},
+		{Row: 2, MessageID: "msg2"},
+		{Row: 2, MessageID: "msg3"},
+	}, report.Rows)
+}
+
+func TestCalendarImport_OverrideKeepsItsOccurrence(t *testing.T) {
+	service, mocks := setupCalendarImportServiceTest(t)
+	var saved []*types.ScheduledMessage
+	data := testICS(
+		"BEGIN:VEVENT", "UID:standup", "DTSTART:20240108T150000Z", "RRULE:FREQ=WEEKLY;COUNT=4", "SUMMARY:Standup", "END:VEVENT",
+		"BEGIN:VEVENT", "UID:standup", "RECURRENCE-ID:20240122T150000Z", "DTSTART:20240123T160000Z", "SUMMARY:Standup (moved)", "END:VEVENT",
+	)
+
+	mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(nil)
+	mocks.expectUserTimezone("UTC")
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return(nil, nil)
+	mocks.expectSaves(t, &saved)
+
+	report, err := service.Import(testUserID, testChannelID, 15*time.Minute, data)
+
+	require.NoError(t, err)
+	require.Equal(t, 3, report.Imported)
+	assert.Equal(t, []int{2, 4, 3}, []int{saved[0].Occurrence, saved[1].Occurrence, saved[2].Occurrence})
+	assert.Equal(t, time.Date(2024, 1, 23, 15, 45, 0, 0, time.UTC), saved[2].PostAt)
+	assert.Equal(t, "standup", saved[2].RecurrenceID)
+}
+
+func TestCalendarImport_FloatingTimeUsesUserTimezone(t *testing.T) {
+	service, mocks := setupCalendarImportServiceTest(t)
+	var saved []*types.ScheduledMessage
+	data := testICS("BEGIN:VEVENT", "UID:1", "DTSTART:20240115T120000", "SUMMARY:Lunch", "END:VEVENT")
+
+	mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(nil)
+	mocks.expectUserTimezone("America/New_York")
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return(nil, nil)
+	mocks.expectSaves(t, &saved)
+
+	report, err := service.Import(testUserID, testChannelID, 0, data)
+
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Imported)
+	assert.Equal(t, time.Date(2024, 1, 15, 17, 0, 0, 0, time.UTC), saved[0].PostAt)
+	assert.Equal(t, formatter.FormatCalendarReminder("Lunch", 0), saved[0].MessageContent)
+}
+
+func TestCalendarImport_RejectsEventsIndividually(t *testing.T) {
+	service, mocks := setupCalendarImportServiceTest(t)
+	var saved []*types.ScheduledMessage
+	data := testICS(
+		"BEGIN:VEVENT", "UID:past", "DTSTART:20240115T100500Z", "SUMMARY:Too soon", "END:VEVENT",
+		"BEGIN:VEVENT", "UID:bad", "DTSTART;TZID=Nowhere/City:20240120T090000", "END:VEVENT",
+		"BEGIN:VEVENT", "UID:far", "DTSTART:20250101T090000Z", "END:VEVENT",
+		"BEGIN:VEVENT", "UID:tmpl", "DTSTART:20240120T090000Z", "SUMMARY:Retro {{dat}}", "END:VEVENT",
+		"BEGIN:VEVENT", "UID:ok", "DTSTART:20240120T090000Z", "SUMMARY:Fine", "END:VEVENT",
+	)
+
+	mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(nil)
+	mocks.expectUserTimezone("UTC")
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return(nil, nil)
+	mocks.expectSaves(t, &saved)
+
+	report, err := service.Import(testUserID, testChannelID, 15*time.Minute, data)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, 3, report.Rejected)
+	assert.Equal(t, []types.ImportRowResult{
+		{Row: 1, Error: "reminder time Jan 15, 2024 9:50 AM is in the past"},
+		{Row: 2, Error: `invalid DTSTART: unknown time zone "Nowhere/City"`},
+		{Row: 3, Error: "no occurrences in the next 90 days"},
+		{Row: 4, MessageID: "msg1"},
+		{Row: 5, MessageID: "msg2"},
+	}, report.Rows)
+	// Event titles are posted as written, not expanded as templates.
+	assert.Equal(t, formatter.FormatCalendarReminder("Retro {{dat}}", 15*time.Minute), saved[0].MessageContent)
+	assert.True(t, saved[0].Literal)
+}
+
+func TestCalendarImport_EnforcesQuota(t *testing.T) {
+	service, mocks := setupCalendarImportServiceTest(t)
+	var saved []*types.ScheduledMessage
+	data := testICS("BEGIN:VEVENT", "UID:daily", "DTSTART:20240116T090000Z", "RRULE:FREQ=DAILY;COUNT=3", "END:VEVENT")
+
+	mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(nil)
+	mocks.expectUserTimezone("UTC")
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{"a", "b", "c"}, nil)
+	mocks.expectSaves(t, &saved)
+
+	report, err := service.Import(testUserID, testChannelID, 15*time.Minute, data)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, 1, report.Rejected)
+	assert.Equal(t, "cannot schedule more than 5 messages (current: 5)", report.Rows[2].Error)
+}
+
+func TestCalendarImport_FileErrors(t *testing.T) {
+	t.Run("missing channel", func(t *testing.T) {
+		service, _ := setupCalendarImportServiceTest(t)
+
+		_, err := service.Import(testUserID, "", 0, testICS())
+
+		assert.EqualError(t, err, "missing channel_id")
+	})
+
+	t.Run("not a member", func(t *testing.T) {
+		service, mocks := setupCalendarImportServiceTest(t)
+		mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(errors.New("you are not a member of channel c"))
+
+		_, err := service.Import(testUserID, testChannelID, 0, testICS())
+
+		assert.EqualError(t, err, "you are not a member of channel c")
+	})
+
+	t.Run("not a calendar", func(t *testing.T) {
+		service, mocks := setupCalendarImportServiceTest(t)
+		mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(nil)
+		mocks.expectUserTimezone("UTC")
+
+		_, err := service.Import(testUserID, testChannelID, 0, []byte("[]"))
+
+		assert.EqualError(t, err, "not an iCalendar file")
+	})
+}
+
+func testDMPost(message string, fileIDs ...string) *model.Post {
+	return &model.Post{Id: "post1", UserId: testUserID, ChannelId: "dm", Message: message, FileIds: fileIDs}
+}
+
+func (m *calendarImportMocks) expectBotDM() {
+	m.channelAPI.EXPECT().Get("dm").Return(&model.Channel{Id: "dm", Type: model.ChannelTypeDirect, Name: model.GetDMNameFromIds(testUserID, testBotID)}, nil)
+}
+
+func TestImportAttachments_ImportsCalendarFile(t *testing.T) {
+	service, mocks := setupCalendarImportServiceTest(t)
+	var saved []*types.ScheduledMessage
+	data := testICS("BEGIN:VEVENT", "UID:1", "DTSTART:20240120T090000Z", "SUMMARY:Release", "END:VEVENT")
+	info := &ports.ChannelInfo{ChannelID: testChannelID, ChannelLink: "~releases"}
+
+	mocks.expectBotDM()
+	mocks.files.EXPECT().GetInfo("f1").Return(&model.FileInfo{Id: "f1", Name: "release.ics", Extension: "ics", Size: int64(len(data))}, nil)
+	mocks.files.EXPECT().GetInfo("f2").Return(&model.FileInfo{Id: "f2", Name: "notes.txt", Extension: "txt"}, nil)
+	mocks.channel.EXPECT().ResolveUserChannel(testUserID, "~releases").Return(testChannelID, nil)
+	mocks.channel.EXPECT().GetInfoOrUnknown(testChannelID).Return(info)
+	mocks.channel.EXPECT().MakeChannelLink(info).Return("in channel: ~releases")
+	mocks.files.EXPECT().Get("f1").Return(strings.NewReader(string(data)), nil)
+	mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(nil)
+	mocks.expectUserTimezone("UTC")
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return(nil, nil)
+	mocks.expectSaves(t, &saved)
+	mocks.poster.EXPECT().DM(testBotID, testUserID, &model.Post{
+		Message: formatter.FormatCalendarImportReport("release.ics", "in channel: ~releases", &types.ImportReport{Imported: 1}),
+	}).Return(nil)
+
+	service.ImportAttachments(testDMPost("~releases 1h", "f1", "f2"))
+
+	require.Len(t, saved, 1)
+	assert.Equal(t, time.Date(2024, 1, 20, 8, 0, 0, 0, time.UTC), saved[0].PostAt)
+}
+
+func TestImportAttachments_MissingChannel(t *testing.T) {
+	service, mocks := setupCalendarImportServiceTest(t)
+
+	mocks.expectBotDM()
+	mocks.files.EXPECT().GetInfo("f1").Return(&model.FileInfo{Id: "f1", Name: "release.ics", Extension: "ICS"}, nil)
+	mocks.poster.EXPECT().DM(testBotID, testUserID, &model.Post{
+		Message: formatter.FormatCalendarImportError("release.ics", errors.New(constants.CalendarImportErrUsage)),
+	}).Return(nil)
+
+	service.ImportAttachments(testDMPost("30m", "f1"))
+}
+
+func TestImportAttachments_TooLarge(t *testing.T) {
+	service, mocks := setupCalendarImportServiceTest(t)
+	info := &ports.ChannelInfo{ChannelID: testChannelID}
+
+	mocks.expectBotDM()
+	mocks.files.EXPECT().GetInfo("f1").Return(&model.FileInfo{Id: "f1", Name: "big.ics", Extension: "ics", Size: constants.MaxImportBytes + 1}, nil)
+	mocks.channel.EXPECT().ResolveUserChannel(testUserID, "~releases").Return(testChannelID, nil)
+	mocks.channel.EXPECT().GetInfoOrUnknown(testChannelID).Return(info)
+	mocks.channel.EXPECT().MakeChannelLink(info).Return("in channel: ~releases")
+	mocks.poster.EXPECT().DM(testBotID, testUserID, gomock.Any()).
+		DoAndReturn(func(_, _ string, post *model.Post) error {
+			assert.Contains(t, post.Message, "file is larger than")
+			return nil
+		})
+
+	service.ImportAttachments(testDMPost("~releases", "f1"))
+}
+
+func TestImportAttachments_IgnoresOtherPosts(t *testing.T) {
+	t.Run("bot's own post", func(t *testing.T) {
+		service, _ := setupCalendarImportServiceTest(t)
+		post := testDMPost("", "f1")
+		post.UserId = testBotID
+
+		service.ImportAttachments(post)
+	})
+
+	t.Run("no files", func(t *testing.T) {
+		service, _ := setupCalendarImportServiceTest(t)
+
+		service.ImportAttachments(testDMPost("~releases"))
+	})
+
+	t.Run("not a DM with the bot", func(t *testing.T) {
+		service, mocks := setupCalendarImportServiceTest(t)
+		mocks.channelAPI.EXPECT().Get("dm").Return(&model.Channel{Id: "dm", Type: model.ChannelTypeOpen, Name: "town-square"}, nil)
+
+		service.ImportAttachments(testDMPost("~releases", "f1"))
+	})
+
+	t.Run("no calendar files", func(t *testing.T) {
+		service, mocks := setupCalendarImportServiceTest(t)
+		mocks.expectBotDM()
+		mocks.files.EXPECT().GetInfo("f1").Return(&model.FileInfo{Id: "f1", Name: "a.png", Extension: "png"}, nil)
+
+		service.ImportAttachments(testDMPost("~releases", "f1"))
+	})
+}
@@ -0,0 +1,186 @@
+package command
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/formatter"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/ical"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// ErrCalendarTokenNotFound is returned by Feed for unknown or revoked tokens.
+var ErrCalendarTokenNotFound = errors.New("calendar feed not found")
+
+// CalendarService manages per-user calendar feed links and renders the feeds.
+type CalendarService struct {
+	logger  ports.Logger
+	store   ports.Store
+	channel ports.ChannelService
+	config  ports.ConfigService
+	clock   ports.Clock
+}
+
+// NewCalendarService constructs a CalendarService.
+func NewCalendarService(
+	logger ports.Logger,
+	store ports.Store,
+	channel ports.ChannelService,
+	config ports.ConfigService,
+	clk ports.Clock,
+) *CalendarService {
+	logger.Debug("Creating new CalendarService")
+	return &CalendarService{
+		logger:  logger,
+		store:   store,
+		channel: channel,
+		config:  config,
+		clock:   clk,
+	}
+}
+
+// Build runs a calendar subcommand: show (creating if needed), reset or revoke
+// the user's feed link.
+func (c *CalendarService) Build(userID, text string) *model.CommandResponse {
+	action := strings.ToLower(strings.TrimSpace(text))
+	c.logger.Debug("Handling calendar subcommand", "user_id", userID, "action", action)
+	switch action {
+	case "":
+		return c.show(userID)
+	case constants.CalendarActionReset:
+		return c.reset(userID)
+	case constants.CalendarActionRevoke:
+		return c.revoke(userID)
+	default:
+		return errorResponse(formatter.FormatCalendarError(errors.New(constants.CalendarErrInvalidFormat)))
+	}
+}
+
+// Feed renders the iCalendar feed for the owner of token.
+func (c *CalendarService) Feed(token string) ([]byte, error) {
+	c.logger.Debug("Rendering calendar feed")
+	if token == "" {
+		return nil, ErrCalendarTokenNotFound
+	}
+	userID, err := c.store.GetCalendarTokenOwner(token)
+	if err != nil {
+		return nil, err
+	}
+	if userID == "" {
+		c.logger.Debug("Calendar feed requested for unknown token")
+		return nil, ErrCalendarTokenNotFound
+	}
+
+	msgs, err := loadUserMessages(c.logger, c.store, userID)
+	if err != nil {
+		c.logger.Error("Failed to load messages for calendar feed", "user_id", userID, "error", err)
+		return nil, err
+	}
+	cal := &ical.Calendar{
+		ProdID: constants.CalendarProdID,
+		Name:   constants.CalendarName,
+		Events: c.buildEvents(msgs),
+	}
+	c.logger.Debug("Rendered calendar feed", "user_id", userID, "events", len(cal.Events))
+	return []byte(cal.Encode(c.clock.Now())), nil
+}
+
+func (c *CalendarService) buildEvents(msgs []*types.ScheduledMessage) []ical.Event {
+	events := make([]ical.Event, 0, len(msgs))
+	channelCache := make(map[string]*ports.ChannelInfo)
+	// Occurrences of a recurring message are stored one message each; those
+	// with the same text and destinations are shown as one repeating event.
+	series := make(map[string]int)
+	seriesStarts := make(map[int][]time.Time)
+	for _, m := range msgs {
+		if m.RecurrenceID != "" {
+			key := strings.Join([]string{m.RecurrenceID, strings.Join(m.Destinations(), " "), m.MessageContent}, "\x00")
+			if i, ok := series[key]; ok {
+				seriesStarts[i] = append(seriesStarts[i], m.PostAt)
+				continue
+			}
+			series[key] = len(events)
+			seriesStarts[len(events)] = []time.Time{m.PostAt}
+		}
+		links := destinationLinks(c.channel, m.Destinations(), channelCache)
+		events = append(events, ical.Event{
+			UID:         fmt.Sprintf("%s@%s", m.ID, constants.PluginID),
+			Start:       m.PostAt,
+			Summary:     formatter.FormatCalendarEventSummary(links),
+			Description: formatter.FormatCalendarEventDescription(links, previewMessage(m.MessageContent)),
+		})
+	}
+	for i, starts := range seriesStarts {
+		events[i].Rule, events[i].Dates = ical.Series(starts)
+		c.logger.Debug("Grouped recurring messages into one calendar event", "uid", events[i].UID, "occurrences", len(starts), "has_rule", events[i].Rule != nil)
+	}
+	return events
+}
+
+func (c *CalendarService) show(userID string) *model.CommandResponse {
+	token, err := c.store.GetCalendarToken(userID)
+	if err != nil {
+		c.logger.Error("Failed to get calendar token", "user_id", userID, "error", err)
+		return errorResponse(formatter.FormatCalendarError(err))
+	}
+	if token == "" {
+		c.logger.Debug("User has no calendar token, creating one", "user_id", userID)
+		return c.reset(userID)
+	}
+	return c.feedResponse(token)
+}
+
+func (c *CalendarService) reset(userID string) *model.CommandResponse {
+	token, err := newCalendarToken()
+	if err != nil {
+		c.logger.Error("Failed to generate calendar token", "user_id", userID, "error", err)
+		return errorResponse(formatter.FormatCalendarError(err))
+	}
+	if err := c.store.SetCalendarToken(userID, token); err != nil {
+		c.logger.Error("Failed to save calendar token", "user_id", userID, "error", err)
+		return errorResponse(formatter.FormatCalendarError(err))
+	}
+	c.logger.Info("Created calendar feed token", "user_id", userID)
+	return c.feedResponse(token)
+}
+
+func (c *CalendarService) revoke(userID string) *model.CommandResponse {
+	if err := c.store.DeleteCalendarToken(userID); err != nil {
+		c.logger.Error("Failed to revoke calendar token", "user_id", userID, "error", err)
+		return errorResponse(formatter.FormatCalendarError(err))
+	}
+	c.logger.Info("Revoked calendar feed token", "user_id", userID)
+	return ephemeralResponse(formatter.FormatCalendarRevoked())
+}
+
+func (c *CalendarService) feedResponse(token string) *model.CommandResponse {
+	siteURL := ""
+	if cfg := c.config.GetConfig(); cfg != nil && cfg.ServiceSettings.SiteURL != nil {
+		siteURL = strings.TrimSuffix(*cfg.ServiceSettings.SiteURL, "/")
+	}
+	if siteURL == "" {
+		c.logger.Warn("Site URL is not configured, cannot build calendar feed link")
+		return errorResponse(formatter.FormatCalendarError(errors.New("the server's Site URL is not configured")))
+	}
+	url := fmt.Sprintf("%s/plugins/%s%s%s%s", siteURL, constants.PluginID, constants.CalendarRoutePrefix, token, constants.CalendarFileExtension)
+	return ephemeralResponse(formatter.FormatCalendarFeed(url))
+}
+
+func newCalendarToken() (string, error) {
+	b := make([]byte, constants.CalendarTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func previewMessage(content string) string {
+	return formatter.FormatPreview(content, constants.CalendarPreviewRunes)
+}
@@ -0,0 +1,235 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/adapters/mock"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/testutil"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/formatter"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const testCalendarToken = "abc123"
+
+type calendarMocks struct {
+	store   *mock.MockStore
+	channel *mock.MockChannelService
+	config  *mock.MockConfigService
+}
+
+func setupCalendarServiceTest(t *testing.T) (*CalendarService, *calendarMocks) {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	mocks := &calendarMocks{
+		store:   mock.NewMockStore(ctrl),
+		channel: mock.NewMockChannelService(ctrl),
+		config:  mock.NewMockConfigService(ctrl),
+	}
+	service := NewCalendarService(&testutil.FakeLogger{}, mocks.store, mocks.channel, mocks.config, testutil.FakeClock{NowTime: testNow})
+	require.NotNil(t, service)
+	return service, mocks
+}
+
+func testSiteConfig(siteURL string) *model.Config {
+	return &model.Config{ServiceSettings: model.ServiceSettings{SiteURL: model.NewPointer(siteURL)}}
+}
+
+func testFeedURL(token string) string {
+	return "https://mm.example.com/plugins/" + constants.PluginID + "/calendar/" + token + ".ics"
+}
+
+func TestCalendarBuild_ShowExisting(t *testing.T) {
+	service, mocks := setupCalendarServiceTest(t)
+
+	mocks.store.EXPECT().GetCalendarToken(testUserID).Return(testCalendarToken, nil)
+	mocks.config.EXPECT().GetConfig().Return(testSiteConfig("https://mm.example.com/"))
+
+	resp := service.Build(testUserID, "")
+
+	require.NotNil(t, resp)
+	assert.Equal(t, model.CommandResponseTypeEphemeral, resp.ResponseType)
+	assert.Equal(t, formatter.FormatCalendarFeed(testFeedURL(testCalendarToken)), resp.Text)
+}
+
+func TestCalendarBuild_ShowCreatesToken(t *testing.T) {
+	service, mocks := setupCalendarServiceTest(t)
+	var saved string
+
+	mocks.store.EXPECT().GetCalendarToken(testUserID).Return("", nil)
+	mocks.store.EXPECT().SetCalendarToken(testUserID, gomock.Any()).
+		DoAndReturn(func(_, token string) error {
+			saved = token
+			return nil
+		})
+	mocks.config.EXPECT().GetConfig().Return(testSiteConfig("https://mm.example.com"))
+
+	resp := service.Build(testUserID, " ")
+
+	require.NotNil(t, resp)
+	assert.Len(t, saved, constants.CalendarTokenBytes*2)
+	assert.Equal(t, formatter.FormatCalendarFeed(testFeedURL(saved)), resp.Text)
+}
+
+func TestCalendarBuild_Reset(t *testing.T) {
+	service, mocks := setupCalendarServiceTest(t)
+	var saved string
+
+	mocks.store.EXPECT().SetCalendarToken(testUserID, gomock.Any()).
+		DoAndReturn(func(_, token string) error {
+			saved = token
+			return nil
+		})
+	mocks.config.EXPECT().GetConfig().Return(testSiteConfig("https://mm.example.com"))
+
+	resp := service.Build(testUserID, " RESET")
+
+	require.NotNil(t, resp)
+	assert.NotEmpty(t, saved)
+	assert.Equal(t, formatter.FormatCalendarFeed(testFeedURL(saved)), resp.Text)
+}
+
+func TestCalendarBuild_ResetStoreError(t *testing.T) {
+	service, mocks := setupCalendarServiceTest(t)
+
+	mocks.store.EXPECT().SetCalendarToken(testUserID, gomock.Any()).Return(errors.New("boom"))
+
+	resp := service.Build(testUserID, "reset")
+
+	require.NotNil(t, resp)
+	assert.Equal(t, formatter.FormatCalendarError(errors.New("boom")), resp.Text)
+}
+
+func TestCalendarBuild_Revoke(t *testing.T) {
+	service, mocks := setupCalendarServiceTest(t)
+
+	mocks.store.EXPECT().DeleteCalendarToken(testUserID).Return(nil)
+
+	resp := service.Build(testUserID, "revoke")
+
+	require.NotNil(t, resp)
+	assert.Equal(t, formatter.FormatCalendarRevoked(), resp.Text)
+}
+
+func TestCalendarBuild_InvalidAction(t *testing.T) {
+	service, _ := setupCalendarServiceTest(t)
+
+	resp := service.Build(testUserID, "rotate")
+
+	require.NotNil(t, resp)
+	assert.Equal(t, formatter.FormatCalendarError(errors.New(constants.CalendarErrInvalidFormat)), resp.Text)
+}
+
+func TestCalendarBuild_MissingSiteURL(t *testing.T) {
+	service, mocks := setupCalendarServiceTest(t)
+
+	mocks.store.EXPECT().GetCalendarToken(testUserID).Return(testCalendarToken, nil)
+	mocks.config.EXPECT().GetConfig().Return(&model.Config{})
+
+	resp := service.Build(testUserID, "")
+
+	require.NotNil(t, resp)
+	assert.Contains(t, resp.Text, "Site URL is not configured")
+}
+
+func TestCalendarFeed_UnknownToken(t *testing.T) {
+	service, mocks := setupCalendarServiceTest(t)
+
+	mocks.store.EXPECT().GetCalendarTokenOwner("nope").Return("", nil)
+
+	feed, err := service.Feed("nope")
+
+	assert.Nil(t, feed)
+	assert.ErrorIs(t, err, ErrCalendarTokenNotFound)
+}
+
+func TestCalendarFeed_EmptyToken(t *testing.T) {
+	service, _ := setupCalendarServiceTest(t)
+
+	_, err := service.Feed("")
+
+	assert.ErrorIs(t, err, ErrCalendarTokenNotFound)
+}
+
+func TestCalendarFeed_StoreError(t *testing.T) {
+	service, mocks := setupCalendarServiceTest(t)
+
+	mocks.store.EXPECT().GetCalendarTokenOwner(testCalendarToken).Return("", errors.New("boom"))
+
+	_, err := service.Feed(testCalendarToken)
+
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrCalendarTokenNotFound)
+}
+
+func TestCalendarFeed_RendersEvents(t *testing.T) {
+	service, mocks := setupCalendarServiceTest(t)
+	msg := createTestMessage("id1", testUserID, testChannelID, "hello, world", "UTC", testNow.Add(2*time.Hour))
+	info := &ports.ChannelInfo{ChannelID: testChannelID, ChannelLink: "~town-square"}
+
+	mocks.store.EXPECT().GetCalendarTokenOwner(testCalendarToken).Return(testUserID, nil)
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{"id1"}, nil)
+	mocks.store.EXPECT().GetScheduledMessage("id1").Return(msg, nil)
+	mocks.channel.EXPECT().GetInfoOrUnknown(testChannelID).Return(info)
+	mocks.channel.EXPECT().MakeChannelLink(info).Return("~town-square")
+
+	feed, err := service.Feed(testCalendarToken)
+
+	require.NoError(t, err)
+	text := string(feed)
+	assert.True(t, strings.HasPrefix(text, "BEGIN:VCALENDAR\r\n"))
+	assert.Contains(t, text, "UID:id1@"+constants.PluginID+"\r\n")
+	assert.Contains(t, text, "DTSTART:20240115T120000Z\r\n")
+	assert.Contains(t, text, "SUMMARY:Scheduled message ~town-square\r\n")
+	assert.Contains(t, text, `DESCRIPTION:~town-square\n\nhello\, world`)
+}
+
+func TestCalendarFeed_GroupsRecurringMessages(t *testing.T) {
+	service, mocks := setupCalendarServiceTest(t)
+	var msgs []*types.ScheduledMessage
+	for i, day := range []int{0, 7, 14} {
+		msg := createTestMessage(fmt.Sprintf("w%d", i), testUserID, testChannelID, "standup", "UTC", testNow.AddDate(0, 0, day))
+		msg.RecurrenceID = "weekly-uid"
+		msgs = append(msgs, msg)
+	}
+	for i, day := range []int{1, 2, 5} {
+		msg := createTestMessage(fmt.Sprintf("i%d", i), testUserID, testChannelID, "retro", "UTC", testNow.AddDate(0, 0, day))
+		msg.RecurrenceID = "irregular-uid"
+		msgs = append(msgs, msg)
+	}
+	info := &ports.ChannelInfo{ChannelID: testChannelID, ChannelLink: "~town-square"}
+
+	mocks.store.EXPECT().GetCalendarTokenOwner(testCalendarToken).Return(testUserID, nil)
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{"w0", "w1", "w2", "i0", "i1", "i2"}, nil)
+	for _, msg := range msgs {
+		mocks.store.EXPECT().GetScheduledMessage(msg.ID).Return(msg, nil)
+	}
+	mocks.channel.EXPECT().GetInfoOrUnknown(testChannelID).Return(info)
+	mocks.channel.EXPECT().MakeChannelLink(info).Return("~town-square").Times(2)
+
+	feed, err := service.Feed(testCalendarToken)
+
+	require.NoError(t, err)
+	text := string(feed)
+	assert.Equal(t, 2, strings.Count(text, "BEGIN:VEVENT"))
+	assert.Contains(t, text, "UID:w0@"+constants.PluginID+"\r\nDTSTAMP:20240115T100000Z\r\nDTSTART:20240115T100000Z\r\nRRULE:FREQ=WEEKLY;COUNT=3\r\n")
+	assert.Contains(t, text, "UID:i0@"+constants.PluginID+"\r\nDTSTAMP:20240115T100000Z\r\nDTSTART:20240116T100000Z\r\nRDATE:20240117T100000Z,20240120T100000Z\r\n")
+}
+
+func TestPreviewMessage_Truncates(t *testing.T) {
+	long := strings.Repeat("é", constants.CalendarPreviewRunes+5)
+
+	got := previewMessage(long)
+
+	assert.Equal(t, strings.Repeat("é", constants.CalendarPreviewRunes)+"…", got)
+	assert.Equal(t, "short", previewMessage("short"))
+}
@@ -0,0 +1,104 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/formatter"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/transfer"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// ExportService sends users a file of their scheduled messages.
+type ExportService struct {
+	logger     ports.Logger
+	store      ports.Store
+	channelAPI ports.ChannelDataService
+	files      ports.FileService
+	poster     ports.PostService
+	botID      string
+	clock      ports.Clock
+}
+
+// NewExportService constructs an ExportService.
+func NewExportService(
+	logger ports.Logger,
+	store ports.Store,
+	channelAPI ports.ChannelDataService,
+	files ports.FileService,
+	poster ports.PostService,
+	botID string,
+	clk ports.Clock,
+) *ExportService {
+	logger.Debug("Creating new ExportService")
+	return &ExportService{
+		logger:     logger,
+		store:      store,
+		channelAPI: channelAPI,
+		files:      files,
+		poster:     poster,
+		botID:      botID,
+		clock:      clk,
+	}
+}
+
+// Build exports the user's scheduled messages and DMs them the file.
+func (e *ExportService) Build(userID, format string) *model.CommandResponse {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		format = transfer.FormatJSON
+	}
+	e.logger.Info("Exporting scheduled messages for user", "user_id", userID, "format", format)
+	if !transfer.IsSupported(format) {
+		e.logger.Debug("Unsupported export format", "user_id", userID, "format", format)
+		return errorResponse(formatter.FormatExportError(fmt.Errorf("unsupported format %q, use %s or %s", format, transfer.FormatJSON, transfer.FormatCSV)))
+	}
+
+	msgs, err := loadUserMessages(e.logger, e.store, userID)
+	if err != nil {
+		e.logger.Error("Failed to load messages for export", "user_id", userID, "error", err)
+		return errorResponse(formatter.FormatExportError(err))
+	}
+	if len(msgs) == 0 {
+		e.logger.Info("User has no scheduled messages to export", "user_id", userID)
+		return emptyResponse()
+	}
+
+	data, err := transfer.Encode(format, msgs)
+	if err != nil {
+		e.logger.Error("Failed to encode export", "user_id", userID, "format", format, "error", err)
+		return errorResponse(formatter.FormatExportError(err))
+	}
+
+	fileName := fmt.Sprintf("%s-%s.%s", constants.ExportFilePrefix, e.clock.Now().UTC().Format(constants.ExportFileTimeLayout), format)
+	if err := e.sendFile(userID, fileName, data, len(msgs)); err != nil {
+		e.logger.Error("Failed to send export file", "user_id", userID, "file_name", fileName, "error", err)
+		return errorResponse(formatter.FormatExportError(err))
+	}
+	e.logger.Info("Exported scheduled messages", "user_id", userID, "count", len(msgs), "file_name", fileName)
+	return ephemeralResponse(formatter.FormatExportSuccess(len(msgs), fileName))
+}
+
+func (e *ExportService) sendFile(userID, fileName string, data []byte, count int) error {
+	e.logger.Debug("Getting bot DM channel for export", "user_id", userID, "bot_id", e.botID)
+	dm, err := e.channelAPI.GetDirect(e.botID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to open direct message channel: %w", err)
+	}
+	e.logger.Debug("Uploading export file", "user_id", userID, "channel_id", dm.Id, "file_name", fileName, "bytes", len(data))
+	info, err := e.files.Upload(bytes.NewReader(data), fileName, dm.Id)
+	if err != nil {
+		return fmt.Errorf("failed to upload export file: %w", err)
+	}
+	post := &model.Post{
+		Message: formatter.FormatExportPost(count),
+		FileIds: []string{info.Id},
+	}
+	if err := e.poster.DM(e.botID, userID, post); err != nil {
+		return fmt.Errorf("failed to send export file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package command
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/adapters/mock"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/testutil"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/formatter"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/transfer"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type exportMocks struct {
+	store      *mock.MockStore
+	channelAPI *mock.MockChannelDataService
+	files      *mock.MockFileService
+	poster     *mock.MockPostService
+}
+
+func setupExportServiceTest(t *testing.T) (*ExportService, *exportMocks) {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	mocks := &exportMocks{
+		store:      mock.NewMockStore(ctrl),
+		channelAPI: mock.NewMockChannelDataService(ctrl),
+		files:      mock.NewMockFileService(ctrl),
+		poster:     mock.NewMockPostService(ctrl),
+	}
+	service := NewExportService(&testutil.FakeLogger{}, mocks.store, mocks.channelAPI, mocks.files, mocks.poster, "bot", testutil.FakeClock{NowTime: testNow})
+	require.NotNil(t, service)
+	return service, mocks
+}
+
+func TestExportBuild_CSV(t *testing.T) {
+	service, mocks := setupExportServiceTest(t)
+	msg := createTestMessage("id1", testUserID, testChannelID, "hello", "UTC", testNow.Add(time.Hour))
+	fileName := "scheduled-messages-20240115-100000.csv"
+
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{"id1"}, nil)
+	mocks.store.EXPECT().GetScheduledMessage("id1").Return(msg, nil)
+	mocks.channelAPI.EXPECT().GetDirect("bot", testUserID).Return(&model.Channel{Id: "dm"}, nil)
+	mocks.files.EXPECT().Upload(gomock.Any(), fileName, "dm").
+		DoAndReturn(func(content io.Reader, _, _ string) (*model.FileInfo, error) {
+			data, err := io.ReadAll(content)
+			require.NoError(t, err)
+			expected, err := transfer.Encode(transfer.FormatCSV, []*types.ScheduledMessage{msg})
+			require.NoError(t, err)
+			assert.Equal(t, expected, data)
+			return &model.FileInfo{Id: "file1"}, nil
+		})
+	mocks.poster.EXPECT().DM("bot", testUserID, &model.Post{Message: formatter.FormatExportPost(1), FileIds: []string{"file1"}}).Return(nil)
+
+	resp := service.Build(testUserID, " CSV")
+
+	require.NotNil(t, resp)
+	assert.Equal(t, model.CommandResponseTypeEphemeral, resp.ResponseType)
+	assert.Equal(t, formatter.FormatExportSuccess(1, fileName), resp.Text)
+}
+
+func TestExportBuild_DefaultsToJSON(t *testing.T) {
+	service, mocks := setupExportServiceTest(t)
+	msg := createTestMessage("id1", testUserID, testChannelID, "hello", "UTC", testNow.Add(time.Hour))
+
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{"id1"}, nil)
+	mocks.store.EXPECT().GetScheduledMessage("id1").Return(msg, nil)
+	mocks.channelAPI.EXPECT().GetDirect("bot", testUserID).Return(&model.Channel{Id: "dm"}, nil)
+	mocks.files.EXPECT().Upload(gomock.Any(), "scheduled-messages-20240115-100000.json", "dm").Return(&model.FileInfo{Id: "file1"}, nil)
+	mocks.poster.EXPECT().DM("bot", testUserID, gomock.Any()).Return(nil)
+
+	resp := service.Build(testUserID, "")
+
+	require.NotNil(t, resp)
+	assert.Contains(t, resp.Text, constants.EmojiSuccess)
+}
+
+func TestExportBuild_UnsupportedFormat(t *testing.T) {
+	service, _ := setupExportServiceTest(t)
+
+	resp := service.Build(testUserID, "xml")
+
+	require.NotNil(t, resp)
+	assert.Contains(t, resp.Text, `unsupported format "xml"`)
+}
+
+func TestExportBuild_NoMessages(t *testing.T) {
+	service, mocks := setupExportServiceTest(t)
+
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return(nil, nil)
+
+	resp := service.Build(testUserID, "json")
+
+	require.NotNil(t, resp)
+	assert.Equal(t, constants.EmptyListMessage, resp.Text)
+}
+
+func TestExportBuild_UploadError(t *testing.T) {
+	service, mocks := setupExportServiceTest(t)
+	msg := createTestMessage("id1", testUserID, testChannelID, "hello", "UTC", testNow.Add(time.Hour))
+
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{"id1"}, nil)
+	mocks.store.EXPECT().GetScheduledMessage("id1").Return(msg, nil)
+	mocks.channelAPI.EXPECT().GetDirect("bot", testUserID).Return(&model.Channel{Id: "dm"}, nil)
+	mocks.files.EXPECT().Upload(gomock.Any(), gomock.Any(), "dm").Return(nil, errors.New("disk full"))
+
+	resp := service.Build(testUserID, "json")
+
+	require.NotNil(t, resp)
+	assert.Contains(t, resp.Text, "failed to upload export file: disk full")
+}
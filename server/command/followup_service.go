@@ -0,0 +1,218 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/formatter"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+var (
+	regexFollowup      = regexp.MustCompile(`(?i)^in[ \t]+([0-9][0-9a-z]*)(?:[ \t]+(dm|bump))?(?:[ \t]+message\s+([\s\S]+))?$`)
+	regexFollowupDelay = regexp.MustCompile(`^(?:(\d+)w)?(?:(\d+)d)?(?:(\d+)h)?(?:(\d+)m)?$`)
+	regexMention       = regexp.MustCompile(`(?:^|[^\w@])@([a-z0-9][a-z0-9._-]*)`)
+)
+
+// FollowupService sets follow-ups for threads: a DM reminder to the author,
+// or a bump in the thread, sent only if the people the author was waiting
+// on have not replied by then.
+type FollowupService struct {
+	logger          ports.Logger
+	userAPI         ports.UserService
+	store           ports.Store
+	poster          ports.PostService
+	channel         ports.ChannelService
+	clock           ports.Clock
+	maxUserMessages int
+}
+
+// NewFollowupService constructs a FollowupService.
+func NewFollowupService(
+	logger ports.Logger,
+	userAPI ports.UserService,
+	store ports.Store,
+	poster ports.PostService,
+	channel ports.ChannelService,
+	clk ports.Clock,
+	maxUserMessages int,
+) *FollowupService {
+	logger.Debug("Creating new FollowupService")
+	return &FollowupService{
+		logger:          logger,
+		userAPI:         userAPI,
+		store:           store,
+		poster:          poster,
+		channel:         channel,
+		clock:           clk,
+		maxUserMessages: maxUserMessages,
+	}
+}
+
+// parsedFollowup is a followup subcommand.
+type parsedFollowup struct {
+	delay time.Duration
+	mode  string
+	note  string
+}
+
+// Build runs a followup subcommand in the thread it was sent from. The
+// follow-up watches the users mentioned in the author's last post in the
+// thread, or anyone else if it mentions nobody.
+func (f *FollowupService) Build(args *model.CommandArgs, text string) *model.CommandResponse {
+	f.logger.Debug("Handling followup subcommand", "user_id", args.UserId, "channel_id", args.ChannelId, "root_id", args.RootId, "text", text)
+	parsed, err := parseFollowup(text)
+	if err != nil {
+		f.logger.Debug("Invalid followup subcommand", "user_id", args.UserId, "error", err)
+		return errorResponse(formatter.FormatFollowupError(err))
+	}
+	if args.RootId == "" {
+		return errorResponse(formatter.FormatFollowupError(errors.New(constants.FollowupErrNoThread)))
+	}
+	if err := checkMaxUserMessages(f.logger, f.store, args.UserId, f.maxUserMessages); err != nil {
+		return errorResponse(formatter.FormatFollowupError(err))
+	}
+
+	thread, err := f.poster.GetPostThread(args.RootId)
+	if err != nil {
+		f.logger.Error("Failed to get thread for follow-up", "user_id", args.UserId, "root_id", args.RootId, "error", err)
+		return errorResponse(formatter.FormatFollowupError(fmt.Errorf("failed to read thread: %w", err)))
+	}
+	now := f.clock.Now().UTC()
+	since := now
+	var watched *model.Post
+	if last := lastPostBy(thread, args.UserId); last != nil {
+		watched = last
+		since = time.UnixMilli(last.CreateAt).UTC()
+	} else {
+		watched = thread.Posts[args.RootId]
+	}
+	var mentions, replyFrom []string
+	if watched != nil {
+		mentions, replyFrom = f.resolveMentions(args.UserId, watched.Message)
+	}
+
+	tz := userTimezone(f.logger, f.userAPI, args.UserId)
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		f.logger.Warn("Failed to load timezone location, proceeding with UTC", "user_id", args.UserId, "timezone", tz, "error", err)
+		loc, tz = time.UTC, constants.DefaultTimezone
+	}
+	content := parsed.note
+	switch {
+	case parsed.mode == constants.FollowupBump:
+		content = formatter.FormatFollowupBump(mentions, parsed.note)
+	case content == "":
+		content = constants.FollowupDefaultNote
+	}
+	msg := &types.ScheduledMessage{
+		ID:                   f.store.GenerateMessageID(),
+		UserID:               args.UserId,
+		ChannelID:            args.ChannelId,
+		RootID:               args.RootId,
+		PostAt:               now.Add(parsed.delay),
+		MessageContent:       content,
+		Timezone:             tz,
+		CancelIfRepliedAfter: since,
+		ReplyFrom:            replyFrom,
+		Followup:             parsed.mode,
+	}
+	if err := f.store.SaveScheduledMessage(args.UserId, msg); err != nil {
+		f.logger.Error("Failed to save follow-up", "user_id", args.UserId, "message_id", msg.ID, "error", err)
+		return errorResponse(formatter.FormatFollowupError(err))
+	}
+	f.logger.Info("Follow-up set", "user_id", args.UserId, "message_id", msg.ID, "root_id", msg.RootID, "mode", msg.Followup, "post_at", msg.PostAt, "reply_from", msg.ReplyFrom)
+	return ephemeralResponse(formatter.FormatFollowupSet(msg.PostAt.In(loc), tz, msg.Followup, mentions))
+}
+
+// resolveMentions returns the @usernames mentioned in message, other than
+// the author, with their user IDs. Mentions that are not users, such as
+// @channel, are ignored.
+func (f *FollowupService) resolveMentions(authorID, message string) ([]string, []string) {
+	var mentions, userIDs []string
+	for _, match := range regexMention.FindAllStringSubmatch(strings.ToLower(message), -1) {
+		username := strings.TrimRight(match[1], ".-_")
+		user, err := f.userAPI.GetByUsername(username)
+		if err != nil || user == nil {
+			f.logger.Debug("Mention is not a user, ignoring", "username", username, "error", err)
+			continue
+		}
+		if user.Id == authorID || slices.Contains(userIDs, user.Id) {
+			continue
+		}
+		mentions = append(mentions, "@"+user.Username)
+		userIDs = append(userIDs, user.Id)
+	}
+	return mentions, userIDs
+}
+
+// lastPostBy returns the user's latest post in the thread, ignoring system
+// messages, or nil if they have none.
+func lastPostBy(thread *model.PostList, userID string) *model.Post {
+	var last *model.Post
+	for _, post := range thread.Posts {
+		if post.UserId != userID || post.IsSystemMessage() || post.DeleteAt != 0 {
+			continue
+		}
+		if last == nil || post.CreateAt > last.CreateAt {
+			last = post
+		}
+	}
+	return last
+}
+
+func parseFollowup(text string) (*parsedFollowup, error) {
+	matches := regexFollowup.FindStringSubmatch(strings.TrimSpace(text))
+	if matches == nil {
+		return nil, errors.New(constants.FollowupErrInvalidFormat)
+	}
+	delay, err := parseFollowupDelay(matches[1])
+	if err != nil {
+		return nil, err
+	}
+	mode := strings.ToLower(matches[2])
+	if mode == "" {
+		mode = constants.FollowupDM
+	}
+	return &parsedFollowup{delay: delay, mode: mode, note: strings.TrimSpace(matches[3])}, nil
+}
+
+// parseFollowupDelay reads a delay made of weeks, days, hours and minutes,
+// in that order, e.g. "2d" or "1d12h".
+func parseFollowupDelay(text string) (time.Duration, error) {
+	text = strings.ToLower(text)
+	matches := regexFollowupDelay.FindStringSubmatch(text)
+	if matches == nil {
+		return 0, fmt.Errorf(constants.FollowupErrInvalidDelay, text)
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute}
+	maxDelay := constants.MaxFollowupDays * 24 * time.Hour
+	var delay time.Duration
+	for i, unit := range units {
+		if matches[i+1] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(matches[i+1])
+		if err != nil {
+			return 0, fmt.Errorf(constants.FollowupErrInvalidDelay, text)
+		}
+		// Checked before multiplying so a huge count cannot overflow back
+		// into the allowed range.
+		if time.Duration(n) > maxDelay/unit {
+			return 0, errors.New(constants.FollowupErrDelayRange)
+		}
+		delay += time.Duration(n) * unit
+	}
+	if delay < time.Minute || delay > maxDelay {
+		return 0, errors.New(constants.FollowupErrDelayRange)
+	}
+	return delay, nil
+}
@@ -0,0 +1,153 @@
+package command
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/adapters/mock"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/testutil"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/formatter"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type followupMocks struct {
+	userAPI *mock.MockUserService
+	store   *mock.MockStore
+	poster  *mock.MockPostService
+}
+
+func setupFollowupServiceTest(t *testing.T) (*FollowupService, *followupMocks) {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	mocks := &followupMocks{
+		userAPI: mock.NewMockUserService(ctrl),
+		store:   mock.NewMockStore(ctrl),
+		poster:  mock.NewMockPostService(ctrl),
+	}
+	service := NewFollowupService(testutil.FakeLogger{}, mocks.userAPI, mocks.store, mocks.poster, mock.NewMockChannelService(ctrl), &testutil.FakeClock{NowTime: testNow}, testMaxUserMsgs)
+	return service, mocks
+}
+
+func followupArgs() *model.CommandArgs {
+	return &model.CommandArgs{UserId: testUserID, ChannelId: testChannelID, RootId: "root"}
+}
+
+func TestFollowupBuild_BumpWatchesMentionedUsers(t *testing.T) {
+	service, mocks := setupFollowupServiceTest(t)
+	asked := testNow.Add(-time.Hour)
+	thread := model.NewPostList()
+	thread.AddPost(&model.Post{Id: "root", UserId: "other", CreateAt: testNow.Add(-2 * time.Hour).UnixMilli(), Message: "@carol ideas?"})
+	thread.AddPost(&model.Post{Id: "p1", UserId: testUserID, CreateAt: asked.UnixMilli(), Message: "@Bob, can you review? cc @channel @me."})
+	thread.AddPost(&model.Post{Id: "p2", UserId: testUserID, CreateAt: testNow.UnixMilli(), Type: model.PostTypeJoinChannel})
+
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return(nil, nil)
+	mocks.poster.EXPECT().GetPostThread("root").Return(thread, nil)
+	mocks.userAPI.EXPECT().GetByUsername("bob").Return(&model.User{Id: "bob-id", Username: "bob"}, nil)
+	mocks.userAPI.EXPECT().GetByUsername("channel").Return(nil, errors.New("not found"))
+	mocks.userAPI.EXPECT().GetByUsername("me").Return(&model.User{Id: testUserID, Username: "me"}, nil)
+	mocks.userAPI.EXPECT().Get(testUserID).Return(&model.User{}, nil)
+	mocks.store.EXPECT().GenerateMessageID().Return(testMsgID)
+	mocks.store.EXPECT().SaveScheduledMessage(testUserID, &types.ScheduledMessage{
+		ID:                   testMsgID,
+		UserID:               testUserID,
+		ChannelID:            testChannelID,
+		RootID:               "root",
+		PostAt:               testNow.Add(36 * time.Hour),
+		MessageContent:       "@bob any news?",
+		Timezone:             testDefaultTZ,
+		CancelIfRepliedAfter: asked,
+		ReplyFrom:            []string{"bob-id"},
+		Followup:             constants.FollowupBump,
+	}).Return(nil)
+
+	resp := service.Build(followupArgs(), " in 1d12h bump message any news?")
+
+	assert.Equal(t, formatter.FormatFollowupSet(testNow.Add(36*time.Hour), testDefaultTZ, constants.FollowupBump, []string{"@bob"}), resp.Text)
+}
+
+func TestFollowupBuild_DMWithoutMentions(t *testing.T) {
+	service, mocks := setupFollowupServiceTest(t)
+	thread := model.NewPostList()
+	thread.AddPost(&model.Post{Id: "root", UserId: "other", Message: "Anyone around?"})
+
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return(nil, nil)
+	mocks.poster.EXPECT().GetPostThread("root").Return(thread, nil)
+	mocks.userAPI.EXPECT().Get(testUserID).Return(&model.User{}, nil)
+	mocks.store.EXPECT().GenerateMessageID().Return(testMsgID)
+	mocks.store.EXPECT().SaveScheduledMessage(testUserID, gomock.Any()).DoAndReturn(func(_ string, msg *types.ScheduledMessage) error {
+		assert.Equal(t, constants.FollowupDM, msg.Followup)
+		assert.Equal(t, testNow, msg.CancelIfRepliedAfter)
+		assert.Empty(t, msg.ReplyFrom)
+		assert.Equal(t, constants.FollowupDefaultNote, msg.MessageContent)
+		assert.Equal(t, testNow.Add(48*time.Hour), msg.PostAt)
+		return nil
+	})
+
+	resp := service.Build(followupArgs(), "in 2d")
+
+	assert.Contains(t, resp.Text, "If nobody else has replied in this thread by then, you will get a DM reminder.")
+}
+
+func TestFollowupBuild_Errors(t *testing.T) {
+	t.Run("outside a thread", func(t *testing.T) {
+		service, _ := setupFollowupServiceTest(t)
+		args := followupArgs()
+		args.RootId = ""
+
+		resp := service.Build(args, "in 2d")
+
+		assert.Equal(t, formatter.FormatFollowupError(errors.New(constants.FollowupErrNoThread)), resp.Text)
+	})
+	t.Run("invalid format", func(t *testing.T) {
+		service, _ := setupFollowupServiceTest(t)
+
+		resp := service.Build(followupArgs(), "tomorrow")
+
+		assert.Contains(t, resp.Text, constants.FollowupErrInvalidFormat)
+	})
+	t.Run("thread cannot be read", func(t *testing.T) {
+		service, mocks := setupFollowupServiceTest(t)
+		mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return(nil, nil)
+		mocks.poster.EXPECT().GetPostThread("root").Return(nil, errors.New("boom"))
+
+		resp := service.Build(followupArgs(), "in 2d")
+
+		assert.Contains(t, resp.Text, "failed to read thread: boom")
+	})
+}
+
+func TestParseFollowupDelay(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr string
+	}{
+		{input: "2d", want: 48 * time.Hour},
+		{input: "1w", want: 7 * 24 * time.Hour},
+		{input: "1D12H", want: 36 * time.Hour},
+		{input: "90m", want: 90 * time.Minute},
+		{input: "2", wantErr: "invalid follow-up delay '2'"},
+		{input: "12h1d", wantErr: "invalid follow-up delay '12h1d'"},
+		{input: "0m", wantErr: constants.FollowupErrDelayRange},
+		{input: "91d", wantErr: constants.FollowupErrDelayRange},
+		{input: "15250284455w", wantErr: constants.FollowupErrDelayRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseFollowupDelay(tt.input)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -4,6 +4,7 @@ package command
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
@@ -20,6 +21,12 @@ type Handler struct {
 	channel         ports.ChannelService
 	listService     ports.ListService
 	scheduleService ports.ScheduleService
+	templateService ports.TemplateService
+	exportService   ports.ExportService
+	calendarService ports.CalendarService
+	receiptService  ports.ReceiptService
+	followupService ports.FollowupService
+	adminService    ports.AdminService
 	helpText        string
 }
 
@@ -32,6 +39,12 @@ func NewHandler(
 	channel ports.ChannelService,
 	listSvc ports.ListService,
 	scheduleSvc ports.ScheduleService,
+	templateSvc ports.TemplateService,
+	exportSvc ports.ExportService,
+	calendarSvc ports.CalendarService,
+	receiptSvc ports.ReceiptService,
+	followupSvc ports.FollowupService,
+	adminSvc ports.AdminService,
 	helpText string,
 ) *Handler {
 	logger.Debug("Creating new command Handler")
@@ -43,6 +56,12 @@ func NewHandler(
 		channel:         channel,
 		listService:     listSvc,
 		scheduleService: scheduleSvc,
+		templateService: templateSvc,
+		exportService:   exportSvc,
+		calendarService: calendarSvc,
+		receiptService:  receiptSvc,
+		followupService: followupSvc,
+		adminService:    adminSvc,
 		helpText:        helpText,
 	}
 }
@@ -70,6 +89,27 @@ func (h *Handler) Execute(args *model.CommandArgs) (*model.CommandResponse, *mod
 	case strings.HasPrefix(commandText, constants.SubcommandList):
 		h.logger.Debug("Handling list subcommand", "user_id", args.UserId)
 		return h.BuildEphemeralList(args), nil
+	case strings.HasPrefix(commandText, constants.SubcommandTemplate):
+		h.logger.Debug("Handling template subcommand", "user_id", args.UserId)
+		return h.templateService.Build(args.UserId, commandText[len(constants.SubcommandTemplate):]), nil
+	case strings.HasPrefix(commandText, constants.SubcommandExport):
+		h.logger.Debug("Handling export subcommand", "user_id", args.UserId)
+		return h.exportService.Build(args.UserId, commandText[len(constants.SubcommandExport):]), nil
+	case strings.HasPrefix(commandText, constants.SubcommandCalendar):
+		h.logger.Debug("Handling calendar subcommand", "user_id", args.UserId)
+		return h.calendarService.Build(args.UserId, commandText[len(constants.SubcommandCalendar):]), nil
+	case strings.HasPrefix(commandText, constants.SubcommandReceipts):
+		h.logger.Debug("Handling receipts subcommand", "user_id", args.UserId)
+		return h.receiptService.Build(args.UserId, commandText[len(constants.SubcommandReceipts):]), nil
+	case strings.HasPrefix(commandText, constants.SubcommandFollowup):
+		h.logger.Debug("Handling followup subcommand", "user_id", args.UserId, "root_id", args.RootId)
+		return h.followupService.Build(args, commandText[len(constants.SubcommandFollowup):]), nil
+	case strings.HasPrefix(commandText, constants.SubcommandForward):
+		h.logger.Debug("Handling forward subcommand", "user_id", args.UserId)
+		return h.scheduleService.BuildForward(args, commandText[len(constants.SubcommandForward):]), nil
+	case strings.HasPrefix(commandText, constants.SubcommandAdmin):
+		h.logger.Debug("Handling admin subcommand", "user_id", args.UserId)
+		return h.adminService.Build(args.UserId, commandText[len(constants.SubcommandAdmin):]), nil
 	default:
 		h.logger.Debug("Handling schedule subcommand", "user_id", args.UserId, "command_text", commandText)
 		return h.handleSchedule(args, commandText), nil
@@ -119,6 +159,38 @@ func (h *Handler) UserSendMessage(userID string, msgID string) (*types.Scheduled
 	return msg, nil
 }
 
+// UserSnoozeMessage pushes a scheduled message back by its heads-up time,
+// so the author gets another heads-up at the time it was due to post.
+func (h *Handler) UserSnoozeMessage(userID string, msgID string) (*types.ScheduledMessage, error) {
+	h.logger.Debug("Attempting to snooze message", "user_id", userID, "message_id", msgID)
+	msg, err := h.store.GetScheduledMessage(msgID)
+	if err != nil {
+		h.logger.Error("Failed to get scheduled message for snooze", "message_id", msgID, "error", err)
+		return nil, err
+	}
+	if msg.UserID != userID {
+		h.logger.Warn("User attempted to snooze message owned by another user", "requesting_user_id", userID, "message_id", msgID, "owner_user_id", msg.UserID)
+		return nil, fmt.Errorf("user %s attempted to snooze message %s owned by %s", userID, msgID, msg.UserID)
+	}
+	if msg.WarnBefore <= 0 {
+		return nil, fmt.Errorf("message %s has no heads-up time to snooze by", msgID)
+	}
+	snoozed := *msg
+	snoozed.PostAt = msg.PostAt.Add(msg.WarnBefore)
+	snoozed.DeferredUntil = time.Time{}
+	snoozed.WarnedAt = time.Time{}
+	ok, err := h.store.SnoozeScheduledMessage(msgID, snoozed.PostAt)
+	if err != nil {
+		h.logger.Error("Failed to snooze scheduled message in store", "user_id", userID, "message_id", msgID, "error", err)
+		return nil, fmt.Errorf("failed to snooze scheduled message %s: %w", msgID, err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("message %s was already sent or changed", msgID)
+	}
+	h.logger.Info("Successfully snoozed scheduled message", "user_id", userID, "message_id", msgID, "post_at", snoozed.PostAt)
+	return &snoozed, nil
+}
+
 func (h *Handler) scheduleDefinition() *model.Command {
 	return &model.Command{
 		Trigger:          constants.CommandTrigger,
@@ -137,12 +209,45 @@ func (h *Handler) getScheduleAutocompleteData() *model.AutocompleteData {
 	at := model.NewAutocompleteData(constants.SubcommandAt, constants.AutocompleteAtHint, constants.AutocompleteAtDesc)
 	at.AddTextArgument(constants.AutocompleteAtArgTimeName, constants.AutocompleteAtArgTimeHint, "")
 	at.AddTextArgument(constants.AutocompleteAtArgDateName, constants.AutocompleteAtArgDateHint, "")
+	at.AddTextArgument(constants.AutocompleteAtArgChannelsName, constants.AutocompleteAtArgChannelsHint, "")
 	at.AddTextArgument(constants.AutocompleteAtArgMsgName, constants.AutocompleteAtArgMsgHint, "")
 	schedule.AddCommand(at)
 
 	list := model.NewAutocompleteData(constants.SubcommandList, constants.AutocompleteListHint, constants.AutocompleteListDesc)
 	schedule.AddCommand(list)
 
+	template := model.NewAutocompleteData(constants.SubcommandTemplate, constants.AutocompleteTemplateHint, constants.AutocompleteTemplateDesc)
+	templateSave := model.NewAutocompleteData(constants.TemplateActionSave, constants.AutocompleteTemplateSaveHint, constants.AutocompleteTemplateSaveDesc)
+	template.AddCommand(templateSave)
+	templateList := model.NewAutocompleteData(constants.TemplateActionList, "", constants.AutocompleteTemplateListDesc)
+	template.AddCommand(templateList)
+	templateDelete := model.NewAutocompleteData(constants.TemplateActionDelete, constants.AutocompleteTemplateDeleteHint, constants.AutocompleteTemplateDeleteDesc)
+	template.AddCommand(templateDelete)
+	schedule.AddCommand(template)
+
+	export := model.NewAutocompleteData(constants.SubcommandExport, constants.AutocompleteExportHint, constants.AutocompleteExportDesc)
+	schedule.AddCommand(export)
+
+	calendar := model.NewAutocompleteData(constants.SubcommandCalendar, constants.AutocompleteCalendarHint, constants.AutocompleteCalendarDesc)
+	schedule.AddCommand(calendar)
+
+	receipts := model.NewAutocompleteData(constants.SubcommandReceipts, constants.AutocompleteReceiptsHint, constants.AutocompleteReceiptsDesc)
+	schedule.AddCommand(receipts)
+
+	forward := model.NewAutocompleteData(constants.SubcommandForward, constants.AutocompleteForwardHint, constants.AutocompleteForwardDesc)
+	schedule.AddCommand(forward)
+
+	followup := model.NewAutocompleteData(constants.SubcommandFollowup, constants.AutocompleteFollowupHint, constants.AutocompleteFollowupDesc)
+	schedule.AddCommand(followup)
+
+	admin := model.NewAutocompleteData(constants.SubcommandAdmin, constants.AutocompleteAdminHint, constants.AutocompleteAdminDesc)
+	admin.RoleID = model.SystemAdminRoleId
+	adminStatus := model.NewAutocompleteData(constants.AdminActionStatus, "", constants.AutocompleteAdminStatusDesc)
+	admin.AddCommand(adminStatus)
+	adminRepair := model.NewAutocompleteData(constants.AdminActionRepair, "", constants.AutocompleteAdminRepairDesc)
+	admin.AddCommand(adminRepair)
+	schedule.AddCommand(admin)
+
 	help := model.NewAutocompleteData(constants.SubcommandHelp, constants.AutocompleteHelpHint, constants.AutocompleteHelpDesc)
 	schedule.AddCommand(help)
 
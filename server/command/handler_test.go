@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/adapters/mock"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/testutil"
@@ -24,6 +25,12 @@ type testMocks struct {
 	channel         *mock.MockChannelService
 	listService     *mock.MockListService
 	scheduleService *mock.MockScheduleService
+	templateService *mock.MockTemplateService
+	exportService   *mock.MockExportService
+	calendarService *mock.MockCalendarService
+	receiptService  *mock.MockReceiptService
+	followupService *mock.MockFollowupService
+	adminService    *mock.MockAdminService
 }
 
 func setup(t *testing.T) (*command.Handler, *testMocks, *gomock.Controller) {
@@ -37,6 +44,12 @@ func setup(t *testing.T) (*command.Handler, *testMocks, *gomock.Controller) {
 		channel:         mock.NewMockChannelService(ctrl),
 		listService:     mock.NewMockListService(ctrl),
 		scheduleService: mock.NewMockScheduleService(ctrl),
+		templateService: mock.NewMockTemplateService(ctrl),
+		exportService:   mock.NewMockExportService(ctrl),
+		calendarService: mock.NewMockCalendarService(ctrl),
+		receiptService:  mock.NewMockReceiptService(ctrl),
+		followupService: mock.NewMockFollowupService(ctrl),
+		adminService:    mock.NewMockAdminService(ctrl),
 	}
 
 	helpText := "Sample help text"
@@ -49,6 +62,12 @@ func setup(t *testing.T) (*command.Handler, *testMocks, *gomock.Controller) {
 		mocks.channel,
 		mocks.listService,
 		mocks.scheduleService,
+		mocks.templateService,
+		mocks.exportService,
+		mocks.calendarService,
+		mocks.receiptService,
+		mocks.followupService,
+		mocks.adminService,
 		helpText,
 	)
 	require.NotNil(t, handler)
@@ -65,6 +84,12 @@ func TestNewHandler_SuccessfulInstantiation(t *testing.T) {
 	mockChannel := mock.NewMockChannelService(ctrl)
 	mockListService := mock.NewMockListService(ctrl)
 	mockScheduleService := mock.NewMockScheduleService(ctrl)
+	mockTemplateService := mock.NewMockTemplateService(ctrl)
+	mockExportService := mock.NewMockExportService(ctrl)
+	mockCalendarService := mock.NewMockCalendarService(ctrl)
+	mockReceiptService := mock.NewMockReceiptService(ctrl)
+	mockFollowupService := mock.NewMockFollowupService(ctrl)
+	mockAdminService := mock.NewMockAdminService(ctrl)
 	helpText := "Test Help"
 
 	handler := command.NewHandler(
@@ -75,6 +100,12 @@ func TestNewHandler_SuccessfulInstantiation(t *testing.T) {
 		mockChannel,
 		mockListService,
 		mockScheduleService,
+		mockTemplateService,
+		mockExportService,
+		mockCalendarService,
+		mockReceiptService,
+		mockFollowupService,
+		mockAdminService,
 		helpText,
 	)
 
@@ -144,6 +175,145 @@ func TestExecute_ListSubcommand(t *testing.T) {
 	assert.Equal(t, expectedResp, resp)
 }
 
+func TestExecute_TemplateSubcommand(t *testing.T) {
+	handler, mocks, ctrl := setup(t)
+	defer ctrl.Finish()
+
+	userID := "testUserID"
+	args := &model.CommandArgs{
+		UserId:    userID,
+		ChannelId: "testChannelID",
+		Command:   "/" + constants.CommandTrigger + " " + constants.SubcommandTemplate + " save release checklist",
+	}
+	expectedResp := &model.CommandResponse{Text: "Template response"}
+
+	mocks.templateService.EXPECT().Build(userID, " save release checklist").Return(expectedResp)
+
+	resp, appErr := handler.Execute(args)
+
+	require.Nil(t, appErr)
+	assert.Equal(t, expectedResp, resp)
+}
+
+func TestExecute_ExportSubcommand(t *testing.T) {
+	handler, mocks, ctrl := setup(t)
+	defer ctrl.Finish()
+
+	userID := "testUserID"
+	args := &model.CommandArgs{
+		UserId:    userID,
+		ChannelId: "testChannelID",
+		Command:   "/" + constants.CommandTrigger + " " + constants.SubcommandExport + " csv",
+	}
+	expectedResp := &model.CommandResponse{Text: "Export response"}
+
+	mocks.exportService.EXPECT().Build(userID, " csv").Return(expectedResp)
+
+	resp, appErr := handler.Execute(args)
+
+	require.Nil(t, appErr)
+	assert.Equal(t, expectedResp, resp)
+}
+
+func TestExecute_ReceiptsSubcommand(t *testing.T) {
+	handler, mocks, ctrl := setup(t)
+	defer ctrl.Finish()
+
+	userID := "testUserID"
+	args := &model.CommandArgs{
+		UserId:    userID,
+		ChannelId: "testChannelID",
+		Command:   "/" + constants.CommandTrigger + " " + constants.SubcommandReceipts + " on",
+	}
+	expectedResp := &model.CommandResponse{Text: "Receipts response"}
+
+	mocks.receiptService.EXPECT().Build(userID, " on").Return(expectedResp)
+
+	resp, appErr := handler.Execute(args)
+
+	require.Nil(t, appErr)
+	assert.Equal(t, expectedResp, resp)
+}
+
+func TestExecute_FollowupSubcommand(t *testing.T) {
+	handler, mocks, ctrl := setup(t)
+	defer ctrl.Finish()
+
+	args := &model.CommandArgs{
+		UserId:    "testUserID",
+		ChannelId: "testChannelID",
+		RootId:    "testRootID",
+		Command:   "/" + constants.CommandTrigger + " " + constants.SubcommandFollowup + " in 2d",
+	}
+	expectedResp := &model.CommandResponse{Text: "Follow-up response"}
+
+	mocks.followupService.EXPECT().Build(args, " in 2d").Return(expectedResp)
+
+	resp, appErr := handler.Execute(args)
+
+	require.Nil(t, appErr)
+	assert.Equal(t, expectedResp, resp)
+}
+
+func TestExecute_ForwardSubcommand(t *testing.T) {
+	handler, mocks, ctrl := setup(t)
+	defer ctrl.Finish()
+
+	args := &model.CommandArgs{
+		UserId:    "testUserID",
+		ChannelId: "testChannelID",
+		Command:   "/" + constants.CommandTrigger + " " + constants.SubcommandForward + " abc at 9am",
+	}
+	expectedResp := &model.CommandResponse{Text: "Forward response"}
+
+	mocks.scheduleService.EXPECT().BuildForward(args, " abc at 9am").Return(expectedResp)
+
+	resp, appErr := handler.Execute(args)
+
+	require.Nil(t, appErr)
+	assert.Equal(t, expectedResp, resp)
+}
+
+func TestExecute_CalendarSubcommand(t *testing.T) {
+	handler, mocks, ctrl := setup(t)
+	defer ctrl.Finish()
+
+	userID := "testUserID"
+	args := &model.CommandArgs{
+		UserId:    userID,
+		ChannelId: "testChannelID",
+		Command:   "/" + constants.CommandTrigger + " " + constants.SubcommandCalendar + " revoke",
+	}
+	expectedResp := &model.CommandResponse{Text: "Calendar response"}
+
+	mocks.calendarService.EXPECT().Build(userID, " revoke").Return(expectedResp)
+
+	resp, appErr := handler.Execute(args)
+
+	require.Nil(t, appErr)
+	assert.Equal(t, expectedResp, resp)
+}
+
+func TestExecute_AdminSubcommand(t *testing.T) {
+	handler, mocks, ctrl := setup(t)
+	defer ctrl.Finish()
+
+	userID := "testUserID"
+	args := &model.CommandArgs{
+		UserId:    userID,
+		ChannelId: "testChannelID",
+		Command:   "/" + constants.CommandTrigger + " " + constants.SubcommandAdmin + " status",
+	}
+	expectedResp := &model.CommandResponse{Text: "Admin response"}
+
+	mocks.adminService.EXPECT().Build(userID, " status").Return(expectedResp)
+
+	resp, appErr := handler.Execute(args)
+
+	require.Nil(t, appErr)
+	assert.Equal(t, expectedResp, resp)
+}
+
 func TestExecute_ScheduleSubcommand_Default(t *testing.T) {
 	handler, mocks, ctrl := setup(t)
 	defer ctrl.Finish()
@@ -326,3 +496,56 @@ func TestUserSendMessage_Failure_OwnershipMismatch(t *testing.T) {
 	assert.Nil(t, returnedMsg)
 	assert.EqualError(t, err, expectedErr.Error())
 }
+
+func TestUserSnoozeMessage_Success(t *testing.T) {
+	handler, mocks, ctrl := setup(t)
+	defer ctrl.Finish()
+
+	postAt := time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+	msg := &types.ScheduledMessage{ID: "m1", UserID: "u1", PostAt: postAt, WarnBefore: 15 * time.Minute, WarnedAt: postAt.Add(-15 * time.Minute)}
+
+	mocks.store.EXPECT().GetScheduledMessage("m1").Return(msg, nil)
+	mocks.store.EXPECT().SnoozeScheduledMessage("m1", postAt.Add(15*time.Minute)).Return(true, nil)
+
+	snoozed, err := handler.UserSnoozeMessage("u1", "m1")
+
+	require.NoError(t, err)
+	assert.Equal(t, postAt.Add(15*time.Minute), snoozed.PostAt)
+	assert.True(t, snoozed.WarnedAt.IsZero())
+	assert.Equal(t, postAt, msg.PostAt, "stored copy is not modified")
+}
+
+func TestUserSnoozeMessage_Failures(t *testing.T) {
+	postAt := time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+
+	t.Run("owned by another user", func(t *testing.T) {
+		handler, mocks, ctrl := setup(t)
+		defer ctrl.Finish()
+		mocks.store.EXPECT().GetScheduledMessage("m1").Return(&types.ScheduledMessage{ID: "m1", UserID: "owner", PostAt: postAt, WarnBefore: time.Minute}, nil)
+
+		_, err := handler.UserSnoozeMessage("u1", "m1")
+
+		assert.EqualError(t, err, "user u1 attempted to snooze message m1 owned by owner")
+	})
+
+	t.Run("no heads-up", func(t *testing.T) {
+		handler, mocks, ctrl := setup(t)
+		defer ctrl.Finish()
+		mocks.store.EXPECT().GetScheduledMessage("m1").Return(&types.ScheduledMessage{ID: "m1", UserID: "u1", PostAt: postAt}, nil)
+
+		_, err := handler.UserSnoozeMessage("u1", "m1")
+
+		assert.EqualError(t, err, "message m1 has no heads-up time to snooze by")
+	})
+
+	t.Run("already sent", func(t *testing.T) {
+		handler, mocks, ctrl := setup(t)
+		defer ctrl.Finish()
+		mocks.store.EXPECT().GetScheduledMessage("m1").Return(&types.ScheduledMessage{ID: "m1", UserID: "u1", PostAt: postAt, WarnBefore: time.Minute}, nil)
+		mocks.store.EXPECT().SnoozeScheduledMessage("m1", postAt.Add(time.Minute)).Return(false, nil)
+
+		_, err := handler.UserSnoozeMessage("u1", "m1")
+
+		assert.EqualError(t, err, "message m1 was already sent or changed")
+	})
+}
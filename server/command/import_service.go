@@ -0,0 +1,228 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/transfer"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+)
+
+// ImportService recreates scheduled messages from an export file.
+type ImportService struct {
+	logger          ports.Logger
+	store           ports.Store
+	channel         ports.ChannelService
+	poster          ports.PostService
+	clock           ports.Clock
+	maxUserMessages int
+}
+
+// NewImportService constructs an ImportService.
+func NewImportService(
+	logger ports.Logger,
+	store ports.Store,
+	channel ports.ChannelService,
+	poster ports.PostService,
+	clk ports.Clock,
+	maxUserMessages int,
+) *ImportService {
+	logger.Debug("Creating new ImportService")
+	return &ImportService{
+		logger:          logger,
+		store:           store,
+		channel:         channel,
+		poster:          poster,
+		clock:           clk,
+		maxUserMessages: maxUserMessages,
+	}
+}
+
+// Import validates each record in the file and schedules the valid ones for
+// the importing user. Records are rejected individually; an error is only
+// returned when the file itself cannot be read.
+func (i *ImportService) Import(userID, format string, data []byte) (*types.ImportReport, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	i.logger.Info("Importing scheduled messages for user", "user_id", userID, "format", format, "bytes", len(data))
+	rows, err := transfer.Decode(format, data)
+	if err != nil {
+		i.logger.Warn("Failed to decode import file", "user_id", userID, "format", format, "error", err)
+		return nil, err
+	}
+
+	ids, err := i.store.ListUserMessageIDs(userID)
+	if err != nil {
+		i.logger.Error("Failed to list user message IDs for import", "user_id", userID, "error", err)
+		return nil, fmt.Errorf("failed to check message count: %w", err)
+	}
+	count := len(ids)
+	now := i.clock.Now()
+
+	report := &types.ImportReport{Rows: make([]types.ImportRowResult, 0, len(rows))}
+	for _, row := range rows {
+		result := types.ImportRowResult{Row: row.Number}
+		msgID, rowErr := i.importRow(userID, row, count, now)
+		if rowErr != nil {
+			i.logger.Debug("Rejected import row", "user_id", userID, "row", row.Number, "error", rowErr)
+			result.Error = rowErr.Error()
+			report.Rejected++
+		} else {
+			result.MessageID = msgID
+			report.Imported++
+			count++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+	i.logger.Info("Finished importing scheduled messages", "user_id", userID, "imported", report.Imported, "rejected", report.Rejected)
+	return report, nil
+}
+
+func (i *ImportService) importRow(userID string, row transfer.Row, count int, now time.Time) (string, error) {
+	if row.Err != nil {
+		return "", row.Err
+	}
+	if err := i.validateRow(userID, row.Message, now); err != nil {
+		return "", err
+	}
+	if count >= i.maxUserMessages {
+		return "", fmt.Errorf("cannot schedule more than %d messages (current: %d)", i.maxUserMessages, count)
+	}
+
+	destinations := row.Message.Destinations()
+	rootID := ""
+	if len(destinations) == 1 && row.Message.RootID != "" {
+		var err error
+		if rootID, err = i.resolveRoot(destinations[0], row.Message.RootID); err != nil {
+			return "", err
+		}
+	}
+	msg := &types.ScheduledMessage{
+		ID:                   i.store.GenerateMessageID(),
+		UserID:               userID,
+		ChannelID:            destinations[0],
+		RootID:               rootID,
+		PostAt:               row.Message.PostAt.UTC(),
+		MessageContent:       row.Message.MessageContent,
+		Timezone:             row.Message.Timezone,
+		Occurrence:           row.Message.Occurrence,
+		RecurrenceID:         row.Message.RecurrenceID,
+		Attribution:          row.Message.Attribution,
+		Receipt:              row.Message.Receipt,
+		WarnBefore:           row.Message.WarnBefore,
+		CancelIfRepliedAfter: row.Message.CancelIfRepliedAfter,
+		ReplyFrom:            row.Message.ReplyFrom,
+		Followup:             row.Message.Followup,
+		ForwardedPostID:      row.Message.ForwardedPostID,
+		FileIDs:              row.Message.FileIDs,
+		CommentBytes:         row.Message.CommentBytes,
+		Literal:              row.Message.Literal,
+	}
+	if len(destinations) > 1 {
+		msg.ChannelIDs = destinations
+	}
+	if err := i.store.SaveScheduledMessage(userID, msg); err != nil {
+		i.logger.Error("Failed to save imported message", "user_id", userID, "row", row.Number, "error", err)
+		return "", fmt.Errorf("failed to save message: %w", err)
+	}
+	i.logger.Debug("Imported scheduled message", "user_id", userID, "row", row.Number, "message_id", msg.ID)
+	return msg.ID, nil
+}
+
+// resolveRoot returns the thread root for a reply to postID, which must be a
+// post in channelID. The user's membership of channelID is already checked.
+func (i *ImportService) resolveRoot(channelID, postID string) (string, error) {
+	post, err := i.poster.GetPost(postID)
+	if err != nil || post == nil || post.DeleteAt != 0 {
+		i.logger.Debug("Imported thread root not found", "channel_id", channelID, "root_id", postID, "error", err)
+		return "", fmt.Errorf("thread root %s not found", postID)
+	}
+	if post.ChannelId != channelID {
+		return "", fmt.Errorf("thread root %s is not in channel %s", postID, channelID)
+	}
+	if post.RootId != "" {
+		return post.RootId, nil
+	}
+	return post.Id, nil
+}
+
+func (i *ImportService) validateRow(userID string, msg *types.ScheduledMessage, now time.Time) error {
+	if strings.TrimSpace(msg.MessageContent) == "" {
+		return errors.New("message is empty")
+	}
+	if err := checkMaxMessageBytes(i.logger, msg.MessageContent); err != nil {
+		return err
+	}
+	if msg.ForwardedPostID != "" && (msg.CommentBytes < 0 || msg.CommentBytes > len(msg.MessageContent)) {
+		return fmt.Errorf("invalid comment_bytes %d", msg.CommentBytes)
+	}
+	switch msg.Attribution {
+	case "", constants.AttributionUser, constants.AttributionBot:
+	default:
+		return fmt.Errorf("invalid attribution %q", msg.Attribution)
+	}
+	switch msg.Receipt {
+	case "", constants.ReceiptOn, constants.ReceiptOff:
+	default:
+		return fmt.Errorf("invalid receipt %q", msg.Receipt)
+	}
+	if msg.WarnBefore != 0 && (msg.WarnBefore < time.Minute || msg.WarnBefore > constants.MaxWarnMinutes*time.Minute) {
+		return errors.New(constants.ParserErrWarnRange)
+	}
+	if !msg.CancelIfRepliedAfter.IsZero() && (msg.RootID == "" || len(msg.Destinations()) > 1) {
+		return errors.New(constants.ScheduleErrUnlessRepliedNoThread)
+	}
+	switch msg.Followup {
+	case "", constants.FollowupDM, constants.FollowupBump:
+	default:
+		return fmt.Errorf("invalid followup %q", msg.Followup)
+	}
+	if (msg.Followup != "" || len(msg.ReplyFrom) > 0) && msg.CancelIfRepliedAfter.IsZero() {
+		return errors.New("follow-ups must have a cancel_if_replied_after time")
+	}
+	if len(msg.FileIDs) > 0 && msg.ForwardedPostID == "" {
+		return errors.New("file_ids are only allowed on forwarded messages")
+	}
+	loc, err := time.LoadLocation(msg.Timezone)
+	if msg.Timezone == "" || err != nil {
+		return fmt.Errorf("invalid timezone %q", msg.Timezone)
+	}
+	if !msg.PostAt.After(now) {
+		return fmt.Errorf("scheduled time %s is in the past", msg.PostAt.In(loc).Format(constants.TimeLayout))
+	}
+	for _, channelID := range msg.Destinations() {
+		if channelID == "" {
+			return errors.New("missing channel_id")
+		}
+		if err := i.channel.VerifyCanPost(channelID, userID); err != nil {
+			return err
+		}
+	}
+	return i.validateForward(userID, msg)
+}
+
+// validateForward checks that a forwarded post is still readable by the user
+// and that any attachments to copy belong to it.
+func (i *ImportService) validateForward(userID string, msg *types.ScheduledMessage) error {
+	if msg.ForwardedPostID == "" {
+		return nil
+	}
+	post, err := i.poster.GetPost(msg.ForwardedPostID)
+	if err != nil || post == nil || post.DeleteAt != 0 {
+		i.logger.Debug("Imported forwarded post not found", "user_id", userID, "post_id", msg.ForwardedPostID, "error", err)
+		return fmt.Errorf("forwarded post %s not found", msg.ForwardedPostID)
+	}
+	if err := i.channel.VerifyMembership(post.ChannelId, userID); err != nil {
+		return err
+	}
+	for _, fileID := range msg.FileIDs {
+		if !slices.Contains(post.FileIds, fileID) {
+			return fmt.Errorf("file %s is not attached to forwarded post %s", fileID, msg.ForwardedPostID)
+		}
+	}
+	return nil
+}
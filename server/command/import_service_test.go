@@ -0,0 +1,328 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/adapters/mock"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/testutil"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/transfer"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func setupImportServiceTest(t *testing.T, maxUserMessages int) (*ImportService, *mock.MockStore, *mock.MockChannelService, *mock.MockPostService) {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	mockStore := mock.NewMockStore(ctrl)
+	mockChannel := mock.NewMockChannelService(ctrl)
+	mockPoster := mock.NewMockPostService(ctrl)
+	service := NewImportService(&testutil.FakeLogger{}, mockStore, mockChannel, mockPoster, testutil.FakeClock{NowTime: testNow}, maxUserMessages)
+	require.NotNil(t, service)
+	return service, mockStore, mockChannel, mockPoster
+}
+
+func encodeForImport(t *testing.T, msgs ...*types.ScheduledMessage) []byte {
+	t.Helper()
+	data, err := transfer.Encode(transfer.FormatJSON, msgs)
+	require.NoError(t, err)
+	return data
+}
+
+func TestImport_ValidatesEachRow(t *testing.T) {
+	service, mockStore, mockChannel, mockPoster := setupImportServiceTest(t, testMaxUserMsgs)
+	future := testNow.Add(time.Hour)
+	valid := &types.ScheduledMessage{ID: "old1", UserID: "someone-else", ChannelID: "chan1", RootID: "root1", PostAt: future, MessageContent: "hello", Timezone: "UTC"}
+	crossPost := &types.ScheduledMessage{ID: "old2", ChannelID: "chan1", ChannelIDs: []string{"chan1", "chan2"}, RootID: "root2", PostAt: future, MessageContent: "all", Timezone: "UTC"}
+	past := &types.ScheduledMessage{ID: "old3", ChannelID: "chan1", PostAt: testNow.Add(-time.Hour), MessageContent: "late", Timezone: "UTC"}
+	noAccess := &types.ScheduledMessage{ID: "old4", ChannelID: "secret", PostAt: future, MessageContent: "hi", Timezone: "UTC"}
+	empty := &types.ScheduledMessage{ID: "old5", ChannelID: "chan1", PostAt: future, MessageContent: "  ", Timezone: "UTC"}
+	badTZ := &types.ScheduledMessage{ID: "old6", ChannelID: "chan1", PostAt: future, MessageContent: "hi", Timezone: "Mars/Olympus"}
+
+	mockStore.EXPECT().ListUserMessageIDs(testUserID).Return([]string{"existing"}, nil)
+	mockChannel.EXPECT().VerifyCanPost("chan1", testUserID).Return(nil).Times(2)
+	mockChannel.EXPECT().VerifyCanPost("chan2", testUserID).Return(nil)
+	mockChannel.EXPECT().VerifyCanPost("secret", testUserID).Return(errors.New("you are not a member of channel secret"))
+	mockPoster.EXPECT().GetPost("root1").Return(&model.Post{Id: "root1", ChannelId: "chan1"}, nil)
+	mockStore.EXPECT().GenerateMessageID().Return("new1")
+	mockStore.EXPECT().GenerateMessageID().Return("new2")
+	mockStore.EXPECT().SaveScheduledMessage(testUserID, &types.ScheduledMessage{ID: "new1", UserID: testUserID, ChannelID: "chan1", RootID: "root1", PostAt: future, MessageContent: "hello", Timezone: "UTC"}).Return(nil)
+	mockStore.EXPECT().SaveScheduledMessage(testUserID, &types.ScheduledMessage{ID: "new2", UserID: testUserID, ChannelID: "chan1", ChannelIDs: []string{"chan1", "chan2"}, PostAt: future, MessageContent: "all", Timezone: "UTC"}).Return(nil)
+
+	report, err := service.Import(testUserID, "JSON", encodeForImport(t, valid, crossPost, past, noAccess, empty, badTZ))
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, 4, report.Rejected)
+	require.Len(t, report.Rows, 6)
+	assert.Equal(t, types.ImportRowResult{Row: 1, MessageID: "new1"}, report.Rows[0])
+	assert.Equal(t, types.ImportRowResult{Row: 2, MessageID: "new2"}, report.Rows[1])
+	assert.Contains(t, report.Rows[2].Error, "is in the past")
+	assert.Equal(t, "you are not a member of channel secret", report.Rows[3].Error)
+	assert.Equal(t, "message is empty", report.Rows[4].Error)
+	assert.Equal(t, `invalid timezone "Mars/Olympus"`, report.Rows[5].Error)
+}
+
+func TestImport_RoundTrip(t *testing.T) {
+	future := testNow.Add(time.Hour)
+	exported := &types.ScheduledMessage{
+		ID:                   "old1",
+		UserID:               "someone-else",
+		ChannelID:            "chan1",
+		RootID:               "root1",
+		PostAt:               future,
+		MessageContent:       "hello\n\n> {{unknown}}",
+		Timezone:             "UTC",
+		Occurrence:           2,
+		RecurrenceID:         "event-uid",
+		Attribution:          constants.AttributionBot,
+		Receipt:              constants.ReceiptOn,
+		WarnBefore:           15 * time.Minute,
+		CancelIfRepliedAfter: testNow.Add(-time.Minute),
+		ReplyFrom:            []string{"bob-id"},
+		Followup:             constants.FollowupDM,
+		ForwardedPostID:      "fwd1",
+		FileIDs:              []string{"file1"},
+		CommentBytes:         len("hello"),
+		WarnedAt:             testNow,
+		Sequence:             7,
+	}
+	want := &types.ScheduledMessage{
+		ID:                   "new1",
+		UserID:               testUserID,
+		ChannelID:            "chan1",
+		RootID:               "root1",
+		PostAt:               future,
+		MessageContent:       "hello\n\n> {{unknown}}",
+		Timezone:             "UTC",
+		Occurrence:           2,
+		RecurrenceID:         "event-uid",
+		Attribution:          constants.AttributionBot,
+		Receipt:              constants.ReceiptOn,
+		WarnBefore:           15 * time.Minute,
+		CancelIfRepliedAfter: testNow.Add(-time.Minute),
+		ReplyFrom:            []string{"bob-id"},
+		Followup:             constants.FollowupDM,
+		ForwardedPostID:      "fwd1",
+		FileIDs:              []string{"file1"},
+		CommentBytes:         len("hello"),
+	}
+	for _, format := range []string{transfer.FormatJSON, transfer.FormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			service, mockStore, mockChannel, mockPoster := setupImportServiceTest(t, testMaxUserMsgs)
+			data, err := transfer.Encode(format, []*types.ScheduledMessage{exported})
+			require.NoError(t, err)
+
+			mockStore.EXPECT().ListUserMessageIDs(testUserID).Return(nil, nil)
+			mockChannel.EXPECT().VerifyCanPost("chan1", testUserID).Return(nil)
+			mockPoster.EXPECT().GetPost("fwd1").Return(&model.Post{Id: "fwd1", ChannelId: "chan2", FileIds: []string{"file1", "file2"}}, nil)
+			mockChannel.EXPECT().VerifyMembership("chan2", testUserID).Return(nil)
+			mockPoster.EXPECT().GetPost("root1").Return(&model.Post{Id: "root1", ChannelId: "chan1"}, nil)
+			mockStore.EXPECT().GenerateMessageID().Return("new1")
+			mockStore.EXPECT().SaveScheduledMessage(testUserID, want).Return(nil)
+
+			report, err := service.Import(testUserID, format, data)
+
+			require.NoError(t, err)
+			assert.Equal(t, 1, report.Imported, report.Rows)
+		})
+	}
+}
+
+func TestImport_LiteralContentIsNotATemplate(t *testing.T) {
+	service, mockStore, mockChannel, _ := setupImportServiceTest(t, testMaxUserMsgs)
+	msg := &types.ScheduledMessage{ChannelID: "chan1", PostAt: testNow.Add(time.Hour), MessageContent: ":calendar: **Retro {{dat}}** starts in 15 minutes.", Timezone: "UTC", Literal: true}
+
+	mockStore.EXPECT().ListUserMessageIDs(testUserID).Return(nil, nil)
+	mockChannel.EXPECT().VerifyCanPost("chan1", testUserID).Return(nil)
+	mockStore.EXPECT().GenerateMessageID().Return("new1")
+	mockStore.EXPECT().SaveScheduledMessage(testUserID, gomock.Any()).DoAndReturn(func(_ string, saved *types.ScheduledMessage) error {
+		assert.True(t, saved.Literal)
+		return nil
+	})
+
+	report, err := service.Import(testUserID, "json", encodeForImport(t, msg))
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Imported, report.Rows)
+}
+
+func TestImport_RejectsInvalidFields(t *testing.T) {
+	future := testNow.Add(time.Hour)
+	tests := []struct {
+		name    string
+		modify  func(*types.ScheduledMessage)
+		wantErr string
+	}{
+		{name: "attribution", modify: func(m *types.ScheduledMessage) { m.Attribution = "robot" }, wantErr: `invalid attribution "robot"`},
+		{name: "receipt", modify: func(m *types.ScheduledMessage) { m.Receipt = "maybe" }, wantErr: `invalid receipt "maybe"`},
+		{name: "heads-up time", modify: func(m *types.ScheduledMessage) { m.WarnBefore = 48 * time.Hour }, wantErr: constants.ParserErrWarnRange},
+		{name: "unless replied outside a thread", modify: func(m *types.ScheduledMessage) { m.CancelIfRepliedAfter = testNow }, wantErr: constants.ScheduleErrUnlessRepliedNoThread},
+		{name: "follow-up mode", modify: func(m *types.ScheduledMessage) { m.Followup = "shout" }, wantErr: `invalid followup "shout"`},
+		{name: "follow-up without cutoff", modify: func(m *types.ScheduledMessage) { m.Followup = constants.FollowupDM }, wantErr: "follow-ups must have a cancel_if_replied_after time"},
+		{name: "comment past the end of a forward", modify: func(m *types.ScheduledMessage) { m.ForwardedPostID, m.CommentBytes = "fwd1", 10 }, wantErr: "invalid comment_bytes 10"},
+		{name: "attachments without a forward", modify: func(m *types.ScheduledMessage) { m.FileIDs = []string{"file1"} }, wantErr: "file_ids are only allowed on forwarded messages"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockStore, _, _ := setupImportServiceTest(t, testMaxUserMsgs)
+			msg := &types.ScheduledMessage{ChannelID: "chan1", PostAt: future, MessageContent: "hi", Timezone: "UTC"}
+			tt.modify(msg)
+
+			mockStore.EXPECT().ListUserMessageIDs(testUserID).Return(nil, nil)
+
+			report, err := service.Import(testUserID, "json", encodeForImport(t, msg))
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantErr, report.Rows[0].Error)
+		})
+	}
+}
+
+func TestImport_ThreadRoot(t *testing.T) {
+	future := testNow.Add(time.Hour)
+	tests := []struct {
+		name     string
+		post     *model.Post
+		err      error
+		wantRoot string
+		wantErr  string
+	}{
+		{name: "reply is moved to its thread root", post: &model.Post{Id: "reply1", ChannelId: "chan1", RootId: "root1"}, wantRoot: "root1"},
+		{name: "root in another channel", post: &model.Post{Id: "reply1", ChannelId: "chan2"}, wantErr: "thread root reply1 is not in channel chan1"},
+		{name: "deleted root", post: &model.Post{Id: "reply1", ChannelId: "chan1", DeleteAt: 1}, wantErr: "thread root reply1 not found"},
+		{name: "missing root", err: errors.New("not found"), wantErr: "thread root reply1 not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockStore, mockChannel, mockPoster := setupImportServiceTest(t, testMaxUserMsgs)
+			msg := &types.ScheduledMessage{ChannelID: "chan1", RootID: "reply1", PostAt: future, MessageContent: "hi", Timezone: "UTC"}
+
+			mockStore.EXPECT().ListUserMessageIDs(testUserID).Return(nil, nil)
+			mockChannel.EXPECT().VerifyCanPost("chan1", testUserID).Return(nil)
+			mockPoster.EXPECT().GetPost("reply1").Return(tt.post, tt.err)
+			if tt.wantErr == "" {
+				mockStore.EXPECT().GenerateMessageID().Return("new1")
+				mockStore.EXPECT().SaveScheduledMessage(testUserID, gomock.Any()).DoAndReturn(func(_ string, saved *types.ScheduledMessage) error {
+					assert.Equal(t, tt.wantRoot, saved.RootID)
+					return nil
+				})
+			}
+
+			report, err := service.Import(testUserID, "json", encodeForImport(t, msg))
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantErr, report.Rows[0].Error)
+		})
+	}
+}
+
+func TestImport_ForwardedPost(t *testing.T) {
+	future := testNow.Add(time.Hour)
+	tests := []struct {
+		name          string
+		fileIDs       []string
+		post          *model.Post
+		err           error
+		membershipErr error
+		wantErr       string
+	}{
+		{name: "readable post with its attachments", fileIDs: []string{"file1"}, post: &model.Post{Id: "fwd1", ChannelId: "chan2", FileIds: []string{"file1"}}},
+		{name: "attachment from another post", fileIDs: []string{"file9"}, post: &model.Post{Id: "fwd1", ChannelId: "chan2", FileIds: []string{"file1"}}, wantErr: "file file9 is not attached to forwarded post fwd1"},
+		{name: "post in an unreadable channel", post: &model.Post{Id: "fwd1", ChannelId: "chan2"}, membershipErr: errors.New("you are not a member of this channel"), wantErr: "you are not a member of this channel"},
+		{name: "deleted post", post: &model.Post{Id: "fwd1", ChannelId: "chan2", DeleteAt: 1}, wantErr: "forwarded post fwd1 not found"},
+		{name: "missing post", err: errors.New("not found"), wantErr: "forwarded post fwd1 not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockStore, mockChannel, mockPoster := setupImportServiceTest(t, testMaxUserMsgs)
+			msg := &types.ScheduledMessage{ChannelID: "chan1", PostAt: future, MessageContent: "hi", Timezone: "UTC", ForwardedPostID: "fwd1", FileIDs: tt.fileIDs}
+
+			mockStore.EXPECT().ListUserMessageIDs(testUserID).Return(nil, nil)
+			mockChannel.EXPECT().VerifyCanPost("chan1", testUserID).Return(nil)
+			mockPoster.EXPECT().GetPost("fwd1").Return(tt.post, tt.err)
+			if tt.post != nil && tt.post.DeleteAt == 0 {
+				mockChannel.EXPECT().VerifyMembership("chan2", testUserID).Return(tt.membershipErr)
+			}
+			if tt.wantErr == "" {
+				mockStore.EXPECT().GenerateMessageID().Return("new1")
+				mockStore.EXPECT().SaveScheduledMessage(testUserID, gomock.Any()).Return(nil)
+			}
+
+			report, err := service.Import(testUserID, "json", encodeForImport(t, msg))
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantErr, report.Rows[0].Error)
+		})
+	}
+}
+
+func TestImport_EnforcesQuota(t *testing.T) {
+	service, mockStore, mockChannel, _ := setupImportServiceTest(t, 2)
+	future := testNow.Add(time.Hour)
+	msg := &types.ScheduledMessage{ChannelID: "chan1", PostAt: future, MessageContent: "hi", Timezone: "UTC"}
+
+	mockStore.EXPECT().ListUserMessageIDs(testUserID).Return([]string{"existing"}, nil)
+	mockChannel.EXPECT().VerifyCanPost("chan1", testUserID).Return(nil).Times(2)
+	mockStore.EXPECT().GenerateMessageID().Return("new1")
+	mockStore.EXPECT().SaveScheduledMessage(testUserID, gomock.Any()).Return(nil)
+
+	report, err := service.Import(testUserID, "json", encodeForImport(t, msg, msg))
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, 1, report.Rejected)
+	assert.Equal(t, "cannot schedule more than 2 messages (current: 2)", report.Rows[1].Error)
+}
+
+func TestImport_CSVDecodeErrorsAreReportedPerRow(t *testing.T) {
+	service, mockStore, _, _ := setupImportServiceTest(t, testMaxUserMsgs)
+	data := "id,channel_id,channel_ids,root_id,post_at,timezone,occurrence,message_content\nid1,chan1,,,yesterday,UTC,,hi\n"
+
+	mockStore.EXPECT().ListUserMessageIDs(testUserID).Return(nil, nil)
+
+	report, err := service.Import(testUserID, "csv", []byte(data))
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Rejected)
+	assert.Contains(t, report.Rows[0].Error, "invalid post_at")
+}
+
+func TestImport_SaveError(t *testing.T) {
+	service, mockStore, mockChannel, _ := setupImportServiceTest(t, testMaxUserMsgs)
+	msg := &types.ScheduledMessage{ChannelID: "chan1", PostAt: testNow.Add(time.Hour), MessageContent: "hi", Timezone: "UTC"}
+
+	mockStore.EXPECT().ListUserMessageIDs(testUserID).Return(nil, nil)
+	mockChannel.EXPECT().VerifyCanPost("chan1", testUserID).Return(nil)
+	mockStore.EXPECT().GenerateMessageID().Return("new1")
+	mockStore.EXPECT().SaveScheduledMessage(testUserID, gomock.Any()).Return(errors.New("kv down"))
+
+	report, err := service.Import(testUserID, "json", encodeForImport(t, msg))
+
+	require.NoError(t, err)
+	assert.Equal(t, "failed to save message: kv down", report.Rows[0].Error)
+}
+
+func TestImport_UnreadableFile(t *testing.T) {
+	service, _, _, _ := setupImportServiceTest(t, testMaxUserMsgs)
+
+	_, err := service.Import(testUserID, "json", []byte("{"))
+
+	assert.ErrorContains(t, err, "invalid JSON export")
+}
+
+func TestImport_ListError(t *testing.T) {
+	service, mockStore, _, _ := setupImportServiceTest(t, testMaxUserMsgs)
+
+	mockStore.EXPECT().ListUserMessageIDs(testUserID).Return(nil, fmt.Errorf("kv down"))
+
+	_, err := service.Import(testUserID, "json", []byte("[]"))
+
+	assert.ErrorContains(t, err, "failed to check message count")
+}
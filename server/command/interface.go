@@ -12,4 +12,5 @@ type Interface interface {
 	BuildEphemeralList(args *model.CommandArgs) *model.CommandResponse
 	UserDeleteMessage(userID, msgID string) (*types.ScheduledMessage, error)
 	UserSendMessage(userID, msgID string) (*types.ScheduledMessage, error)
+	UserSnoozeMessage(userID, msgID string) (*types.ScheduledMessage, error)
 }
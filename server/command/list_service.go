@@ -49,41 +49,47 @@ func (l *ListService) Build(userID string) *model.CommandResponse {
 }
 
 func (l *ListService) loadMessages(userID string) ([]*types.ScheduledMessage, error) {
-	l.logger.Debug("Loading scheduled message IDs for user", "user_id", userID)
-	ids, err := l.store.ListUserMessageIDs(userID)
+	return loadUserMessages(l.logger, l.store, userID)
+}
+
+// loadUserMessages loads a user's scheduled messages sorted by post time,
+// cleaning up index entries whose message no longer exists.
+func loadUserMessages(logger ports.Logger, store ports.Store, userID string) ([]*types.ScheduledMessage, error) {
+	logger.Debug("Loading scheduled message IDs for user", "user_id", userID)
+	ids, err := store.ListUserMessageIDs(userID)
 	if err != nil {
-		l.logger.Error("Failed to list user message IDs", "user_id", userID, "error", err)
+		logger.Error("Failed to list user message IDs", "user_id", userID, "error", err)
 		return nil, err
 	}
-	l.logger.Debug("Found message IDs for user", "user_id", userID, "count", len(ids))
+	logger.Debug("Found message IDs for user", "user_id", userID, "count", len(ids))
 
 	msgs := []*types.ScheduledMessage{}
 	for _, id := range ids {
-		l.logger.Debug("Loading scheduled message details", "user_id", userID, "message_id", id)
-		msg, err := l.store.GetScheduledMessage(id)
+		logger.Debug("Loading scheduled message details", "user_id", userID, "message_id", id)
+		msg, err := store.GetScheduledMessage(id)
 		if err != nil {
 			// Log the error but continue trying to load other messages
-			l.logger.Error("Failed to get scheduled message details", "user_id", userID, "message_id", id, "error", err)
+			logger.Error("Failed to get scheduled message details", "user_id", userID, "message_id", id, "error", err)
 			continue
 		}
 		if msg == nil || msg.ID == "" {
-			l.logger.Warn("Scheduled message referenced in user index not found, cleaning up", "user_id", userID, "message_id", id)
+			logger.Warn("Scheduled message referenced in user index not found, cleaning up", "user_id", userID, "message_id", id)
 			// Attempt cleanup, log if cleanup fails but continue processing
-			if cleanupErr := l.store.CleanupMessageFromUserIndex(userID, id); cleanupErr != nil {
-				l.logger.Error("Failed to cleanup missing message from user index", "user_id", userID, "message_id", id, "error", cleanupErr)
+			if cleanupErr := store.CleanupMessageFromUserIndex(userID, id); cleanupErr != nil {
+				logger.Error("Failed to cleanup missing message from user index", "user_id", userID, "message_id", id, "error", cleanupErr)
 			}
 			continue
 		}
-		l.logger.Debug("Successfully loaded scheduled message", "user_id", userID, "message_id", msg.ID)
+		logger.Debug("Successfully loaded scheduled message", "user_id", userID, "message_id", msg.ID)
 		msgs = append(msgs, msg)
 	}
 
-	l.logger.Debug("Sorting loaded messages by post time", "user_id", userID, "count", len(msgs))
+	logger.Debug("Sorting loaded messages by post time", "user_id", userID, "count", len(msgs))
 	sort.Slice(msgs, func(i, j int) bool {
 		return msgs[i].PostAt.Before(msgs[j].PostAt)
 	})
 
-	l.logger.Debug("Finished loading and sorting messages for user", "user_id", userID, "count", len(msgs))
+	logger.Debug("Finished loading and sorting messages for user", "user_id", userID, "count", len(msgs))
 	return msgs, nil
 }
 
@@ -93,21 +99,30 @@ func (l *ListService) buildAttachments(msgs []*types.ScheduledMessage) []*model.
 	channelCache := make(map[string]*ports.ChannelInfo)
 
 	for _, m := range msgs {
-		l.logger.Debug("Processing message for attachment", "message_id", m.ID, "channel_id", m.ChannelID)
-		if _, ok := channelCache[m.ChannelID]; !ok {
-			l.logger.Debug("Channel info not in cache, fetching", "channel_id", m.ChannelID)
-			channelCache[m.ChannelID] = l.channel.GetInfoOrUnknown(m.ChannelID)
-		} else {
-			l.logger.Debug("Channel info found in cache", "channel_id", m.ChannelID)
-		}
+		l.logger.Debug("Processing message for attachment", "message_id", m.ID, "destinations", m.Destinations())
 		loc, _ := time.LoadLocation(m.Timezone)
 		localTime := m.PostAt.In(loc)
 		header := formatter.FormatListAttachmentHeader(
 			localTime,
-			l.channel.MakeChannelLink(channelCache[m.ChannelID]),
+			destinationLinks(l.channel, m.Destinations(), channelCache),
 			m.MessageContent,
 			m.RootID != "",
 		)
+		if m.Attribution == constants.AttributionBot {
+			header += "\n\n" + formatter.FormatAttributionNote()
+		}
+		if m.DueAt().After(m.PostAt) {
+			header += "\n\n" + formatter.FormatDeferredNote(m.DueAt().In(loc))
+		}
+		switch {
+		case m.Followup != "":
+			header += "\n\n" + formatter.FormatFollowupNote(m.Followup)
+		case !m.CancelIfRepliedAfter.IsZero():
+			header += "\n\n" + formatter.FormatUnlessRepliedNote()
+		}
+		if m.WarnBefore > 0 && m.WarnedAt.IsZero() {
+			header += "\n\n" + formatter.FormatWarningNote(m.WarnBefore)
+		}
 		attachments = append(attachments, createAttachment(header, m.ID))
 		l.logger.Debug("Created attachment for message", "message_id", m.ID)
 	}
@@ -116,6 +131,23 @@ func (l *ListService) buildAttachments(msgs []*types.ScheduledMessage) []*model.
 	return attachments
 }
 
+// destinationLinks renders the channel links for every destination, reusing
+// cached channel info when a cache is provided.
+func destinationLinks(channel ports.ChannelService, channelIDs []string, cache map[string]*ports.ChannelInfo) string {
+	links := make([]string, 0, len(channelIDs))
+	for _, channelID := range channelIDs {
+		info, ok := cache[channelID]
+		if !ok {
+			info = channel.GetInfoOrUnknown(channelID)
+			if cache != nil {
+				cache[channelID] = info
+			}
+		}
+		links = append(links, channel.MakeChannelLink(info))
+	}
+	return formatter.FormatDestinationList(links)
+}
+
 func errorResponse(txt string) *model.CommandResponse {
 	return &model.CommandResponse{
 		ResponseType: model.CommandResponseTypeEphemeral,
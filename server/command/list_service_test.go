@@ -406,6 +406,56 @@ func TestBuildAttachments_SingleMessage(t *testing.T) {
 	assert.Equal(t, "msg1", deleteAction.Integration.Context["id"])
 }
 
+func TestBuildAttachments_MultipleDestinations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockChannel := mock.NewMockChannelService(ctrl)
+	logger := testutil.FakeLogger{}
+	service := &ListService{logger: logger, channel: mockChannel}
+
+	now := time.Date(2023, 10, 27, 14, 30, 0, 0, time.UTC)
+	msg := createTestMessage("msg1", "user1", "ch1", "Hello world", "UTC", now)
+	msg.ChannelIDs = []string{"ch1", "ch2"}
+	info1 := &ports.ChannelInfo{ChannelID: "ch1", ChannelType: model.ChannelTypeOpen, ChannelLink: "~town-square"}
+	info2 := &ports.ChannelInfo{ChannelID: "ch2", ChannelType: model.ChannelTypeOpen, ChannelLink: "~off-topic"}
+
+	mockChannel.EXPECT().GetInfoOrUnknown("ch1").Return(info1)
+	mockChannel.EXPECT().GetInfoOrUnknown("ch2").Return(info2)
+	mockChannel.EXPECT().MakeChannelLink(info1).Return("in channel: ~town-square")
+	mockChannel.EXPECT().MakeChannelLink(info2).Return("in channel: ~off-topic")
+
+	attachments := service.buildAttachments([]*types.ScheduledMessage{msg})
+
+	require.Len(t, attachments, 1)
+	expectedHeader := formatter.FormatListAttachmentHeader(now, "in channel: ~town-square, in channel: ~off-topic", "Hello world", false)
+	assert.Equal(t, expectedHeader, attachments[0].Text)
+}
+
+func TestBuildAttachments_Notes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockChannel := mock.NewMockChannelService(ctrl)
+	service := &ListService{logger: testutil.FakeLogger{}, channel: mockChannel}
+
+	postAt := time.Date(2023, 7, 4, 14, 0, 0, 0, time.UTC)
+	msg := createTestMessage("msg1", "user1", "ch1", "Hello world", "America/New_York", postAt)
+	msg.DeferredUntil = postAt.Add(90 * time.Second)
+	msg.Attribution = constants.AttributionBot
+	msg.WarnBefore = 15 * time.Minute
+	msg.CancelIfRepliedAfter = postAt.Add(-time.Hour)
+	info := &ports.ChannelInfo{ChannelID: "ch1", ChannelType: model.ChannelTypeOpen, ChannelLink: "~test"}
+
+	mockChannel.EXPECT().GetInfoOrUnknown("ch1").Return(info)
+	mockChannel.EXPECT().MakeChannelLink(info).Return("in channel: ~test")
+
+	attachments := service.buildAttachments([]*types.ScheduledMessage{msg})
+
+	require.Len(t, attachments, 1)
+	assert.Contains(t, attachments[0].Text, "\n\n_Posts as the bot on your behalf_\n\n_Held back by a rate limit; posting at Jul 4, 2023 10:01 AM_\n\n_Cancelled if someone else replies in the thread first_\n\n_Heads-up 15 minutes before posting_")
+}
+
 func TestBuildAttachments_MultipleMessages_SameChannel_CacheHit(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -0,0 +1,22 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+)
+
+func checkMaxMessageBytes(logger ports.Logger, text string) error {
+	length := len(text)
+	logger.Debug("Checking max message bytes", "length", length, "limit", constants.MaxMessageBytes)
+	if length > constants.MaxMessageBytes {
+		kb := float64(constants.MaxMessageBytes) / 1024
+		userKb := float64(length) / 1024
+		err := fmt.Errorf("message length %.2f KB exceeds limit %.2f KB", userKb, kb)
+		logger.Error("Message length exceeds limit", "length", length, "limit", constants.MaxMessageBytes)
+		return err
+	}
+	logger.Debug("Message length is within limit", "length", length, "limit", constants.MaxMessageBytes)
+	return nil
+}
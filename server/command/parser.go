@@ -4,11 +4,13 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/mattermost/mattermost/server/public/model"
 )
 
 type dateFormat int
@@ -21,8 +23,17 @@ const (
 	dateFormatShortDayMonth
 )
 
+// scheduleOptionsPattern matches the time and options shared by the schedule
+// and forward subcommands, capturing scheduleOptionGroups groups.
+const scheduleOptionsPattern = `at[ \t]+([0-9]{1,2}(?::[0-9]{2})?[ \t]*(?:am|pm)?)(?:[ \t]+on[ \t]+((?:\d{4}-\d{2}-\d{2})|(?:\d{1,2}[a-z]{3})|(?:mon|tue|wed|thu|fri|sat|sun)|(?:monday|tuesday|wednesday|thursday|friday|saturday|sunday)))?(?:[ \t]+reply[ \t]+to[ \t]+(\S+))?(?:[ \t]+to[ \t]+(~[\w.-]+(?:[ \t]*,?[ \t]*~[\w.-]+)*))?(?:[ \t]+as[ \t]+(bot|me))?(?:[ \t]+(with|without)[ \t]+receipts?)?(?:[ \t]+warn[ \t]+([0-9][0-9a-z]*))?(?:[ \t]+(unless[ \t]+replied))?`
+
+// scheduleOptionGroups is the number of groups in scheduleOptionsPattern.
+const scheduleOptionGroups = 8
+
 var (
-	regexFullCommand    = regexp.MustCompile(`(?i)^at[ \t]+([0-9]{1,2}(?::[0-9]{2})?[ \t]*(?:am|pm)?)(?:[ \t]+on[ \t]+((?:\d{4}-\d{2}-\d{2})|(?:\d{1,2}[a-z]{3})|(?:mon|tue|wed|thu|fri|sat|sun)|(?:monday|tuesday|wednesday|thursday|friday|saturday|sunday)))?[ \t]+message\s+([\s\S]+)$`)
+	regexFullCommand    = regexp.MustCompile(`(?i)^` + scheduleOptionsPattern + `[ \t]+(?:message\s+([\s\S]+)|template[ \t]+([\w.-]+))$`)
+	regexForwardCommand = regexp.MustCompile(`(?i)^(\S+)[ \t]+` + scheduleOptionsPattern + `(?:[ \t]+message\s+([\s\S]+))?$`)
+	regexpChannelName   = regexp.MustCompile(`~[\w.-]+`)
 	regexpYYYYMMDD      = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
 	regexpShortDayMonth = regexp.MustCompile(`^(\d{1,2})([a-z]{3})$`)
 )
@@ -65,7 +76,24 @@ var (
 type ParsedSchedule struct {
 	TimeStr string
 	DateStr string
-	Message string
+	// ReplyTo is the ID of the post the message replies to, or empty to
+	// use the thread the command was run in, if any.
+	ReplyTo  string
+	Channels []string
+	// Attribution is who the message is posted as, one of the
+	// constants.Attribution values, or empty for the admin default.
+	Attribution string
+	// Receipt is whether the author gets a delivery receipt, one of the
+	// constants.Receipt values, or empty for their own preference.
+	Receipt string
+	// WarnBefore is how long before posting the author gets a heads-up, or
+	// zero for none.
+	WarnBefore time.Duration
+	// UnlessReplied cancels a thread reply if anyone else posts in the
+	// thread first.
+	UnlessReplied bool
+	Message       string
+	Template      string
 }
 
 func parseScheduleInput(input string) (*ParsedSchedule, error) {
@@ -74,20 +102,132 @@ func parseScheduleInput(input string) (*ParsedSchedule, error) {
 	if matches == nil {
 		return nil, errors.New(constants.ParserErrInvalidFormat)
 	}
-	timeStr := strings.ToLower(strings.ReplaceAll(matches[1], " ", ""))
+	parsed, err := parseScheduleOptions(matches[1 : 1+scheduleOptionGroups])
+	if err != nil {
+		return nil, err
+	}
+	parsed.Message = strings.TrimSpace(matches[1+scheduleOptionGroups])
+	parsed.Template = strings.ToLower(matches[2+scheduleOptionGroups])
+	return parsed, nil
+}
+
+// parseForwardInput reads a forward subcommand: the post to forward, then
+// the schedule options and an optional comment, returned as the message.
+func parseForwardInput(input string) (string, *ParsedSchedule, error) {
+	matches := regexForwardCommand.FindStringSubmatch(strings.TrimSpace(input))
+	if matches == nil {
+		return "", nil, errors.New(constants.ForwardErrInvalidFormat)
+	}
+	postID, err := parsePostRef(matches[1])
+	if err != nil {
+		return "", nil, err
+	}
+	parsed, err := parseScheduleOptions(matches[2 : 2+scheduleOptionGroups])
+	if err != nil {
+		return "", nil, err
+	}
+	parsed.Message = strings.TrimSpace(matches[2+scheduleOptionGroups])
+	return postID, parsed, nil
+}
+
+// parseScheduleOptions reads the groups matched by scheduleOptionsPattern.
+func parseScheduleOptions(matches []string) (*ParsedSchedule, error) {
+	timeStr := strings.ToLower(strings.ReplaceAll(matches[0], " ", ""))
 	if len(timeStr) > 1 && timeStr[0] == '0' {
 		timeStr = timeStr[1:]
 	}
-	dateStr := strings.ToLower(matches[2])
-	message := strings.TrimSpace(matches[3])
+	dateStr := strings.ToLower(matches[1])
+	replyTo, err := parsePostRef(matches[2])
+	if err != nil {
+		return nil, err
+	}
+	channels := parseChannelNames(matches[3])
+	attribution := parseAttribution(matches[4])
+	receipt := parseReceipt(matches[5])
+	warnBefore, err := parseWarnBefore(matches[6])
+	if err != nil {
+		return nil, err
+	}
+	unlessReplied := matches[7] != ""
 
 	return &ParsedSchedule{
-		TimeStr: timeStr,
-		DateStr: dateStr,
-		Message: message,
+		TimeStr:       timeStr,
+		DateStr:       dateStr,
+		ReplyTo:       replyTo,
+		Channels:      channels,
+		Attribution:   attribution,
+		Receipt:       receipt,
+		WarnBefore:    warnBefore,
+		UnlessReplied: unlessReplied,
 	}, nil
 }
 
+func parseAttribution(as string) string {
+	switch strings.ToLower(as) {
+	case constants.AttributionKeywordBot:
+		return constants.AttributionBot
+	case constants.AttributionKeywordMe:
+		return constants.AttributionUser
+	}
+	return ""
+}
+
+func parseReceipt(with string) string {
+	switch strings.ToLower(with) {
+	case constants.ReceiptKeywordWith:
+		return constants.ReceiptOn
+	case constants.ReceiptKeywordWithout:
+		return constants.ReceiptOff
+	}
+	return ""
+}
+
+// parsePostRef reads a post ID from a permalink, such as
+// https://mm.example.com/team/pl/<id>, or a bare post ID.
+func parsePostRef(ref string) (string, error) {
+	if ref == "" {
+		return "", nil
+	}
+	postID := strings.TrimSuffix(ref, "/")
+	if i := strings.LastIndex(postID, "/pl/"); i >= 0 {
+		postID = postID[i+len("/pl/"):]
+	}
+	postID = strings.ToLower(postID)
+	if !model.IsValidId(postID) {
+		return "", fmt.Errorf(constants.ParserErrInvalidReplyTo, ref)
+	}
+	return postID, nil
+}
+
+// parseWarnBefore reads a heads-up time as a duration ("15m", "1h30m") or
+// a number of minutes ("15"). An empty value means no heads-up.
+func parseWarnBefore(text string) (time.Duration, error) {
+	if text == "" {
+		return 0, nil
+	}
+	text = strings.ToLower(text)
+	var warn time.Duration
+	if minutes, err := strconv.Atoi(text); err == nil {
+		warn = time.Duration(minutes) * time.Minute
+	} else if warn, err = time.ParseDuration(text); err != nil {
+		return 0, fmt.Errorf(constants.ParserErrInvalidWarn, text)
+	}
+	if warn < time.Minute || warn > constants.MaxWarnMinutes*time.Minute {
+		return 0, errors.New(constants.ParserErrWarnRange)
+	}
+	return warn, nil
+}
+
+func parseChannelNames(list string) []string {
+	var names []string
+	for _, name := range regexpChannelName.FindAllString(strings.ToLower(list), -1) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 func determineDateFormat(dateStr string) dateFormat {
 	if dateStr == "" {
 		return dateFormatNone
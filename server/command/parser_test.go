@@ -2,6 +2,7 @@ package command
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -133,6 +134,128 @@ func main() {
 A [link](http://example.com) too.`,
 			},
 		},
+		{
+			name:  "Single destination channel",
+			input: "at 9am to ~announcements message Hello all",
+			want:  &ParsedSchedule{TimeStr: "9am", DateStr: "", Channels: []string{"~announcements"}, Message: "Hello all"},
+		},
+		{
+			name:  "Multiple destination channels with date",
+			input: "at 9am on fri to ~Town-Square, ~off-topic ~dev.ops message Hello all",
+			want:  &ParsedSchedule{TimeStr: "9am", DateStr: "fri", Channels: []string{"~town-square", "~off-topic", "~dev.ops"}, Message: "Hello all"},
+		},
+		{
+			name:  "Duplicate destination channels collapsed",
+			input: "at 9am to ~general ~GENERAL message Hi",
+			want:  &ParsedSchedule{TimeStr: "9am", DateStr: "", Channels: []string{"~general"}, Message: "Hi"},
+		},
+		{
+			name:  "Saved template",
+			input: "at 9am on mon template Release-Checklist",
+			want:  &ParsedSchedule{TimeStr: "9am", DateStr: "mon", Template: "release-checklist"},
+		},
+		{
+			name:  "Saved template with destination channels",
+			input: "at 9am to ~town-square template handoff",
+			want:  &ParsedSchedule{TimeStr: "9am", DateStr: "", Channels: []string{"~town-square"}, Template: "handoff"},
+		},
+		{
+			name:  "Post as the bot",
+			input: "at 9am on mon to ~town-square As Bot message Office closed",
+			want:  &ParsedSchedule{TimeStr: "9am", DateStr: "mon", Channels: []string{"~town-square"}, Attribution: constants.AttributionBot, Message: "Office closed"},
+		},
+		{
+			name:  "Post as the author with a template",
+			input: "at 9am as me template handoff",
+			want:  &ParsedSchedule{TimeStr: "9am", DateStr: "", Attribution: constants.AttributionUser, Template: "handoff"},
+		},
+		{
+			name:  "With a delivery receipt",
+			input: "at 9am to ~town-square as bot With Receipt message Office closed",
+			want:  &ParsedSchedule{TimeStr: "9am", DateStr: "", Channels: []string{"~town-square"}, Attribution: constants.AttributionBot, Receipt: constants.ReceiptOn, Message: "Office closed"},
+		},
+		{
+			name:  "Without a delivery receipt",
+			input: "at 9am on fri without receipt template handoff",
+			want:  &ParsedSchedule{TimeStr: "9am", DateStr: "fri", Receipt: constants.ReceiptOff, Template: "handoff"},
+		},
+		{
+			name:  "Heads-up before posting",
+			input: "at 9am to ~town-square without receipt warn 1h30m message Office closed",
+			want:  &ParsedSchedule{TimeStr: "9am", DateStr: "", Channels: []string{"~town-square"}, Receipt: constants.ReceiptOff, WarnBefore: 90 * time.Minute, Message: "Office closed"},
+		},
+		{
+			name:  "Heads-up in minutes",
+			input: "at 9am warn 15 template handoff",
+			want:  &ParsedSchedule{TimeStr: "9am", DateStr: "", WarnBefore: 15 * time.Minute, Template: "handoff"},
+		},
+		{
+			name:        "Heads-up that is not a duration",
+			input:       "at 9am warn 5x message Hi",
+			wantErr:     true,
+			errContains: "invalid heads-up time '5x'",
+		},
+		{
+			name:        "Heads-up too far ahead",
+			input:       "at 9am warn 25h message Hi",
+			wantErr:     true,
+			errContains: constants.ParserErrWarnRange,
+		},
+		{
+			name:  "Unless replied",
+			input: "at 9am on mon warn 15m Unless Replied message Any update?",
+			want:  &ParsedSchedule{TimeStr: "9am", DateStr: "mon", WarnBefore: 15 * time.Minute, UnlessReplied: true, Message: "Any update?"},
+		},
+		{
+			name:  "Reply to a permalink",
+			input: "at 9am on mon reply to https://mm.example.com/team/pl/ABCDEFGHIJKLMNOPQRSTUVWXYZ/ message Any update?",
+			want:  &ParsedSchedule{TimeStr: "9am", DateStr: "mon", ReplyTo: "abcdefghijklmnopqrstuvwxyz", Message: "Any update?"},
+		},
+		{
+			name:  "Reply to a post ID",
+			input: "at 9am reply to abcdefghijklmnopqrstuvwxyz as me message Thanks",
+			want:  &ParsedSchedule{TimeStr: "9am", DateStr: "", ReplyTo: "abcdefghijklmnopqrstuvwxyz", Attribution: constants.AttributionUser, Message: "Thanks"},
+		},
+		{
+			name:        "Reply to something that is not a post",
+			input:       "at 9am reply to https://mm.example.com/team/channels/town-square message Hi",
+			wantErr:     true,
+			errContains: "invalid post 'https://mm.example.com/team/channels/town-square'",
+		},
+		{
+			name:  "Unless replied in message text is not an option",
+			input: "at 9am message unless replied",
+			want:  &ParsedSchedule{TimeStr: "9am", DateStr: "", Message: "unless replied"},
+		},
+		{
+			name:  "As in message text is not an option",
+			input: "at 9am message as bot",
+			want:  &ParsedSchedule{TimeStr: "9am", DateStr: "", Message: "as bot"},
+		},
+		{
+			name:        "Unknown attribution",
+			input:       "at 9am as robot message Hi",
+			wantErr:     true,
+			errContains: constants.ParserErrInvalidFormat,
+		},
+		{
+			name:        "Template without name",
+			input:       "at 9am template",
+			wantErr:     true,
+			errContains: constants.ParserErrInvalidFormat,
+		},
+		{
+			name:        "Template name with trailing text",
+			input:       "at 9am template handoff extra words",
+			wantErr:     true,
+			errContains: constants.ParserErrInvalidFormat,
+		},
+		{
+			name:        "Destination without channel names",
+			input:       "at 9am to message Hi",
+			wantErr:     true,
+			errContains: constants.ParserErrInvalidFormat,
+		},
 		{
 			name:        "Missing 'message' keyword",
 			input:       "at 3pm on mon foo bar",
@@ -174,9 +297,90 @@ A [link](http://example.com) too.`,
 			if ps.DateStr != tc.want.DateStr {
 				t.Errorf("DateStr = %q, want %q", ps.DateStr, tc.want.DateStr)
 			}
+			if ps.ReplyTo != tc.want.ReplyTo {
+				t.Errorf("ReplyTo = %q, want %q", ps.ReplyTo, tc.want.ReplyTo)
+			}
+			if !slices.Equal(ps.Channels, tc.want.Channels) {
+				t.Errorf("Channels = %v, want %v", ps.Channels, tc.want.Channels)
+			}
+			if ps.Attribution != tc.want.Attribution {
+				t.Errorf("Attribution = %q, want %q", ps.Attribution, tc.want.Attribution)
+			}
+			if ps.WarnBefore != tc.want.WarnBefore {
+				t.Errorf("WarnBefore = %v, want %v", ps.WarnBefore, tc.want.WarnBefore)
+			}
+			if ps.Receipt != tc.want.Receipt {
+				t.Errorf("Receipt = %q, want %q", ps.Receipt, tc.want.Receipt)
+			}
 			if ps.Message != tc.want.Message {
 				t.Errorf("Message = %q, want %q", ps.Message, tc.want.Message)
 			}
+			if ps.Template != tc.want.Template {
+				t.Errorf("Template = %q, want %q", ps.Template, tc.want.Template)
+			}
+		})
+	}
+}
+
+func TestParseForwardInput(t *testing.T) {
+	const postID = "abcdefghijklmnopqrstuvwxyz"
+	tests := []struct {
+		name        string
+		input       string
+		wantPostID  string
+		want        *ParsedSchedule
+		errContains string
+	}{
+		{
+			name:       "Permalink with options and comment",
+			input:      "https://mm.example.com/team/pl/" + postID + " at 9am on tue to ~town-square as bot message Reminder:",
+			wantPostID: postID,
+			want:       &ParsedSchedule{TimeStr: "9am", DateStr: "tue", Channels: []string{"~town-square"}, Attribution: constants.AttributionBot, Message: "Reminder:"},
+		},
+		{
+			name:       "Post ID without comment",
+			input:      postID + " at 17:30",
+			wantPostID: postID,
+			want:       &ParsedSchedule{TimeStr: "17:30"},
+		},
+		{
+			name:        "Templates are not allowed",
+			input:       postID + " at 9am template standup",
+			errContains: constants.ForwardErrInvalidFormat,
+		},
+		{
+			name:        "Missing time",
+			input:       postID,
+			errContains: constants.ForwardErrInvalidFormat,
+		},
+		{
+			name:        "Not a post",
+			input:       "~town-square at 9am",
+			errContains: "invalid post '~town-square'",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			postID, ps, err := parseForwardInput(tc.input)
+			if tc.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errContains) {
+					t.Fatalf("expected error containing %q, got %v", tc.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error for input %q: %v", tc.input, err)
+			}
+			if postID != tc.wantPostID {
+				t.Errorf("postID = %q, want %q", postID, tc.wantPostID)
+			}
+			if ps.TimeStr != tc.want.TimeStr || ps.DateStr != tc.want.DateStr || ps.Attribution != tc.want.Attribution || ps.Message != tc.want.Message {
+				t.Errorf("parsed = %+v, want %+v", ps, tc.want)
+			}
+			if !slices.Equal(ps.Channels, tc.want.Channels) {
+				t.Errorf("Channels = %v, want %v", ps.Channels, tc.want.Channels)
+			}
 		})
 	}
 }
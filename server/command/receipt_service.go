@@ -0,0 +1,58 @@
+package command
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/formatter"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// ReceiptService manages whether a user is sent a receipt when their
+// scheduled messages are posted.
+type ReceiptService struct {
+	logger ports.Logger
+	store  ports.Store
+}
+
+// NewReceiptService constructs a ReceiptService.
+func NewReceiptService(logger ports.Logger, store ports.Store) *ReceiptService {
+	logger.Debug("Creating new ReceiptService")
+	return &ReceiptService{
+		logger: logger,
+		store:  store,
+	}
+}
+
+// Build runs a receipts subcommand: show, or turn on or off, the user's
+// delivery receipts.
+func (r *ReceiptService) Build(userID, text string) *model.CommandResponse {
+	action := strings.ToLower(strings.TrimSpace(text))
+	r.logger.Debug("Handling receipts subcommand", "user_id", userID, "action", action)
+	switch action {
+	case "":
+		enabled, err := r.store.GetReceiptPreference(userID)
+		if err != nil {
+			r.logger.Error("Failed to get receipt preference", "user_id", userID, "error", err)
+			return errorResponse(formatter.FormatReceiptsError(err))
+		}
+		return ephemeralResponse(formatter.FormatReceiptsStatus(enabled))
+	case constants.ReceiptsActionOn:
+		return r.set(userID, true)
+	case constants.ReceiptsActionOff:
+		return r.set(userID, false)
+	default:
+		return errorResponse(formatter.FormatReceiptsError(errors.New(constants.ReceiptsErrInvalidFormat)))
+	}
+}
+
+func (r *ReceiptService) set(userID string, enabled bool) *model.CommandResponse {
+	if err := r.store.SetReceiptPreference(userID, enabled); err != nil {
+		r.logger.Error("Failed to save receipt preference", "user_id", userID, "enabled", enabled, "error", err)
+		return errorResponse(formatter.FormatReceiptsError(err))
+	}
+	r.logger.Info("Updated receipt preference", "user_id", userID, "enabled", enabled)
+	return ephemeralResponse(formatter.FormatReceiptsStatus(enabled))
+}
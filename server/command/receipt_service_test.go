@@ -0,0 +1,66 @@
+package command
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/adapters/mock"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/testutil"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/formatter"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func setupReceiptServiceTest(t *testing.T) (*ReceiptService, *mock.MockStore) {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	store := mock.NewMockStore(ctrl)
+	service := NewReceiptService(&testutil.FakeLogger{}, store)
+	require.NotNil(t, service)
+	return service, store
+}
+
+func TestReceiptBuild_Show(t *testing.T) {
+	service, store := setupReceiptServiceTest(t)
+
+	store.EXPECT().GetReceiptPreference(testUserID).Return(true, nil)
+
+	resp := service.Build(testUserID, " ")
+
+	require.NotNil(t, resp)
+	assert.Equal(t, model.CommandResponseTypeEphemeral, resp.ResponseType)
+	assert.Equal(t, formatter.FormatReceiptsStatus(true), resp.Text)
+}
+
+func TestReceiptBuild_TurnOnAndOff(t *testing.T) {
+	service, store := setupReceiptServiceTest(t)
+
+	gomock.InOrder(
+		store.EXPECT().SetReceiptPreference(testUserID, true).Return(nil),
+		store.EXPECT().SetReceiptPreference(testUserID, false).Return(nil),
+	)
+
+	assert.Equal(t, formatter.FormatReceiptsStatus(true), service.Build(testUserID, " ON").Text)
+	assert.Equal(t, formatter.FormatReceiptsStatus(false), service.Build(testUserID, " off").Text)
+}
+
+func TestReceiptBuild_StoreError(t *testing.T) {
+	service, store := setupReceiptServiceTest(t)
+
+	store.EXPECT().SetReceiptPreference(testUserID, true).Return(errors.New("kv down"))
+
+	resp := service.Build(testUserID, "on")
+
+	assert.Equal(t, formatter.FormatReceiptsError(errors.New("kv down")), resp.Text)
+}
+
+func TestReceiptBuild_InvalidAction(t *testing.T) {
+	service, _ := setupReceiptServiceTest(t)
+
+	resp := service.Build(testUserID, "maybe")
+
+	assert.Equal(t, formatter.FormatReceiptsError(errors.New(constants.ReceiptsErrInvalidFormat)), resp.Text)
+}
@@ -1,7 +1,9 @@
 package command
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -18,6 +20,7 @@ type ScheduleService struct {
 	userAPI         ports.UserService
 	store           ports.Store
 	channel         ports.ChannelService
+	poster          ports.PostService
 	clock           ports.Clock
 	maxUserMessages int
 }
@@ -28,6 +31,7 @@ func NewScheduleService(
 	userAPI ports.UserService,
 	store ports.Store,
 	channel ports.ChannelService,
+	poster ports.PostService,
 	clk ports.Clock,
 	maxUserMessages int,
 ) *ScheduleService {
@@ -37,6 +41,7 @@ func NewScheduleService(
 		userAPI:         userAPI,
 		store:           store,
 		channel:         channel,
+		poster:          poster,
 		clock:           clk,
 		maxUserMessages: maxUserMessages,
 	}
@@ -45,7 +50,19 @@ func NewScheduleService(
 // Build validates and schedules a message.
 func (s *ScheduleService) Build(args *model.CommandArgs, text string) *model.CommandResponse {
 	s.logger.Debug("Attempting to schedule message", "user_id", args.UserId, "channel_id", args.ChannelId, "text", text)
+	return s.build(args, text, s.prepareSchedule)
+}
+
+// BuildForward validates and schedules a re-post of an existing post.
+func (s *ScheduleService) BuildForward(args *model.CommandArgs, text string) *model.CommandResponse {
+	s.logger.Debug("Attempting to schedule forward", "user_id", args.UserId, "channel_id", args.ChannelId, "text", text)
+	return s.build(args, text, s.prepareForward)
+}
 
+// prepareFunc turns command text into a scheduled message.
+type prepareFunc func(userID, teamID, channelID, rootID, text string) (*types.ScheduledMessage, *time.Location, string, error)
+
+func (s *ScheduleService) build(args *model.CommandArgs, text string, prepare prepareFunc) *model.CommandResponse {
 	s.logger.Debug("Validating schedule request", "user_id", args.UserId)
 	if resp := s.validateRequest(args.UserId, text); resp != nil {
 		s.logger.Error("Schedule request validation failed", "user_id", args.UserId, "reason", resp.Text)
@@ -54,7 +71,7 @@ func (s *ScheduleService) Build(args *model.CommandArgs, text string) *model.Com
 	s.logger.Debug("Schedule request validated successfully", "user_id", args.UserId)
 
 	s.logger.Debug("Preparing schedule details", "user_id", args.UserId, "channel_id", args.ChannelId)
-	msg, loc, tz, err := s.prepareSchedule(args.UserId, args.ChannelId, args.RootId, text)
+	msg, loc, tz, err := prepare(args.UserId, args.TeamId, args.ChannelId, args.RootId, text)
 	if err != nil {
 		errMsg := fmt.Sprintf("Error preparing schedule: %v, Original input: `%v`", err, text)
 		s.logger.Error("Failed to prepare schedule", "user_id", args.UserId, "channel_id", args.ChannelId, "error", err, "original_text", text)
@@ -65,53 +82,51 @@ func (s *ScheduleService) Build(args *model.CommandArgs, text string) *model.Com
 
 	s.logger.Debug("Persisting scheduled message", "user_id", args.UserId, "message_id", msg.ID)
 	if err := s.persist(args.UserId, msg); err != nil {
-		channelLink := s.channel.MakeChannelLink(s.channel.GetInfoOrUnknown(args.ChannelId))
+		channelLink := destinationLinks(s.channel, msg.Destinations(), nil)
 		formatted := formatter.FormatScheduleError(localTime, tz, channelLink, err)
 		s.logger.Error("Failed to persist scheduled message", "user_id", args.UserId, "message_id", msg.ID, "error", err)
 		return s.errorResponse(formatted)
 	}
 	s.logger.Info("Scheduled message persisted successfully", "user_id", args.UserId, "message_id", msg.ID)
 
-	return s.successResponse(msg, localTime, tz, args.ChannelId)
+	return s.successResponse(msg, localTime, tz)
 }
 
 func (s *ScheduleService) checkMaxUserMessages(userID string) error {
-	s.logger.Debug("Checking max user messages limit", "user_id", userID, "limit", s.maxUserMessages)
-	ids, err := s.store.ListUserMessageIDs(userID)
+	return checkMaxUserMessages(s.logger, s.store, userID, s.maxUserMessages)
+}
+
+// checkMaxUserMessages returns an error if the user already has the most
+// scheduled messages allowed.
+func checkMaxUserMessages(logger ports.Logger, store ports.Store, userID string, maxUserMessages int) error {
+	logger.Debug("Checking max user messages limit", "user_id", userID, "limit", maxUserMessages)
+	ids, err := store.ListUserMessageIDs(userID)
 	if err != nil {
-		s.logger.Error("Failed to list user message IDs for count check", "user_id", userID, "error", err)
+		logger.Error("Failed to list user message IDs for count check", "user_id", userID, "error", err)
 		return fmt.Errorf("failed to check message count: %w", err)
 	}
 	count := len(ids)
-	s.logger.Debug("Current user message count", "user_id", userID, "count", count)
-	if count >= s.maxUserMessages {
-		err := fmt.Errorf("cannot schedule more than %d messages (current: %d)", s.maxUserMessages, count)
-		s.logger.Error("User message limit reached", "user_id", userID, "count", count, "limit", s.maxUserMessages)
+	logger.Debug("Current user message count", "user_id", userID, "count", count)
+	if count >= maxUserMessages {
+		err := fmt.Errorf("cannot schedule more than %d messages (current: %d)", maxUserMessages, count)
+		logger.Error("User message limit reached", "user_id", userID, "count", count, "limit", maxUserMessages)
 		return err
 	}
-	s.logger.Debug("User is under message limit", "user_id", userID, "count", count, "limit", s.maxUserMessages)
+	logger.Debug("User is under message limit", "user_id", userID, "count", count, "limit", maxUserMessages)
 	return nil
 }
 
-func (s *ScheduleService) checkMaxMessageBytes(text string) error {
-	length := len(text)
-	s.logger.Debug("Checking max message bytes", "length", length, "limit", constants.MaxMessageBytes)
-	if length > constants.MaxMessageBytes {
-		kb := float64(constants.MaxMessageBytes) / 1024
-		userKb := float64(length) / 1024
-		err := fmt.Errorf("message length %.2f KB exceeds limit %.2f KB", userKb, kb)
-		s.logger.Error("Message length exceeds limit", "length", length, "limit", constants.MaxMessageBytes)
-		return err
-	}
-	s.logger.Debug("Message length is within limit", "length", length, "limit", constants.MaxMessageBytes)
-	return nil
+func (s *ScheduleService) getUserTimezone(userID string) string {
+	return userTimezone(s.logger, s.userAPI, userID)
 }
 
-func (s *ScheduleService) getUserTimezone(userID string) string {
-	s.logger.Debug("Attempting to get user timezone", "user_id", userID)
-	user, err := s.userAPI.Get(userID)
+// userTimezone returns the user's preferred timezone name, falling back to
+// the default timezone.
+func userTimezone(logger ports.Logger, userAPI ports.UserService, userID string) string {
+	logger.Debug("Attempting to get user timezone", "user_id", userID)
+	user, err := userAPI.Get(userID)
 	if err != nil {
-		s.logger.Warn("Failed to get user object, falling back to default timezone", "user_id", userID, "error", err, "default_timezone", constants.DefaultTimezone)
+		logger.Warn("Failed to get user object, falling back to default timezone", "user_id", userID, "error", err, "default_timezone", constants.DefaultTimezone)
 		return constants.DefaultTimezone
 	}
 
@@ -130,7 +145,7 @@ func (s *ScheduleService) getUserTimezone(userID string) string {
 		source = "manual"
 	}
 
-	s.logger.Debug("Determined user timezone", "user_id", userID, "timezone", tz, "source", source)
+	logger.Debug("Determined user timezone", "user_id", userID, "timezone", tz, "source", source)
 	return tz
 }
 
@@ -139,7 +154,7 @@ func (s *ScheduleService) validateRequest(userID, text string) *model.CommandRes
 	if maxUserMessagesErr := s.checkMaxUserMessages(userID); maxUserMessagesErr != nil {
 		return s.errorResponse(formatter.FormatScheduleValidationError(maxUserMessagesErr))
 	}
-	if maxMessageBytesErr := s.checkMaxMessageBytes(text); maxMessageBytesErr != nil {
+	if maxMessageBytesErr := checkMaxMessageBytes(s.logger, text); maxMessageBytesErr != nil {
 		return s.errorResponse(formatter.FormatScheduleValidationError(maxMessageBytesErr))
 	}
 	trimmedText := strings.TrimSpace(text)
@@ -168,8 +183,8 @@ func (s *ScheduleService) errorResponse(text string) *model.CommandResponse {
 	}
 }
 
-func (s *ScheduleService) prepareSchedule(userID, channelID, rootID, text string) (*types.ScheduledMessage, *time.Location, string, error) {
-	s.logger.Debug("Preparing schedule", "user_id", userID, "channel_id", channelID, "root_id", rootID)
+func (s *ScheduleService) prepareSchedule(userID, teamID, channelID, rootID, text string) (*types.ScheduledMessage, *time.Location, string, error) {
+	s.logger.Debug("Preparing schedule", "user_id", userID, "team_id", teamID, "channel_id", channelID, "root_id", rootID)
 
 	s.logger.Debug("Parsing schedule input text", "user_id", userID, "text", text)
 	parsed, parseErr := parseScheduleInput(text)
@@ -177,7 +192,84 @@ func (s *ScheduleService) prepareSchedule(userID, channelID, rootID, text string
 		s.logger.Error("Failed to parse schedule input", "user_id", userID, "text", text, "error", parseErr)
 		return nil, nil, "", fmt.Errorf("failed to parse input: %w", parseErr)
 	}
-	s.logger.Debug("Parsed schedule input", "user_id", userID, "parsed_time", parsed.TimeStr, "parsed_date", parsed.DateStr, "message", parsed.Message)
+	s.logger.Debug("Parsed schedule input", "user_id", userID, "parsed_time", parsed.TimeStr, "parsed_date", parsed.DateStr, "reply_to", parsed.ReplyTo, "channels", parsed.Channels, "attribution", parsed.Attribution, "receipt", parsed.Receipt, "warn_before", parsed.WarnBefore, "unless_replied", parsed.UnlessReplied, "message", parsed.Message, "template", parsed.Template)
+
+	if parsed.Template != "" {
+		s.logger.Debug("Loading saved template for message content", "user_id", userID, "template", parsed.Template)
+		content, templateErr := s.store.GetTemplate(userID, parsed.Template)
+		if templateErr != nil {
+			s.logger.Error("Failed to load saved template", "user_id", userID, "template", parsed.Template, "error", templateErr)
+			return nil, nil, "", fmt.Errorf("failed to load template: %w", templateErr)
+		}
+		parsed.Message = content
+	}
+
+	return s.prepareParsed(userID, teamID, channelID, rootID, parsed)
+}
+
+// prepareForward builds a scheduled message quoting an existing post, with
+// the author's comment, if any, as the template.
+func (s *ScheduleService) prepareForward(userID, teamID, channelID, rootID, text string) (*types.ScheduledMessage, *time.Location, string, error) {
+	s.logger.Debug("Preparing forward", "user_id", userID, "team_id", teamID, "channel_id", channelID, "root_id", rootID)
+	postID, parsed, parseErr := parseForwardInput(text)
+	if parseErr != nil {
+		s.logger.Error("Failed to parse forward input", "user_id", userID, "text", text, "error", parseErr)
+		return nil, nil, "", fmt.Errorf("failed to parse input: %w", parseErr)
+	}
+	post, postErr := s.readablePost(userID, postID)
+	if postErr != nil {
+		return nil, nil, "", fmt.Errorf("cannot forward post %s: %w", postID, postErr)
+	}
+	author := ""
+	if user, err := s.userAPI.Get(post.UserId); err != nil {
+		s.logger.Warn("Failed to get forwarded post author, quoting without it", "user_id", userID, "post_id", postID, "author_id", post.UserId, "error", err)
+	} else {
+		author = "@" + user.Username
+	}
+	commentBytes := len(parsed.Message)
+	parsed.Message = formatter.FormatForward(parsed.Message, author, post.Message)
+	if sizeErr := checkMaxMessageBytes(s.logger, parsed.Message); sizeErr != nil {
+		return nil, nil, "", fmt.Errorf("cannot forward post %s: %w", postID, sizeErr)
+	}
+
+	msg, loc, tz, err := s.prepareParsed(userID, teamID, channelID, rootID, parsed)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	msg.ForwardedPostID = post.Id
+	msg.CommentBytes = commentBytes
+	msg.FileIDs = post.FileIds
+	s.logger.Debug("Prepared forward", "user_id", userID, "message_id", msg.ID, "post_id", post.Id, "file_ids", msg.FileIDs)
+	return msg, loc, tz, nil
+}
+
+// prepareParsed resolves the destination and time of a parsed schedule and
+// builds the scheduled message.
+func (s *ScheduleService) prepareParsed(userID, teamID, channelID, rootID string, parsed *ParsedSchedule) (*types.ScheduledMessage, *time.Location, string, error) {
+	if parsed.ReplyTo != "" {
+		if len(parsed.Channels) > 0 {
+			s.logger.Debug("Reply to combined with destination channels", "user_id", userID, "reply_to", parsed.ReplyTo, "channels", parsed.Channels)
+			return nil, nil, "", errors.New(constants.ScheduleErrReplyToWithChannels)
+		}
+		replyChannelID, replyRootID, replyErr := s.resolveReplyTo(userID, parsed.ReplyTo)
+		if replyErr != nil {
+			return nil, nil, "", replyErr
+		}
+		channelID, rootID = replyChannelID, replyRootID
+	}
+
+	destinations, destErr := s.resolveDestinations(userID, teamID, channelID, parsed.Channels)
+	if destErr != nil {
+		return nil, nil, "", fmt.Errorf("failed to resolve destination: %w", destErr)
+	}
+	if !slices.Equal(destinations, []string{channelID}) {
+		s.logger.Debug("Message has explicit destinations, dropping thread root", "user_id", userID, "destinations", destinations, "root_id", rootID)
+		rootID = ""
+	}
+	if parsed.UnlessReplied && rootID == "" {
+		s.logger.Debug("Unless replied requested outside a thread", "user_id", userID, "channel_id", channelID)
+		return nil, nil, "", errors.New(constants.ScheduleErrUnlessRepliedNoThread)
+	}
 
 	tz := s.getUserTimezone(userID)
 	s.logger.Debug("Loading location based on timezone", "user_id", userID, "timezone", tz)
@@ -201,19 +293,82 @@ func (s *ScheduleService) prepareSchedule(userID, channelID, rootID, text string
 	msg := &types.ScheduledMessage{
 		ID:             msgID,
 		UserID:         userID,
-		ChannelID:      channelID,
+		ChannelID:      destinations[0],
 		RootID:         rootID,
 		PostAt:         schedTime.UTC(),
 		MessageContent: parsed.Message,
 		Timezone:       tz,
+		Attribution:    parsed.Attribution,
+		Receipt:        parsed.Receipt,
+		WarnBefore:     parsed.WarnBefore,
 	}
-	s.logger.Debug("Prepared scheduled message object", "user_id", userID, "message_id", msg.ID, "channel_id", msg.ChannelID, "root_id", msg.RootID, "post_at_utc", msg.PostAt, "timezone", msg.Timezone)
+	if len(destinations) > 1 {
+		msg.ChannelIDs = destinations
+	}
+	if parsed.UnlessReplied {
+		msg.CancelIfRepliedAfter = s.clock.Now().UTC()
+	}
+	s.logger.Debug("Prepared scheduled message object", "user_id", userID, "message_id", msg.ID, "channel_id", msg.ChannelID, "channel_ids", msg.ChannelIDs, "root_id", msg.RootID, "post_at_utc", msg.PostAt, "timezone", msg.Timezone)
 	return msg, loc, tz, nil
 }
 
-func (s *ScheduleService) successResponse(msg *types.ScheduledMessage, localTime time.Time, tz, channelID string) *model.CommandResponse {
-	s.logger.Debug("Formatting success response", "user_id", msg.UserID, "message_id", msg.ID, "channel_id", channelID, "timezone", tz)
-	channelLink := s.channel.MakeChannelLink(s.channel.GetInfoOrUnknown(channelID))
+func (s *ScheduleService) resolveDestinations(userID, teamID, channelID string, names []string) ([]string, error) {
+	if len(names) == 0 {
+		if err := s.channel.VerifyCanPost(channelID, userID); err != nil {
+			s.logger.Warn("User cannot post in destination channel", "user_id", userID, "channel_id", channelID, "error", err)
+			return nil, err
+		}
+		return []string{channelID}, nil
+	}
+	s.logger.Debug("Resolving destination channels", "user_id", userID, "team_id", teamID, "channels", names)
+	ids := make([]string, 0, len(names))
+	for _, name := range names {
+		id, err := s.channel.ResolveDestination(teamID, userID, name)
+		if err != nil {
+			s.logger.Warn("Failed to resolve destination channel", "user_id", userID, "channel_name", name, "error", err)
+			return nil, err
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// resolveReplyTo returns the channel and thread root of the post a message
+// replies to, checking the user can read that channel.
+func (s *ScheduleService) resolveReplyTo(userID, postID string) (string, string, error) {
+	s.logger.Debug("Resolving reply target", "user_id", userID, "post_id", postID)
+	post, err := s.readablePost(userID, postID)
+	if err != nil {
+		return "", "", fmt.Errorf("cannot reply to post %s: %w", postID, err)
+	}
+	rootID := post.RootId
+	if rootID == "" {
+		rootID = post.Id
+	}
+	s.logger.Debug("Resolved reply target", "user_id", userID, "post_id", postID, "channel_id", post.ChannelId, "root_id", rootID)
+	return post.ChannelId, rootID, nil
+}
+
+// readablePost returns a post that exists in a channel the user is a member
+// of.
+func (s *ScheduleService) readablePost(userID, postID string) (*model.Post, error) {
+	post, err := s.poster.GetPost(postID)
+	if err != nil || post == nil || post.DeleteAt != 0 {
+		s.logger.Warn("Post not found", "user_id", userID, "post_id", postID, "error", err)
+		return nil, errors.New("post not found")
+	}
+	if err := s.channel.VerifyMembership(post.ChannelId, userID); err != nil {
+		s.logger.Warn("User cannot read post channel", "user_id", userID, "post_id", postID, "channel_id", post.ChannelId, "error", err)
+		return nil, err
+	}
+	return post, nil
+}
+
+func (s *ScheduleService) successResponse(msg *types.ScheduledMessage, localTime time.Time, tz string) *model.CommandResponse {
+	s.logger.Debug("Formatting success response", "user_id", msg.UserID, "message_id", msg.ID, "destinations", msg.Destinations(), "timezone", tz)
+	channelLink := destinationLinks(s.channel, msg.Destinations(), nil)
 	text := formatter.FormatScheduleSuccess(localTime, tz, channelLink, msg.RootID != "")
 	s.logger.Debug("Formatted success response text", "user_id", msg.UserID, "message_id", msg.ID, "response_text", text)
 	return &model.CommandResponse{
@@ -38,6 +38,7 @@ type testMocks struct {
 	userAPI *mock.MockUserService
 	store   *mock.MockStore
 	channel *mock.MockChannelService
+	poster  *mock.MockPostService
 	clock   *testutil.FakeClock
 	logger  *testutil.FakeLogger
 }
@@ -50,6 +51,7 @@ func setupScheduleServiceTest(t *testing.T) (*ScheduleService, *testMocks) {
 		userAPI: mock.NewMockUserService(ctrl),
 		store:   mock.NewMockStore(ctrl),
 		channel: mock.NewMockChannelService(ctrl),
+		poster:  mock.NewMockPostService(ctrl),
 		clock:   &testutil.FakeClock{NowTime: testNow},
 		logger:  &testutil.FakeLogger{},
 	}
@@ -59,6 +61,7 @@ func setupScheduleServiceTest(t *testing.T) (*ScheduleService, *testMocks) {
 		mocks.userAPI,
 		mocks.store,
 		mocks.channel,
+		mocks.poster,
 		mocks.clock,
 		testMaxUserMsgs,
 	)
@@ -80,6 +83,7 @@ func TestNewScheduleService(t *testing.T) {
 	assert.Equal(t, mocks.userAPI, service.userAPI)
 	assert.Equal(t, mocks.store, service.store)
 	assert.Equal(t, mocks.channel, service.channel)
+	assert.Equal(t, mocks.poster, service.poster)
 	assert.Equal(t, mocks.clock, service.clock)
 	assert.Equal(t, testMaxUserMsgs, service.maxUserMessages)
 }
@@ -94,6 +98,7 @@ func TestBuild_HappyPath(t *testing.T) {
 	channelInfo := &ports.ChannelInfo{ChannelID: testChannelID, ChannelLink: testChannelLink, TeamName: testTeamName, ChannelType: model.ChannelTypeOpen}
 
 	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{"id1"}, nil)
+	mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(nil)
 	mocks.userAPI.EXPECT().Get(testUserID).Return(&model.User{Timezone: map[string]string{"manualTimezone": testTimezone}}, nil)
 	mocks.store.EXPECT().GenerateMessageID().Return(testMsgID)
 	mocks.store.EXPECT().SaveScheduledMessage(testUserID, gomock.AssignableToTypeOf(&types.ScheduledMessage{})).
@@ -128,6 +133,7 @@ func TestBuild_TimezoneLogic_DefaultUsed_NoSettings(t *testing.T) {
 	channelInfo := &ports.ChannelInfo{ChannelID: testChannelID, ChannelLink: testChannelLink, TeamName: testTeamName, ChannelType: model.ChannelTypeOpen}
 
 	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+	mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(nil)
 	mocks.userAPI.EXPECT().Get(testUserID).Return(&model.User{Timezone: map[string]string{}}, nil)
 	mocks.store.EXPECT().GenerateMessageID().Return(testMsgID)
 	mocks.store.EXPECT().SaveScheduledMessage(testUserID, gomock.AssignableToTypeOf(&types.ScheduledMessage{})).
@@ -159,6 +165,7 @@ func TestBuild_TimezoneLogic_ManualUsed(t *testing.T) {
 	channelInfo := &ports.ChannelInfo{ChannelID: testChannelID, ChannelLink: testChannelLink, TeamName: testTeamName, ChannelType: model.ChannelTypeOpen}
 
 	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+	mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(nil)
 	mocks.userAPI.EXPECT().Get(testUserID).Return(&model.User{Timezone: map[string]string{
 		"useAutomaticTimezone": "false", // Important
 		"automaticTimezone":    autoTZ,
@@ -188,6 +195,7 @@ func TestBuild_PreparationFailure_TimeResolutionError(t *testing.T) {
 	text := "at 9:00AM on 2024-01-15 message Hello Past" // 9 AM UTC on Jan 15, testNow is 10 AM UTC Jan 15
 
 	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+	mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(nil)
 	mocks.userAPI.EXPECT().Get(testUserID).Return(&model.User{Timezone: map[string]string{"manualTimezone": testDefaultTZ}}, nil) // Use UTC
 
 	resp := service.Build(args, text)
@@ -209,6 +217,7 @@ func TestBuild_PersistenceFailure_StoreSaveError(t *testing.T) {
 	saveErr := errors.New("kv set failed")
 
 	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+	mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(nil)
 	mocks.userAPI.EXPECT().Get(testUserID).Return(&model.User{Timezone: map[string]string{"manualTimezone": testTimezone}}, nil)
 	mocks.store.EXPECT().GenerateMessageID().Return(testMsgID)
 	mocks.store.EXPECT().SaveScheduledMessage(testUserID, gomock.AssignableToTypeOf(&types.ScheduledMessage{})).Return(saveErr)
@@ -223,6 +232,68 @@ func TestBuild_PersistenceFailure_StoreSaveError(t *testing.T) {
 	assert.Equal(t, expectedFormattedErr, resp.Text)
 }
 
+func TestBuild_MultipleDestinations(t *testing.T) {
+	service, mocks := setupScheduleServiceTest(t)
+	args := defaultArgs()
+	args.TeamId = "test-team-id"
+	args.RootId = "test-root-id"
+	text := "at 3:00PM on 2024-01-16 to ~town-square ~off-topic message Hello everyone"
+	expectedPostAtLocal := time.Date(2024, 1, 16, 15, 0, 0, 0, testutil.MustLoadLocation(t, testTimezone))
+	info1 := &ports.ChannelInfo{ChannelID: "chan-1", ChannelLink: "~town-square", ChannelType: model.ChannelTypeOpen}
+	info2 := &ports.ChannelInfo{ChannelID: "chan-2", ChannelLink: "~off-topic", ChannelType: model.ChannelTypeOpen}
+
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+	mocks.channel.EXPECT().ResolveDestination("test-team-id", testUserID, "~town-square").Return("chan-1", nil)
+	mocks.channel.EXPECT().ResolveDestination("test-team-id", testUserID, "~off-topic").Return("chan-2", nil)
+	mocks.userAPI.EXPECT().Get(testUserID).Return(&model.User{Timezone: map[string]string{"manualTimezone": testTimezone}}, nil)
+	mocks.store.EXPECT().GenerateMessageID().Return(testMsgID)
+	mocks.store.EXPECT().SaveScheduledMessage(testUserID, gomock.AssignableToTypeOf(&types.ScheduledMessage{})).
+		DoAndReturn(func(_ string, msg *types.ScheduledMessage) error {
+			assert.Equal(t, "chan-1", msg.ChannelID)
+			assert.Equal(t, []string{"chan-1", "chan-2"}, msg.ChannelIDs)
+			assert.Empty(t, msg.RootID, "cross-posted messages must not keep the thread root")
+			assert.Equal(t, "Hello everyone", msg.MessageContent)
+			return nil
+		})
+	mocks.channel.EXPECT().GetInfoOrUnknown("chan-1").Return(info1)
+	mocks.channel.EXPECT().GetInfoOrUnknown("chan-2").Return(info2)
+	mocks.channel.EXPECT().MakeChannelLink(info1).Return("in channel: ~town-square")
+	mocks.channel.EXPECT().MakeChannelLink(info2).Return("in channel: ~off-topic")
+
+	resp := service.Build(args, text)
+
+	require.NotNil(t, resp)
+	expectedSuccessMsg := formatter.FormatScheduleSuccess(expectedPostAtLocal, testTimezone, "in channel: ~town-square, in channel: ~off-topic", false)
+	assert.Equal(t, expectedSuccessMsg, resp.Text)
+}
+
+func TestBuild_PreparationFailure_DestinationNotResolved(t *testing.T) {
+	service, mocks := setupScheduleServiceTest(t)
+	args := defaultArgs()
+	args.TeamId = "test-team-id"
+	text := "at 3:00PM to ~secret message Hello"
+
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+	mocks.channel.EXPECT().ResolveDestination("test-team-id", testUserID, "~secret").Return("", errors.New("you are not a member of ~secret"))
+
+	resp := service.Build(args, text)
+
+	require.NotNil(t, resp)
+	assert.Contains(t, resp.Text, "failed to resolve destination: you are not a member of ~secret")
+}
+
+func TestBuild_PreparationFailure_CannotPostInChannel(t *testing.T) {
+	service, mocks := setupScheduleServiceTest(t)
+
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+	mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(errors.New("channel " + testChannelID + " is archived"))
+
+	resp := service.Build(defaultArgs(), "at 3:00PM on 2024-01-16 message Hello")
+
+	require.NotNil(t, resp)
+	assert.Contains(t, resp.Text, "failed to resolve destination: channel "+testChannelID+" is archived")
+}
+
 func TestBuild_TimezoneLogic_AutomaticUsed(t *testing.T) {
 	service, mocks := setupScheduleServiceTest(t)
 	args := defaultArgs()
@@ -235,6 +306,7 @@ func TestBuild_TimezoneLogic_AutomaticUsed(t *testing.T) {
 	channelInfo := &ports.ChannelInfo{ChannelID: testChannelID, ChannelLink: testChannelLink, TeamName: testTeamName, ChannelType: model.ChannelTypeOpen}
 
 	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+	mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(nil)
 	mocks.userAPI.EXPECT().Get(testUserID).Return(&model.User{Timezone: map[string]string{
 		"useAutomaticTimezone": "true",
 		"automaticTimezone":    autoTZ,
@@ -294,6 +366,289 @@ func TestBuild_PreparationFailure_InputParsingError(t *testing.T) {
 	assert.Contains(t, resp.Text, "Original input: `at noon tomorrow do stuff`")
 }
 
+func TestBuild_SavedTemplate(t *testing.T) {
+	service, mocks := setupScheduleServiceTest(t)
+	args := defaultArgs()
+	text := "at 3:00PM on 2024-01-16 template release"
+	channelInfo := &ports.ChannelInfo{ChannelID: testChannelID, ChannelLink: testChannelLink, TeamName: testTeamName, ChannelType: model.ChannelTypeOpen}
+
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+	mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(nil)
+	mocks.store.EXPECT().GetTemplate(testUserID, "release").Return("Release checklist for {{date}}", nil)
+	mocks.userAPI.EXPECT().Get(testUserID).Return(&model.User{}, nil)
+	mocks.store.EXPECT().GenerateMessageID().Return(testMsgID)
+	mocks.store.EXPECT().SaveScheduledMessage(testUserID, gomock.AssignableToTypeOf(&types.ScheduledMessage{})).
+		DoAndReturn(func(_ string, msg *types.ScheduledMessage) error {
+			assert.Equal(t, "Release checklist for {{date}}", msg.MessageContent)
+			return nil
+		})
+	mocks.channel.EXPECT().GetInfoOrUnknown(testChannelID).Return(channelInfo)
+	mocks.channel.EXPECT().MakeChannelLink(channelInfo).Return(testFormattedLink)
+
+	resp := service.Build(args, text)
+
+	require.NotNil(t, resp)
+	assert.Contains(t, resp.Text, constants.EmojiSuccess)
+}
+
+func TestBuild_PostAsBot(t *testing.T) {
+	service, mocks := setupScheduleServiceTest(t)
+	args := defaultArgs()
+	channelInfo := &ports.ChannelInfo{ChannelID: testChannelID, ChannelLink: testChannelLink, TeamName: testTeamName, ChannelType: model.ChannelTypeOpen}
+
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+	mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(nil)
+	mocks.userAPI.EXPECT().Get(testUserID).Return(&model.User{}, nil)
+	mocks.store.EXPECT().GenerateMessageID().Return(testMsgID)
+	mocks.store.EXPECT().SaveScheduledMessage(testUserID, gomock.AssignableToTypeOf(&types.ScheduledMessage{})).
+		DoAndReturn(func(_ string, msg *types.ScheduledMessage) error {
+			assert.Equal(t, constants.AttributionBot, msg.Attribution)
+			assert.Equal(t, "Office closed", msg.MessageContent)
+			return nil
+		})
+	mocks.channel.EXPECT().GetInfoOrUnknown(testChannelID).Return(channelInfo)
+	mocks.channel.EXPECT().MakeChannelLink(channelInfo).Return(testFormattedLink)
+
+	resp := service.Build(args, "at 3:00PM on 2024-01-16 as bot message Office closed")
+
+	require.NotNil(t, resp)
+	assert.Contains(t, resp.Text, constants.EmojiSuccess)
+}
+
+func TestBuild_WithoutReceipt(t *testing.T) {
+	service, mocks := setupScheduleServiceTest(t)
+	args := defaultArgs()
+	channelInfo := &ports.ChannelInfo{ChannelID: testChannelID, ChannelLink: testChannelLink, TeamName: testTeamName, ChannelType: model.ChannelTypeOpen}
+
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+	mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(nil)
+	mocks.userAPI.EXPECT().Get(testUserID).Return(&model.User{}, nil)
+	mocks.store.EXPECT().GenerateMessageID().Return(testMsgID)
+	mocks.store.EXPECT().SaveScheduledMessage(testUserID, gomock.AssignableToTypeOf(&types.ScheduledMessage{})).
+		DoAndReturn(func(_ string, msg *types.ScheduledMessage) error {
+			assert.Equal(t, constants.ReceiptOff, msg.Receipt)
+			assert.Equal(t, "Stand-up notes", msg.MessageContent)
+			return nil
+		})
+	mocks.channel.EXPECT().GetInfoOrUnknown(testChannelID).Return(channelInfo)
+	mocks.channel.EXPECT().MakeChannelLink(channelInfo).Return(testFormattedLink)
+
+	resp := service.Build(args, "at 3:00PM on 2024-01-16 without receipt message Stand-up notes")
+
+	require.NotNil(t, resp)
+	assert.Contains(t, resp.Text, constants.EmojiSuccess)
+}
+
+func TestBuild_UnlessReplied(t *testing.T) {
+	service, mocks := setupScheduleServiceTest(t)
+	args := defaultArgs()
+	args.RootId = "root-post-id"
+	channelInfo := &ports.ChannelInfo{ChannelID: testChannelID, ChannelLink: testChannelLink, TeamName: testTeamName, ChannelType: model.ChannelTypeOpen}
+
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+	mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(nil)
+	mocks.userAPI.EXPECT().Get(testUserID).Return(&model.User{}, nil)
+	mocks.store.EXPECT().GenerateMessageID().Return(testMsgID)
+	mocks.store.EXPECT().SaveScheduledMessage(testUserID, gomock.AssignableToTypeOf(&types.ScheduledMessage{})).
+		DoAndReturn(func(_ string, msg *types.ScheduledMessage) error {
+			assert.Equal(t, "root-post-id", msg.RootID)
+			assert.Equal(t, testNow, msg.CancelIfRepliedAfter)
+			return nil
+		})
+	mocks.channel.EXPECT().GetInfoOrUnknown(testChannelID).Return(channelInfo)
+	mocks.channel.EXPECT().MakeChannelLink(channelInfo).Return(testFormattedLink)
+
+	resp := service.Build(args, "at 3:00PM on 2024-01-16 unless replied message Any update?")
+
+	require.NotNil(t, resp)
+	assert.Contains(t, resp.Text, constants.EmojiSuccess)
+}
+
+func TestBuild_PreparationFailure_UnlessRepliedOutsideThread(t *testing.T) {
+	service, mocks := setupScheduleServiceTest(t)
+
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+	mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(nil)
+
+	resp := service.Build(defaultArgs(), "at 3:00PM on 2024-01-16 unless replied message Any update?")
+
+	require.NotNil(t, resp)
+	assert.Contains(t, resp.Text, "Error preparing schedule:")
+	assert.Contains(t, resp.Text, constants.ScheduleErrUnlessRepliedNoThread)
+}
+
+func TestBuild_ReplyTo(t *testing.T) {
+	const replyID = "abcdefghijklmnopqrstuvwxyz"
+	tests := []struct {
+		name     string
+		post     *model.Post
+		wantRoot string
+	}{
+		{name: "root post", post: &model.Post{Id: replyID, ChannelId: "other-channel-id"}, wantRoot: replyID},
+		{name: "reply in a thread", post: &model.Post{Id: replyID, ChannelId: "other-channel-id", RootId: "thread-root-id"}, wantRoot: "thread-root-id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mocks := setupScheduleServiceTest(t)
+			args := defaultArgs()
+			args.RootId = "current-root-id"
+			channelInfo := &ports.ChannelInfo{ChannelID: "other-channel-id", ChannelLink: testChannelLink, TeamName: testTeamName, ChannelType: model.ChannelTypeOpen}
+
+			mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+			mocks.channel.EXPECT().VerifyCanPost("other-channel-id", testUserID).Return(nil)
+			mocks.poster.EXPECT().GetPost(replyID).Return(tt.post, nil)
+			mocks.channel.EXPECT().VerifyMembership("other-channel-id", testUserID).Return(nil)
+			mocks.userAPI.EXPECT().Get(testUserID).Return(&model.User{}, nil)
+			mocks.store.EXPECT().GenerateMessageID().Return(testMsgID)
+			mocks.store.EXPECT().SaveScheduledMessage(testUserID, gomock.AssignableToTypeOf(&types.ScheduledMessage{})).
+				DoAndReturn(func(_ string, msg *types.ScheduledMessage) error {
+					assert.Equal(t, "other-channel-id", msg.ChannelID)
+					assert.Equal(t, tt.wantRoot, msg.RootID)
+					return nil
+				})
+			mocks.channel.EXPECT().GetInfoOrUnknown("other-channel-id").Return(channelInfo)
+			mocks.channel.EXPECT().MakeChannelLink(channelInfo).Return(testFormattedLink)
+
+			resp := service.Build(args, "at 3:00PM on 2024-01-16 reply to https://mm.example.com/team/pl/"+replyID+" message Following up")
+
+			require.NotNil(t, resp)
+			assert.Contains(t, resp.Text, constants.EmojiSuccess)
+		})
+	}
+}
+
+func TestBuild_PreparationFailure_ReplyTo(t *testing.T) {
+	const replyID = "abcdefghijklmnopqrstuvwxyz"
+	t.Run("post not found", func(t *testing.T) {
+		service, mocks := setupScheduleServiceTest(t)
+		mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+		mocks.poster.EXPECT().GetPost(replyID).Return(nil, errors.New("not found"))
+
+		resp := service.Build(defaultArgs(), "at 3:00PM reply to "+replyID+" message Hi")
+
+		assert.Contains(t, resp.Text, "cannot reply to post "+replyID+": post not found")
+	})
+	t.Run("deleted post", func(t *testing.T) {
+		service, mocks := setupScheduleServiceTest(t)
+		mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+		mocks.poster.EXPECT().GetPost(replyID).Return(&model.Post{Id: replyID, ChannelId: "other-channel-id", DeleteAt: 1}, nil)
+
+		resp := service.Build(defaultArgs(), "at 3:00PM reply to "+replyID+" message Hi")
+
+		assert.Contains(t, resp.Text, "cannot reply to post "+replyID+": post not found")
+	})
+	t.Run("not a channel member", func(t *testing.T) {
+		service, mocks := setupScheduleServiceTest(t)
+		mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+		mocks.poster.EXPECT().GetPost(replyID).Return(&model.Post{Id: replyID, ChannelId: "other-channel-id"}, nil)
+		mocks.channel.EXPECT().VerifyMembership("other-channel-id", testUserID).Return(errors.New("you are not a member of channel other-channel-id"))
+
+		resp := service.Build(defaultArgs(), "at 3:00PM reply to "+replyID+" message Hi")
+
+		assert.Contains(t, resp.Text, "cannot reply to post "+replyID+": you are not a member of channel other-channel-id")
+	})
+	t.Run("with destination channels", func(t *testing.T) {
+		service, mocks := setupScheduleServiceTest(t)
+		mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+
+		resp := service.Build(defaultArgs(), "at 3:00PM reply to "+replyID+" to ~town-square message Hi")
+
+		assert.Contains(t, resp.Text, constants.ScheduleErrReplyToWithChannels)
+	})
+}
+
+func TestBuildForward(t *testing.T) {
+	const postID = "abcdefghijklmnopqrstuvwxyz"
+	service, mocks := setupScheduleServiceTest(t)
+	channelInfo := &ports.ChannelInfo{ChannelID: "dest-channel-id", ChannelLink: testChannelLink, TeamName: testTeamName, ChannelType: model.ChannelTypeOpen}
+
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+	mocks.poster.EXPECT().GetPost(postID).Return(&model.Post{Id: postID, ChannelId: "source-channel-id", UserId: "author-id", Message: "Launch at 9 by {{author}}", FileIds: model.StringArray{"file-id"}}, nil)
+	mocks.channel.EXPECT().VerifyMembership("source-channel-id", testUserID).Return(nil)
+	mocks.userAPI.EXPECT().Get("author-id").Return(&model.User{Username: "alice"}, nil)
+	mocks.channel.EXPECT().ResolveDestination("", testUserID, "~dest").Return("dest-channel-id", nil)
+	mocks.userAPI.EXPECT().Get(testUserID).Return(&model.User{}, nil)
+	mocks.store.EXPECT().GenerateMessageID().Return(testMsgID)
+	mocks.store.EXPECT().SaveScheduledMessage(testUserID, gomock.AssignableToTypeOf(&types.ScheduledMessage{})).
+		DoAndReturn(func(_ string, msg *types.ScheduledMessage) error {
+			assert.Equal(t, "dest-channel-id", msg.ChannelID)
+			assert.Empty(t, msg.RootID)
+			assert.Equal(t, "Reminder:\n\n> **@alice**\n> Launch at 9 by {{author}}", msg.MessageContent)
+			assert.Equal(t, len("Reminder:"), msg.CommentBytes)
+			assert.Equal(t, postID, msg.ForwardedPostID)
+			assert.Equal(t, []string{"file-id"}, msg.FileIDs)
+			assert.Equal(t, time.Date(2024, 1, 16, 9, 0, 0, 0, time.UTC), msg.PostAt)
+			return nil
+		})
+	mocks.channel.EXPECT().GetInfoOrUnknown("dest-channel-id").Return(channelInfo)
+	mocks.channel.EXPECT().MakeChannelLink(channelInfo).Return(testFormattedLink)
+
+	resp := service.BuildForward(defaultArgs(), " https://mm.example.com/team/pl/"+postID+" at 9am to ~dest message Reminder:")
+
+	require.NotNil(t, resp)
+	assert.Contains(t, resp.Text, constants.EmojiSuccess)
+}
+
+func TestBuildForward_PreparationFailure(t *testing.T) {
+	const postID = "abcdefghijklmnopqrstuvwxyz"
+	t.Run("invalid format", func(t *testing.T) {
+		service, mocks := setupScheduleServiceTest(t)
+		mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+
+		resp := service.BuildForward(defaultArgs(), postID+" at 9am template standup")
+
+		assert.Contains(t, resp.Text, constants.ForwardErrInvalidFormat)
+	})
+	t.Run("post not readable", func(t *testing.T) {
+		service, mocks := setupScheduleServiceTest(t)
+		mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+		mocks.poster.EXPECT().GetPost(postID).Return(&model.Post{Id: postID, ChannelId: "private-channel-id"}, nil)
+		mocks.channel.EXPECT().VerifyMembership("private-channel-id", testUserID).Return(errors.New("you are not a member of channel private-channel-id"))
+
+		resp := service.BuildForward(defaultArgs(), postID+" at 9am")
+
+		assert.Contains(t, resp.Text, "cannot forward post "+postID+": you are not a member of channel private-channel-id")
+	})
+}
+
+func TestBuild_PreparationFailure_SavedTemplateNotFound(t *testing.T) {
+	service, mocks := setupScheduleServiceTest(t)
+	args := defaultArgs()
+	text := "at 3:00PM on 2024-01-16 template missing"
+
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+	mocks.store.EXPECT().GetTemplate(testUserID, "missing").Return("", errors.New("template missing not found"))
+
+	resp := service.Build(args, text)
+
+	require.NotNil(t, resp)
+	assert.Contains(t, resp.Text, "Error preparing schedule:")
+	assert.Contains(t, resp.Text, "template missing not found")
+}
+
+func TestBuild_OtherTemplateSyntaxIsKept(t *testing.T) {
+	service, mocks := setupScheduleServiceTest(t)
+	args := defaultArgs()
+	channelInfo := &ports.ChannelInfo{ChannelID: testChannelID, ChannelLink: testChannelLink, TeamName: testTeamName, ChannelType: model.ChannelTypeOpen}
+
+	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+	mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(nil)
+	mocks.userAPI.EXPECT().Get(testUserID).Return(&model.User{}, nil)
+	mocks.store.EXPECT().GenerateMessageID().Return(testMsgID)
+	mocks.store.EXPECT().SaveScheduledMessage(testUserID, gomock.AssignableToTypeOf(&types.ScheduledMessage{})).
+		DoAndReturn(func(_ string, msg *types.ScheduledMessage) error {
+			assert.Equal(t, "Hi {{name}}, bump {{ .Values.foo }} on {{date}}", msg.MessageContent)
+			return nil
+		})
+	mocks.channel.EXPECT().GetInfoOrUnknown(testChannelID).Return(channelInfo)
+	mocks.channel.EXPECT().MakeChannelLink(channelInfo).Return(testFormattedLink)
+
+	resp := service.Build(args, "at 3:00PM on 2024-01-16 message Hi {{name}}, bump {{ .Values.foo }} on {{date}}")
+
+	require.NotNil(t, resp)
+	assert.Contains(t, resp.Text, constants.EmojiSuccess)
+}
+
 func TestBuild_PreparationFailure_UserTimezoneFetchError(t *testing.T) {
 	service, mocks := setupScheduleServiceTest(t)
 	args := defaultArgs()
@@ -304,6 +659,7 @@ func TestBuild_PreparationFailure_UserTimezoneFetchError(t *testing.T) {
 	fetchErr := errors.New("api error")
 
 	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+	mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(nil)
 	mocks.userAPI.EXPECT().Get(testUserID).Return(nil, fetchErr)
 	mocks.store.EXPECT().GenerateMessageID().Return(testMsgID)
 	mocks.store.EXPECT().SaveScheduledMessage(testUserID, gomock.AssignableToTypeOf(&types.ScheduledMessage{})).
@@ -334,6 +690,7 @@ func TestBuild_PreparationFailure_InvalidUserTimezone(t *testing.T) {
 	channelInfo := &ports.ChannelInfo{ChannelID: testChannelID, ChannelLink: testChannelLink, TeamName: testTeamName, ChannelType: model.ChannelTypeOpen}
 
 	mocks.store.EXPECT().ListUserMessageIDs(testUserID).Return([]string{}, nil)
+	mocks.channel.EXPECT().VerifyCanPost(testChannelID, testUserID).Return(nil)
 	mocks.userAPI.EXPECT().Get(testUserID).Return(&model.User{Timezone: map[string]string{"manualTimezone": invalidTZ}}, nil)
 	mocks.store.EXPECT().GenerateMessageID().Return(testMsgID)
 	mocks.store.EXPECT().SaveScheduledMessage(testUserID, gomock.AssignableToTypeOf(&types.ScheduledMessage{})).
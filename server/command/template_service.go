@@ -0,0 +1,95 @@
+package command
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/formatter"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+var (
+	regexTemplateSave   = regexp.MustCompile(`(?i)^save[ \t]+([\w.-]+)\s+([\s\S]+)$`)
+	regexTemplateDelete = regexp.MustCompile(`(?i)^delete[ \t]+([\w.-]+)$`)
+)
+
+// TemplateService manages a user's saved message templates.
+type TemplateService struct {
+	logger ports.Logger
+	store  ports.Store
+}
+
+// NewTemplateService constructs a TemplateService.
+func NewTemplateService(logger ports.Logger, store ports.Store) *TemplateService {
+	logger.Debug("Creating new TemplateService")
+	return &TemplateService{
+		logger: logger,
+		store:  store,
+	}
+}
+
+// Build runs a template subcommand and returns the response.
+func (t *TemplateService) Build(userID, text string) *model.CommandResponse {
+	trimmedText := strings.TrimSpace(text)
+	t.logger.Debug("Handling template subcommand", "user_id", userID, "text", trimmedText)
+
+	if strings.EqualFold(trimmedText, constants.TemplateActionList) {
+		return t.list(userID)
+	}
+	if matches := regexTemplateSave.FindStringSubmatch(trimmedText); matches != nil {
+		return t.save(userID, strings.ToLower(matches[1]), strings.TrimSpace(matches[2]))
+	}
+	if matches := regexTemplateDelete.FindStringSubmatch(trimmedText); matches != nil {
+		return t.delete(userID, strings.ToLower(matches[1]))
+	}
+	t.logger.Debug("Unrecognized template subcommand", "user_id", userID, "text", trimmedText)
+	return errorResponse(formatter.FormatTemplateError(errors.New(constants.TemplateErrInvalidFormat)))
+}
+
+func (t *TemplateService) save(userID, name, content string) *model.CommandResponse {
+	t.logger.Debug("Saving template", "user_id", userID, "template", name)
+	if err := checkMaxMessageBytes(t.logger, content); err != nil {
+		return errorResponse(formatter.FormatTemplateError(err))
+	}
+	if err := t.store.SaveTemplate(userID, name, content); err != nil {
+		t.logger.Error("Failed to save template", "user_id", userID, "template", name, "error", err)
+		return errorResponse(formatter.FormatTemplateError(err))
+	}
+	t.logger.Info("Saved template", "user_id", userID, "template", name)
+	return ephemeralResponse(formatter.FormatTemplateSaved(name))
+}
+
+func (t *TemplateService) list(userID string) *model.CommandResponse {
+	t.logger.Debug("Listing templates", "user_id", userID)
+	templates, err := t.store.ListTemplates(userID)
+	if err != nil {
+		t.logger.Error("Failed to list templates", "user_id", userID, "error", err)
+		return errorResponse(formatter.FormatTemplateError(err))
+	}
+	if len(templates) == 0 {
+		t.logger.Debug("User has no saved templates", "user_id", userID)
+		return ephemeralResponse(constants.EmptyTemplateListMessage)
+	}
+	t.logger.Debug("Found templates", "user_id", userID, "count", len(templates))
+	return ephemeralResponse(formatter.FormatTemplateList(templates))
+}
+
+func (t *TemplateService) delete(userID, name string) *model.CommandResponse {
+	t.logger.Debug("Deleting template", "user_id", userID, "template", name)
+	if err := t.store.DeleteTemplate(userID, name); err != nil {
+		t.logger.Error("Failed to delete template", "user_id", userID, "template", name, "error", err)
+		return errorResponse(formatter.FormatTemplateError(err))
+	}
+	t.logger.Info("Deleted template", "user_id", userID, "template", name)
+	return ephemeralResponse(formatter.FormatTemplateDeleted(name))
+}
+
+func ephemeralResponse(txt string) *model.CommandResponse {
+	return &model.CommandResponse{
+		ResponseType: model.CommandResponseTypeEphemeral,
+		Text:         txt,
+	}
+}
@@ -0,0 +1,122 @@
+package command
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/adapters/mock"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/testutil"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/formatter"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func setupTemplateServiceTest(t *testing.T) (*TemplateService, *mock.MockStore) {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	mockStore := mock.NewMockStore(ctrl)
+	service := NewTemplateService(&testutil.FakeLogger{}, mockStore)
+	require.NotNil(t, service)
+	return service, mockStore
+}
+
+func TestTemplateBuild_Save(t *testing.T) {
+	service, mockStore := setupTemplateServiceTest(t)
+
+	mockStore.EXPECT().SaveTemplate(testUserID, "release", "Release checklist\n- tag\n- deploy").Return(nil)
+
+	resp := service.Build(testUserID, " save Release Release checklist\n- tag\n- deploy")
+
+	require.NotNil(t, resp)
+	assert.Equal(t, model.CommandResponseTypeEphemeral, resp.ResponseType)
+	assert.Equal(t, formatter.FormatTemplateSaved("release"), resp.Text)
+}
+
+func TestTemplateBuild_SaveTooLarge(t *testing.T) {
+	service, _ := setupTemplateServiceTest(t)
+
+	resp := service.Build(testUserID, "save big "+strings.Repeat("a", constants.MaxMessageBytes+1))
+
+	require.NotNil(t, resp)
+	assert.Contains(t, resp.Text, "exceeds limit")
+}
+
+func TestTemplateBuild_SaveOtherTemplateSyntax(t *testing.T) {
+	service, mockStore := setupTemplateServiceTest(t)
+
+	mockStore.EXPECT().SaveTemplate(testUserID, "helm", "Set {{ .Values.foo }} for {{name}}").Return(nil)
+
+	resp := service.Build(testUserID, "save helm Set {{ .Values.foo }} for {{name}}")
+
+	require.NotNil(t, resp)
+	assert.Equal(t, formatter.FormatTemplateSaved("helm"), resp.Text)
+}
+
+func TestTemplateBuild_SaveStoreError(t *testing.T) {
+	service, mockStore := setupTemplateServiceTest(t)
+
+	mockStore.EXPECT().SaveTemplate(testUserID, "release", "checklist").Return(errors.New("boom"))
+
+	resp := service.Build(testUserID, "save release checklist")
+
+	require.NotNil(t, resp)
+	assert.Equal(t, formatter.FormatTemplateError(errors.New("boom")), resp.Text)
+}
+
+func TestTemplateBuild_List(t *testing.T) {
+	service, mockStore := setupTemplateServiceTest(t)
+	templates := map[string]string{"release": "checklist"}
+
+	mockStore.EXPECT().ListTemplates(testUserID).Return(templates, nil)
+
+	resp := service.Build(testUserID, "list")
+
+	require.NotNil(t, resp)
+	assert.Equal(t, formatter.FormatTemplateList(templates), resp.Text)
+}
+
+func TestTemplateBuild_ListEmpty(t *testing.T) {
+	service, mockStore := setupTemplateServiceTest(t)
+
+	mockStore.EXPECT().ListTemplates(testUserID).Return(nil, nil)
+
+	resp := service.Build(testUserID, "list")
+
+	require.NotNil(t, resp)
+	assert.Equal(t, constants.EmptyTemplateListMessage, resp.Text)
+}
+
+func TestTemplateBuild_Delete(t *testing.T) {
+	service, mockStore := setupTemplateServiceTest(t)
+
+	mockStore.EXPECT().DeleteTemplate(testUserID, "release").Return(nil)
+
+	resp := service.Build(testUserID, "delete release")
+
+	require.NotNil(t, resp)
+	assert.Equal(t, formatter.FormatTemplateDeleted("release"), resp.Text)
+}
+
+func TestTemplateBuild_DeleteNotFound(t *testing.T) {
+	service, mockStore := setupTemplateServiceTest(t)
+
+	mockStore.EXPECT().DeleteTemplate(testUserID, "release").Return(errors.New("template release not found"))
+
+	resp := service.Build(testUserID, "delete release")
+
+	require.NotNil(t, resp)
+	assert.Contains(t, resp.Text, "template release not found")
+}
+
+func TestTemplateBuild_InvalidFormat(t *testing.T) {
+	service, _ := setupTemplateServiceTest(t)
+
+	resp := service.Build(testUserID, "rename a b")
+
+	require.NotNil(t, resp)
+	assert.Equal(t, formatter.FormatTemplateError(errors.New(constants.TemplateErrInvalidFormat)), resp.Text)
+}
@@ -1,8 +1,15 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
+	"time"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/encryption"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
 )
 
 // configuration captures the plugin's external configuration as exposed in the Mattermost server
@@ -17,12 +24,38 @@ import (
 // If you add non-reference types to your configuration struct, be sure to rewrite Clone as a deep
 // copy appropriate for your types.
 type configuration struct {
+	// EncryptionKey seals scheduled message content at rest. Empty stores
+	// content in plain text.
+	EncryptionKey string
+	// PreviousEncryptionKeys lists retired keys, one per line, that stored
+	// content may still be sealed with until it is reencrypted.
+	PreviousEncryptionKeys string
+	// LatePolicy is what happens to messages found more than
+	// LateThresholdMinutes past their time: send, annotate or skip. A nil
+	// threshold was never set and takes the default; zero applies the
+	// policy to any message posted after its time.
+	LatePolicy           string
+	LateThresholdMinutes *int
+	// MaxChannelPostsPerMinute and MaxPostsPerMinute limit how fast due
+	// messages are posted to one channel and overall. Zero is no limit.
+	MaxChannelPostsPerMinute int
+	MaxPostsPerMinute        int
+	// DefaultAttribution is who scheduled messages are posted as unless
+	// the author chose: user or bot.
+	DefaultAttribution string
+	// ShowScheduledIndicator adds a note to posted messages saying they
+	// were scheduled.
+	ShowScheduledIndicator bool
 }
 
 // Clone shallow copies the configuration. Your implementation may require a deep copy if
 // your configuration has reference types.
 func (c *configuration) Clone() *configuration {
 	var clone = *c
+	if c.LateThresholdMinutes != nil {
+		threshold := *c.LateThresholdMinutes
+		clone.LateThresholdMinutes = &threshold
+	}
 	return &clone
 }
 
@@ -78,7 +111,68 @@ func (p *Plugin) OnConfigurationChange() error {
 		return fmt.Errorf("failed to load plugin configuration: %w", err)
 	}
 
+	keyring, err := configuration.keyring()
+	if err != nil {
+		return fmt.Errorf("invalid encryption configuration: %w", err)
+	}
+	policy, err := configuration.deliveryPolicy()
+	if err != nil {
+		return fmt.Errorf("invalid delivery configuration: %w", err)
+	}
+
 	p.setConfiguration(configuration)
+	keysChanged := p.cipher.SetKeyring(keyring)
+	if p.Scheduler != nil {
+		p.Scheduler.SetPolicy(policy)
+	}
+
+	// Before activation there is no store yet; activation reencrypts instead.
+	// Rewriting every record is slow, so it only happens when the keys change
+	// and runs in the background rather than holding up the configuration save.
+	if p.Store != nil && keysChanged {
+		p.reencrypting.Go(p.reencryptMessages)
+	}
 
 	return nil
 }
+
+// keyring derives the encryption keyring from the configured keys.
+func (c *configuration) keyring() (*encryption.Keyring, error) {
+	return encryption.NewKeyring(c.EncryptionKey, strings.Split(c.PreviousEncryptionKeys, "\n"))
+}
+
+// deliveryPolicy builds the scheduler settings, defaulting unset values.
+func (c *configuration) deliveryPolicy() (types.DeliveryPolicy, error) {
+	policy := types.DeliveryPolicy{
+		LatePolicy:         c.LatePolicy,
+		LateThreshold:      constants.DefaultLateThresholdMinutes * time.Minute,
+		ChannelRateLimit:   c.MaxChannelPostsPerMinute,
+		GlobalRateLimit:    c.MaxPostsPerMinute,
+		Attribution:        c.DefaultAttribution,
+		ScheduledIndicator: c.ShowScheduledIndicator,
+	}
+	switch policy.LatePolicy {
+	case "":
+		policy.LatePolicy = constants.LatePolicySend
+	case constants.LatePolicySend, constants.LatePolicyAnnotate, constants.LatePolicySkip:
+	default:
+		return types.DeliveryPolicy{}, fmt.Errorf("unknown late message policy %q", c.LatePolicy)
+	}
+	if c.LateThresholdMinutes != nil {
+		if *c.LateThresholdMinutes < 0 {
+			return types.DeliveryPolicy{}, errors.New("late threshold must not be negative")
+		}
+		policy.LateThreshold = time.Duration(*c.LateThresholdMinutes) * time.Minute
+	}
+	switch policy.Attribution {
+	case "":
+		policy.Attribution = constants.AttributionUser
+	case constants.AttributionUser, constants.AttributionBot:
+	default:
+		return types.DeliveryPolicy{}, fmt.Errorf("unknown default attribution %q", c.DefaultAttribution)
+	}
+	if policy.ChannelRateLimit < 0 || policy.GlobalRateLimit < 0 {
+		return types.DeliveryPolicy{}, errors.New("posts per minute limits must not be negative")
+	}
+	return policy, nil
+}
@@ -0,0 +1,135 @@
+// Package consistency finds and repairs drift between stored scheduled
+// messages and the per-user indexes that list them.
+package consistency
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+)
+
+// Find compares stored messages with user indexes. It reports index entries
+// that point at missing messages, or at messages owned by another user, and
+// messages that are not in their owner's index. Index entries for the
+// unreadable message IDs are left out, since their owner cannot be checked.
+// Issues are sorted by user and message ID.
+func Find(msgs []*types.ScheduledMessage, unreadable []string, indexes map[string][]string) []types.ConsistencyIssue {
+	owners := make(map[string]string, len(msgs))
+	for _, msg := range msgs {
+		owners[msg.ID] = msg.UserID
+	}
+	var issues []types.ConsistencyIssue
+	for userID, ids := range indexes {
+		for _, id := range ids {
+			if owners[id] != userID && !slices.Contains(unreadable, id) {
+				issues = append(issues, types.ConsistencyIssue{Kind: constants.ConsistencyIssueOrphanedIndexEntry, UserID: userID, MessageID: id})
+			}
+		}
+	}
+	for _, msg := range msgs {
+		if !slices.Contains(indexes[msg.UserID], msg.ID) {
+			issues = append(issues, types.ConsistencyIssue{Kind: constants.ConsistencyIssueMissingIndexEntry, UserID: msg.UserID, MessageID: msg.ID})
+		}
+	}
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].UserID != issues[j].UserID {
+			return issues[i].UserID < issues[j].UserID
+		}
+		return issues[i].MessageID < issues[j].MessageID
+	})
+	return issues
+}
+
+// Checker scans the store and repairs the drift Find reports.
+type Checker struct {
+	logger ports.Logger
+	store  ports.Store
+	mu     sync.Mutex
+}
+
+// NewChecker constructs a Checker.
+func NewChecker(logger ports.Logger, store ports.Store) *Checker {
+	logger.Debug("Creating new consistency Checker")
+	return &Checker{logger: logger, store: store}
+}
+
+// Repair scans the store and fixes every issue that is still present when it
+// is rechecked. Issues that resolve themselves in the meantime, such as a
+// message sent during the scan, are left alone. Repairs are idempotent, so
+// overlapping runs on other cluster nodes are harmless.
+func (c *Checker) Repair() (*types.ConsistencyReport, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logger.Debug("Starting consistency check")
+
+	// Indexes are read before messages: saves write the index first and
+	// deletes remove the message first, so this order keeps a concurrent
+	// save or delete from looking like a lost message.
+	indexes, err := c.store.ListUserIndexes()
+	if err != nil {
+		c.logger.Error("Failed to list user indexes for consistency check", "error", err)
+		return nil, fmt.Errorf("failed to list user indexes: %w", err)
+	}
+	msgs, unreadable, err := c.store.ScanScheduledMessages()
+	if err != nil {
+		c.logger.Error("Failed to list scheduled messages for consistency check", "error", err)
+		return nil, fmt.Errorf("failed to list scheduled messages: %w", err)
+	}
+
+	report := &types.ConsistencyReport{
+		Messages: len(msgs),
+		Indexes:  len(indexes),
+		Repaired: []types.ConsistencyIssue{},
+		Failed:   []types.ConsistencyIssue{},
+	}
+	for _, issue := range Find(msgs, unreadable, indexes) {
+		present, err := c.stillPresent(issue)
+		if err != nil {
+			issue.Error = err.Error()
+			report.Failed = append(report.Failed, issue)
+			continue
+		}
+		if !present {
+			c.logger.Debug("Consistency issue resolved during scan", "kind", issue.Kind, "user_id", issue.UserID, "message_id", issue.MessageID)
+			continue
+		}
+		if err := c.repair(issue); err != nil {
+			issue.Error = err.Error()
+			report.Failed = append(report.Failed, issue)
+			continue
+		}
+		report.Repaired = append(report.Repaired, issue)
+	}
+	c.logger.Info("Finished consistency check", "messages", report.Messages, "indexes", report.Indexes, "repaired", len(report.Repaired), "failed", len(report.Failed))
+	return report, nil
+}
+
+// stillPresent rechecks an issue against the stored message. Only a message
+// that is really gone counts as missing; any other read error is returned so
+// the issue is reported rather than repaired.
+func (c *Checker) stillPresent(issue types.ConsistencyIssue) (bool, error) {
+	msg, err := c.store.GetScheduledMessage(issue.MessageID)
+	if err != nil && !errors.Is(err, types.ErrMessageNotFound) {
+		c.logger.Warn("Failed to recheck consistency issue", "kind", issue.Kind, "user_id", issue.UserID, "message_id", issue.MessageID, "error", err)
+		return false, fmt.Errorf("failed to read message: %w", err)
+	}
+	exists := err == nil && msg.UserID == issue.UserID
+	if issue.Kind == constants.ConsistencyIssueOrphanedIndexEntry {
+		return !exists, nil
+	}
+	return exists, nil
+}
+
+func (c *Checker) repair(issue types.ConsistencyIssue) error {
+	c.logger.Warn("Repairing consistency issue", "kind", issue.Kind, "user_id", issue.UserID, "message_id", issue.MessageID)
+	if issue.Kind == constants.ConsistencyIssueOrphanedIndexEntry {
+		return c.store.CleanupMessageFromUserIndex(issue.UserID, issue.MessageID)
+	}
+	return c.store.RestoreMessageToUserIndex(issue.UserID, issue.MessageID)
+}
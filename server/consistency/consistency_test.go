@@ -0,0 +1,173 @@
+package consistency
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/adapters/mock"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/testutil"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func msg(id, userID string) *types.ScheduledMessage {
+	return &types.ScheduledMessage{ID: id, UserID: userID, ChannelID: "chan", PostAt: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)}
+}
+
+func orphaned(userID, msgID string) types.ConsistencyIssue {
+	return types.ConsistencyIssue{Kind: constants.ConsistencyIssueOrphanedIndexEntry, UserID: userID, MessageID: msgID}
+}
+
+func missing(userID, msgID string) types.ConsistencyIssue {
+	return types.ConsistencyIssue{Kind: constants.ConsistencyIssueMissingIndexEntry, UserID: userID, MessageID: msgID}
+}
+
+func TestFind(t *testing.T) {
+	msgs := []*types.ScheduledMessage{msg("a", "u1"), msg("b", "u1"), msg("c", "u2")}
+	indexes := map[string][]string{
+		"u1": {"a", "gone"},
+		"u2": {"b", "c"},
+	}
+
+	got := Find(msgs, nil, indexes)
+
+	assert.Equal(t, []types.ConsistencyIssue{
+		missing("u1", "b"),
+		orphaned("u1", "gone"),
+		orphaned("u2", "b"),
+	}, got)
+}
+
+func TestFind_Consistent(t *testing.T) {
+	msgs := []*types.ScheduledMessage{msg("a", "u1")}
+
+	assert.Empty(t, Find(msgs, nil, map[string][]string{"u1": {"a"}}))
+}
+
+func TestFind_SkipsUnreadableMessages(t *testing.T) {
+	msgs := []*types.ScheduledMessage{msg("a", "u1")}
+
+	got := Find(msgs, []string{"sealed"}, map[string][]string{"u1": {"a", "sealed", "gone"}})
+
+	assert.Equal(t, []types.ConsistencyIssue{orphaned("u1", "gone")}, got)
+}
+
+func setupChecker(t *testing.T) (*Checker, *mock.MockStore) {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	st := mock.NewMockStore(ctrl)
+	return NewChecker(testutil.FakeLogger{}, st), st
+}
+
+func TestRepair(t *testing.T) {
+	checker, st := setupChecker(t)
+
+	gomock.InOrder(
+		st.EXPECT().ListUserIndexes().Return(map[string][]string{"u1": {"a", "gone"}}, nil),
+		st.EXPECT().ScanScheduledMessages().Return([]*types.ScheduledMessage{msg("a", "u1"), msg("b", "u2")}, nil, nil),
+	)
+	st.EXPECT().GetScheduledMessage("gone").Return(nil, types.ErrMessageNotFound)
+	st.EXPECT().CleanupMessageFromUserIndex("u1", "gone").Return(nil)
+	st.EXPECT().GetScheduledMessage("b").Return(msg("b", "u2"), nil)
+	st.EXPECT().RestoreMessageToUserIndex("u2", "b").Return(nil)
+
+	report, err := checker.Repair()
+
+	require.NoError(t, err)
+	assert.Equal(t, &types.ConsistencyReport{
+		Messages: 2,
+		Indexes:  1,
+		Repaired: []types.ConsistencyIssue{orphaned("u1", "gone"), missing("u2", "b")},
+		Failed:   []types.ConsistencyIssue{},
+	}, report)
+}
+
+func TestRepair_SkipsIssuesResolvedDuringScan(t *testing.T) {
+	checker, st := setupChecker(t)
+
+	st.EXPECT().ListUserIndexes().Return(map[string][]string{"u1": {"saving"}}, nil)
+	st.EXPECT().ScanScheduledMessages().Return([]*types.ScheduledMessage{msg("sent", "u2")}, nil, nil)
+	// Saved after the message list was read.
+	st.EXPECT().GetScheduledMessage("saving").Return(msg("saving", "u1"), nil)
+	// Sent and deleted after the message list was read.
+	st.EXPECT().GetScheduledMessage("sent").Return(nil, types.ErrMessageNotFound)
+
+	report, err := checker.Repair()
+
+	require.NoError(t, err)
+	assert.Empty(t, report.Repaired)
+	assert.Empty(t, report.Failed)
+}
+
+func TestRepair_ReportsFailedRepairs(t *testing.T) {
+	checker, st := setupChecker(t)
+
+	st.EXPECT().ListUserIndexes().Return(map[string][]string{}, nil)
+	st.EXPECT().ScanScheduledMessages().Return([]*types.ScheduledMessage{msg("b", "u2")}, nil, nil)
+	st.EXPECT().GetScheduledMessage("b").Return(msg("b", "u2"), nil)
+	st.EXPECT().RestoreMessageToUserIndex("u2", "b").Return(errors.New("kv down"))
+
+	report, err := checker.Repair()
+
+	require.NoError(t, err)
+	assert.Empty(t, report.Repaired)
+	failed := missing("u2", "b")
+	failed.Error = "kv down"
+	assert.Equal(t, []types.ConsistencyIssue{failed}, report.Failed)
+}
+
+func TestRepair_LeavesUnreadableMessagesAlone(t *testing.T) {
+	checker, st := setupChecker(t)
+
+	st.EXPECT().ListUserIndexes().Return(map[string][]string{"u1": {"sealed"}}, nil)
+	st.EXPECT().ScanScheduledMessages().Return([]*types.ScheduledMessage{msg("b", "u2")}, nil, nil)
+	st.EXPECT().GetScheduledMessage("sealed").Return(nil, errors.New("failed to decrypt message sealed"))
+	st.EXPECT().GetScheduledMessage("b").Return(nil, errors.New("kv down"))
+
+	report, err := checker.Repair()
+
+	require.NoError(t, err)
+	assert.Empty(t, report.Repaired)
+	unreadable := orphaned("u1", "sealed")
+	unreadable.Error = "failed to read message: failed to decrypt message sealed"
+	unreachable := missing("u2", "b")
+	unreachable.Error = "failed to read message: kv down"
+	assert.Equal(t, []types.ConsistencyIssue{unreadable, unreachable}, report.Failed)
+}
+
+func TestRepair_SkipsIndexEntriesOfUnreadableMessages(t *testing.T) {
+	checker, st := setupChecker(t)
+
+	st.EXPECT().ListUserIndexes().Return(map[string][]string{"u1": {"a", "sealed"}}, nil)
+	st.EXPECT().ScanScheduledMessages().Return([]*types.ScheduledMessage{msg("a", "u1")}, []string{"sealed"}, nil)
+
+	report, err := checker.Repair()
+
+	require.NoError(t, err)
+	assert.Empty(t, report.Repaired)
+	assert.Empty(t, report.Failed)
+}
+
+func TestRepair_ListErrors(t *testing.T) {
+	t.Run("indexes", func(t *testing.T) {
+		checker, st := setupChecker(t)
+		st.EXPECT().ListUserIndexes().Return(nil, errors.New("kv down"))
+
+		_, err := checker.Repair()
+
+		assert.EqualError(t, err, "failed to list user indexes: kv down")
+	})
+	t.Run("messages", func(t *testing.T) {
+		checker, st := setupChecker(t)
+		st.EXPECT().ListUserIndexes().Return(map[string][]string{}, nil)
+		st.EXPECT().ScanScheduledMessages().Return(nil, nil, errors.New("kv down"))
+
+		_, err := checker.Repair()
+
+		assert.EqualError(t, err, "failed to list scheduled messages: kv down")
+	})
+}
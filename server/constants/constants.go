@@ -6,10 +6,108 @@ const (
 	SchedPrefix = "schedmsg:"
 	// UserIndexPrefix is the prefix used for user message index keys in the KV store.
 	UserIndexPrefix = "user_sched_index:"
+	// TemplatePrefix is the prefix used for per-user saved template keys in the KV store.
+	TemplatePrefix = "user_templates:"
+	// CalendarTokenPrefix is the prefix used for calendar feed token keys in the KV store.
+	CalendarTokenPrefix = "ical_token:"
+	// UserCalendarTokenPrefix is the prefix used for per-user calendar feed token keys in the KV store.
+	UserCalendarTokenPrefix = "user_ical_token:"
+	// ReceiptPreferencePrefix is the prefix used for per-user delivery receipt preference keys in the KV store.
+	ReceiptPreferencePrefix = "user_receipts:"
+	// SequenceKey is the KV key holding the last scheduled message sequence number.
+	SequenceKey = "sched_sequence"
+	// SequenceMaxAttempts is how many times saving retries a contended sequence number.
+	SequenceMaxAttempts = 10
+	// SchemaVersionKey is the KV key holding the version of the last completed migration.
+	SchemaVersionKey = "schema_version"
+	// MigrationMutexKey names the cluster mutex held while migrations run.
+	MigrationMutexKey = "migrations"
+	// ReencryptMutexKey names the cluster mutex held while stored messages are reencrypted.
+	ReencryptMutexKey = "reencrypt"
+	// ReencryptedKeyIDKey is the KV key holding the encryption key ID the last complete reencryption used.
+	ReencryptedKeyIDKey = "reencrypted_key_id"
+	// ScheduledMessageSchemaVersion is the current layout version of stored scheduled messages.
+	// Version 2 added encrypted content, which version 1 readers would see as an empty message.
+	ScheduledMessageSchemaVersion = 2
+	// PlainMessageSchemaVersion is the layout version of records stored without encrypted
+	// content, which version 1 readers can still read.
+	PlainMessageSchemaVersion = 1
+	// ConsistencyIssueOrphanedIndexEntry marks a user index entry whose message is missing or owned by another user.
+	ConsistencyIssueOrphanedIndexEntry = "orphaned_index_entry"
+	// ConsistencyIssueMissingIndexEntry marks a stored message that is not in its owner's index.
+	ConsistencyIssueMissingIndexEntry = "missing_index_entry"
+	// ConsistencyJobKey names the cluster job that runs the consistency check.
+	ConsistencyJobKey = "consistency_check"
+	// ConsistencyCheckIntervalMinutes is how often the consistency check runs.
+	ConsistencyCheckIntervalMinutes = 60
+	// SchedulerResyncIntervalMinutes is how often the scheduler rescans the store for messages its queue missed.
+	SchedulerResyncIntervalMinutes = 5
+	// SchedulerStopTimeoutSeconds is how long Stop waits for in-flight deliveries.
+	SchedulerStopTimeoutSeconds = 30
+	// LatePolicySend posts late messages as if they were on time.
+	LatePolicySend = "send"
+	// LatePolicyAnnotate posts late messages with a note saying when they were due.
+	LatePolicyAnnotate = "annotate"
+	// LatePolicySkip deletes late messages without posting them and tells the author.
+	LatePolicySkip = "skip"
+	// DefaultLateThresholdMinutes is how far past its time a message must be before the late policy applies.
+	DefaultLateThresholdMinutes = 15
+	// AttributionUser posts scheduled messages as their author.
+	AttributionUser = "user"
+	// AttributionBot posts scheduled messages as the bot, on behalf of their author.
+	AttributionBot = "bot"
+	// AttributionKeywordBot is the schedule command keyword, after "as", for AttributionBot.
+	AttributionKeywordBot = "bot"
+	// AttributionKeywordMe is the schedule command keyword, after "as", for AttributionUser.
+	AttributionKeywordMe = "me"
+	// ReceiptOn sends the author a receipt when the message is posted.
+	ReceiptOn = "on"
+	// ReceiptOff never sends the author a receipt for the message.
+	ReceiptOff = "off"
+	// ReceiptKeywordWith is the schedule command keyword, before "receipt", for ReceiptOn.
+	ReceiptKeywordWith = "with"
+	// ReceiptKeywordWithout is the schedule command keyword, before "receipt", for ReceiptOff.
+	ReceiptKeywordWithout = "without"
+	// MaxWarnMinutes is the longest allowed heads-up before a message is posted (one day).
+	MaxWarnMinutes = 24 * 60
+	// WarningPreviewRunes is the maximum message preview length in a heads-up DM.
+	WarningPreviewRunes = 300
+	// ActionSourceWarning marks interactive button requests sent from a heads-up DM rather than the list.
+	ActionSourceWarning = "warning"
+	// ScheduleErrReplyToWithChannels is returned when "reply to" is combined with destination channels.
+	ScheduleErrReplyToWithChannels = "`reply to` cannot be combined with `to ~channel`; replies are posted in the post's channel"
+	// ScheduleErrUnlessRepliedNoThread is returned for "unless replied" outside a thread.
+	ScheduleErrUnlessRepliedNoThread = "`unless replied` only works for messages scheduled in a thread"
+	// ReceiptPermalinkPath is the path, after the site URL, of a post permalink that works in any team.
+	ReceiptPermalinkPath = "/_redirect/pl/"
+	// PropFromScheduledMessage is the post prop, always true, marking posts made from scheduled messages.
+	PropFromScheduledMessage = "from_scheduled_message"
+	// PropScheduledMessageID is the post prop holding the ID of the scheduled message a post came from.
+	PropScheduledMessageID = "scheduled_message_id"
+	// PropScheduledPostAt is the post prop holding when the message was scheduled for, in Unix milliseconds.
+	PropScheduledPostAt = "scheduled_post_at"
+	// PropScheduledRecurrenceID is the post prop identifying the series a recurring scheduled message belongs to.
+	PropScheduledRecurrenceID = "scheduled_recurrence_id"
+	// PropScheduledOccurrence is the post prop holding the 1-based occurrence of a recurring scheduled message.
+	PropScheduledOccurrence = "scheduled_occurrence"
+	// PropOnBehalfOfUserID is the post prop naming the author of a message the bot posted for them.
+	PropOnBehalfOfUserID = "on_behalf_of_user_id"
+	// RateLimitWindowSeconds is the sliding window the scheduler's per-minute rate limits count posts over.
+	RateLimitWindowSeconds = 60
+	// MinEncryptionKeyLength is the shortest encryption key accepted from the plugin configuration.
+	MinEncryptionKeyLength = 32
 	// MaxUserMessages is a common limit used in tests involving user message counts.
 	MaxUserMessages = 1000
 	// MaxMessageBytes is the maximum message size in bytes.
 	MaxMessageBytes = 50 * 1024
+	// MaxUserTemplates is the maximum number of saved templates per user.
+	MaxUserTemplates = 100
+	// MaxUserTemplatesBytes caps the combined size in bytes of a user's template
+	// names and bodies, which are all stored in one KV value. Each body is
+	// limited by MaxMessageBytes like any other message.
+	MaxUserTemplatesBytes = 1024 * 1024
+	// PluginID is the plugin identifier from plugin.json.
+	PluginID = "com.mattermost.plugin-poor-mans-scheduled-messages"
 	// AssetsDir is the plugin assets directory name.
 	AssetsDir = "assets"
 
@@ -30,6 +128,48 @@ const (
 	SubcommandHelp = "help"
 	// SubcommandList is the list subcommand keyword.
 	SubcommandList = "list"
+	// SubcommandTemplate is the saved template subcommand keyword.
+	SubcommandTemplate = "template"
+	// TemplateActionSave saves a template.
+	TemplateActionSave = "save"
+	// TemplateActionList lists saved templates.
+	TemplateActionList = "list"
+	// TemplateActionDelete deletes a template.
+	TemplateActionDelete = "delete"
+	// SubcommandExport is the export subcommand keyword.
+	SubcommandExport = "export"
+	// SubcommandCalendar is the calendar feed subcommand keyword.
+	SubcommandCalendar = "calendar"
+	// CalendarActionReset replaces the calendar feed link.
+	CalendarActionReset = "reset"
+	// CalendarActionRevoke turns the calendar feed off.
+	CalendarActionRevoke = "revoke"
+	// SubcommandReceipts is the delivery receipt preference subcommand keyword.
+	SubcommandReceipts = "receipts"
+	// SubcommandFollowup is the follow-up reminder subcommand keyword.
+	SubcommandFollowup = "followup"
+	// FollowupDM reminds the author by DM when a watched thread gets no answer.
+	FollowupDM = "dm"
+	// FollowupBump posts a bump in a watched thread that gets no answer.
+	FollowupBump = "bump"
+	// FollowupDefaultNote is the message of a follow-up DM set without one.
+	FollowupDefaultNote = "Follow up on this thread."
+	// FollowupDefaultBumpNote is the message of a follow-up bump set without one.
+	FollowupDefaultBumpNote = "Friendly bump: any update on this?"
+	// MaxFollowupDays is how far ahead a follow-up can be set.
+	MaxFollowupDays = 90
+	// SubcommandForward is the forward subcommand keyword.
+	SubcommandForward = "forward"
+	// ReceiptsActionOn turns delivery receipts on.
+	ReceiptsActionOn = "on"
+	// ReceiptsActionOff turns delivery receipts off.
+	ReceiptsActionOff = "off"
+	// SubcommandAdmin is the system admin subcommand keyword.
+	SubcommandAdmin = "admin"
+	// AdminActionStatus shows the plugin health report.
+	AdminActionStatus = "status"
+	// AdminActionRepair runs the store consistency check and repair.
+	AdminActionRepair = "repair"
 	// SubcommandAt is the schedule subcommand keyword.
 	SubcommandAt = "at"
 	// AutocompleteDesc is the description used in autocomplete.
@@ -37,7 +177,7 @@ const (
 	// AutocompleteHint is the hint used in autocomplete.
 	AutocompleteHint = "[subcommand]"
 	// AutocompleteAtHint is the hint for the schedule subcommand.
-	AutocompleteAtHint = "<time> [on <date>] message <text>"
+	AutocompleteAtHint = "<time> [on <date>] [reply to <post link>] [to ~channel ...] [as bot|me] [with|without receipt] [warn <duration>] [unless replied] (message <text> | template <name>)"
 	// AutocompleteAtDesc describes the schedule subcommand.
 	AutocompleteAtDesc = "Schedule a new message"
 	// AutocompleteAtArgTimeName is the name of the time argument.
@@ -48,10 +188,56 @@ const (
 	AutocompleteAtArgDateName = "Date"
 	// AutocompleteAtArgDateHint is the hint for the date argument.
 	AutocompleteAtArgDateHint = "(Optional) Date to send the message, e.g. 2026-01-01"
+	// AutocompleteAtArgChannelsName is the name of the destination channels argument.
+	AutocompleteAtArgChannelsName = "Channels"
+	// AutocompleteAtArgChannelsHint is the hint for the destination channels argument.
+	AutocompleteAtArgChannelsHint = "(Optional) Channels to post to instead of the current one, e.g. to ~town-square ~off-topic"
 	// AutocompleteAtArgMsgName is the name of the message argument.
 	AutocompleteAtArgMsgName = "Message"
 	// AutocompleteAtArgMsgHint is the hint for the message argument.
 	AutocompleteAtArgMsgHint = "The message content"
+	// AutocompleteTemplateHint is the hint for the template subcommand.
+	AutocompleteTemplateHint = "save <name> <text> | list | delete <name>"
+	// AutocompleteTemplateDesc describes the template subcommand.
+	AutocompleteTemplateDesc = "Manage your saved message templates"
+	// AutocompleteTemplateSaveHint is the hint for the template save action.
+	AutocompleteTemplateSaveHint = "<name> <text>"
+	// AutocompleteTemplateSaveDesc describes the template save action.
+	AutocompleteTemplateSaveDesc = "Save a message template, replacing any template with the same name"
+	// AutocompleteTemplateListDesc describes the template list action.
+	AutocompleteTemplateListDesc = "List your saved templates"
+	// AutocompleteTemplateDeleteHint is the hint for the template delete action.
+	AutocompleteTemplateDeleteHint = "<name>"
+	// AutocompleteTemplateDeleteDesc describes the template delete action.
+	AutocompleteTemplateDeleteDesc = "Delete a saved template"
+	// AutocompleteExportHint is the hint for the export subcommand.
+	AutocompleteExportHint = "[json|csv]"
+	// AutocompleteExportDesc describes the export subcommand.
+	AutocompleteExportDesc = "Send yourself a file of your scheduled messages"
+	// AutocompleteCalendarHint is the hint for the calendar subcommand.
+	AutocompleteCalendarHint = "[reset|revoke]"
+	// AutocompleteCalendarDesc describes the calendar subcommand.
+	AutocompleteCalendarDesc = "Get a calendar feed link for your scheduled messages"
+	// AutocompleteReceiptsHint is the hint for the receipts subcommand.
+	AutocompleteReceiptsHint = "[on|off]"
+	// AutocompleteReceiptsDesc describes the receipts subcommand.
+	AutocompleteReceiptsDesc = "Get a DM when your scheduled messages are posted"
+	// AutocompleteForwardHint is the hint for the forward subcommand.
+	AutocompleteForwardHint = "<post link> at <time> [on <date>] [to ~channel ...] [message <comment>]"
+	// AutocompleteForwardDesc describes the forward subcommand.
+	AutocompleteForwardDesc = "Re-share an existing post later, quoting it with a link to the original"
+	// AutocompleteFollowupHint is the hint for the followup subcommand.
+	AutocompleteFollowupHint = "in <delay> [dm|bump] [message <text>]"
+	// AutocompleteFollowupDesc describes the followup subcommand.
+	AutocompleteFollowupDesc = "Get reminded, or bump this thread, if the people you mentioned have not replied"
+	// AutocompleteAdminHint is the hint for the admin subcommand.
+	AutocompleteAdminHint = "status | repair"
+	// AutocompleteAdminDesc describes the admin subcommand.
+	AutocompleteAdminDesc = "System admin tools"
+	// AutocompleteAdminStatusDesc describes the admin status action.
+	AutocompleteAdminStatusDesc = "Show scheduler health and plugin settings"
+	// AutocompleteAdminRepairDesc describes the admin repair action.
+	AutocompleteAdminRepairDesc = "Find and fix scheduled messages missing from, or left behind in, user indexes"
 	// AutocompleteListHint is the hint for the list subcommand.
 	AutocompleteListHint = ""
 	// AutocompleteListDesc describes the list subcommand.
@@ -66,14 +252,68 @@ const (
 	// Parser Errors
 
 	// ParserErrInvalidFormat is returned for invalid command formats.
-	ParserErrInvalidFormat = "invalid format. Use: `at <time> [on <date>] message <your message text>`"
+	ParserErrInvalidFormat = "invalid format. Use: `at <time> [on <date>] [reply to <post link>] [to ~channel ...] [as bot|me] [with|without receipt] [warn <duration>] [unless replied] message <your message text>` or `at <time> [on <date>] [reply to <post link>] [to ~channel ...] [as bot|me] [with|without receipt] [warn <duration>] [unless replied] template <name>`"
+	// ParserErrInvalidReplyTo is returned for "reply to" targets that are not posts.
+	ParserErrInvalidReplyTo = "invalid post '%s'. Use a post permalink or post ID"
+	// ParserErrInvalidWarn is returned for heads-up times that are not durations.
+	ParserErrInvalidWarn = "invalid heads-up time '%s'. Use a duration like 15m or 1h30m"
+	// ParserErrWarnRange is returned for heads-up times outside the allowed range.
+	ParserErrWarnRange = "heads-up time must be between 1 minute and 24 hours"
+	// TemplateErrInvalidFormat is returned for invalid template subcommands.
+	TemplateErrInvalidFormat = "invalid format. Use: `template save <name> <text>`, `template list` or `template delete <name>`"
 	// ParserErrInvalidDateFormat is returned for invalid date inputs.
 	ParserErrInvalidDateFormat = "invalid date format specified: '%s'. Use YYYY-MM-DD, day name (e.g., 'tuesday', 'fri'), or short date (e.g., '3jan', '25dec')"
 	// ParserErrUnknownDateFormat is returned for unknown date formats.
 	ParserErrUnknownDateFormat = "unknown date format detected"
+	// CalendarErrInvalidFormat is returned for invalid calendar subcommands.
+	CalendarErrInvalidFormat = "invalid format. Use: `calendar`, `calendar reset` or `calendar revoke`"
+	// ReceiptsErrInvalidFormat is returned for invalid receipts subcommands.
+	ReceiptsErrInvalidFormat = "invalid format. Use: `receipts`, `receipts on` or `receipts off`"
+	// ForwardErrInvalidFormat is returned for invalid forward subcommands.
+	ForwardErrInvalidFormat = "invalid format. Use: `forward <post link> at <time> [on <date>] [reply to <post link>] [to ~channel ...] [as bot|me] [with|without receipt] [warn <duration>] [unless replied] [message <comment>]`"
+	// FollowupErrInvalidFormat is returned for invalid followup subcommands.
+	FollowupErrInvalidFormat = "invalid format. Use: `followup in <delay> [dm|bump] [message <text>]`, e.g. `followup in 2d`"
+	// FollowupErrInvalidDelay is returned for follow-up delays that are not durations.
+	FollowupErrInvalidDelay = "invalid follow-up delay '%s'. Use a delay like 2d, 4h or 1d12h"
+	// FollowupErrDelayRange is returned for follow-up delays outside the allowed range.
+	FollowupErrDelayRange = "follow-up delay must be between 1 minute and 90 days"
+	// FollowupErrNoThread is returned when followup is used outside a thread.
+	FollowupErrNoThread = "`followup` only works in a thread"
+	// AdminErrInvalidFormat is returned for invalid admin subcommands.
+	AdminErrInvalidFormat = "invalid format. Use: `admin status` or `admin repair`"
+	// AdminErrPermission is returned when a non-admin runs an admin subcommand.
+	AdminErrPermission = "you must be a system admin to use this command"
+	// CalendarImportErrUsage is returned when an ICS file is sent to the bot without a target channel.
+	CalendarImportErrUsage = "add the channel for the reminders and, optionally, how long before each event to post them, e.g. `~releases 15m`"
 
 	// API & HTTP
 
+	// MaxImportBytes is the maximum size of an uploaded import file.
+	MaxImportBytes = 5 * 1024 * 1024
+	// HTTPHeaderContentType is the content type header name.
+	HTTPHeaderContentType = "Content-Type"
+	// ContentTypeJSON is the JSON content type.
+	ContentTypeJSON = "application/json"
+	// ContentTypeCalendar is the iCalendar content type.
+	ContentTypeCalendar = "text/calendar; charset=utf-8"
+	// CalendarRoutePrefix is the path prefix of the token-authenticated calendar feed.
+	CalendarRoutePrefix = "/calendar/"
+	// CalendarFileExtension is the file extension of the calendar feed.
+	CalendarFileExtension = ".ics"
+	// CalendarTokenBytes is the number of random bytes in a calendar feed token.
+	CalendarTokenBytes = 32
+	// CalendarProdID identifies the plugin in calendar feeds.
+	CalendarProdID = "-//Poor Man's Scheduled Messages//Mattermost Plugin//EN"
+	// CalendarName is the display name of the calendar feed.
+	CalendarName = "Scheduled messages"
+	// CalendarPreviewRunes is the maximum message preview length in calendar events.
+	CalendarPreviewRunes = 200
+	// DefaultCalendarLeadMinutes is how long before an imported event its reminder is posted by default.
+	DefaultCalendarLeadMinutes = 15
+	// MaxCalendarLeadMinutes is the longest allowed reminder lead time (one week).
+	MaxCalendarLeadMinutes = 7 * 24 * 60
+	// CalendarImportHorizonDays limits how far ahead recurring events are expanded on import.
+	CalendarImportHorizonDays = 90
 	// HTTPHeaderMattermostUserID is the header containing the Mattermost user ID.
 	HTTPHeaderMattermostUserID = "Mattermost-User-ID"
 
@@ -81,14 +321,26 @@ const (
 
 	// TimeLayout is the time format for user-facing messages.
 	TimeLayout = "Jan 2, 2006 3:04 PM"
+	// TemplateDateLayout is the date format for the {{date}} template variable.
+	TemplateDateLayout = "Jan 2, 2006"
+	// TemplateTimeLayout is the time format for the {{time}} template variable.
+	TemplateTimeLayout = "3:04 PM"
 	// EmojiSuccess is the success indicator emoji.
 	EmojiSuccess = "✅"
 	// EmojiError is the error indicator emoji.
 	EmojiError = "❌"
 	// UnknownChannelPlaceholder is used when channel info is unavailable.
 	UnknownChannelPlaceholder = "N/A"
+	// UnknownUserPlaceholder is used when a user cannot be looked up.
+	UnknownUserPlaceholder = "unknown user"
 	// EmptyListMessage is shown when no scheduled messages exist.
 	EmptyListMessage = "You have no scheduled messages."
+	// EmptyTemplateListMessage is shown when no saved templates exist.
+	EmptyTemplateListMessage = "You have no saved templates."
+	// TemplateListHeader is the heading for the template list response.
+	TemplateListHeader = "### Saved Templates"
+	// AdminStatusHeader is the heading for the admin status response.
+	AdminStatusHeader = "### Scheduled Messages Status"
 	// ListHeader is the heading for the list response.
 	ListHeader = "### Scheduled Messages"
 
@@ -101,6 +353,10 @@ const (
 
 	// File Paths
 
+	// ExportFilePrefix is the base name of export files.
+	ExportFilePrefix = "scheduled-messages"
+	// ExportFileTimeLayout is the timestamp format used in export file names.
+	ExportFileTimeLayout = "20060102-150405"
 	// HelpFilename is the help text filename.
 	HelpFilename = "help.md"
 
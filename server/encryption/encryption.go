@@ -0,0 +1,221 @@
+// Package encryption seals scheduled message content at rest.
+//
+// Content is encrypted with a random per-message data key, and the data key
+// is encrypted with a key derived from the plugin configuration. Rotating the
+// configured key only rewraps data keys; message ciphertext is unchanged.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+)
+
+const dataKeySize = 32
+
+type key struct {
+	id       string
+	material []byte
+}
+
+func deriveKey(secret string) (*key, error) {
+	if len(secret) < constants.MinEncryptionKeyLength {
+		return nil, fmt.Errorf("encryption key must be at least %d characters", constants.MinEncryptionKeyLength)
+	}
+	material := sha256.Sum256([]byte(secret))
+	id := sha256.Sum256(material[:])
+	return &key{id: hex.EncodeToString(id[:8]), material: material[:]}, nil
+}
+
+// Keyring holds the key new content is sealed with and the previous keys
+// that existing content may still be sealed with.
+type Keyring struct {
+	current *key
+	keys    map[string]*key
+}
+
+// NewKeyring derives a Keyring from configured secrets. current may be empty,
+// in which case content is stored in plain text and previous keys are only
+// used to read existing records.
+func NewKeyring(current string, previous []string) (*Keyring, error) {
+	k := &Keyring{keys: map[string]*key{}}
+	for i, secret := range previous {
+		secret = strings.TrimSpace(secret)
+		if secret == "" {
+			continue
+		}
+		derived, err := deriveKey(secret)
+		if err != nil {
+			return nil, fmt.Errorf("previous key %d: %w", i+1, err)
+		}
+		k.keys[derived.id] = derived
+	}
+	if current = strings.TrimSpace(current); current != "" {
+		derived, err := deriveKey(current)
+		if err != nil {
+			return nil, err
+		}
+		k.current = derived
+		k.keys[derived.id] = derived
+	}
+	return k, nil
+}
+
+// keyIDs lists the current key ID, empty when there is none, followed by the
+// sorted previous key IDs. A nil Keyring has no keys.
+func (k *Keyring) keyIDs() []string {
+	ids := []string{""}
+	if k == nil {
+		return ids
+	}
+	if k.current != nil {
+		ids[0] = k.current.id
+	}
+	for id := range k.keys {
+		if id != ids[0] {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids[1:])
+	return ids
+}
+
+// Box is a ContentCipher whose keyring can be replaced while in use, so a
+// configuration change takes effect without rebuilding the store. The zero
+// value stores content in plain text.
+type Box struct {
+	keyring atomic.Pointer[Keyring]
+}
+
+// NewBox constructs a Box using keyring, which may be nil.
+func NewBox(keyring *Keyring) *Box {
+	b := &Box{}
+	b.SetKeyring(keyring)
+	return b
+}
+
+// SetKeyring replaces the keyring. A nil keyring disables encryption. It
+// reports whether the current or previous key IDs changed, in which case
+// stored content may need to be reencrypted.
+func (b *Box) SetKeyring(keyring *Keyring) bool {
+	return !slices.Equal(b.keyring.Swap(keyring).keyIDs(), keyring.keyIDs())
+}
+
+// KeyID names the key new content is sealed with, or is empty when
+// encryption is disabled.
+func (b *Box) KeyID() string {
+	if k := b.keyring.Load(); k != nil && k.current != nil {
+		return k.current.id
+	}
+	return ""
+}
+
+// Encrypt seals plaintext with a new data key. It returns nil when
+// encryption is disabled.
+func (b *Box) Encrypt(plaintext string) (*types.EncryptedContent, error) {
+	k := b.keyring.Load()
+	if k == nil || k.current == nil {
+		return nil, nil
+	}
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	ciphertext, err := seal(dataKey, []byte(plaintext))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt content: %w", err)
+	}
+	wrapped, err := seal(k.current.material, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	return &types.EncryptedContent{KeyID: k.current.id, WrappedKey: wrapped, Ciphertext: ciphertext}, nil
+}
+
+// Decrypt opens content sealed with the current or a previous key.
+func (b *Box) Decrypt(enc *types.EncryptedContent) (string, error) {
+	dataKey, err := b.unwrap(enc)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dataKey, enc.Ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt content: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// Rewrap reseals the data key of enc with the current key.
+func (b *Box) Rewrap(enc *types.EncryptedContent) (*types.EncryptedContent, error) {
+	k := b.keyring.Load()
+	if k == nil || k.current == nil {
+		return nil, errors.New("no current encryption key configured")
+	}
+	dataKey, err := b.unwrap(enc)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := seal(k.current.material, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	return &types.EncryptedContent{KeyID: k.current.id, WrappedKey: wrapped, Ciphertext: enc.Ciphertext}, nil
+}
+
+func (b *Box) unwrap(enc *types.EncryptedContent) ([]byte, error) {
+	var wrapping *key
+	if k := b.keyring.Load(); k != nil {
+		wrapping = k.keys[enc.KeyID]
+	}
+	if wrapping == nil {
+		return nil, fmt.Errorf("encryption key %s is not configured", enc.KeyID)
+	}
+	dataKey, err := open(wrapping.material, enc.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	return dataKey, nil
+}
+
+// seal encrypts with AES-GCM and prefixes the random nonce.
+func seal(keyMaterial, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(keyMaterial)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(keyMaterial, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(keyMaterial)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(keyMaterial []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(keyMaterial)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
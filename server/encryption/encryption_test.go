@@ -0,0 +1,134 @@
+package encryption
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	oldSecret = strings.Repeat("o", 32)
+	newSecret = strings.Repeat("n", 32)
+)
+
+func mustKeyring(t *testing.T, current string, previous ...string) *Keyring {
+	t.Helper()
+	k, err := NewKeyring(current, previous)
+	require.NoError(t, err)
+	return k
+}
+
+func TestBox_RoundTrip(t *testing.T) {
+	box := NewBox(mustKeyring(t, newSecret))
+
+	enc, err := box.Encrypt("incident notes")
+	require.NoError(t, err)
+	require.NotNil(t, enc)
+	assert.Equal(t, box.KeyID(), enc.KeyID)
+	assert.NotContains(t, string(enc.Ciphertext), "incident notes")
+
+	got, err := box.Decrypt(enc)
+	require.NoError(t, err)
+	assert.Equal(t, "incident notes", got)
+}
+
+func TestBox_DisabledStoresPlainText(t *testing.T) {
+	for name, box := range map[string]*Box{
+		"nil keyring":    NewBox(nil),
+		"no current key": NewBox(mustKeyring(t, "", oldSecret)),
+		"zero value":     {},
+	} {
+		t.Run(name, func(t *testing.T) {
+			enc, err := box.Encrypt("hello")
+			require.NoError(t, err)
+			assert.Nil(t, enc)
+			assert.Empty(t, box.KeyID())
+		})
+	}
+}
+
+func TestBox_SetKeyringReportsKeyChanges(t *testing.T) {
+	var box Box
+
+	assert.False(t, box.SetKeyring(mustKeyring(t, "")), "no keys either way")
+	assert.True(t, box.SetKeyring(mustKeyring(t, oldSecret)))
+	assert.False(t, box.SetKeyring(mustKeyring(t, oldSecret)), "same key re-saved")
+	assert.True(t, box.SetKeyring(mustKeyring(t, newSecret, oldSecret)))
+	assert.False(t, box.SetKeyring(mustKeyring(t, " "+newSecret, "", oldSecret)), "same keys, different whitespace")
+	assert.True(t, box.SetKeyring(mustKeyring(t, newSecret)), "previous key dropped")
+	assert.True(t, box.SetKeyring(nil))
+}
+
+func TestBox_RewrapRotatesKey(t *testing.T) {
+	box := NewBox(mustKeyring(t, oldSecret))
+	enc, err := box.Encrypt("hello")
+	require.NoError(t, err)
+	oldKeyID := box.KeyID()
+
+	box.SetKeyring(mustKeyring(t, newSecret, oldSecret))
+	rewrapped, err := box.Rewrap(enc)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, oldKeyID, rewrapped.KeyID)
+	assert.Equal(t, box.KeyID(), rewrapped.KeyID)
+	assert.Equal(t, enc.Ciphertext, rewrapped.Ciphertext)
+
+	// The retired key is no longer needed once content is rewrapped.
+	box.SetKeyring(mustKeyring(t, newSecret))
+	got, err := box.Decrypt(rewrapped)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", got)
+	_, err = box.Decrypt(enc)
+	assert.EqualError(t, err, "encryption key "+oldKeyID+" is not configured")
+}
+
+func TestBox_DecryptWithPreviousKey(t *testing.T) {
+	box := NewBox(mustKeyring(t, oldSecret))
+	enc, err := box.Encrypt("hello")
+	require.NoError(t, err)
+
+	box.SetKeyring(mustKeyring(t, "", oldSecret))
+	got, err := box.Decrypt(enc)
+
+	require.NoError(t, err)
+	assert.Equal(t, "hello", got)
+}
+
+func TestBox_RewrapWithoutCurrentKey(t *testing.T) {
+	box := NewBox(mustKeyring(t, oldSecret))
+	enc, err := box.Encrypt("hello")
+	require.NoError(t, err)
+
+	box.SetKeyring(mustKeyring(t, "", oldSecret))
+	_, err = box.Rewrap(enc)
+
+	assert.EqualError(t, err, "no current encryption key configured")
+}
+
+func TestBox_DecryptRejectsTamperedContent(t *testing.T) {
+	box := NewBox(mustKeyring(t, newSecret))
+	enc, err := box.Encrypt("hello")
+	require.NoError(t, err)
+
+	enc.Ciphertext[len(enc.Ciphertext)-1] ^= 1
+	_, err = box.Decrypt(enc)
+
+	assert.ErrorContains(t, err, "failed to decrypt content")
+}
+
+func TestNewKeyring_RejectsShortKeys(t *testing.T) {
+	_, err := NewKeyring("short", nil)
+	assert.EqualError(t, err, "encryption key must be at least 32 characters")
+
+	_, err = NewKeyring(newSecret, []string{"", "short"})
+	assert.EqualError(t, err, "previous key 2: encryption key must be at least 32 characters")
+}
+
+func TestNewKeyring_TrimsWhitespace(t *testing.T) {
+	a := NewBox(mustKeyring(t, " "+newSecret+"\r"))
+	b := NewBox(mustKeyring(t, newSecret))
+
+	assert.Equal(t, b.KeyID(), a.KeyID())
+}
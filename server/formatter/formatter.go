@@ -3,9 +3,13 @@ package formatter
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
 )
 
 // FormatScheduleSuccess renders a success message for scheduling.
@@ -34,11 +38,453 @@ func FormatSchedulerFailure(channelLink string, postErr error, originalMsg strin
 	return fmt.Sprintf("%s Error scheduling message %s: %v -- original message: %s", constants.EmojiError, channelLink, postErr, originalMsg)
 }
 
+// FormatSchedulerPartialFailure renders a DM for a cross-posted message that failed for some destinations.
+func FormatSchedulerPartialFailure(delivered, total int, failures []string, originalMsg string) string {
+	return fmt.Sprintf("%s Scheduled message was posted to %d of %d destinations. Failed: %s -- original message: %s", constants.EmojiError, delivered, total, strings.Join(failures, ", "), originalMsg)
+}
+
+// FormatDelayedNote renders the note appended to a message posted late.
+// postAt and now should be in the author's time zone; the date is only
+// shown when the message was due on an earlier day.
+func FormatDelayedNote(postAt, now time.Time) string {
+	layout := constants.TemplateTimeLayout
+	if postAt.Format(constants.DateParseLayoutYYYYMMDD) != now.Format(constants.DateParseLayoutYYYYMMDD) {
+		layout = constants.TimeLayout
+	}
+	return fmt.Sprintf("_(delayed from %s)_", postAt.Format(layout))
+}
+
+// FormatSkippedLateMessage renders the DM sent when a late message is skipped.
+func FormatSkippedLateMessage(channelLink string, postAt time.Time, late time.Duration, originalMsg string) string {
+	return fmt.Sprintf("%s Scheduled message for %s was not posted because it was %s late (due %s) -- original message: %s", constants.EmojiError, channelLink, FormatLeadTime(late), postAt.Format(constants.TimeLayout), originalMsg)
+}
+
 // FormatListAttachmentHeader renders list attachment header text.
 func FormatListAttachmentHeader(postAt time.Time, channelLink, messageContent string, inThread bool) string {
 	return fmt.Sprintf("##### %s\n%s\n\n%s", postAt.Format(constants.TimeLayout), formatDestination(channelLink, inThread), messageContent)
 }
 
+// FormatDeferredNote renders the list note for a message a rate limit held
+// back past its time.
+func FormatDeferredNote(until time.Time) string {
+	return fmt.Sprintf("_Held back by a rate limit; posting at %s_", until.Format(constants.TimeLayout))
+}
+
+// FormatWarningNote renders the list note for a message whose author gets a
+// heads-up before it posts.
+func FormatWarningNote(warnBefore time.Duration) string {
+	return fmt.Sprintf("_Heads-up %s before posting_", FormatLeadTime(warnBefore))
+}
+
+// FormatUnlessRepliedNote renders the list note for a thread reply that is
+// cancelled if someone else replies first.
+func FormatUnlessRepliedNote() string {
+	return "_Cancelled if someone else replies in the thread first_"
+}
+
+// FormatCancelledReplied renders the DM sent when a message is cancelled
+// because someone replied in its thread.
+func FormatCancelledReplied(channelLink, originalMsg string) string {
+	return fmt.Sprintf("%s Scheduled message %s was not posted because someone replied in the thread -- original message: %s", constants.EmojiSuccess, channelLink, originalMsg)
+}
+
+// FormatFollowupSet renders the confirmation for a follow-up. mentions are
+// the usernames, with @, whose reply cancels it.
+func FormatFollowupSet(postAt time.Time, tz, mode string, mentions []string) string {
+	who := "nobody else has"
+	if len(mentions) > 0 {
+		who = strings.Join(mentions, " or ") + " hasn't"
+	}
+	action := "you will get a DM reminder"
+	if mode == constants.FollowupBump {
+		action = "the thread will be bumped"
+	}
+	return fmt.Sprintf("%s Follow-up set for %s (%s). If %s replied in this thread by then, %s.", constants.EmojiSuccess, postAt.Format(constants.TimeLayout), tz, who, action)
+}
+
+// FormatFollowupBump renders the post that bumps an unanswered thread,
+// mentioning who it is waiting on.
+func FormatFollowupBump(mentions []string, note string) string {
+	if note == "" {
+		note = constants.FollowupDefaultBumpNote
+	}
+	if len(mentions) == 0 {
+		return note
+	}
+	return strings.Join(mentions, " ") + " " + note
+}
+
+// FormatFollowupReminder renders the DM reminding the author of an
+// unanswered thread.
+func FormatFollowupReminder(permalink, channelLink, note string) string {
+	return fmt.Sprintf(":alarm_clock: No reply yet in your [thread](%s) %s.\n\n%s", permalink, channelLink, note)
+}
+
+// FormatFollowupNote renders the list note for a follow-up.
+func FormatFollowupNote(mode string) string {
+	if mode == constants.FollowupBump {
+		return "_Follow-up: bumps the thread unless it gets a reply_"
+	}
+	return "_Follow-up: DM reminder unless the thread gets a reply_"
+}
+
+// FormatFollowupAnswered renders the DM sent when a follow-up is cancelled
+// because its thread got a reply.
+func FormatFollowupAnswered(channelLink string) string {
+	return fmt.Sprintf("%s Your thread %s got a reply, so its follow-up was cancelled.", constants.EmojiSuccess, channelLink)
+}
+
+// FormatFollowupError renders a follow-up error message.
+func FormatFollowupError(err error) string {
+	return fmt.Sprintf("%s Error setting follow-up: %v", constants.EmojiError, err)
+}
+
+// FormatWarning renders the heads-up DM sent ahead of a scheduled message.
+// postAt should be in the author's time zone.
+func FormatWarning(postAt time.Time, channelLinks string) string {
+	return fmt.Sprintf(":bell: Heads-up: your scheduled message %s posts at **%s**. Send it now, snooze it, or cancel it.", channelLinks, postAt.Format(constants.TimeLayout))
+}
+
+// FormatWarningSent renders a heads-up after its message was sent early.
+func FormatWarningSent(channelLinks string) string {
+	return fmt.Sprintf("%s Sent your scheduled message %s.", constants.EmojiSuccess, channelLinks)
+}
+
+// FormatWarningSnoozed renders a heads-up after its message was snoozed.
+// postAt should be in the author's time zone.
+func FormatWarningSnoozed(postAt time.Time, channelLinks string) string {
+	return fmt.Sprintf("%s Snoozed your scheduled message %s until **%s**. You will get another heads-up before it posts.", constants.EmojiSuccess, channelLinks, postAt.Format(constants.TimeLayout))
+}
+
+// FormatWarningCancelled renders a heads-up after its message was cancelled.
+func FormatWarningCancelled(channelLinks string) string {
+	return fmt.Sprintf("%s Cancelled your scheduled message %s.", constants.EmojiSuccess, channelLinks)
+}
+
+// FormatPreview shortens content to at most maxRunes runes, marking the cut
+// with an ellipsis.
+func FormatPreview(content string, maxRunes int) string {
+	runes := []rune(content)
+	if len(runes) <= maxRunes {
+		return content
+	}
+	return string(runes[:maxRunes]) + "…"
+}
+
+// FormatAttributionFooter renders the footer of a message the bot posts on
+// behalf of its author.
+func FormatAttributionFooter(author string) string {
+	return fmt.Sprintf("_Scheduled by %s_", author)
+}
+
+// FormatForward renders the content of a forwarded post: the author's
+// comment, if any, then the original message quoted under its author, if
+// known.
+func FormatForward(comment, author, original string) string {
+	var lines []string
+	if author != "" {
+		lines = append(lines, fmt.Sprintf("> **%s**", author))
+	}
+	if original != "" {
+		for _, line := range strings.Split(original, "\n") {
+			lines = append(lines, "> "+line)
+		}
+	}
+	quote := strings.Join(lines, "\n")
+	if comment == "" {
+		return quote
+	}
+	return comment + "\n\n" + quote
+}
+
+// FormatForwardedFrom renders the link to the original of a forwarded post.
+func FormatForwardedFrom(permalink string) string {
+	return fmt.Sprintf("_Forwarded from [this post](%s)_", permalink)
+}
+
+// FormatScheduledIndicator renders the note marking a post as scheduled.
+func FormatScheduledIndicator() string {
+	return "_Scheduled message_"
+}
+
+// FormatAttributionNote renders the list note for a message posted as the
+// bot.
+func FormatAttributionNote() string {
+	return "_Posts as the bot on your behalf_"
+}
+
+// FormatDestinationList joins the channel links of a cross-posted message.
+func FormatDestinationList(channelLinks []string) string {
+	return strings.Join(channelLinks, ", ")
+}
+
+// FormatTemplateSaved renders a confirmation for a saved template.
+func FormatTemplateSaved(name string) string {
+	return fmt.Sprintf("%s Saved template `%s`. Use it with `/%s %s <time> %s %s`.", constants.EmojiSuccess, name, constants.CommandTrigger, constants.SubcommandAt, constants.SubcommandTemplate, name)
+}
+
+// FormatTemplateDeleted renders a confirmation for a deleted template.
+func FormatTemplateDeleted(name string) string {
+	return fmt.Sprintf("%s Deleted template `%s`.", constants.EmojiSuccess, name)
+}
+
+// FormatTemplateError renders a template management error message.
+func FormatTemplateError(err error) string {
+	return fmt.Sprintf("%s Error managing templates: %v", constants.EmojiError, err)
+}
+
+// FormatTemplateList renders a user's saved templates sorted by name.
+func FormatTemplateList(templates map[string]string) string {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sections := []string{constants.TemplateListHeader}
+	for _, name := range names {
+		sections = append(sections, fmt.Sprintf("##### %s\n%s", name, templates[name]))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// FormatExportPost renders the DM text that accompanies an export file.
+func FormatExportPost(count int) string {
+	return fmt.Sprintf("Here is the export of your %d scheduled messages.", count)
+}
+
+// FormatExportSuccess renders a confirmation for a completed export.
+func FormatExportSuccess(count int, fileName string) string {
+	return fmt.Sprintf("%s Exported %d scheduled messages to `%s`. The file was sent to you as a direct message.", constants.EmojiSuccess, count, fileName)
+}
+
+// FormatExportError renders an export error message.
+func FormatExportError(err error) string {
+	return fmt.Sprintf("%s Error exporting scheduled messages: %v", constants.EmojiError, err)
+}
+
+// FormatCalendarFeed renders the calendar feed subscription instructions.
+func FormatCalendarFeed(url string) string {
+	return fmt.Sprintf("%s Subscribe to this link in your calendar app to see your scheduled messages:\n%s\n\nAnyone with the link can see your schedule. Use `/%s %s %s` to replace it or `/%s %s %s` to turn it off.",
+		constants.EmojiSuccess, url,
+		constants.CommandTrigger, constants.SubcommandCalendar, constants.CalendarActionReset,
+		constants.CommandTrigger, constants.SubcommandCalendar, constants.CalendarActionRevoke)
+}
+
+// FormatCalendarRevoked renders a confirmation for a revoked calendar feed.
+func FormatCalendarRevoked() string {
+	return fmt.Sprintf("%s Your calendar feed link has been revoked.", constants.EmojiSuccess)
+}
+
+// FormatCalendarError renders a calendar feed error message.
+func FormatCalendarError(err error) string {
+	return fmt.Sprintf("%s Error managing calendar feed: %v", constants.EmojiError, err)
+}
+
+// FormatReceiptsStatus renders whether the user gets delivery receipts.
+func FormatReceiptsStatus(enabled bool) string {
+	if enabled {
+		return fmt.Sprintf("%s Delivery receipts are on. You will get a DM when your scheduled messages are posted. Use `/%s %s %s` to turn them off, or add `%s receipt` to a schedule command to skip one.",
+			constants.EmojiSuccess, constants.CommandTrigger, constants.SubcommandReceipts, constants.ReceiptsActionOff, constants.ReceiptKeywordWithout)
+	}
+	return fmt.Sprintf("%s Delivery receipts are off. Use `/%s %s %s` to get a DM when your scheduled messages are posted, or add `%s receipt` to a schedule command to get one for that message.",
+		constants.EmojiSuccess, constants.CommandTrigger, constants.SubcommandReceipts, constants.ReceiptsActionOn, constants.ReceiptKeywordWith)
+}
+
+// FormatReceiptsError renders a delivery receipt preference error message.
+func FormatReceiptsError(err error) string {
+	return fmt.Sprintf("%s Error managing delivery receipts: %v", constants.EmojiError, err)
+}
+
+// FormatReceiptEntry renders one posted message in a delivery receipt,
+// with how long after its scheduled time it was posted.
+func FormatReceiptEntry(permalink, channelLink string, delay time.Duration) string {
+	if delay < time.Minute {
+		return fmt.Sprintf("- [Posted](%s) %s, on time", permalink, channelLink)
+	}
+	return fmt.Sprintf("- [Posted](%s) %s, %s late", permalink, channelLink, FormatLeadTime(delay))
+}
+
+// FormatDeliveryReceipt renders the DM listing scheduled messages that were
+// just posted.
+func FormatDeliveryReceipt(entries []string) string {
+	header := "Your scheduled message was posted:"
+	if len(entries) > 1 {
+		header = "Your scheduled messages were posted:"
+	}
+	return fmt.Sprintf("%s %s\n%s", constants.EmojiSuccess, header, strings.Join(entries, "\n"))
+}
+
+// FormatCalendarEventSummary renders the title of a calendar event.
+func FormatCalendarEventSummary(channelLinks string) string {
+	return fmt.Sprintf("Scheduled message %s", channelLinks)
+}
+
+// FormatCalendarEventDescription renders the body of a calendar event.
+func FormatCalendarEventDescription(channelLinks, preview string) string {
+	return fmt.Sprintf("%s\n\n%s", channelLinks, preview)
+}
+
+// FormatCalendarReminder renders the reminder posted ahead of an imported
+// calendar event.
+func FormatCalendarReminder(summary string, lead time.Duration) string {
+	if strings.TrimSpace(summary) == "" {
+		summary = "Untitled event"
+	}
+	if lead <= 0 {
+		return fmt.Sprintf(":calendar: **%s** is starting now.", summary)
+	}
+	return fmt.Sprintf(":calendar: **%s** starts in %s.", summary, FormatLeadTime(lead))
+}
+
+// FormatLeadTime renders a reminder lead time, e.g. "1 hour 30 minutes".
+func FormatLeadTime(d time.Duration) string {
+	units := []struct {
+		size time.Duration
+		name string
+	}{
+		{24 * time.Hour, "day"},
+		{time.Hour, "hour"},
+		{time.Minute, "minute"},
+	}
+	var parts []string
+	for _, u := range units {
+		n := int(d / u.size)
+		d -= time.Duration(n) * u.size
+		switch {
+		case n == 1:
+			parts = append(parts, "1 "+u.name)
+		case n > 1:
+			parts = append(parts, fmt.Sprintf("%d %ss", n, u.name))
+		}
+	}
+	if len(parts) == 0 {
+		return "0 minutes"
+	}
+	return strings.Join(parts, " ")
+}
+
+// FormatCalendarImportReport summarizes the reminders scheduled from a
+// calendar file, listing the events that were skipped.
+func FormatCalendarImportReport(fileName, channelLink string, report *types.ImportReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s Scheduled %d reminders from `%s` %s.", constants.EmojiSuccess, report.Imported, fileName, channelLink)
+	if report.Rejected > 0 {
+		b.WriteString("\n\nSkipped:")
+		for _, row := range report.Rows {
+			if row.Error != "" {
+				fmt.Fprintf(&b, "\n* Event %d: %s", row.Row, row.Error)
+			}
+		}
+	}
+	return b.String()
+}
+
+// FormatCalendarImportError renders an error importing a calendar file.
+func FormatCalendarImportError(fileName string, err error) string {
+	return fmt.Sprintf("%s Error importing `%s`: %v", constants.EmojiError, fileName, err)
+}
+
+// FormatAdminStatus renders the plugin health report for system admins.
+func FormatAdminStatus(status *types.AdminStatus) string {
+	var b strings.Builder
+	b.WriteString(constants.AdminStatusHeader)
+	b.WriteString("\n\n| | |\n|:--|:--|")
+	rows := [][2]string{
+		{"Bot ID", "`" + status.BotID + "`"},
+		{"Last scheduler tick", formatAdminTime(status.LastTick, "never")},
+		{"Next scheduler tick", formatAdminTime(status.NextTick, "not running")},
+		{"Scheduled messages", strconv.Itoa(status.ScheduledMessages)},
+		{"Due but unsent", strconv.Itoa(status.DueMessages)},
+		{"Unreadable messages", formatAdminUnreadable(status.UnreadableMessages)},
+		{"Orphaned index entries", strconv.Itoa(status.OrphanedIndexEntries)},
+		{"Messages missing from index", strconv.Itoa(status.MissingIndexEntries)},
+		{"Max messages per user", strconv.Itoa(status.Settings.MaxUserMessages)},
+		{"Max message size", fmt.Sprintf("%d bytes", status.Settings.MaxMessageBytes)},
+		{"Max import file size", fmt.Sprintf("%d bytes", status.Settings.MaxImportBytes)},
+		{"Max messages per scheduler scan", strconv.Itoa(status.Settings.MaxFetchScheduledMessages)},
+		{"Encryption at rest", formatAdminEncryption(status.Settings)},
+		{"Late messages", fmt.Sprintf("%s when over %d minutes late", status.Settings.LatePolicy, status.Settings.LateThresholdMinutes)},
+		{"Max posts per minute to one channel", formatAdminRateLimit(status.Settings.MaxChannelPostsPerMinute)},
+		{"Max posts per minute overall", formatAdminRateLimit(status.Settings.MaxPostsPerMinute)},
+		{"Posted as by default", status.Settings.DefaultAttribution},
+		{"Scheduled message indicator", formatAdminOnOff(status.Settings.ShowScheduledIndicator)},
+	}
+	for _, row := range rows {
+		fmt.Fprintf(&b, "\n| %s | %s |", row[0], row[1])
+	}
+	return b.String()
+}
+
+// FormatConsistencyReport summarizes a consistency repair run.
+func FormatConsistencyReport(report *types.ConsistencyReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s Checked %d scheduled messages and %d user indexes.", constants.EmojiSuccess, report.Messages, report.Indexes)
+	if len(report.Repaired) == 0 && len(report.Failed) == 0 {
+		b.WriteString(" No problems found.")
+		return b.String()
+	}
+	if len(report.Repaired) > 0 {
+		fmt.Fprintf(&b, "\n\nRepaired %d problems:", len(report.Repaired))
+		for _, issue := range report.Repaired {
+			fmt.Fprintf(&b, "\n* %s", formatConsistencyIssue(issue))
+		}
+	}
+	if len(report.Failed) > 0 {
+		fmt.Fprintf(&b, "\n\n%s Could not repair %d problems:", constants.EmojiError, len(report.Failed))
+		for _, issue := range report.Failed {
+			fmt.Fprintf(&b, "\n* %s: %s", formatConsistencyIssue(issue), issue.Error)
+		}
+	}
+	return b.String()
+}
+
+func formatConsistencyIssue(issue types.ConsistencyIssue) string {
+	if issue.Kind == constants.ConsistencyIssueOrphanedIndexEntry {
+		return fmt.Sprintf("Index of user `%s` listed missing message `%s`", issue.UserID, issue.MessageID)
+	}
+	return fmt.Sprintf("Message `%s` was missing from the index of user `%s`", issue.MessageID, issue.UserID)
+}
+
+// FormatAdminError renders an admin subcommand error message.
+func FormatAdminError(err error) string {
+	return fmt.Sprintf("%s Error running admin command: %v", constants.EmojiError, err)
+}
+
+func formatAdminEncryption(settings types.AdminSettings) string {
+	if !settings.EncryptionEnabled {
+		return "off"
+	}
+	return fmt.Sprintf("on, key `%s`", settings.EncryptionKeyID)
+}
+
+func formatAdminOnOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+func formatAdminUnreadable(count int) string {
+	if count == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%s %d, see the server logs", constants.EmojiError, count)
+}
+
+func formatAdminRateLimit(perMinute int) string {
+	if perMinute == 0 {
+		return "no limit"
+	}
+	return strconv.Itoa(perMinute)
+}
+
+func formatAdminTime(t time.Time, zero string) string {
+	if t.IsZero() {
+		return zero
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
 func formatDestination(channelLink string, inThread bool) string {
 	if inThread {
 		return channelLink + " (thread)"
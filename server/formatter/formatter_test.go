@@ -3,10 +3,12 @@ package formatter
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
 )
 
 func TestFormatScheduleSuccess(t *testing.T) {
@@ -103,3 +105,403 @@ func TestFormatListAttachmentHeader(t *testing.T) {
 		}
 	})
 }
+
+func TestFormatDeferredNote(t *testing.T) {
+	until := time.Date(2025, time.January, 2, 15, 6, 30, 0, time.UTC)
+
+	if got := FormatDeferredNote(until); got != "_Held back by a rate limit; posting at Jan 2, 2025 3:06 PM_" {
+		t.Fatalf("FormatDeferredNote() = %q", got)
+	}
+}
+
+func TestFormatWarning(t *testing.T) {
+	postAt := time.Date(2025, time.January, 2, 15, 0, 0, 0, time.UTC)
+
+	if got := FormatWarningNote(90 * time.Minute); got != "_Heads-up 1 hour 30 minutes before posting_" {
+		t.Fatalf("FormatWarningNote() = %q", got)
+	}
+	if got := FormatWarning(postAt, "in channel: ~town-square"); got != ":bell: Heads-up: your scheduled message in channel: ~town-square posts at **Jan 2, 2025 3:00 PM**. Send it now, snooze it, or cancel it." {
+		t.Fatalf("FormatWarning() = %q", got)
+	}
+	if got := FormatWarningSnoozed(postAt, "in channel: ~town-square"); !strings.Contains(got, "until **Jan 2, 2025 3:00 PM**") {
+		t.Fatalf("FormatWarningSnoozed() = %q", got)
+	}
+}
+
+func TestFormatFollowup(t *testing.T) {
+	postAt := time.Date(2025, time.January, 2, 15, 0, 0, 0, time.UTC)
+
+	if got := FormatFollowupSet(postAt, "UTC", constants.FollowupBump, []string{"@bob", "@carol"}); got != constants.EmojiSuccess+" Follow-up set for Jan 2, 2025 3:00 PM (UTC). If @bob or @carol hasn't replied in this thread by then, the thread will be bumped." {
+		t.Fatalf("FormatFollowupSet() = %q", got)
+	}
+	if got := FormatFollowupBump([]string{"@bob"}, ""); got != "@bob "+constants.FollowupDefaultBumpNote {
+		t.Fatalf("FormatFollowupBump() = %q", got)
+	}
+	if got := FormatFollowupBump(nil, "ping"); got != "ping" {
+		t.Fatalf("FormatFollowupBump() = %q", got)
+	}
+}
+
+func TestFormatPreview(t *testing.T) {
+	if got := FormatPreview("héllo world", 5); got != "héllo…" {
+		t.Fatalf("FormatPreview() = %q", got)
+	}
+	if got := FormatPreview("short", 5); got != "short" {
+		t.Fatalf("FormatPreview() = %q", got)
+	}
+}
+
+func TestFormatForward(t *testing.T) {
+	if got := FormatForward("Reminder:", "@alice", "Launch at 9\nBring snacks"); got != "Reminder:\n\n> **@alice**\n> Launch at 9\n> Bring snacks" {
+		t.Fatalf("FormatForward() = %q", got)
+	}
+	if got := FormatForward("", "", "Launch at 9"); got != "> Launch at 9" {
+		t.Fatalf("FormatForward() = %q", got)
+	}
+	if got := FormatForwardedFrom("https://mm.example.com/_redirect/pl/abc"); got != "_Forwarded from [this post](https://mm.example.com/_redirect/pl/abc)_" {
+		t.Fatalf("FormatForwardedFrom() = %q", got)
+	}
+}
+
+func TestFormatAttributionFooter(t *testing.T) {
+	if got := FormatAttributionFooter("@alice"); got != "_Scheduled by @alice_" {
+		t.Fatalf("FormatAttributionFooter() = %q", got)
+	}
+}
+
+func TestFormatScheduledIndicator(t *testing.T) {
+	if got := FormatScheduledIndicator(); got != "_Scheduled message_" {
+		t.Fatalf("FormatScheduledIndicator() = %q", got)
+	}
+}
+
+func TestFormatDestinationList(t *testing.T) {
+	links := []string{"in channel: ~town-square", "in channel: ~off-topic"}
+	expected := "in channel: ~town-square, in channel: ~off-topic"
+
+	got := FormatDestinationList(links)
+	if got != expected {
+		t.Fatalf("FormatDestinationList() = %q, want %q", got, expected)
+	}
+}
+
+func TestFormatSchedulerPartialFailure(t *testing.T) {
+	failures := []string{"in channel: ~a (boom)", "in channel: ~b (bang)"}
+	orig := "hello world"
+
+	expected := fmt.Sprintf("%s Scheduled message was posted to 1 of 3 destinations. Failed: in channel: ~a (boom), in channel: ~b (bang) -- original message: %s", constants.EmojiError, orig)
+
+	got := FormatSchedulerPartialFailure(1, 3, failures, orig)
+	if got != expected {
+		t.Fatalf("FormatSchedulerPartialFailure() = %q, want %q", got, expected)
+	}
+}
+
+func TestFormatTemplateSaved(t *testing.T) {
+	expected := fmt.Sprintf("%s Saved template `release`. Use it with `/schedule at <time> template release`.", constants.EmojiSuccess)
+
+	got := FormatTemplateSaved("release")
+	if got != expected {
+		t.Fatalf("FormatTemplateSaved() = %q, want %q", got, expected)
+	}
+}
+
+func TestFormatTemplateDeleted(t *testing.T) {
+	expected := fmt.Sprintf("%s Deleted template `release`.", constants.EmojiSuccess)
+
+	got := FormatTemplateDeleted("release")
+	if got != expected {
+		t.Fatalf("FormatTemplateDeleted() = %q, want %q", got, expected)
+	}
+}
+
+func TestFormatTemplateError(t *testing.T) {
+	expected := fmt.Sprintf("%s Error managing templates: boom", constants.EmojiError)
+
+	got := FormatTemplateError(errors.New("boom"))
+	if got != expected {
+		t.Fatalf("FormatTemplateError() = %q, want %q", got, expected)
+	}
+}
+
+func TestFormatTemplateList(t *testing.T) {
+	templates := map[string]string{"release": "checklist", "handoff": "on-call notes"}
+	expected := constants.TemplateListHeader + "\n\n##### handoff\non-call notes\n\n##### release\nchecklist"
+
+	got := FormatTemplateList(templates)
+	if got != expected {
+		t.Fatalf("FormatTemplateList() = %q, want %q", got, expected)
+	}
+}
+
+func TestFormatExportPost(t *testing.T) {
+	expected := "Here is the export of your 3 scheduled messages."
+
+	got := FormatExportPost(3)
+	if got != expected {
+		t.Fatalf("FormatExportPost() = %q, want %q", got, expected)
+	}
+}
+
+func TestFormatExportSuccess(t *testing.T) {
+	expected := fmt.Sprintf("%s Exported 3 scheduled messages to `export.csv`. The file was sent to you as a direct message.", constants.EmojiSuccess)
+
+	got := FormatExportSuccess(3, "export.csv")
+	if got != expected {
+		t.Fatalf("FormatExportSuccess() = %q, want %q", got, expected)
+	}
+}
+
+func TestFormatExportError(t *testing.T) {
+	expected := fmt.Sprintf("%s Error exporting scheduled messages: boom", constants.EmojiError)
+
+	got := FormatExportError(errors.New("boom"))
+	if got != expected {
+		t.Fatalf("FormatExportError() = %q, want %q", got, expected)
+	}
+}
+
+func TestFormatCalendarFeed(t *testing.T) {
+	expected := fmt.Sprintf("%s Subscribe to this link in your calendar app to see your scheduled messages:\nhttps://mm.example.com/feed.ics\n\nAnyone with the link can see your schedule. Use `/schedule calendar reset` to replace it or `/schedule calendar revoke` to turn it off.", constants.EmojiSuccess)
+
+	got := FormatCalendarFeed("https://mm.example.com/feed.ics")
+	if got != expected {
+		t.Fatalf("FormatCalendarFeed() = %q, want %q", got, expected)
+	}
+}
+
+func TestFormatCalendarRevoked(t *testing.T) {
+	expected := fmt.Sprintf("%s Your calendar feed link has been revoked.", constants.EmojiSuccess)
+
+	got := FormatCalendarRevoked()
+	if got != expected {
+		t.Fatalf("FormatCalendarRevoked() = %q, want %q", got, expected)
+	}
+}
+
+func TestFormatCalendarError(t *testing.T) {
+	expected := fmt.Sprintf("%s Error managing calendar feed: boom", constants.EmojiError)
+
+	got := FormatCalendarError(errors.New("boom"))
+	if got != expected {
+		t.Fatalf("FormatCalendarError() = %q, want %q", got, expected)
+	}
+}
+
+func TestFormatReceiptsStatus(t *testing.T) {
+	on := FormatReceiptsStatus(true)
+	if !strings.Contains(on, "Delivery receipts are on") || !strings.Contains(on, "`/schedule receipts off`") {
+		t.Fatalf("FormatReceiptsStatus(true) = %q", on)
+	}
+	off := FormatReceiptsStatus(false)
+	if !strings.Contains(off, "Delivery receipts are off") || !strings.Contains(off, "`/schedule receipts on`") {
+		t.Fatalf("FormatReceiptsStatus(false) = %q", off)
+	}
+}
+
+func TestFormatReceiptEntry(t *testing.T) {
+	tests := []struct {
+		name  string
+		delay time.Duration
+		want  string
+	}{
+		{"on time", 40 * time.Second, "- [Posted](https://mm.example.com/_redirect/pl/p1) in channel: ~town-square, on time"},
+		{"late", 90 * time.Minute, "- [Posted](https://mm.example.com/_redirect/pl/p1) in channel: ~town-square, 1 hour 30 minutes late"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := FormatReceiptEntry("https://mm.example.com/_redirect/pl/p1", "in channel: ~town-square", tc.delay)
+			if got != tc.want {
+				t.Fatalf("FormatReceiptEntry() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFormatDeliveryReceipt(t *testing.T) {
+	single := FormatDeliveryReceipt([]string{"- a"})
+	if want := fmt.Sprintf("%s Your scheduled message was posted:\n- a", constants.EmojiSuccess); single != want {
+		t.Fatalf("FormatDeliveryReceipt() = %q, want %q", single, want)
+	}
+	batch := FormatDeliveryReceipt([]string{"- a", "- b"})
+	if want := fmt.Sprintf("%s Your scheduled messages were posted:\n- a\n- b", constants.EmojiSuccess); batch != want {
+		t.Fatalf("FormatDeliveryReceipt() = %q, want %q", batch, want)
+	}
+}
+
+func TestFormatCalendarEventSummary(t *testing.T) {
+	expected := "Scheduled message ~town-square"
+
+	got := FormatCalendarEventSummary("~town-square")
+	if got != expected {
+		t.Fatalf("FormatCalendarEventSummary() = %q, want %q", got, expected)
+	}
+}
+
+func TestFormatCalendarEventDescription(t *testing.T) {
+	expected := "~town-square\n\nhello"
+
+	got := FormatCalendarEventDescription("~town-square", "hello")
+	if got != expected {
+		t.Fatalf("FormatCalendarEventDescription() = %q, want %q", got, expected)
+	}
+}
+
+func TestFormatCalendarReminder(t *testing.T) {
+	tests := []struct {
+		summary  string
+		lead     time.Duration
+		expected string
+	}{
+		{"Release 2.1", 15 * time.Minute, ":calendar: **Release 2.1** starts in 15 minutes."},
+		{"Standup", 0, ":calendar: **Standup** is starting now."},
+		{" ", time.Hour, ":calendar: **Untitled event** starts in 1 hour."},
+	}
+	for _, tt := range tests {
+		got := FormatCalendarReminder(tt.summary, tt.lead)
+		if got != tt.expected {
+			t.Fatalf("FormatCalendarReminder(%q, %v) = %q, want %q", tt.summary, tt.lead, got, tt.expected)
+		}
+	}
+}
+
+func TestFormatLeadTime(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                            "0 minutes",
+		time.Minute:                  "1 minute",
+		90 * time.Minute:             "1 hour 30 minutes",
+		2 * time.Hour:                "2 hours",
+		7 * 24 * time.Hour:           "7 days",
+		25*time.Hour + 5*time.Minute: "1 day 1 hour 5 minutes",
+		30 * time.Second:             "0 minutes",
+	}
+	for d, expected := range tests {
+		if got := FormatLeadTime(d); got != expected {
+			t.Fatalf("FormatLeadTime(%v) = %q, want %q", d, got, expected)
+		}
+	}
+}
+
+func TestFormatCalendarImportReport(t *testing.T) {
+	report := &types.ImportReport{
+		Imported: 2,
+		Rejected: 2,
+		Rows: []types.ImportRowResult{
+			{Row: 1, MessageID: "a"},
+			{Row: 2, Error: "event has no DTSTART"},
+			{Row: 3, MessageID: "b"},
+			{Row: 4, Error: "no occurrences in the next 90 days"},
+		},
+	}
+	expected := fmt.Sprintf("%s Scheduled 2 reminders from `release.ics` in channel: ~releases.\n\nSkipped:\n* Event 2: event has no DTSTART\n* Event 4: no occurrences in the next 90 days", constants.EmojiSuccess)
+
+	got := FormatCalendarImportReport("release.ics", "in channel: ~releases", report)
+	if got != expected {
+		t.Fatalf("FormatCalendarImportReport() = %q, want %q", got, expected)
+	}
+}
+
+func TestFormatCalendarImportError(t *testing.T) {
+	expected := fmt.Sprintf("%s Error importing `release.ics`: boom", constants.EmojiError)
+
+	got := FormatCalendarImportError("release.ics", errors.New("boom"))
+	if got != expected {
+		t.Fatalf("FormatCalendarImportError() = %q, want %q", got, expected)
+	}
+}
+
+func TestFormatAdminStatus(t *testing.T) {
+	status := &types.AdminStatus{
+		BotID:                "bot1",
+		LastTick:             time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		ScheduledMessages:    4,
+		DueMessages:          1,
+		UnreadableMessages:   3,
+		OrphanedIndexEntries: 2,
+		Settings: types.AdminSettings{
+			MaxUserMessages:           1000,
+			MaxMessageBytes:           51200,
+			MaxImportBytes:            5242880,
+			MaxFetchScheduledMessages: 10000,
+			EncryptionEnabled:         true,
+			EncryptionKeyID:           "0123abcd",
+			LatePolicy:                constants.LatePolicyAnnotate,
+			LateThresholdMinutes:      15,
+			MaxChannelPostsPerMinute:  5,
+			DefaultAttribution:        constants.AttributionUser,
+			ShowScheduledIndicator:    true,
+		},
+	}
+	expected := constants.AdminStatusHeader + "\n\n| | |\n|:--|:--|" +
+		"\n| Bot ID | `bot1` |" +
+		"\n| Last scheduler tick | 2024-01-15T10:00:00Z |" +
+		"\n| Next scheduler tick | not running |" +
+		"\n| Scheduled messages | 4 |" +
+		"\n| Due but unsent | 1 |" +
+		"\n| Unreadable messages | " + constants.EmojiError + " 3, see the server logs |" +
+		"\n| Orphaned index entries | 2 |" +
+		"\n| Messages missing from index | 0 |" +
+		"\n| Max messages per user | 1000 |" +
+		"\n| Max message size | 51200 bytes |" +
+		"\n| Max import file size | 5242880 bytes |" +
+		"\n| Max messages per scheduler scan | 10000 |" +
+		"\n| Encryption at rest | on, key `0123abcd` |" +
+		"\n| Late messages | annotate when over 15 minutes late |" +
+		"\n| Max posts per minute to one channel | 5 |" +
+		"\n| Max posts per minute overall | no limit |" +
+		"\n| Posted as by default | user |" +
+		"\n| Scheduled message indicator | on |"
+
+	got := FormatAdminStatus(status)
+	if got != expected {
+		t.Fatalf("FormatAdminStatus() = %q, want %q", got, expected)
+	}
+}
+
+func TestFormatConsistencyReport(t *testing.T) {
+	t.Run("no problems", func(t *testing.T) {
+		report := &types.ConsistencyReport{Messages: 3, Indexes: 2}
+		expected := fmt.Sprintf("%s Checked 3 scheduled messages and 2 user indexes. No problems found.", constants.EmojiSuccess)
+
+		got := FormatConsistencyReport(report)
+		if got != expected {
+			t.Fatalf("FormatConsistencyReport() = %q, want %q", got, expected)
+		}
+	})
+	t.Run("repaired and failed", func(t *testing.T) {
+		report := &types.ConsistencyReport{
+			Messages: 3,
+			Indexes:  2,
+			Repaired: []types.ConsistencyIssue{{Kind: constants.ConsistencyIssueOrphanedIndexEntry, UserID: "u1", MessageID: "gone"}},
+			Failed:   []types.ConsistencyIssue{{Kind: constants.ConsistencyIssueMissingIndexEntry, UserID: "u2", MessageID: "b", Error: "kv down"}},
+		}
+		expected := fmt.Sprintf("%s Checked 3 scheduled messages and 2 user indexes.\n\nRepaired 1 problems:\n* Index of user `u1` listed missing message `gone`\n\n%s Could not repair 1 problems:\n* Message `b` was missing from the index of user `u2`: kv down", constants.EmojiSuccess, constants.EmojiError)
+
+		got := FormatConsistencyReport(report)
+		if got != expected {
+			t.Fatalf("FormatConsistencyReport() = %q, want %q", got, expected)
+		}
+	})
+}
+
+func TestFormatDelayedNote(t *testing.T) {
+	postAt := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+
+	if got := FormatDelayedNote(postAt, postAt.Add(3*time.Hour)); got != "_(delayed from 9:00 AM)_" {
+		t.Fatalf("same day: got %q", got)
+	}
+	if got := FormatDelayedNote(postAt, postAt.Add(24*time.Hour)); got != "_(delayed from Jan 15, 2024 9:00 AM)_" {
+		t.Fatalf("earlier day: got %q", got)
+	}
+}
+
+func TestFormatSkippedLateMessage(t *testing.T) {
+	postAt := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+
+	got := FormatSkippedLateMessage("in channel: ~town-square", postAt, 2*time.Hour+5*time.Minute, "hello")
+
+	want := constants.EmojiError + " Scheduled message for in channel: ~town-square was not posted because it was 2 hours 5 minutes late (due Jan 15, 2024 9:00 AM) -- original message: hello"
+	if got != want {
+		t.Fatalf("FormatSkippedLateMessage() = %q, want %q", got, want)
+	}
+}
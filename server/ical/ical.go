@@ -0,0 +1,100 @@
+// Package ical renders and parses iCalendar (RFC 5545) documents.
+package ical
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// dateTimeLayoutUTC is the RFC 5545 UTC DATE-TIME form.
+	dateTimeLayoutUTC = "20060102T150405Z"
+	// maxLineOctets is the folding limit for content lines, excluding CRLF.
+	maxLineOctets = 75
+	crlf          = "\r\n"
+)
+
+// Event is a single VEVENT.
+type Event struct {
+	UID         string
+	Start       time.Time
+	Summary     string
+	Description string
+	// Rule repeats the event from Start, in UTC.
+	Rule *Rule
+	// Dates are further starts of the event, for series no rule describes.
+	Dates []time.Time
+}
+
+// Calendar is a VCALENDAR containing events.
+type Calendar struct {
+	ProdID string
+	Name   string
+	Events []Event
+}
+
+// Encode renders the calendar, stamping each event with now.
+func (c *Calendar) Encode(now time.Time) string {
+	var b strings.Builder
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:"+c.ProdID)
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "METHOD:PUBLISH")
+	if c.Name != "" {
+		writeLine(&b, "X-WR-CALNAME:"+EscapeText(c.Name))
+	}
+	stamp := now.UTC().Format(dateTimeLayoutUTC)
+	for _, e := range c.Events {
+		writeLine(&b, "BEGIN:VEVENT")
+		writeLine(&b, "UID:"+e.UID)
+		writeLine(&b, "DTSTAMP:"+stamp)
+		writeLine(&b, "DTSTART:"+e.Start.UTC().Format(dateTimeLayoutUTC))
+		if e.Rule != nil {
+			writeLine(&b, "RRULE:"+e.Rule.String())
+		}
+		if len(e.Dates) > 0 {
+			dates := make([]string, 0, len(e.Dates))
+			for _, d := range e.Dates {
+				dates = append(dates, d.UTC().Format(dateTimeLayoutUTC))
+			}
+			writeLine(&b, "RDATE:"+strings.Join(dates, ","))
+		}
+		writeLine(&b, "SUMMARY:"+EscapeText(e.Summary))
+		if e.Description != "" {
+			writeLine(&b, "DESCRIPTION:"+EscapeText(e.Description))
+		}
+		writeLine(&b, "END:VEVENT")
+	}
+	writeLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+// EscapeText escapes a TEXT property value.
+func EscapeText(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+// writeLine writes a content line folded at 75 octets without splitting
+// multi-byte characters.
+func writeLine(b *strings.Builder, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString(crlf + " ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts toward the limit.
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString(crlf)
+}
+
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
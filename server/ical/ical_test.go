@@ -0,0 +1,90 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncode(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cal := &Calendar{
+		ProdID: "-//test//EN",
+		Name:   "My messages",
+		Events: []Event{
+			{UID: "id1@test", Start: time.Date(2024, 1, 16, 9, 0, 0, 0, ny), Summary: "Message in ~town-square", Description: "hello, world; line\nnext"},
+			{UID: "id2@test", Start: time.Date(2024, 1, 17, 9, 0, 0, 0, time.UTC), Summary: "Weekly", Rule: &Rule{Freq: FreqWeekly, Interval: 1, Count: 3}},
+			{UID: "id3@test", Start: time.Date(2024, 1, 18, 9, 0, 0, 0, time.UTC), Summary: "Irregular", Dates: []time.Time{
+				time.Date(2024, 1, 19, 9, 0, 0, 0, time.UTC),
+				time.Date(2024, 1, 22, 14, 0, 0, 0, ny),
+			}},
+		},
+	}
+
+	got := cal.Encode(now)
+
+	expected := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//test//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:My messages",
+		"BEGIN:VEVENT",
+		"UID:id1@test",
+		"DTSTAMP:20240115T100000Z",
+		"DTSTART:20240116T140000Z",
+		"SUMMARY:Message in ~town-square",
+		`DESCRIPTION:hello\, world\; line\nnext`,
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:id2@test",
+		"DTSTAMP:20240115T100000Z",
+		"DTSTART:20240117T090000Z",
+		"RRULE:FREQ=WEEKLY;COUNT=3",
+		"SUMMARY:Weekly",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:id3@test",
+		"DTSTAMP:20240115T100000Z",
+		"DTSTART:20240118T090000Z",
+		"RDATE:20240119T090000Z,20240122T190000Z",
+		"SUMMARY:Irregular",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	assert.Equal(t, expected, got)
+}
+
+func TestWriteLine_Folding(t *testing.T) {
+	var b strings.Builder
+	line := "DESCRIPTION:" + strings.Repeat("é", 100)
+
+	writeLine(&b, line)
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	assert.Greater(t, len(lines), 1)
+	var unfolded strings.Builder
+	for i, l := range lines {
+		assert.LessOrEqual(t, len(l), maxLineOctets)
+		assert.True(t, utf8.ValidString(l))
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(l, " "))
+			l = l[1:]
+		}
+		unfolded.WriteString(l)
+	}
+	assert.Equal(t, line, unfolded.String())
+}
+
+func TestEscapeText(t *testing.T) {
+	assert.Equal(t, `a\\b\;c\,d\ne\nf`, EscapeText("a\\b;c,d\r\ne\nf"))
+}
@@ -0,0 +1,242 @@
+package ical
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405"
+)
+
+// ParsedEvent is a VEVENT read from an iCalendar file. Events that cannot be
+// understood carry Err so callers can report them individually.
+type ParsedEvent struct {
+	Event
+	// Number is the 1-based position of the VEVENT in the file.
+	Number int
+	// Rule is the event's RRULE, nil for one-off events.
+	Rule *Rule
+	// Exclude lists EXDATE instances removed from the series.
+	Exclude []time.Time
+	// RecurrenceID is set when the event overrides one instance of a series
+	// with the same UID.
+	RecurrenceID time.Time
+	Err          error
+}
+
+type contentLine struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse reads the VEVENTs in an iCalendar file. Floating and all-day times
+// are interpreted in loc. An error is returned only when the file is not an
+// iCalendar document at all.
+func Parse(data []byte, loc *time.Location) ([]ParsedEvent, error) {
+	lines := unfold(string(data))
+	var events []ParsedEvent
+	var current *ParsedEvent
+	inCalendar := false
+	depth := 0
+	for _, raw := range lines {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		line, err := parseContentLine(raw)
+		if err != nil {
+			if current != nil && current.Err == nil {
+				current.Err = err
+			}
+			continue
+		}
+		switch {
+		case line.name == "BEGIN" && strings.EqualFold(line.value, "VCALENDAR"):
+			inCalendar = true
+		case !inCalendar:
+			return nil, errors.New("not an iCalendar file")
+		case line.name == "BEGIN" && strings.EqualFold(line.value, "VEVENT") && depth == 0:
+			events = append(events, ParsedEvent{Number: len(events) + 1})
+			current = &events[len(events)-1]
+		case line.name == "BEGIN":
+			// Nested components (VALARM) and VTIMEZONE definitions are
+			// skipped; TZID values are resolved against the IANA database.
+			depth++
+		case line.name == "END" && depth > 0:
+			depth--
+		case line.name == "END" && strings.EqualFold(line.value, "VEVENT"):
+			if current != nil {
+				current.finish()
+			}
+			current = nil
+		case current != nil && depth == 0 && current.Err == nil:
+			current.Err = current.apply(line, loc)
+		}
+	}
+	if !inCalendar {
+		return nil, errors.New("not an iCalendar file")
+	}
+	if current != nil && current.Err == nil {
+		current.Err = errors.New("event is missing END:VEVENT")
+	}
+	return events, nil
+}
+
+func (e *ParsedEvent) apply(line contentLine, loc *time.Location) error {
+	switch line.name {
+	case "UID":
+		e.UID = line.value
+	case "SUMMARY":
+		e.Summary = UnescapeText(line.value)
+	case "DESCRIPTION":
+		e.Description = UnescapeText(line.value)
+	case "DTSTART":
+		start, err := parseTime(line, loc)
+		if err != nil {
+			return fmt.Errorf("invalid DTSTART: %w", err)
+		}
+		e.Start = start
+	case "RRULE":
+		rule, err := ParseRule(line.value, loc)
+		if err != nil {
+			return fmt.Errorf("invalid RRULE: %w", err)
+		}
+		e.Rule = rule
+	case "EXDATE":
+		for _, v := range strings.Split(line.value, ",") {
+			t, err := parseTime(contentLine{name: line.name, params: line.params, value: v}, loc)
+			if err != nil {
+				return fmt.Errorf("invalid EXDATE: %w", err)
+			}
+			e.Exclude = append(e.Exclude, t)
+		}
+	case "RECURRENCE-ID":
+		t, err := parseTime(line, loc)
+		if err != nil {
+			return fmt.Errorf("invalid RECURRENCE-ID: %w", err)
+		}
+		e.RecurrenceID = t
+	case "RDATE":
+		return errors.New("RDATE is not supported")
+	}
+	return nil
+}
+
+func (e *ParsedEvent) finish() {
+	if e.Err == nil && e.Start.IsZero() {
+		e.Err = errors.New("event has no DTSTART")
+	}
+}
+
+// parseTime reads a DATE or DATE-TIME value, honoring TZID and the UTC
+// suffix. All-day dates start at midnight in loc.
+func parseTime(line contentLine, loc *time.Location) (time.Time, error) {
+	value := strings.TrimSpace(line.value)
+	if strings.EqualFold(line.params["VALUE"], "DATE") || len(value) == len(dateLayout) {
+		return time.ParseInLocation(dateLayout, value, loc)
+	}
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(dateTimeLayoutUTC, value)
+	}
+	if tzid, ok := line.params["TZID"]; ok {
+		tzLoc, err := time.LoadLocation(strings.TrimPrefix(tzid, "/"))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unknown time zone %q", tzid)
+		}
+		loc = tzLoc
+	}
+	return time.ParseInLocation(dateTimeLayout, value, loc)
+}
+
+// UnescapeText reverses EscapeText.
+func UnescapeText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// unfold splits the document into logical content lines.
+func unfold(doc string) []string {
+	doc = strings.ReplaceAll(doc, "\r\n", "\n")
+	var lines []string
+	for _, l := range strings.Split(doc, "\n") {
+		if (strings.HasPrefix(l, " ") || strings.HasPrefix(l, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += l[1:]
+			continue
+		}
+		lines = append(lines, strings.TrimSuffix(l, "\r"))
+	}
+	return lines
+}
+
+// parseContentLine splits NAME;PARAM=VALUE:value, allowing quoted parameter
+// values to contain ':' and ';'.
+func parseContentLine(raw string) (contentLine, error) {
+	line := contentLine{params: map[string]string{}}
+	inQuote := false
+	nameEnd := -1
+	colon := -1
+	for i := 0; i < len(raw) && colon < 0; i++ {
+		switch raw[i] {
+		case '"':
+			inQuote = !inQuote
+		case ';':
+			if !inQuote && nameEnd < 0 {
+				nameEnd = i
+			}
+		case ':':
+			if !inQuote {
+				colon = i
+			}
+		}
+	}
+	if colon < 0 {
+		return line, fmt.Errorf("malformed line %q", raw)
+	}
+	if nameEnd < 0 {
+		nameEnd = colon
+	}
+	line.name = strings.ToUpper(raw[:nameEnd])
+	line.value = raw[colon+1:]
+	if nameEnd < colon {
+		for _, p := range splitParams(raw[nameEnd+1 : colon]) {
+			k, v, _ := strings.Cut(p, "=")
+			line.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return line, nil
+}
+
+func splitParams(s string) []string {
+	var parts []string
+	inQuote := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			inQuote = !inQuote
+		case ';':
+			if !inQuote {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,138 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ics(lines ...string) []byte {
+	return []byte(strings.Join(append(append([]string{"BEGIN:VCALENDAR", "VERSION:2.0"}, lines...), "END:VCALENDAR"), "\r\n"))
+}
+
+func TestParse_TimeForms(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	data := ics(
+		"BEGIN:VEVENT", "UID:utc", "DTSTART:20240115T150000Z", "SUMMARY:UTC", "END:VEVENT",
+		"BEGIN:VEVENT", "UID:tzid", `DTSTART;TZID="America/New_York":20240115T090000`, "SUMMARY:Zoned", "END:VEVENT",
+		"BEGIN:VEVENT", "UID:floating", "DTSTART:20240115T090000", "SUMMARY:Floating", "END:VEVENT",
+		"BEGIN:VEVENT", "UID:allday", "DTSTART;VALUE=DATE:20240116", "SUMMARY:All day", "END:VEVENT",
+	)
+
+	events, err := Parse(data, berlin)
+
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+	for _, e := range events {
+		require.NoError(t, e.Err, e.UID)
+	}
+	assert.True(t, events[0].Start.Equal(time.Date(2024, 1, 15, 15, 0, 0, 0, time.UTC)))
+	assert.True(t, events[1].Start.Equal(time.Date(2024, 1, 15, 9, 0, 0, 0, ny)))
+	assert.True(t, events[2].Start.Equal(time.Date(2024, 1, 15, 9, 0, 0, 0, berlin)))
+	assert.True(t, events[3].Start.Equal(time.Date(2024, 1, 16, 0, 0, 0, 0, berlin)))
+	assert.Equal(t, []int{1, 2, 3, 4}, []int{events[0].Number, events[1].Number, events[2].Number, events[3].Number})
+}
+
+func TestParse_TextAndFolding(t *testing.T) {
+	data := ics(
+		"BEGIN:VEVENT",
+		"UID:1",
+		"DTSTART:20240115T150000Z",
+		"SUMMARY:Release\\, phase 1",
+		"DESCRIPTION:line one\\nline",
+		"  two",
+		"BEGIN:VALARM",
+		"DESCRIPTION:ignored",
+		"END:VALARM",
+		"END:VEVENT",
+	)
+
+	events, err := Parse(data, time.UTC)
+
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "Release, phase 1", events[0].Summary)
+	assert.Equal(t, "line one\nline two", events[0].Description)
+}
+
+func TestParse_SkipsTimezoneDefinitions(t *testing.T) {
+	data := ics(
+		"BEGIN:VTIMEZONE", "TZID:Custom", "BEGIN:STANDARD", "DTSTART:19701025T030000", "END:STANDARD", "END:VTIMEZONE",
+		"BEGIN:VEVENT", "UID:1", "DTSTART:20240115T150000Z", "END:VEVENT",
+	)
+
+	events, err := Parse(data, time.UTC)
+
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.NoError(t, events[0].Err)
+}
+
+func TestParse_RecurrenceProperties(t *testing.T) {
+	data := ics(
+		"BEGIN:VEVENT",
+		"UID:1",
+		"DTSTART:20240115T150000Z",
+		"RRULE:FREQ=WEEKLY;COUNT=3",
+		"EXDATE:20240122T150000Z,20240129T150000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:1",
+		"RECURRENCE-ID:20240122T150000Z",
+		"DTSTART:20240123T150000Z",
+		"END:VEVENT",
+	)
+
+	events, err := Parse(data, time.UTC)
+
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.NotNil(t, events[0].Rule)
+	assert.Equal(t, FreqWeekly, events[0].Rule.Freq)
+	assert.Equal(t, 3, events[0].Rule.Count)
+	assert.Len(t, events[0].Exclude, 2)
+	assert.True(t, events[1].RecurrenceID.Equal(time.Date(2024, 1, 22, 15, 0, 0, 0, time.UTC)))
+}
+
+func TestParse_EventErrors(t *testing.T) {
+	data := ics(
+		"BEGIN:VEVENT", "UID:nostart", "SUMMARY:No start", "END:VEVENT",
+		"BEGIN:VEVENT", "UID:badtz", "DTSTART;TZID=Eastern Standard Time:20240115T090000", "END:VEVENT",
+		"BEGIN:VEVENT", "UID:badrule", "DTSTART:20240115T150000Z", "RRULE:FREQ=HOURLY", "END:VEVENT",
+		"BEGIN:VEVENT", "UID:ok", "DTSTART:20240115T150000Z", "END:VEVENT",
+	)
+
+	events, err := Parse(data, time.UTC)
+
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+	assert.EqualError(t, events[0].Err, "event has no DTSTART")
+	assert.EqualError(t, events[1].Err, `invalid DTSTART: unknown time zone "Eastern Standard Time"`)
+	assert.EqualError(t, events[2].Err, "invalid RRULE: FREQ=HOURLY is not supported")
+	assert.NoError(t, events[3].Err)
+}
+
+func TestParse_NotCalendar(t *testing.T) {
+	_, err := Parse([]byte("id,channel_id\n1,2\n"), time.UTC)
+
+	assert.EqualError(t, err, "not an iCalendar file")
+}
+
+func TestParse_EmptyInput(t *testing.T) {
+	_, err := Parse(nil, time.UTC)
+
+	assert.Error(t, err)
+}
+
+func TestUnescapeText_RoundTrip(t *testing.T) {
+	original := "a,b;c\\d\nnext"
+
+	assert.Equal(t, original, UnescapeText(EscapeText(original)))
+}
@@ -0,0 +1,310 @@
+package ical
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the RRULE FREQ value.
+type Frequency int
+
+const (
+	FreqDaily Frequency = iota + 1
+	FreqWeekly
+	FreqMonthly
+	FreqYearly
+)
+
+// maxPeriods bounds rule expansion for series without COUNT or UNTIL that
+// started long before the requested window.
+const maxPeriods = 100000
+
+var frequencyNames = map[Frequency]string{
+	FreqDaily:   "DAILY",
+	FreqWeekly:  "WEEKLY",
+	FreqMonthly: "MONTHLY",
+	FreqYearly:  "YEARLY",
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Rule is the supported subset of an RRULE: FREQ, INTERVAL, COUNT, UNTIL and
+// plain weekday BYDAY lists for daily and weekly rules.
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	Count    int
+	Until    time.Time
+	ByDay    []time.Weekday
+}
+
+// Instance is one occurrence of an event.
+type Instance struct {
+	Event *ParsedEvent
+	// Number is the 1-based position of the occurrence in its series. An
+	// override takes the position of the instance it replaces.
+	Number int
+	Start  time.Time
+}
+
+// ParseRule parses an RRULE value. A floating UNTIL is read in loc.
+func ParseRule(value string, loc *time.Location) (*Rule, error) {
+	rule := &Rule{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("malformed rule part %q", part)
+		}
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq, err = parseFrequency(val)
+		case "INTERVAL":
+			rule.Interval, err = parsePositive(key, val)
+		case "COUNT":
+			rule.Count, err = parsePositive(key, val)
+		case "UNTIL":
+			rule.Until, err = parseTime(contentLine{value: val}, loc)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(val)
+		case "WKST":
+			// Only affects weekly rules with an interval and BYDAY; weeks
+			// are taken to start on Monday.
+		default:
+			err = fmt.Errorf("%s is not supported", strings.ToUpper(key))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if rule.Freq == 0 {
+		return nil, errors.New("missing FREQ")
+	}
+	if len(rule.ByDay) > 0 && rule.Freq != FreqDaily && rule.Freq != FreqWeekly {
+		return nil, errors.New("BYDAY is only supported for daily and weekly rules")
+	}
+	return rule, nil
+}
+
+// String encodes the rule as an RRULE value, with UNTIL in UTC.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + frequencyNames[r.Freq]}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(dateTimeLayoutUTC))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			codes = append(codes, strings.ToUpper(day.String()[:2]))
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Series describes starts, in ascending order, as a series from the first:
+// a daily or weekly rule when they are evenly spaced by whole days, or
+// otherwise the starts after the first as extra dates.
+func Series(starts []time.Time) (*Rule, []time.Time) {
+	if len(starts) < 2 {
+		return nil, nil
+	}
+	rest := starts[1:]
+	gap := starts[1].Sub(starts[0])
+	for i := 2; i < len(starts); i++ {
+		if starts[i].Sub(starts[i-1]) != gap {
+			return nil, rest
+		}
+	}
+	const day = 24 * time.Hour
+	switch {
+	case gap <= 0 || gap%day != 0:
+		return nil, rest
+	case gap%(7*day) == 0:
+		return &Rule{Freq: FreqWeekly, Interval: int(gap / (7 * day)), Count: len(starts)}, nil
+	default:
+		return &Rule{Freq: FreqDaily, Interval: int(gap / day), Count: len(starts)}, nil
+	}
+}
+
+func parseFrequency(val string) (Frequency, error) {
+	switch strings.ToUpper(val) {
+	case "DAILY":
+		return FreqDaily, nil
+	case "WEEKLY":
+		return FreqWeekly, nil
+	case "MONTHLY":
+		return FreqMonthly, nil
+	case "YEARLY":
+		return FreqYearly, nil
+	default:
+		return 0, fmt.Errorf("FREQ=%s is not supported", val)
+	}
+}
+
+func parsePositive(key, val string) (int, error) {
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid %s %q", strings.ToUpper(key), val)
+	}
+	return n, nil
+}
+
+func parseByDay(val string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, code := range strings.Split(val, ",") {
+		day, ok := weekdayCodes[strings.ToUpper(code)]
+		if !ok {
+			return nil, fmt.Errorf("BYDAY=%s is not supported", code)
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// Expand returns the instances of events that start within [from, to],
+// in file order. Events with errors are skipped, excluded dates are removed,
+// and instances overridden by a RECURRENCE-ID event are replaced by it.
+func Expand(events []ParsedEvent, from, to time.Time) []Instance {
+	overridden := make(map[string]bool)
+	series := make(map[string]*ParsedEvent)
+	for i := range events {
+		e := &events[i]
+		switch {
+		case e.Err != nil:
+		case !e.RecurrenceID.IsZero():
+			overridden[overrideKey(e.UID, e.RecurrenceID)] = true
+		case e.Rule != nil:
+			series[e.UID] = e
+		}
+	}
+	var instances []Instance
+	for i := range events {
+		e := &events[i]
+		if e.Err != nil {
+			continue
+		}
+		for _, inst := range e.instances(from, to) {
+			if e.RecurrenceID.IsZero() && overridden[overrideKey(e.UID, inst.Start)] {
+				continue
+			}
+			if master := series[e.UID]; master != nil && !e.RecurrenceID.IsZero() {
+				if number := master.occurrence(e.RecurrenceID); number > 0 {
+					inst.Number = number
+				}
+			}
+			instances = append(instances, inst)
+		}
+	}
+	return instances
+}
+
+func overrideKey(uid string, t time.Time) string {
+	return uid + "|" + t.UTC().Format(dateTimeLayoutUTC)
+}
+
+func (e *ParsedEvent) instances(from, to time.Time) []Instance {
+	if e.Rule == nil || !e.RecurrenceID.IsZero() {
+		if e.Start.Before(from) || e.Start.After(to) {
+			return nil
+		}
+		return []Instance{{Event: e, Number: 1, Start: e.Start}}
+	}
+	var instances []Instance
+	number := 0
+	for period := 0; period < maxPeriods; period++ {
+		for _, start := range e.Rule.period(e.Start, period) {
+			if start.Before(e.Start) {
+				continue
+			}
+			if start.After(to) || (!e.Rule.Until.IsZero() && start.After(e.Rule.Until)) {
+				return instances
+			}
+			number++
+			if !start.Before(from) && !e.excluded(start) {
+				instances = append(instances, Instance{Event: e, Number: number, Start: start})
+			}
+			if e.Rule.Count > 0 && number >= e.Rule.Count {
+				return instances
+			}
+		}
+	}
+	return instances
+}
+
+// occurrence returns the position in the series of the instance starting at
+// t, or 0 if the series has no such instance.
+func (e *ParsedEvent) occurrence(t time.Time) int {
+	for _, inst := range e.instances(e.Start, t) {
+		if inst.Start.Equal(t) {
+			return inst.Number
+		}
+	}
+	return 0
+}
+
+func (e *ParsedEvent) excluded(t time.Time) bool {
+	return slices.ContainsFunc(e.Exclude, t.Equal)
+}
+
+// period returns the candidate starts generated by the rule's n-th interval,
+// keeping the wall-clock time of start across DST changes.
+func (r *Rule) period(start time.Time, n int) []time.Time {
+	step := n * r.Interval
+	y, m, d := start.Date()
+	hh, mm, ss := start.Clock()
+	loc := start.Location()
+	switch r.Freq {
+	case FreqDaily:
+		t := time.Date(y, m, d+step, hh, mm, ss, 0, loc)
+		if len(r.ByDay) > 0 && !slices.Contains(r.ByDay, t.Weekday()) {
+			return nil
+		}
+		return []time.Time{t}
+	case FreqWeekly:
+		if len(r.ByDay) == 0 {
+			return []time.Time{time.Date(y, m, d+7*step, hh, mm, ss, 0, loc)}
+		}
+		monday := d - (int(start.Weekday())+6)%7 + 7*step
+		var out []time.Time
+		for offset := 0; offset < 7; offset++ {
+			t := time.Date(y, m, monday+offset, hh, mm, ss, 0, loc)
+			if slices.Contains(r.ByDay, t.Weekday()) {
+				out = append(out, t)
+			}
+		}
+		return out
+	case FreqMonthly:
+		t := time.Date(y, m+time.Month(step), d, hh, mm, ss, 0, loc)
+		if t.Day() != d {
+			// Months without this day (the 31st, Feb 30) are skipped.
+			return nil
+		}
+		return []time.Time{t}
+	case FreqYearly:
+		t := time.Date(y+step, m, d, hh, mm, ss, 0, loc)
+		if t.Day() != d {
+			return nil
+		}
+		return []time.Time{t}
+	}
+	return nil
+}
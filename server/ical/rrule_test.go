@@ -0,0 +1,205 @@
+package ical
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func starts(instances []Instance) []time.Time {
+	out := make([]time.Time, 0, len(instances))
+	for _, inst := range instances {
+		out = append(out, inst.Start)
+	}
+	return out
+}
+
+func numbers(instances []Instance) []int {
+	out := make([]int, 0, len(instances))
+	for _, inst := range instances {
+		out = append(out, inst.Number)
+	}
+	return out
+}
+
+func mustRule(t *testing.T, value string) *Rule {
+	t.Helper()
+	rule, err := ParseRule(value, time.UTC)
+	require.NoError(t, err)
+	return rule
+}
+
+func TestParseRule(t *testing.T) {
+	rule := mustRule(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;UNTIL=20240301T000000Z;WKST=MO")
+
+	assert.Equal(t, FreqWeekly, rule.Freq)
+	assert.Equal(t, 2, rule.Interval)
+	assert.Equal(t, []time.Weekday{time.Monday, time.Wednesday}, rule.ByDay)
+	assert.True(t, rule.Until.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestParseRule_Errors(t *testing.T) {
+	tests := map[string]string{
+		"INTERVAL=2":                  "missing FREQ",
+		"FREQ=MINUTELY":               "FREQ=MINUTELY is not supported",
+		"FREQ=DAILY;COUNT=0":          `invalid COUNT "0"`,
+		"FREQ=MONTHLY;BYDAY=1MO":      "BYDAY=1MO is not supported",
+		"FREQ=MONTHLY;BYDAY=MO":       "BYDAY is only supported for daily and weekly rules",
+		"FREQ=DAILY;BYSETPOS=1":       "BYSETPOS is not supported",
+		"FREQ=DAILY;COUNT":            `malformed rule part "COUNT"`,
+		"FREQ=WEEKLY;UNTIL=not-a-day": `parsing time "not-a-day" as "20060102T150405": cannot parse "not-a-day" as "2006"`,
+	}
+	for value, expected := range tests {
+		t.Run(value, func(t *testing.T) {
+			_, err := ParseRule(value, time.UTC)
+			assert.EqualError(t, err, expected)
+		})
+	}
+}
+
+func TestRuleString_RoundTrips(t *testing.T) {
+	value := "FREQ=WEEKLY;INTERVAL=2;UNTIL=20240301T000000Z;BYDAY=MO,WE"
+
+	assert.Equal(t, value, mustRule(t, value).String())
+	assert.Equal(t, "FREQ=DAILY;COUNT=4", (&Rule{Freq: FreqDaily, Interval: 1, Count: 4}).String())
+}
+
+func TestSeries(t *testing.T) {
+	at := func(day, hour int) time.Time { return time.Date(2024, 3, day, hour, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name      string
+		starts    []time.Time
+		wantRule  *Rule
+		wantDates []time.Time
+	}{
+		{name: "single start", starts: []time.Time{at(1, 9)}},
+		{name: "every other day", starts: []time.Time{at(1, 9), at(3, 9), at(5, 9)}, wantRule: &Rule{Freq: FreqDaily, Interval: 2, Count: 3}},
+		{name: "weekly", starts: []time.Time{at(1, 9), at(8, 9), at(15, 9)}, wantRule: &Rule{Freq: FreqWeekly, Interval: 1, Count: 3}},
+		{name: "uneven gaps", starts: []time.Time{at(1, 9), at(2, 9), at(4, 9)}, wantDates: []time.Time{at(2, 9), at(4, 9)}},
+		{name: "not whole days", starts: []time.Time{at(1, 9), at(1, 21), at(2, 9)}, wantDates: []time.Time{at(1, 21), at(2, 9)}},
+		{name: "same time", starts: []time.Time{at(1, 9), at(1, 9)}, wantDates: []time.Time{at(1, 9)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, dates := Series(tt.starts)
+
+			assert.Equal(t, tt.wantRule, rule)
+			assert.Equal(t, tt.wantDates, dates)
+		})
+	}
+}
+
+func TestExpand_SingleEvent(t *testing.T) {
+	start := time.Date(2024, 1, 20, 9, 0, 0, 0, time.UTC)
+	events := []ParsedEvent{{Event: Event{UID: "1", Start: start}, Number: 1}}
+
+	inside := Expand(events, start.Add(-time.Hour), start.Add(time.Hour))
+	outside := Expand(events, start.Add(time.Minute), start.Add(time.Hour))
+
+	require.Len(t, inside, 1)
+	assert.Equal(t, 1, inside[0].Number)
+	assert.Same(t, &events[0], inside[0].Event)
+	assert.Empty(t, outside)
+}
+
+func TestExpand_SkipsEventsWithErrors(t *testing.T) {
+	start := time.Date(2024, 1, 20, 9, 0, 0, 0, time.UTC)
+	events := []ParsedEvent{{Event: Event{UID: "1", Start: start}, Err: assert.AnError}}
+
+	assert.Empty(t, Expand(events, start.Add(-time.Hour), start.Add(time.Hour)))
+}
+
+func TestExpand_DailyCountNumbersFromStart(t *testing.T) {
+	start := time.Date(2024, 1, 10, 9, 0, 0, 0, time.UTC)
+	events := []ParsedEvent{{Event: Event{UID: "1", Start: start}, Rule: mustRule(t, "FREQ=DAILY;COUNT=5")}}
+
+	got := Expand(events, time.Date(2024, 1, 12, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, []int{3, 4, 5}, numbers(got))
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 1, 12, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 13, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 14, 9, 0, 0, 0, time.UTC),
+	}, starts(got))
+}
+
+func TestExpand_WeeklyByDayKeepsWallClockAcrossDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	// Friday before the March 10, 2024 DST change.
+	start := time.Date(2024, 3, 8, 9, 30, 0, 0, ny)
+	events := []ParsedEvent{{Event: Event{UID: "1", Start: start}, Rule: mustRule(t, "FREQ=WEEKLY;BYDAY=TU,FR;COUNT=4")}}
+
+	got := Expand(events, start, start.AddDate(0, 1, 0))
+
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 3, 8, 9, 30, 0, 0, ny),
+		time.Date(2024, 3, 12, 9, 30, 0, 0, ny),
+		time.Date(2024, 3, 15, 9, 30, 0, 0, ny),
+		time.Date(2024, 3, 19, 9, 30, 0, 0, ny),
+	}, starts(got))
+	assert.Equal(t, 13, got[1].Start.UTC().Hour())
+	assert.Equal(t, 14, got[0].Start.UTC().Hour())
+}
+
+func TestExpand_WeeklyInterval(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC) // Monday
+	events := []ParsedEvent{{Event: Event{UID: "1", Start: start}, Rule: mustRule(t, "FREQ=WEEKLY;INTERVAL=2;UNTIL=20240201T000000Z")}}
+
+	got := Expand(events, start, start.AddDate(1, 0, 0))
+
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 29, 10, 0, 0, 0, time.UTC),
+	}, starts(got))
+}
+
+func TestExpand_MonthlySkipsShortMonths(t *testing.T) {
+	start := time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+	events := []ParsedEvent{{Event: Event{UID: "1", Start: start}, Rule: mustRule(t, "FREQ=MONTHLY;COUNT=3")}}
+
+	got := Expand(events, start, start.AddDate(1, 0, 0))
+
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 31, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 5, 31, 10, 0, 0, 0, time.UTC),
+	}, starts(got))
+}
+
+func TestExpand_YearlyWithoutEndStopsAtWindow(t *testing.T) {
+	start := time.Date(2000, 2, 29, 10, 0, 0, 0, time.UTC)
+	events := []ParsedEvent{{Event: Event{UID: "1", Start: start}, Rule: mustRule(t, "FREQ=YEARLY")}}
+
+	got := Expand(events, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2029, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, []time.Time{time.Date(2024, 2, 29, 10, 0, 0, 0, time.UTC), time.Date(2028, 2, 29, 10, 0, 0, 0, time.UTC)}, starts(got))
+	assert.Equal(t, []int{7, 8}, numbers(got))
+}
+
+func TestExpand_ExdateAndOverride(t *testing.T) {
+	start := time.Date(2024, 1, 15, 15, 0, 0, 0, time.UTC)
+	events := []ParsedEvent{
+		{
+			Event:   Event{UID: "series", Start: start},
+			Rule:    mustRule(t, "FREQ=WEEKLY;COUNT=4"),
+			Exclude: []time.Time{time.Date(2024, 1, 29, 15, 0, 0, 0, time.UTC)},
+		},
+		{
+			Event:        Event{UID: "series", Start: time.Date(2024, 1, 23, 16, 0, 0, 0, time.UTC)},
+			RecurrenceID: time.Date(2024, 1, 22, 15, 0, 0, 0, time.UTC),
+		},
+	}
+
+	got := Expand(events, start, start.AddDate(0, 2, 0))
+
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 1, 15, 15, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 5, 15, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 23, 16, 0, 0, 0, time.UTC),
+	}, starts(got))
+	assert.Equal(t, []int{1, 4, 2}, numbers(got))
+}
@@ -0,0 +1,175 @@
+// Package metrics collects scheduler and store metrics and renders them in
+// the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ContentType is the Prometheus text exposition content type.
+	ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+	namePending      = "scheduled_messages_pending"
+	nameDelivered    = "scheduled_messages_delivered_total"
+	nameFailed       = "scheduled_messages_failed_total"
+	nameLateness     = "scheduled_messages_delivery_lateness_seconds"
+	nameTickDuration = "scheduled_messages_scheduler_tick_duration_seconds"
+	nameKVErrors     = "scheduled_messages_kv_errors_total"
+	nameDeferred     = "scheduled_messages_deferred_total"
+)
+
+var (
+	latenessBuckets = []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600}
+	tickBuckets     = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+)
+
+// Metrics holds the plugin's counters, gauges and histograms. It is safe for
+// concurrent use.
+type Metrics struct {
+	mu           sync.Mutex
+	pending      int
+	delivered    uint64
+	failed       uint64
+	lateness     *histogram
+	tickDuration *histogram
+	kvErrors     map[string]uint64
+	deferred     uint64
+}
+
+// New returns an empty Metrics.
+func New() *Metrics {
+	return &Metrics{
+		lateness:     newHistogram(latenessBuckets),
+		tickDuration: newHistogram(tickBuckets),
+		kvErrors:     make(map[string]uint64),
+	}
+}
+
+// SetPendingMessages records how many messages are waiting to be delivered.
+func (m *Metrics) SetPendingMessages(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pending = n
+}
+
+// IncDelivered counts a message posted to one destination.
+func (m *Metrics) IncDelivered() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.delivered++
+}
+
+// IncDeliveryFailed counts a message that could not be posted to one destination.
+func (m *Metrics) IncDeliveryFailed() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failed++
+}
+
+// ObserveLateness records the delay between a message's PostAt and its delivery.
+func (m *Metrics) ObserveLateness(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lateness.observe(d.Seconds())
+}
+
+// ObserveTickDuration records how long one scheduler pass took.
+func (m *Metrics) ObserveTickDuration(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tickDuration.observe(d.Seconds())
+}
+
+// IncKVError counts a failed KV store operation.
+func (m *Metrics) IncKVError(op string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.kvErrors[op]++
+}
+
+// IncDeferred counts a due message held back by a rate limit.
+func (m *Metrics) IncDeferred() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deferred++
+}
+
+// WriteTo renders all metrics in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	var b strings.Builder
+	writeHeader(&b, namePending, "gauge", "Scheduled messages waiting to be delivered.")
+	fmt.Fprintf(&b, "%s %d\n", namePending, m.pending)
+	writeHeader(&b, nameDelivered, "counter", "Scheduled messages posted, per destination channel.")
+	fmt.Fprintf(&b, "%s %d\n", nameDelivered, m.delivered)
+	writeHeader(&b, nameFailed, "counter", "Scheduled messages that failed to post, per destination channel.")
+	fmt.Fprintf(&b, "%s %d\n", nameFailed, m.failed)
+	writeHeader(&b, nameDeferred, "counter", "Times a due message was held back by a rate limit.")
+	fmt.Fprintf(&b, "%s %d\n", nameDeferred, m.deferred)
+	writeHeader(&b, nameLateness, "histogram", "Delay between a message's scheduled time and its delivery.")
+	m.lateness.write(&b, nameLateness)
+	writeHeader(&b, nameTickDuration, "histogram", "Duration of one scheduler pass over due messages.")
+	m.tickDuration.write(&b, nameTickDuration)
+	writeHeader(&b, nameKVErrors, "counter", "Failed KV store operations by operation.")
+	ops := make([]string, 0, len(m.kvErrors))
+	for op := range m.kvErrors {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	for _, op := range ops {
+		fmt.Fprintf(&b, "%s{operation=%q} %d\n", nameKVErrors, op, m.kvErrors[op])
+	}
+	m.mu.Unlock()
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func writeHeader(b *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+type histogram struct {
+	bounds []float64
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+func (h *histogram) write(b *strings.Builder, name string) {
+	for i, bound := range h.bounds {
+		fmt.Fprintf(b, "%s_bucket{le=%q} %d\n", name, formatFloat(bound), h.counts[i])
+	}
+	fmt.Fprintf(b, "%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+	fmt.Fprintf(b, "%s_sum %s\n", name, formatFloat(h.sum))
+	fmt.Fprintf(b, "%s_count %d\n", name, h.count)
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func render(t *testing.T, m *Metrics) string {
+	t.Helper()
+	var b strings.Builder
+	_, err := m.WriteTo(&b)
+	require.NoError(t, err)
+	return b.String()
+}
+
+func TestWriteTo_Empty(t *testing.T) {
+	out := render(t, New())
+
+	assert.Contains(t, out, "# TYPE scheduled_messages_pending gauge\nscheduled_messages_pending 0\n")
+	assert.Contains(t, out, "scheduled_messages_delivered_total 0\n")
+	assert.Contains(t, out, "scheduled_messages_failed_total 0\n")
+	assert.Contains(t, out, "scheduled_messages_deferred_total 0\n")
+	assert.Contains(t, out, "scheduled_messages_delivery_lateness_seconds_bucket{le=\"+Inf\"} 0\n")
+	assert.Contains(t, out, "# TYPE scheduled_messages_kv_errors_total counter\n")
+	assert.NotContains(t, out, "scheduled_messages_kv_errors_total{")
+}
+
+func TestWriteTo_RecordsValues(t *testing.T) {
+	m := New()
+	m.SetPendingMessages(7)
+	m.IncDelivered()
+	m.IncDelivered()
+	m.IncDeliveryFailed()
+	m.IncDeferred()
+	m.ObserveLateness(3 * time.Second)
+	m.ObserveLateness(90 * time.Second)
+	m.ObserveTickDuration(20 * time.Millisecond)
+	m.IncKVError("set")
+	m.IncKVError("get")
+	m.IncKVError("set")
+
+	out := render(t, m)
+
+	assert.Contains(t, out, "scheduled_messages_pending 7\n")
+	assert.Contains(t, out, "scheduled_messages_delivered_total 2\n")
+	assert.Contains(t, out, "scheduled_messages_failed_total 1\n")
+	assert.Contains(t, out, "scheduled_messages_deferred_total 1\n")
+	assert.Contains(t, out, "scheduled_messages_delivery_lateness_seconds_bucket{le=\"1\"} 0\n")
+	assert.Contains(t, out, "scheduled_messages_delivery_lateness_seconds_bucket{le=\"5\"} 1\n")
+	assert.Contains(t, out, "scheduled_messages_delivery_lateness_seconds_bucket{le=\"60\"} 1\n")
+	assert.Contains(t, out, "scheduled_messages_delivery_lateness_seconds_bucket{le=\"120\"} 2\n")
+	assert.Contains(t, out, "scheduled_messages_delivery_lateness_seconds_bucket{le=\"+Inf\"} 2\n")
+	assert.Contains(t, out, "scheduled_messages_delivery_lateness_seconds_sum 93\n")
+	assert.Contains(t, out, "scheduled_messages_delivery_lateness_seconds_count 2\n")
+	assert.Contains(t, out, "scheduled_messages_scheduler_tick_duration_seconds_bucket{le=\"0.01\"} 0\n")
+	assert.Contains(t, out, "scheduled_messages_scheduler_tick_duration_seconds_bucket{le=\"0.025\"} 1\n")
+	assert.Contains(t, out, "scheduled_messages_kv_errors_total{operation=\"get\"} 1\nscheduled_messages_kv_errors_total{operation=\"set\"} 2\n")
+}
+
+func TestMetrics_ConcurrentUse(t *testing.T) {
+	m := New()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.IncDelivered()
+			m.ObserveLateness(time.Second)
+			m.IncKVError("get")
+		}()
+	}
+	wg.Wait()
+
+	out := render(t, m)
+
+	assert.Contains(t, out, "scheduled_messages_delivered_total 50\n")
+	assert.Contains(t, out, "scheduled_messages_kv_errors_total{operation=\"get\"} 50\n")
+}
@@ -0,0 +1,159 @@
+// Package migration upgrades data stored by older plugin versions.
+package migration
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+// Migration upgrades stored data to Version. Apply must be safe to run again
+// on data it has already upgraded, since a run interrupted part way through
+// is repeated from the start on the next activation.
+type Migration struct {
+	Version int
+	Name    string
+	Apply   func(logger ports.Logger, kv ports.KVService, listMatching ports.ListMatchingService) error
+}
+
+// All returns the plugin's migrations in version order.
+func All() []Migration {
+	return []Migration{
+		{Version: 1, Name: "stamp schema version on scheduled messages", Apply: stampMessageSchemaVersion(1, allMessages)},
+		{Version: 2, Name: "stamp schema version 2 on encrypted content", Apply: stampMessageSchemaVersion(2, encryptedMessages)},
+	}
+}
+
+// Runner applies the migrations newer than the stored schema version.
+type Runner struct {
+	logger       ports.Logger
+	kv           ports.KVService
+	listMatching ports.ListMatchingService
+	locker       sync.Locker
+	migrations   []Migration
+}
+
+// NewRunner constructs a Runner. locker keeps other cluster nodes from
+// migrating at the same time.
+func NewRunner(logger ports.Logger, kv ports.KVService, listMatching ports.ListMatchingService, locker sync.Locker, migrations []Migration) *Runner {
+	logger.Debug("Creating new migration Runner", "migrations", len(migrations))
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	return &Runner{logger: logger, kv: kv, listMatching: listMatching, locker: locker, migrations: sorted}
+}
+
+// Run applies pending migrations, recording the schema version after each
+// one so a failed run resumes from the migration that failed.
+func (r *Runner) Run() error {
+	r.logger.Debug("Acquiring migration lock")
+	r.locker.Lock()
+	defer r.locker.Unlock()
+
+	var current int
+	if err := r.kv.Get(constants.SchemaVersionKey, &current); err != nil {
+		r.logger.Error("Failed to read schema version", "error", err)
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	latest := 0
+	if len(r.migrations) > 0 {
+		latest = r.migrations[len(r.migrations)-1].Version
+	}
+	r.logger.Debug("Checked schema version", "current", current, "latest", latest)
+	if current > latest {
+		r.logger.Warn("Stored schema version is newer than this plugin version; skipping migrations", "current", current, "latest", latest)
+		return nil
+	}
+
+	for _, m := range r.migrations {
+		if m.Version <= current {
+			continue
+		}
+		r.logger.Info("Running migration", "version", m.Version, "name", m.Name)
+		if err := m.Apply(r.logger, r.kv, r.listMatching); err != nil {
+			r.logger.Error("Migration failed", "version", m.Version, "name", m.Name, "error", err)
+			return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		if _, err := r.kv.Set(constants.SchemaVersionKey, m.Version); err != nil {
+			r.logger.Error("Failed to save schema version", "version", m.Version, "error", err)
+			return fmt.Errorf("failed to save schema version %d: %w", m.Version, err)
+		}
+		r.logger.Info("Completed migration", "version", m.Version, "name", m.Name)
+	}
+	return nil
+}
+
+// listAllKeys returns every key with prefix, reading all pages so that
+// migrations cover stores larger than one page.
+func listAllKeys(kv ports.KVService, listMatching ports.ListMatchingService, prefix string) ([]string, error) {
+	var all []string
+	for page := constants.DefaultPage; ; page++ {
+		keys, err := kv.ListKeys(page, constants.MaxFetchScheduledMessages, listMatching.WithPrefix(prefix))
+		if err != nil {
+			return nil, fmt.Errorf("kv.ListKeys failed for prefix %s page %d: %w", prefix, page, err)
+		}
+		all = append(all, keys...)
+		if len(keys) < constants.MaxFetchScheduledMessages {
+			return all, nil
+		}
+	}
+}
+
+func allMessages(*types.ScheduledMessage) bool { return true }
+
+// encryptedMessages picks records that use encrypted content. Plain records
+// stay at version 1 so a downgraded plugin can still read them.
+func encryptedMessages(msg *types.ScheduledMessage) bool { return msg.Encrypted != nil }
+
+// stampMessageSchemaVersion returns a migration marking the scheduled messages
+// needs picks, when they have an older schema version, as version. It is used
+// for versions that only add optional fields, so existing records are already
+// valid: version 1 versioned records for the first time, and version 2 added
+// encrypted content.
+func stampMessageSchemaVersion(version int, needs func(*types.ScheduledMessage) bool) func(ports.Logger, ports.KVService, ports.ListMatchingService) error {
+	return func(logger ports.Logger, kv ports.KVService, listMatching ports.ListMatchingService) error {
+		keys, err := listAllKeys(kv, listMatching, constants.SchedPrefix)
+		if err != nil {
+			return err
+		}
+		stamped, changed := 0, 0
+		for _, key := range keys {
+			// Schedulers on other nodes keep running during a rolling upgrade,
+			// so the write only succeeds if the record is still exactly as
+			// read. Otherwise a message sent and deleted meanwhile would be
+			// written back and posted again.
+			var stored []byte
+			if err := kv.Get(key, &stored); err != nil {
+				return fmt.Errorf("kv.Get failed for key %s: %w", key, err)
+			}
+			if len(stored) == 0 {
+				continue
+			}
+			var msg types.ScheduledMessage
+			if err := json.Unmarshal(stored, &msg); err != nil {
+				return fmt.Errorf("failed to decode scheduled message %s: %w", key, err)
+			}
+			if msg.ID == "" || msg.SchemaVersion >= version || !needs(&msg) {
+				continue
+			}
+			msg.SchemaVersion = version
+			set, err := kv.Set(key, &msg, pluginapi.SetAtomic(stored))
+			if err != nil {
+				return fmt.Errorf("kv.Set failed for key %s: %w", key, err)
+			}
+			if !set {
+				logger.Debug("Scheduled message changed during migration, skipping", "key", key)
+				changed++
+				continue
+			}
+			stamped++
+		}
+		logger.Debug("Stamped schema version on scheduled messages", "version", version, "keys", len(keys), "stamped", stamped, "changed", changed)
+		return nil
+	}
+}
@@ -0,0 +1,293 @@
+package migration
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/testutil"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memKV is an in-memory KV store holding JSON like the plugin KV store. It
+// also stands in for the list matching service, since pluginapi list options
+// cannot be inspected. For the same reason atomic writes compare against
+// the value last read rather than the old value passed in. beforeSet, when
+// set, runs before each write so tests can change the store between a
+// migration's read and its write.
+type memKV struct {
+	data      map[string][]byte
+	read      map[string][]byte
+	prefix    string
+	sets      []string
+	failSet   map[string]error
+	beforeSet func(key string)
+}
+
+func newMemKV(fixtures map[string]string) *memKV {
+	kv := &memKV{data: map[string][]byte{}, read: map[string][]byte{}, failSet: map[string]error{}}
+	for key, value := range fixtures {
+		kv.data[key] = []byte(value)
+	}
+	return kv
+}
+
+func (m *memKV) Get(key string, o any) error {
+	data, ok := m.data[key]
+	if !ok {
+		return nil
+	}
+	m.read[key] = data
+	if raw, ok := o.(*[]byte); ok {
+		*raw = data
+		return nil
+	}
+	return json.Unmarshal(data, o)
+}
+
+func (m *memKV) Set(key string, value any, opts ...pluginapi.KVSetOption) (bool, error) {
+	if m.beforeSet != nil {
+		m.beforeSet(key)
+	}
+	if err := m.failSet[key]; err != nil {
+		return false, err
+	}
+	var o pluginapi.KVSetOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.Atomic && !bytes.Equal(m.read[key], m.data[key]) {
+		return false, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	m.data[key] = data
+	m.sets = append(m.sets, key)
+	return true, nil
+}
+
+func (m *memKV) Delete(key string) error {
+	delete(m.data, key)
+	return nil
+}
+
+func (m *memKV) ListKeys(page, perPage int, _ ...pluginapi.ListKeysOption) ([]string, error) {
+	var keys []string
+	for key := range m.data {
+		if strings.HasPrefix(key, m.prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	start := min(page*perPage, len(keys))
+	return keys[start:min(start+perPage, len(keys))], nil
+}
+
+func (m *memKV) WithPrefix(prefix string) pluginapi.ListKeysOption {
+	m.prefix = prefix
+	return pluginapi.WithPrefix(prefix)
+}
+
+func (m *memKV) message(t *testing.T, id string) types.ScheduledMessage {
+	t.Helper()
+	var msg types.ScheduledMessage
+	require.NoError(t, m.Get(testutil.SchedKey(id), &msg))
+	return msg
+}
+
+func (m *memKV) version(t *testing.T) int {
+	t.Helper()
+	var v int
+	require.NoError(t, m.Get(constants.SchemaVersionKey, &v))
+	return v
+}
+
+type fakeLocker struct{ events []string }
+
+func (l *fakeLocker) Lock()   { l.events = append(l.events, "lock") }
+func (l *fakeLocker) Unlock() { l.events = append(l.events, "unlock") }
+
+// legacyFixtures are records as written by plugin versions before schema
+// versioning, including one from before cross-posting.
+func legacyFixtures() map[string]string {
+	return map[string]string{
+		testutil.SchedKey("a"):  `{"id":"a","user_id":"u1","channel_id":"c1","post_at":"2024-01-15T10:00:00Z","message_content":"hello","timezone":"America/New_York"}`,
+		testutil.SchedKey("b"):  `{"id":"b","user_id":"u1","channel_id":"c1","channel_ids":["c1","c2"],"root_id":"r1","post_at":"2024-01-16T10:00:00Z","message_content":"multi","timezone":"UTC","occurrence":2}`,
+		testutil.IndexKey("u1"): `["a","b"]`,
+	}
+}
+
+func TestRun_UpgradesLegacyRecords(t *testing.T) {
+	kv := newMemKV(legacyFixtures())
+	locker := &fakeLocker{}
+
+	err := NewRunner(testutil.FakeLogger{}, kv, kv, locker, All()).Run()
+
+	require.NoError(t, err)
+	assert.Equal(t, types.ScheduledMessage{
+		ID:             "a",
+		UserID:         "u1",
+		ChannelID:      "c1",
+		PostAt:         time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		MessageContent: "hello",
+		Timezone:       "America/New_York",
+		SchemaVersion:  1,
+	}, kv.message(t, "a"))
+	assert.Equal(t, types.ScheduledMessage{
+		ID:             "b",
+		UserID:         "u1",
+		ChannelID:      "c1",
+		ChannelIDs:     []string{"c1", "c2"},
+		RootID:         "r1",
+		PostAt:         time.Date(2024, 1, 16, 10, 0, 0, 0, time.UTC),
+		MessageContent: "multi",
+		Timezone:       "UTC",
+		Occurrence:     2,
+		SchemaVersion:  1,
+	}, kv.message(t, "b"))
+	assert.JSONEq(t, `["a","b"]`, string(kv.data[testutil.IndexKey("u1")]))
+	assert.Equal(t, constants.ScheduledMessageSchemaVersion, kv.version(t))
+	assert.Equal(t, []string{"lock", "unlock"}, locker.events)
+}
+
+func TestRun_IsIdempotent(t *testing.T) {
+	kv := newMemKV(legacyFixtures())
+	require.NoError(t, NewRunner(testutil.FakeLogger{}, kv, kv, &fakeLocker{}, All()).Run())
+	kv.sets = nil
+
+	require.NoError(t, NewRunner(testutil.FakeLogger{}, kv, kv, &fakeLocker{}, All()).Run())
+
+	assert.Empty(t, kv.sets)
+}
+
+func TestStampMessageSchemaVersion_SkipsCurrentRecords(t *testing.T) {
+	kv := newMemKV(map[string]string{
+		testutil.SchedKey("a"): `{"id":"a","user_id":"u1","channel_id":"c1","post_at":"2024-01-15T10:00:00Z","message_content":"new","timezone":"UTC","schema_version":1}`,
+		testutil.SchedKey("b"): `{"id":"b","user_id":"u1","channel_id":"c1","post_at":"2024-01-15T10:00:00Z","message_content":"old","timezone":"UTC"}`,
+	})
+
+	require.NoError(t, stampMessageSchemaVersion(1, allMessages)(testutil.FakeLogger{}, kv, kv))
+
+	assert.Equal(t, []string{testutil.SchedKey("b")}, kv.sets)
+}
+
+func TestStampMessageSchemaVersion_SkipsRecordsChangedMeanwhile(t *testing.T) {
+	kv := newMemKV(map[string]string{
+		testutil.SchedKey("sent"):    `{"id":"sent","user_id":"u1","channel_id":"c1","post_at":"2024-01-15T10:00:00Z","message_content":"sent","timezone":"UTC"}`,
+		testutil.SchedKey("snoozed"): `{"id":"snoozed","user_id":"u1","channel_id":"c1","post_at":"2024-01-15T10:00:00Z","message_content":"snoozed","timezone":"UTC"}`,
+	})
+	snoozed := `{"id":"snoozed","user_id":"u1","channel_id":"c1","post_at":"2024-01-15T11:00:00Z","message_content":"snoozed","timezone":"UTC"}`
+	// A scheduler on another node sends one message and snoozes the other
+	// after the migration read them.
+	kv.beforeSet = func(key string) {
+		switch key {
+		case testutil.SchedKey("sent"):
+			delete(kv.data, key)
+		case testutil.SchedKey("snoozed"):
+			kv.data[key] = []byte(snoozed)
+		}
+	}
+
+	require.NoError(t, stampMessageSchemaVersion(1, allMessages)(testutil.FakeLogger{}, kv, kv))
+
+	assert.Empty(t, kv.sets)
+	assert.NotContains(t, kv.data, testutil.SchedKey("sent"))
+	assert.JSONEq(t, snoozed, string(kv.data[testutil.SchedKey("snoozed")]))
+}
+
+func TestRun_StampsVersionOneRecordsForEncryption(t *testing.T) {
+	kv := newMemKV(map[string]string{
+		constants.SchemaVersionKey: "1",
+		testutil.SchedKey("plain"): `{"id":"plain","user_id":"u1","channel_id":"c1","post_at":"2024-01-15T10:00:00Z","message_content":"hi","timezone":"UTC","schema_version":1}`,
+		testutil.SchedKey("sealed"): `{"id":"sealed","user_id":"u1","channel_id":"c1","post_at":"2024-01-15T10:00:00Z","message_content":"","timezone":"UTC","schema_version":1,` +
+			`"encrypted":{"key_id":"k1","wrapped_key":"d2s=","ciphertext":"Y3Q="}}`,
+	})
+
+	require.NoError(t, NewRunner(testutil.FakeLogger{}, kv, kv, &fakeLocker{}, All()).Run())
+
+	// Plain records stay readable by version 1 if the plugin is downgraded.
+	assert.Equal(t, 1, kv.message(t, "plain").SchemaVersion)
+	assert.Equal(t, 2, kv.message(t, "sealed").SchemaVersion)
+	assert.Equal(t, []string{testutil.SchedKey("sealed"), constants.SchemaVersionKey}, kv.sets)
+	assert.Equal(t, 2, kv.version(t))
+}
+
+func TestStampMessageSchemaVersion_ReadsEveryPage(t *testing.T) {
+	fixtures := map[string]string{}
+	for i := range constants.MaxFetchScheduledMessages + 1 {
+		id := fmt.Sprintf("m%05d", i)
+		fixtures[testutil.SchedKey(id)] = fmt.Sprintf(`{"id":%q,"user_id":"u1","channel_id":"c1","post_at":"2024-01-15T10:00:00Z","message_content":"hi","timezone":"UTC"}`, id)
+	}
+	kv := newMemKV(fixtures)
+
+	require.NoError(t, stampMessageSchemaVersion(1, allMessages)(testutil.FakeLogger{}, kv, kv))
+
+	assert.Len(t, kv.sets, constants.MaxFetchScheduledMessages+1)
+	assert.Equal(t, 1, kv.message(t, fmt.Sprintf("m%05d", constants.MaxFetchScheduledMessages)).SchemaVersion)
+}
+
+func TestRun_ResumesAfterFailure(t *testing.T) {
+	kv := newMemKV(legacyFixtures())
+	kv.failSet[testutil.SchedKey("b")] = errors.New("kv down")
+
+	err := NewRunner(testutil.FakeLogger{}, kv, kv, &fakeLocker{}, All()).Run()
+
+	require.EqualError(t, err, "migration 1 (stamp schema version on scheduled messages) failed: kv.Set failed for key schedmsg:b: kv down")
+	assert.Equal(t, 0, kv.version(t))
+	assert.Equal(t, 1, kv.message(t, "a").SchemaVersion)
+
+	delete(kv.failSet, testutil.SchedKey("b"))
+	kv.sets = nil
+	require.NoError(t, NewRunner(testutil.FakeLogger{}, kv, kv, &fakeLocker{}, All()).Run())
+
+	assert.Equal(t, []string{testutil.SchedKey("b"), constants.SchemaVersionKey}, kv.sets[:2])
+	assert.Equal(t, 1, kv.message(t, "b").SchemaVersion)
+	assert.Equal(t, 2, kv.version(t))
+}
+
+func TestRun_RecordsVersionAfterEachMigration(t *testing.T) {
+	kv := newMemKV(nil)
+	var applied []int
+	step := func(version int, err error) Migration {
+		return Migration{Version: version, Name: "step", Apply: func(ports.Logger, ports.KVService, ports.ListMatchingService) error {
+			applied = append(applied, version)
+			return err
+		}}
+	}
+	// Out of order on purpose: migrations run by version.
+	migrations := []Migration{step(3, errors.New("boom")), step(1, nil), step(2, nil)}
+
+	err := NewRunner(testutil.FakeLogger{}, kv, kv, &fakeLocker{}, migrations).Run()
+
+	require.EqualError(t, err, "migration 3 (step) failed: boom")
+	assert.Equal(t, []int{1, 2, 3}, applied)
+	assert.Equal(t, 2, kv.version(t))
+
+	applied = nil
+	migrations[0] = step(3, nil)
+	require.NoError(t, NewRunner(testutil.FakeLogger{}, kv, kv, &fakeLocker{}, migrations).Run())
+	assert.Equal(t, []int{3}, applied)
+	assert.Equal(t, 3, kv.version(t))
+}
+
+func TestRun_SkipsWhenStoredVersionIsNewer(t *testing.T) {
+	kv := newMemKV(map[string]string{constants.SchemaVersionKey: "99"})
+	locker := &fakeLocker{}
+
+	require.NoError(t, NewRunner(testutil.FakeLogger{}, kv, kv, locker, All()).Run())
+
+	assert.Empty(t, kv.sets)
+	assert.Equal(t, []string{"lock", "unlock"}, locker.events)
+}
@@ -0,0 +1,40 @@
+// Package placeholder expands template variables in scheduled message content.
+package placeholder
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Supported template variable names.
+const (
+	VarDate       = "date"
+	VarWeekday    = "weekday"
+	VarTime       = "time"
+	VarChannel    = "channel"
+	VarAuthor     = "author"
+	VarOccurrence = "occurrence"
+)
+
+// Names lists every supported template variable.
+var Names = []string{VarDate, VarWeekday, VarTime, VarChannel, VarAuthor, VarOccurrence}
+
+var regexpVariable = regexp.MustCompile(`\{\{\s*([A-Za-z_]+)\s*\}\}`)
+
+// Has reports whether the content contains any template variables.
+func Has(content string) bool {
+	return regexpVariable.MatchString(content)
+}
+
+// Expand replaces template variables with their values. Anything else in
+// double braces, such as an unknown name or a snippet of another template
+// language, is left untouched.
+func Expand(content string, values map[string]string) string {
+	return regexpVariable.ReplaceAllStringFunc(content, func(match string) string {
+		name := strings.ToLower(regexpVariable.FindStringSubmatch(match)[1])
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return match
+	})
+}
@@ -0,0 +1,37 @@
+package placeholder
+
+import (
+	"testing"
+)
+
+func TestHas(t *testing.T) {
+	if !Has("Retro on {{date}}") {
+		t.Fatalf("expected template variable to be detected")
+	}
+	if Has("No variables { here }") {
+		t.Fatalf("expected no template variables")
+	}
+}
+
+func TestExpand(t *testing.T) {
+	values := map[string]string{
+		VarDate:       "Oct 16, 2026",
+		VarOccurrence: "42",
+	}
+
+	got := Expand("Sprint {{occurrence}} retro, {{ DATE }} ({{author}})", values)
+	want := "Sprint 42 retro, Oct 16, 2026 ({{author}})"
+	if got != want {
+		t.Fatalf("Expand() = %q, want %q", got, want)
+	}
+}
+
+func TestExpand_LeavesOtherTemplatesAlone(t *testing.T) {
+	content := "Hi {{name}}, set {{ .Values.foo }} and {{ user.name }} by {{date}}"
+
+	got := Expand(content, map[string]string{VarDate: "Oct 16, 2026"})
+	want := "Hi {{name}}, set {{ .Values.foo }} and {{ user.name }} by Oct 16, 2026"
+	if got != want {
+		t.Fatalf("Expand() = %q, want %q", got, want)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/adapters/mm"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
@@ -12,30 +13,41 @@ import (
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/channel"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/clock"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/command"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/consistency"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/encryption"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/metrics"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/migration"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/scheduler"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/store"
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin"
 	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
 )
 
 type ClientFactory func(api plugin.API, drv plugin.Driver) *pluginapi.Client
 
-type ClockFactory func() ports.Clock
+type ClockFactory func() ports.TimerClock
 
 type BotEnsurer func(ports.BotService, ports.BotProfileImageService) (string, error)
 
 type AppBuilder interface {
 	NewChannel(cli *pluginapi.Client) *channel.Channel
-	NewStore(cli *pluginapi.Client, maxUserMessages int) ports.Store
-	NewScheduler(cli *pluginapi.Client, st ports.Store, ch ports.ChannelService, botID string, clk ports.Clock) *scheduler.Scheduler
+	NewStore(cli *pluginapi.Client, maxUserMessages int, m ports.Metrics, cipher ports.ContentCipher) ports.Store
+	NewScheduler(cli *pluginapi.Client, st ports.Store, ch ports.ChannelService, botID string, clk ports.TimerClock, m ports.Metrics) *scheduler.Scheduler
 	NewCommandHandler(
 		cli *pluginapi.Client,
 		st ports.Store,
 		ch ports.ChannelService,
 		listSvc ports.ListService,
 		scheduleSvc ports.ScheduleService,
+		templateSvc ports.TemplateService,
+		exportSvc ports.ExportService,
+		calendarSvc ports.CalendarService,
+		receiptSvc ports.ReceiptService,
+		followupSvc ports.FollowupService,
+		adminSvc ports.AdminService,
 		help string,
 	) *command.Handler
 }
@@ -43,15 +55,15 @@ type AppBuilder interface {
 type prodBuilder struct{}
 
 func (prodBuilder) NewChannel(cli *pluginapi.Client) *channel.Channel {
-	return channel.New(&cli.Log, &cli.Channel, &cli.Team, &cli.User)
+	return channel.New(&cli.Log, &cli.Channel, &cli.Team, &cli.User, &cli.User)
 }
 
-func (prodBuilder) NewStore(cli *pluginapi.Client, maxUserMessages int) ports.Store {
-	return store.NewKVStore(&cli.Log, &cli.KV, mm.NewListMatchingService(), maxUserMessages)
+func (prodBuilder) NewStore(cli *pluginapi.Client, maxUserMessages int, m ports.Metrics, cipher ports.ContentCipher) ports.Store {
+	return store.NewKVStore(&cli.Log, store.NewInstrumentedKV(&cli.KV, m), mm.NewListMatchingService(), maxUserMessages, cipher)
 }
 
-func (prodBuilder) NewScheduler(cli *pluginapi.Client, st ports.Store, ch ports.ChannelService, botID string, clk ports.Clock) *scheduler.Scheduler {
-	return scheduler.New(&cli.Log, &cli.Post, st, ch, botID, clk)
+func (prodBuilder) NewScheduler(cli *pluginapi.Client, st ports.Store, ch ports.ChannelService, botID string, clk ports.TimerClock, m ports.Metrics) *scheduler.Scheduler {
+	return scheduler.New(&cli.Log, &cli.Post, st, ch, &cli.User, &cli.File, &cli.Configuration, botID, clk, m)
 }
 
 func (prodBuilder) NewCommandHandler(
@@ -60,6 +72,12 @@ func (prodBuilder) NewCommandHandler(
 	ch ports.ChannelService,
 	listSvc ports.ListService,
 	scheduleSvc ports.ScheduleService,
+	templateSvc ports.TemplateService,
+	exportSvc ports.ExportService,
+	calendarSvc ports.CalendarService,
+	receiptSvc ports.ReceiptService,
+	followupSvc ports.FollowupService,
+	adminSvc ports.AdminService,
 	help string,
 ) *command.Handler {
 	return command.NewHandler(
@@ -70,6 +88,12 @@ func (prodBuilder) NewCommandHandler(
 		ch,
 		listSvc,
 		scheduleSvc,
+		templateSvc,
+		exportSvc,
+		calendarSvc,
+		receiptSvc,
+		followupSvc,
+		adminSvc,
 		help,
 	)
 }
@@ -87,10 +111,23 @@ type Plugin struct {
 	Store                  ports.Store
 	Channel                ports.ChannelService
 	Command                command.Interface
+	Importer               ports.ImportService
+	Calendar               ports.CalendarService
+	CalendarImporter       ports.CalendarImportService
+	Admin                  ports.AdminService
+	Consistency            ports.ConsistencyChecker
+	consistencyJob         *cluster.Job
 	defaultMaxUserMessages int
 	helpText               string
 	logger                 ports.Logger
 	poster                 ports.PostService
+	permissions            ports.PermissionService
+	metrics                *metrics.Metrics
+	// cipher seals message content at rest. OnConfigurationChange swaps its
+	// keyring, which may happen before activation.
+	cipher encryption.Box
+	// reencrypting tracks reencryption started by activation and configuration changes.
+	reencrypting sync.WaitGroup
 }
 
 func (p *Plugin) loadHelpText(text string) (string, error) {
@@ -161,6 +198,15 @@ func (p *Plugin) OnActivateWith(
 	clk := clockFactory()
 	p.API.LogDebug("Clock created")
 
+	// Created before migrating so KV errors during migrations are counted.
+	p.metrics = metrics.New()
+
+	if migrateErr := p.runMigrations(); migrateErr != nil {
+		p.API.LogError("Plugin activation failed: could not migrate stored data.", "error", migrateErr.Error())
+		return migrateErr
+	}
+	p.API.LogDebug("Stored data migrated")
+
 	if initErr := p.initialize(botID, clk, builder); initErr != nil {
 		p.API.LogError("Plugin activation failed: could not initialize dependencies.", "error", initErr.Error())
 		return initErr
@@ -171,38 +217,107 @@ func (p *Plugin) OnActivateWith(
 	return nil
 }
 
+func (p *Plugin) runMigrations() error {
+	p.API.LogDebug("Running data migrations")
+	mutex, err := cluster.NewMutex(p.API, constants.MigrationMutexKey)
+	if err != nil {
+		return fmt.Errorf("failed to create migration mutex: %w", err)
+	}
+	runner := migration.NewRunner(&p.client.Log, store.NewInstrumentedKV(&p.client.KV, p.metrics), mm.NewListMatchingService(), mutex, migration.All())
+	return runner.Run()
+}
+
 func (p *Plugin) OnDeactivate() error {
 	p.API.LogInfo("Deactivating Scheduled Messages plugin")
 	if p.Scheduler != nil {
 		p.API.LogDebug("Stopping scheduler")
-		p.Scheduler.Stop()
-		p.API.LogDebug("Scheduler stopped")
+		if err := p.Scheduler.Stop(); err != nil {
+			p.API.LogError("Scheduler did not stop cleanly", "error", err.Error())
+		} else {
+			p.API.LogDebug("Scheduler stopped")
+		}
 	} else {
 		p.API.LogWarn("Scheduler was nil during deactivation")
 	}
+	p.API.LogDebug("Waiting for reencryption to finish")
+	p.reencrypting.Wait()
+	if p.consistencyJob != nil {
+		p.API.LogDebug("Stopping consistency check job")
+		if err := p.consistencyJob.Close(); err != nil {
+			p.API.LogError("Failed to stop consistency check job", "error", err.Error())
+		}
+	}
 	p.API.LogInfo("Scheduled Messages plugin deactivated.")
 	return nil
 }
 
-func (p *Plugin) initialize(botID string, clk ports.Clock, builder AppBuilder) error {
+func (p *Plugin) initialize(botID string, clk ports.TimerClock, builder AppBuilder) error {
 	p.API.LogDebug("Initializing plugin components", "bot_id", botID)
 	p.BotID = botID
 	p.defaultMaxUserMessages = constants.MaxUserMessages
 	p.logger = &p.client.Log
 	p.poster = &p.client.Post
+	p.permissions = &p.client.User
 
 	p.logger.Debug("Initializing Channel service")
 	p.Channel = builder.NewChannel(p.client)
 	p.logger.Debug("Initializing Store service", "max_user_messages", p.defaultMaxUserMessages)
-	p.Store = builder.NewStore(p.client, p.defaultMaxUserMessages)
+	kvStore := builder.NewStore(p.client, p.defaultMaxUserMessages, p.metrics, &p.cipher)
 	p.logger.Debug("Initializing Scheduler service", "bot_id", p.BotID)
-	p.Scheduler = builder.NewScheduler(p.client, p.Store, p.Channel, p.BotID, clk)
+	p.Scheduler = builder.NewScheduler(p.client, kvStore, p.Channel, p.BotID, clk, p.metrics)
+	if policy, err := p.getConfiguration().deliveryPolicy(); err != nil {
+		p.logger.Warn("Invalid delivery configuration, using defaults", "error", err)
+	} else {
+		p.Scheduler.SetPolicy(policy)
+	}
+	// Everything else goes through the observed store so the scheduler's
+	// queue follows saves and deletes.
+	p.Store = store.NewObservedStore(kvStore, p.Scheduler)
+	p.reencrypting.Go(p.reencryptMessages)
 
 	p.logger.Debug("Initializing List service")
 	listService := command.NewListService(p.logger, p.Store, p.Channel)
 
 	p.logger.Debug("Initializing Schedule service", "max_user_messages", p.defaultMaxUserMessages)
-	scheduleService := command.NewScheduleService(p.logger, &p.client.User, p.Store, p.Channel, clk, p.defaultMaxUserMessages)
+	scheduleService := command.NewScheduleService(p.logger, &p.client.User, p.Store, p.Channel, p.poster, clk, p.defaultMaxUserMessages)
+
+	p.logger.Debug("Initializing Template service")
+	templateService := command.NewTemplateService(p.logger, p.Store)
+
+	p.logger.Debug("Initializing Export service")
+	exportService := command.NewExportService(p.logger, p.Store, &p.client.Channel, &p.client.File, p.poster, p.BotID, clk)
+
+	p.logger.Debug("Initializing Import service", "max_user_messages", p.defaultMaxUserMessages)
+	p.Importer = command.NewImportService(p.logger, p.Store, p.Channel, p.poster, clk, p.defaultMaxUserMessages)
+
+	p.logger.Debug("Initializing Calendar service")
+	p.Calendar = command.NewCalendarService(p.logger, p.Store, p.Channel, &p.client.Configuration, clk)
+
+	p.logger.Debug("Initializing Receipt service")
+	receiptService := command.NewReceiptService(p.logger, p.Store)
+
+	p.logger.Debug("Initializing Follow-up service", "max_user_messages", p.defaultMaxUserMessages)
+	followupService := command.NewFollowupService(p.logger, &p.client.User, p.Store, p.poster, p.Channel, clk, p.defaultMaxUserMessages)
+
+	p.logger.Debug("Initializing Calendar import service", "max_user_messages", p.defaultMaxUserMessages)
+	p.CalendarImporter = command.NewCalendarImportService(
+		p.logger,
+		p.Store,
+		p.Channel,
+		&p.client.Channel,
+		&p.client.File,
+		p.poster,
+		&p.client.User,
+		p.BotID,
+		clk,
+		p.defaultMaxUserMessages,
+	)
+
+	p.logger.Debug("Initializing Consistency checker")
+	p.Consistency = consistency.NewChecker(p.logger, p.Store)
+
+	p.logger.Debug("Initializing Admin service")
+	p.Admin = command.NewAdminService(p.logger, p.Store, p.Scheduler, p.Consistency, p.permissions, &p.cipher, p.BotID, clk, p.defaultMaxUserMessages)
 
 	p.logger.Debug("Initializing Command handler")
 	p.Command = builder.NewCommandHandler(
@@ -211,6 +326,12 @@ func (p *Plugin) initialize(botID string, clk ports.Clock, builder AppBuilder) e
 		p.Channel,
 		listService,
 		scheduleService,
+		templateService,
+		exportService,
+		p.Calendar,
+		receiptService,
+		followupService,
+		p.Admin,
 		p.helpText,
 	)
 
@@ -221,6 +342,14 @@ func (p *Plugin) initialize(botID string, clk ports.Clock, builder AppBuilder) e
 	}
 	p.logger.Debug("Command handler registered successfully")
 
+	p.logger.Debug("Scheduling consistency check job", "interval_minutes", constants.ConsistencyCheckIntervalMinutes)
+	job, err := cluster.Schedule(p.API, constants.ConsistencyJobKey, cluster.MakeWaitForRoundedInterval(constants.ConsistencyCheckIntervalMinutes*time.Minute), p.runConsistencyCheck)
+	if err != nil {
+		p.logger.Error("Failed to schedule consistency check job", "error", err)
+		return err
+	}
+	p.consistencyJob = job
+
 	p.logger.Info("Starting scheduler goroutine")
 	go p.Scheduler.Start()
 
@@ -228,6 +357,49 @@ func (p *Plugin) initialize(botID string, clk ports.Clock, builder AppBuilder) e
 	return nil
 }
 
+func (p *Plugin) runConsistencyCheck() {
+	report, err := p.Consistency.Repair()
+	if err != nil {
+		p.logger.Error("Scheduled consistency check failed", "error", err)
+		return
+	}
+	if len(report.Repaired) > 0 || len(report.Failed) > 0 {
+		p.logger.Warn("Scheduled consistency check found drift", "repaired", len(report.Repaired), "failed", len(report.Failed))
+	}
+}
+
+// reencryptMessages brings stored messages in line with the configured
+// encryption key, unless the last complete run already used it. Failures are
+// logged; affected messages keep their old encryption and are retried on the
+// next configuration change or activation. Every node sees the same
+// configuration change, so the cluster mutex keeps them from rewriting the
+// same records at once, and the ones that wait find the work already done.
+func (p *Plugin) reencryptMessages() {
+	mutex, err := cluster.NewMutex(p.API, constants.ReencryptMutexKey)
+	if err != nil {
+		p.logger.Error("Failed to create reencryption mutex", "error", err)
+		return
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	needed, err := p.Store.NeedsReencryption()
+	if err != nil {
+		p.logger.Error("Failed to check whether scheduled messages need reencryption", "error", err)
+		return
+	}
+	if !needed {
+		p.logger.Debug("Scheduled messages already use the configured encryption key")
+		return
+	}
+	rewritten, err := p.Store.ReencryptMessages()
+	if err != nil {
+		p.logger.Error("Failed to reencrypt some scheduled messages", "rewritten", rewritten, "error", err)
+		return
+	}
+	p.logger.Info("Reencrypted scheduled messages", "rewritten", rewritten)
+}
+
 func (p *Plugin) ExecuteCommand(_ *plugin.Context, args *model.CommandArgs) (*model.CommandResponse, *model.AppError) {
 	p.logger.Debug("ExecuteCommand hook triggered", "user_id", args.UserId, "channel_id", args.ChannelId, "command", args.Command)
 	resp, appErr := p.Command.Execute(args)
@@ -238,3 +410,11 @@ func (p *Plugin) ExecuteCommand(_ *plugin.Context, args *model.CommandArgs) (*mo
 	}
 	return resp, appErr
 }
+
+func (p *Plugin) MessageHasBeenPosted(_ *plugin.Context, post *model.Post) {
+	if p.CalendarImporter == nil || len(post.FileIds) == 0 {
+		return
+	}
+	p.logger.Debug("MessageHasBeenPosted hook triggered for post with files", "user_id", post.UserId, "channel_id", post.ChannelId, "post_id", post.Id)
+	p.CalendarImporter.ImportAttachments(post)
+}
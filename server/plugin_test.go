@@ -4,25 +4,44 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	mocks "github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/adapters/mock"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/testutil"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
 	"github.com/mattermost/mattermost/server/public/pluginapi"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
 )
 
+// allowLogs accepts log calls with a message plus any number of key/value pairs.
+func allowLogs(api *plugintest.API) {
+	for pairs := 0; pairs <= 10; pairs++ {
+		args := make([]any, 1+2*pairs)
+		for i := range args {
+			args[i] = mock.Anything
+		}
+		for _, level := range []string{"LogDebug", "LogInfo", "LogWarn", "LogError"} {
+			api.On(level, args...).Maybe()
+		}
+	}
+}
+
 func pluginTestAPI() *plugintest.API {
 	api := &plugintest.API{}
-	api.On("LogDebug", mock.Anything).Maybe()
-	api.On("LogInfo", mock.Anything).Maybe()
-	api.On("LogWarn", mock.Anything).Maybe()
-	api.On("LogDebug", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
-	api.On("LogError", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+	allowLogs(api)
+	// Activation runs migrations and schedules the consistency check job
+	// against an empty KV store.
+	api.On("KVSetWithOptions", mock.Anything, mock.Anything, mock.Anything).Return(true, nil).Maybe()
+	api.On("KVGet", mock.Anything).Return(nil, nil).Maybe()
+	api.On("KVList", mock.Anything, mock.Anything).Return([]string{}, nil).Maybe()
 	return api
 }
 
@@ -76,7 +95,7 @@ func TestOnActivateWithSuccess(t *testing.T) {
 	api := pluginTestAPI()
 	api.On("RegisterCommand", mock.Anything).Return(nil)
 
-	clk := func() ports.Clock { return testutil.FakeClock{NowTime: time.Now()} }
+	clk := func() ports.TimerClock { return testutil.NewManualClock(time.Now()) }
 
 	pl := &Plugin{}
 	pl.API = api
@@ -104,6 +123,8 @@ func TestOnActivateWithSuccess(t *testing.T) {
 	require.Equal(t, constants.MaxUserMessages, pl.defaultMaxUserMessages)
 	require.Equal(t, &pl.client.Log, pl.logger)
 	require.Equal(t, &pl.client.Post, pl.poster)
+	require.NotNil(t, pl.Consistency)
+	require.NotNil(t, pl.consistencyJob)
 	require.NoError(t, pl.OnDeactivate())
 }
 
@@ -119,7 +140,7 @@ func TestOnActivateWithBotError(t *testing.T) {
 	}
 
 	err := pl.OnActivateWith(pluginapi.NewClient,
-		func() ports.Clock { return testutil.FakeClock{NowTime: time.Now()} },
+		func() ports.TimerClock { return testutil.NewManualClock(time.Now()) },
 		nil,
 		stubErr,
 		"help")
@@ -139,13 +160,36 @@ func TestOnActivateHelpTextError(t *testing.T) {
 	}
 
 	err := p.OnActivateWith(pluginapi.NewClient,
-		func() ports.Clock { return testutil.FakeClock{NowTime: time.Now()} },
+		func() ports.TimerClock { return testutil.NewManualClock(time.Now()) },
 		nil,
 		stubOK,
 		"")
 	require.Error(t, err)
 }
 
+func TestOnActivateWithMigrationError(t *testing.T) {
+	api := &plugintest.API{}
+	allowLogs(api)
+	api.On("KVSetWithOptions", mock.Anything, mock.Anything, mock.Anything).Return(true, nil).Maybe()
+	api.On("KVGet", constants.SchemaVersionKey).Return(nil, model.NewAppError("KVGet", "kv.down", nil, "", 500))
+
+	pl := &Plugin{}
+	pl.API = api
+	pl.Driver = &plugintest.Driver{}
+
+	stubOK := func(ports.BotService, ports.BotProfileImageService) (string, error) {
+		return "bot-id", nil
+	}
+
+	err := pl.OnActivateWith(pluginapi.NewClient,
+		func() ports.TimerClock { return testutil.NewManualClock(time.Now()) },
+		nil,
+		stubOK,
+		"help")
+	require.ErrorContains(t, err, "failed to read schema version")
+	require.Nil(t, pl.Scheduler)
+}
+
 func TestOnActivateWithRegisterError(t *testing.T) {
 	api := pluginTestAPI()
 	api.On("RegisterCommand", mock.Anything).Return(errors.New("register-fail"))
@@ -159,9 +203,168 @@ func TestOnActivateWithRegisterError(t *testing.T) {
 	}
 
 	err := pl.OnActivateWith(pluginapi.NewClient,
-		func() ports.Clock { return testutil.FakeClock{NowTime: time.Now()} },
+		func() ports.TimerClock { return testutil.NewManualClock(time.Now()) },
 		nil,
 		stubOK,
 		"help")
 	require.Error(t, err)
 }
+
+func TestMessageHasBeenPosted_ForwardsPostsWithFiles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	importer := mocks.NewMockCalendarImportService(ctrl)
+	pl := &Plugin{logger: &testutil.FakeLogger{}, CalendarImporter: importer}
+	post := &model.Post{Id: "p1", UserId: "u1", ChannelId: "dm", FileIds: []string{"f1"}}
+
+	importer.EXPECT().ImportAttachments(post)
+
+	pl.MessageHasBeenPosted(nil, post)
+}
+
+func TestMessageHasBeenPosted_IgnoresPostsWithoutFiles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	importer := mocks.NewMockCalendarImportService(ctrl)
+	pl := &Plugin{logger: &testutil.FakeLogger{}, CalendarImporter: importer}
+
+	pl.MessageHasBeenPosted(nil, &model.Post{Id: "p1", UserId: "u1", ChannelId: "dm"})
+}
+
+func TestRunConsistencyCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	checker := mocks.NewMockConsistencyChecker(ctrl)
+	pl := &Plugin{logger: &testutil.FakeLogger{}, Consistency: checker}
+
+	checker.EXPECT().Repair().Return(&types.ConsistencyReport{Repaired: []types.ConsistencyIssue{{MessageID: "m1"}}}, nil)
+	pl.runConsistencyCheck()
+
+	checker.EXPECT().Repair().Return(nil, errors.New("kv down"))
+	pl.runConsistencyCheck()
+}
+
+func configAPI(cfg configuration) *plugintest.API {
+	api := pluginTestAPI()
+	api.On("LoadPluginConfiguration", mock.AnythingOfType("*main.configuration")).Run(func(args mock.Arguments) {
+		*args.Get(0).(*configuration) = cfg
+	}).Return(nil)
+	return api
+}
+
+func TestOnConfigurationChange_SetsEncryptionKey(t *testing.T) {
+	pl := &Plugin{}
+	pl.API = configAPI(configuration{EncryptionKey: strings.Repeat("k", 32)})
+
+	require.NoError(t, pl.OnConfigurationChange())
+
+	require.NotEmpty(t, pl.cipher.KeyID())
+	require.Equal(t, strings.Repeat("k", 32), pl.getConfiguration().EncryptionKey)
+}
+
+func TestOnConfigurationChange_ReencryptsAfterActivation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := mocks.NewMockStore(ctrl)
+	pl := &Plugin{logger: &testutil.FakeLogger{}, Store: st}
+	pl.API = configAPI(configuration{EncryptionKey: strings.Repeat("n", 32), PreviousEncryptionKeys: strings.Repeat("o", 32) + "\n"})
+
+	st.EXPECT().NeedsReencryption().Return(true, nil)
+	st.EXPECT().ReencryptMessages().Return(3, nil)
+
+	require.NoError(t, pl.OnConfigurationChange())
+	pl.reencrypting.Wait()
+}
+
+func TestReencryptMessages_SkipsWhenAlreadyDone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := mocks.NewMockStore(ctrl)
+	pl := &Plugin{logger: &testutil.FakeLogger{}, Store: st}
+	pl.API = pluginTestAPI()
+
+	// Another node finished while this one waited for the mutex, or the keys
+	// are the same as at the last activation.
+	st.EXPECT().NeedsReencryption().Return(false, nil)
+
+	pl.reencryptMessages()
+}
+
+func TestOnConfigurationChange_SkipsReencryptionWhenKeysAreUnchanged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := mocks.NewMockStore(ctrl)
+	pl := &Plugin{logger: &testutil.FakeLogger{}, Store: st}
+	pl.API = configAPI(configuration{EncryptionKey: strings.Repeat("n", 32), LateThresholdMinutes: model.NewPointer(5)})
+
+	st.EXPECT().NeedsReencryption().Return(true, nil).Times(1)
+	st.EXPECT().ReencryptMessages().Return(3, nil).Times(1)
+
+	require.NoError(t, pl.OnConfigurationChange())
+	pl.reencrypting.Wait()
+	// Saving an unrelated setting leaves stored messages alone.
+	pl.API = configAPI(configuration{EncryptionKey: strings.Repeat("n", 32), LateThresholdMinutes: model.NewPointer(10)})
+	require.NoError(t, pl.OnConfigurationChange())
+	pl.reencrypting.Wait()
+}
+
+func TestOnConfigurationChange_RejectsInvalidKey(t *testing.T) {
+	pl := &Plugin{}
+	pl.API = configAPI(configuration{EncryptionKey: "short"})
+
+	err := pl.OnConfigurationChange()
+
+	require.EqualError(t, err, "invalid encryption configuration: encryption key must be at least 32 characters")
+	require.Empty(t, pl.cipher.KeyID())
+}
+
+func TestOnDeactivate_SchedulerStopTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sched := mocks.NewMockScheduler(ctrl)
+	pl := &Plugin{Scheduler: sched}
+	pl.API = pluginTestAPI()
+
+	sched.EXPECT().Stop().Return(errors.New("timed out after 30s waiting for in-flight deliveries"))
+
+	require.NoError(t, pl.OnDeactivate())
+}
+
+func TestConfigurationDeliveryPolicy(t *testing.T) {
+	policy, err := (&configuration{}).deliveryPolicy()
+	require.NoError(t, err)
+	require.Equal(t, types.DeliveryPolicy{LatePolicy: constants.LatePolicySend, LateThreshold: 15 * time.Minute, Attribution: constants.AttributionUser}, policy)
+
+	policy, err = (&configuration{LatePolicy: constants.LatePolicySkip, LateThresholdMinutes: model.NewPointer(60)}).deliveryPolicy()
+	require.NoError(t, err)
+	require.Equal(t, types.DeliveryPolicy{LatePolicy: constants.LatePolicySkip, LateThreshold: time.Hour, Attribution: constants.AttributionUser}, policy)
+
+	policy, err = (&configuration{MaxChannelPostsPerMinute: 5, MaxPostsPerMinute: 60, DefaultAttribution: constants.AttributionBot, ShowScheduledIndicator: true}).deliveryPolicy()
+	require.NoError(t, err)
+	require.Equal(t, 5, policy.ChannelRateLimit)
+	require.Equal(t, 60, policy.GlobalRateLimit)
+	require.Equal(t, constants.AttributionBot, policy.Attribution)
+	require.True(t, policy.ScheduledIndicator)
+
+	_, err = (&configuration{LatePolicy: "drop"}).deliveryPolicy()
+	require.EqualError(t, err, `unknown late message policy "drop"`)
+
+	_, err = (&configuration{DefaultAttribution: "admin"}).deliveryPolicy()
+	require.EqualError(t, err, `unknown default attribution "admin"`)
+
+	_, err = (&configuration{MaxPostsPerMinute: -1}).deliveryPolicy()
+	require.EqualError(t, err, "posts per minute limits must not be negative")
+}
+
+func TestConfigurationDeliveryPolicy_LateThreshold(t *testing.T) {
+	policy, err := (&configuration{LateThresholdMinutes: model.NewPointer(0)}).deliveryPolicy()
+	require.NoError(t, err)
+	require.Equal(t, time.Duration(0), policy.LateThreshold)
+
+	_, err = (&configuration{LateThresholdMinutes: model.NewPointer(-5)}).deliveryPolicy()
+	require.EqualError(t, err, "late threshold must not be negative")
+}
+
+func TestOnConfigurationChange_SetsSchedulerPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sched := mocks.NewMockScheduler(ctrl)
+	pl := &Plugin{Scheduler: sched}
+	pl.API = configAPI(configuration{LatePolicy: constants.LatePolicyAnnotate, LateThresholdMinutes: model.NewPointer(30)})
+
+	sched.EXPECT().SetPolicy(types.DeliveryPolicy{LatePolicy: constants.LatePolicyAnnotate, LateThreshold: 30 * time.Minute, Attribution: constants.AttributionUser})
+
+	require.NoError(t, pl.OnConfigurationChange())
+}
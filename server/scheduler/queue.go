@@ -0,0 +1,132 @@
+package scheduler
+
+import (
+	"container/heap"
+	"time"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+)
+
+type queueItem struct {
+	id       string
+	dueAt    time.Time
+	sequence int64
+	// seq orders insertions so a resync can tell which entries were
+	// added while it was reading the store.
+	seq   uint64
+	index int
+}
+
+// queue is a min-heap of upcoming deliveries and heads-ups in delivery
+// order, keyed by message ID. It is not safe for concurrent use.
+type queue struct {
+	items []*queueItem
+	byID  map[string]*queueItem
+	seq   uint64
+}
+
+func newQueue() *queue {
+	return &queue{byID: map[string]*queueItem{}}
+}
+
+func (q *queue) Len() int { return len(q.items) }
+
+// Less matches ScheduledMessage.DeliversBefore.
+func (q *queue) Less(i, j int) bool {
+	a, b := q.items[i], q.items[j]
+	if a.dueAt.Unix() != b.dueAt.Unix() {
+		return a.dueAt.Unix() < b.dueAt.Unix()
+	}
+	if a.sequence != b.sequence {
+		return a.sequence < b.sequence
+	}
+	return a.id < b.id
+}
+
+func (q *queue) Swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+	q.items[i].index = i
+	q.items[j].index = j
+}
+
+func (q *queue) Push(x any) {
+	item := x.(*queueItem)
+	item.index = len(q.items)
+	q.items = append(q.items, item)
+}
+
+func (q *queue) Pop() any {
+	last := len(q.items) - 1
+	item := q.items[last]
+	q.items[last] = nil
+	q.items = q.items[:last]
+	return item
+}
+
+// upsert adds a message or moves it to a new NextActionAt.
+func (q *queue) upsert(msg *types.ScheduledMessage) {
+	q.seq++
+	if item, ok := q.byID[msg.ID]; ok {
+		item.dueAt = msg.NextActionAt()
+		item.sequence = msg.Sequence
+		item.seq = q.seq
+		heap.Fix(q, item.index)
+		return
+	}
+	item := &queueItem{id: msg.ID, dueAt: msg.NextActionAt(), sequence: msg.Sequence, seq: q.seq}
+	q.byID[msg.ID] = item
+	heap.Push(q, item)
+}
+
+func (q *queue) remove(id string) {
+	item, ok := q.byID[id]
+	if !ok {
+		return
+	}
+	heap.Remove(q, item.index)
+	delete(q.byID, id)
+}
+
+// next returns the earliest NextActionAt, if any.
+func (q *queue) next() (time.Time, bool) {
+	if len(q.items) == 0 {
+		return time.Time{}, false
+	}
+	return q.items[0].dueAt, true
+}
+
+// popDue removes and returns the IDs of messages due at now, in delivery
+// order. Like the full scan, due is compared to the second.
+func (q *queue) popDue(now time.Time) []string {
+	var ids []string
+	for len(q.items) > 0 && q.items[0].dueAt.Unix() <= now.Unix() {
+		item := heap.Pop(q).(*queueItem)
+		delete(q.byID, item.id)
+		ids = append(ids, item.id)
+	}
+	return ids
+}
+
+// mark returns a position in the insertion order for a later sync.
+func (q *queue) mark() uint64 {
+	return q.seq
+}
+
+// sync replaces the queue with msgs, which were read from the store after
+// mark was taken. Entries added since then are kept, as the store read may
+// have missed them.
+func (q *queue) sync(msgs []*types.ScheduledMessage, mark uint64) {
+	listed := make(map[string]bool, len(msgs))
+	for _, msg := range msgs {
+		listed[msg.ID] = true
+		if item, ok := q.byID[msg.ID]; ok && item.seq > mark {
+			continue
+		}
+		q.upsert(msg)
+	}
+	for id, item := range q.byID {
+		if !listed[id] && item.seq <= mark {
+			q.remove(id)
+		}
+	}
+}
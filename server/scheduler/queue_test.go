@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var queueBase = time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+func TestQueue_PopDueInOrder(t *testing.T) {
+	q := newQueue()
+	q.upsert(&types.ScheduledMessage{ID: "c", PostAt: queueBase.Add(3 * time.Second)})
+	q.upsert(&types.ScheduledMessage{ID: "a", PostAt: queueBase.Add(time.Second)})
+	q.upsert(&types.ScheduledMessage{ID: "b", PostAt: queueBase.Add(time.Second)})
+	q.upsert(&types.ScheduledMessage{ID: "later", PostAt: queueBase.Add(time.Hour)})
+
+	next, ok := q.next()
+	require.True(t, ok)
+	assert.Equal(t, queueBase.Add(time.Second), next)
+	assert.Equal(t, []string{"a", "b", "c"}, q.popDue(queueBase.Add(3*time.Second)))
+	assert.Equal(t, 1, q.Len())
+}
+
+func TestQueue_PopDueInScheduledOrder(t *testing.T) {
+	q := newQueue()
+	q.upsert(&types.ScheduledMessage{ID: "a", PostAt: queueBase, Sequence: 3})
+	q.upsert(&types.ScheduledMessage{ID: "b", PostAt: queueBase.Add(500 * time.Millisecond), Sequence: 1})
+	q.upsert(&types.ScheduledMessage{ID: "c", PostAt: queueBase, Sequence: 2})
+
+	assert.Equal(t, []string{"b", "c", "a"}, q.popDue(queueBase))
+}
+
+func TestQueue_PopDueComparesSeconds(t *testing.T) {
+	q := newQueue()
+	q.upsert(&types.ScheduledMessage{ID: "a", PostAt: queueBase.Add(1500 * time.Millisecond)})
+
+	assert.Empty(t, q.popDue(queueBase))
+	assert.Equal(t, []string{"a"}, q.popDue(queueBase.Add(time.Second)))
+}
+
+func TestQueue_UpsertMovesAndRemoveDrops(t *testing.T) {
+	q := newQueue()
+	q.upsert(&types.ScheduledMessage{ID: "a", PostAt: queueBase})
+	q.upsert(&types.ScheduledMessage{ID: "b", PostAt: queueBase.Add(time.Minute)})
+
+	q.upsert(&types.ScheduledMessage{ID: "a", PostAt: queueBase.Add(time.Hour)})
+	next, _ := q.next()
+	assert.Equal(t, queueBase.Add(time.Minute), next)
+
+	q.remove("b")
+	q.remove("missing")
+	next, _ = q.next()
+	assert.Equal(t, queueBase.Add(time.Hour), next)
+	assert.Equal(t, 1, q.Len())
+
+	q.remove("a")
+	_, ok := q.next()
+	assert.False(t, ok)
+}
+
+func TestQueue_SyncKeepsEntriesAddedAfterMark(t *testing.T) {
+	q := newQueue()
+	q.upsert(&types.ScheduledMessage{ID: "stale", PostAt: queueBase})
+	q.upsert(&types.ScheduledMessage{ID: "moved", PostAt: queueBase})
+	mark := q.mark()
+	q.upsert(&types.ScheduledMessage{ID: "new", PostAt: queueBase.Add(time.Minute)})
+
+	q.sync([]*types.ScheduledMessage{
+		{ID: "moved", PostAt: queueBase.Add(2 * time.Minute)},
+		{ID: "listed", PostAt: queueBase.Add(3 * time.Minute)},
+	}, mark)
+
+	assert.Equal(t, []string{"new", "moved", "listed"}, q.popDue(queueBase.Add(time.Hour)))
+}
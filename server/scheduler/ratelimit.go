@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+)
+
+// rateLimiter counts recent posts per channel and overall over a sliding
+// window. It is not safe for concurrent use.
+type rateLimiter struct {
+	window   time.Duration
+	global   []time.Time
+	channels map[string][]time.Time
+	// blocked holds channels with a deferred message until it is due again,
+	// so later messages to them cannot overtake it.
+	blocked map[string]time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		window:   constants.RateLimitWindowSeconds * time.Second,
+		channels: map[string][]time.Time{},
+		blocked:  map[string]time.Time{},
+	}
+}
+
+// reserve records one post to each channel at now if that keeps within the
+// limits, where zero means no limit. Otherwise it records nothing and
+// returns when to try again. A message with more destinations than a limit
+// allows is let through once the window is empty, rather than held forever.
+func (r *rateLimiter) reserve(channelIDs []string, now time.Time, perChannel, global int) (time.Time, bool) {
+	r.prune(now)
+	var retryAt time.Time
+	for _, channelID := range channelIDs {
+		if until, ok := r.blocked[channelID]; ok && until.After(retryAt) {
+			retryAt = until
+		}
+		if at, ok := r.retryAt(r.channels[channelID], 1, perChannel); !ok && at.After(retryAt) {
+			retryAt = at
+		}
+	}
+	if at, ok := r.retryAt(r.global, len(channelIDs), global); !ok && at.After(retryAt) {
+		retryAt = at
+	}
+	if !retryAt.IsZero() {
+		return retryAt, false
+	}
+	for _, channelID := range channelIDs {
+		r.channels[channelID] = append(r.channels[channelID], now)
+		r.global = append(r.global, now)
+	}
+	return time.Time{}, true
+}
+
+// block holds channelIDs until the given time.
+func (r *rateLimiter) block(channelIDs []string, until time.Time) {
+	for _, channelID := range channelIDs {
+		if until.After(r.blocked[channelID]) {
+			r.blocked[channelID] = until
+		}
+	}
+}
+
+// retryAt reports whether n more posts fit alongside sent, and if not, when
+// enough of sent will have left the window.
+func (r *rateLimiter) retryAt(sent []time.Time, n, limit int) (time.Time, bool) {
+	if limit <= 0 || len(sent) == 0 || len(sent)+n <= limit {
+		return time.Time{}, true
+	}
+	expire := min(len(sent)+n-limit, len(sent)) - 1
+	return sent[expire].Add(r.window), false
+}
+
+// prune drops posts older than the window and expired blocks.
+func (r *rateLimiter) prune(now time.Time) {
+	cutoff := now.Add(-r.window)
+	r.global = dropBefore(r.global, cutoff)
+	for channelID, sent := range r.channels {
+		if sent = dropBefore(sent, cutoff); len(sent) == 0 {
+			delete(r.channels, channelID)
+		} else {
+			r.channels[channelID] = sent
+		}
+	}
+	for channelID, until := range r.blocked {
+		if !until.After(now) {
+			delete(r.blocked, channelID)
+		}
+	}
+}
+
+func dropBefore(times []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(times) && !times[i].After(cutoff) {
+		i++
+	}
+	return times[i:]
+}
@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var limitBase = time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+func TestRateLimiter_ChannelLimit(t *testing.T) {
+	r := newRateLimiter()
+
+	_, ok := r.reserve([]string{"a"}, limitBase, 2, 0)
+	require.True(t, ok)
+	_, ok = r.reserve([]string{"a"}, limitBase.Add(10*time.Second), 2, 0)
+	require.True(t, ok)
+	_, ok = r.reserve([]string{"b"}, limitBase.Add(20*time.Second), 2, 0)
+	require.True(t, ok)
+
+	retryAt, ok := r.reserve([]string{"a"}, limitBase.Add(20*time.Second), 2, 0)
+	assert.False(t, ok)
+	assert.Equal(t, limitBase.Add(time.Minute), retryAt)
+
+	_, ok = r.reserve([]string{"a"}, limitBase.Add(time.Minute), 2, 0)
+	assert.True(t, ok)
+}
+
+func TestRateLimiter_GlobalLimitCountsDestinations(t *testing.T) {
+	r := newRateLimiter()
+
+	_, ok := r.reserve([]string{"a"}, limitBase, 0, 3)
+	require.True(t, ok)
+	_, ok = r.reserve([]string{"b"}, limitBase.Add(5*time.Second), 0, 3)
+	require.True(t, ok)
+
+	// Two more posts only fit once the first has left the window.
+	retryAt, ok := r.reserve([]string{"c", "d"}, limitBase.Add(10*time.Second), 0, 3)
+	assert.False(t, ok)
+	assert.Equal(t, limitBase.Add(time.Minute), retryAt)
+}
+
+func TestRateLimiter_OversizedMessageWaitsForEmptyWindow(t *testing.T) {
+	r := newRateLimiter()
+
+	_, ok := r.reserve([]string{"a"}, limitBase, 0, 2)
+	require.True(t, ok)
+	retryAt, ok := r.reserve([]string{"b", "c", "d"}, limitBase.Add(time.Second), 0, 2)
+	require.False(t, ok)
+	assert.Equal(t, limitBase.Add(time.Minute), retryAt)
+
+	_, ok = r.reserve([]string{"b", "c", "d"}, retryAt, 0, 2)
+	assert.True(t, ok)
+}
+
+func TestRateLimiter_BlockHoldsChannel(t *testing.T) {
+	r := newRateLimiter()
+	r.block([]string{"a"}, limitBase.Add(30*time.Second))
+
+	retryAt, ok := r.reserve([]string{"a"}, limitBase, 0, 0)
+	assert.False(t, ok)
+	assert.Equal(t, limitBase.Add(30*time.Second), retryAt)
+	_, ok = r.reserve([]string{"b"}, limitBase, 0, 0)
+	assert.True(t, ok)
+
+	_, ok = r.reserve([]string{"a"}, limitBase.Add(30*time.Second), 0, 0)
+	assert.True(t, ok)
+}
+
+func TestRateLimiter_NoLimits(t *testing.T) {
+	r := newRateLimiter()
+	for i := range 100 {
+		_, ok := r.reserve([]string{"a"}, limitBase.Add(time.Duration(i)*time.Millisecond), 0, 0)
+		require.True(t, ok)
+	}
+}
@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/formatter"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// deliveryReceipt records a message posted during a pass, for its author's
+// receipt.
+type deliveryReceipt struct {
+	msg         *types.ScheduledMessage
+	posted      []types.DeliveryResult
+	deliveredAt time.Time
+}
+
+// sendReceipts DMs each author one receipt for the messages posted during
+// the pass, then clears them. Messages without their own receipt setting
+// follow the author's preference. Callers must hold mu.
+func (s *Scheduler) sendReceipts() {
+	if len(s.receipts) == 0 {
+		return
+	}
+	userIDs := make([]string, 0, len(s.receipts))
+	for userID := range s.receipts {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+	siteURL := ""
+	for _, userID := range userIDs {
+		entries := s.receiptEntries(userID, s.receipts[userID])
+		if len(entries) == 0 {
+			s.logger.Debug("User does not want receipts for the messages posted", "user_id", userID, "messages", len(s.receipts[userID]))
+			continue
+		}
+		if siteURL == "" {
+			siteURL = s.siteURL()
+		}
+		s.sendReceipt(userID, entries, siteURL)
+	}
+	clear(s.receipts)
+}
+
+// receiptEntries returns the deliveries the author wants a receipt for.
+func (s *Scheduler) receiptEntries(userID string, receipts []deliveryReceipt) []deliveryReceipt {
+	var entries []deliveryReceipt
+	preference, checked := false, false
+	for _, receipt := range receipts {
+		if receipt.msg.Receipt != constants.ReceiptOn {
+			if !checked {
+				enabled, err := s.store.GetReceiptPreference(userID)
+				if err != nil {
+					s.logger.Error("Failed to get receipt preference, not sending receipts that rely on it", "user_id", userID, "error", err)
+				}
+				preference, checked = enabled, true
+			}
+			if !preference {
+				continue
+			}
+		}
+		entries = append(entries, receipt)
+	}
+	return entries
+}
+
+func (s *Scheduler) sendReceipt(userID string, receipts []deliveryReceipt, siteURL string) {
+	var lines []string
+	for _, receipt := range receipts {
+		delay := receipt.deliveredAt.Sub(receipt.msg.PostAt)
+		for _, result := range receipt.posted {
+			channelLink := s.linker.MakeChannelLink(s.linker.GetInfoOrUnknown(result.ChannelID))
+			lines = append(lines, formatter.FormatReceiptEntry(siteURL+constants.ReceiptPermalinkPath+result.PostID, channelLink, delay))
+		}
+	}
+	s.logger.Debug("Sending delivery receipt", "user_id", userID, "posts", len(lines))
+	post := &model.Post{
+		Message: formatter.FormatDeliveryReceipt(lines),
+	}
+	if err := s.poster.DM(s.botID, userID, post); err != nil {
+		s.logger.Error("Failed to send delivery receipt DM", "user_id", userID, "error", err)
+	} else {
+		s.logger.Debug("Successfully sent delivery receipt DM", "user_id", userID)
+	}
+}
+
+// siteURL returns the server's Site URL without a trailing slash. Without
+// one, receipts fall back to links relative to the server.
+func (s *Scheduler) siteURL() string {
+	if cfg := s.config.GetConfig(); cfg != nil && cfg.ServiceSettings.SiteURL != nil && *cfg.ServiceSettings.SiteURL != "" {
+		return strings.TrimSuffix(*cfg.ServiceSettings.SiteURL, "/")
+	}
+	s.logger.Warn("Site URL is not configured, delivery receipts will use relative links")
+	return ""
+}
@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/formatter"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// threadReplied reports whether anyone other than the author, or only the
+// users in ReplyFrom if set, has posted in the thread of a message
+// scheduled "unless replied" since it was scheduled. Posts the bot made on
+// someone's behalf count as theirs, and system messages do not count. If
+// the thread cannot be read, the message is posted.
+func (s *Scheduler) threadReplied(msg *types.ScheduledMessage) bool {
+	if msg.CancelIfRepliedAfter.IsZero() || msg.RootID == "" {
+		return false
+	}
+	thread, err := s.poster.GetPostThread(msg.RootID)
+	if err != nil {
+		s.logger.Warn("Failed to read thread for unless replied check, posting message", "message_id", msg.ID, "root_id", msg.RootID, "error", err)
+		return false
+	}
+	since := msg.CancelIfRepliedAfter.UnixMilli()
+	for _, post := range thread.Posts {
+		if post.CreateAt <= since || post.DeleteAt != 0 || post.IsSystemMessage() {
+			continue
+		}
+		author := post.UserId
+		if onBehalfOf, _ := post.GetProp(constants.PropOnBehalfOfUserID).(string); onBehalfOf != "" {
+			author = onBehalfOf
+		}
+		if author == msg.UserID || (len(msg.ReplyFrom) > 0 && !slices.Contains(msg.ReplyFrom, author)) {
+			continue
+		}
+		s.logger.Debug("Thread has a reply since the message was scheduled", "message_id", msg.ID, "root_id", msg.RootID, "post_id", post.Id, "post_user_id", author)
+		return true
+	}
+	s.logger.Debug("Thread has no replies since the message was scheduled", "message_id", msg.ID, "root_id", msg.RootID)
+	return false
+}
+
+// cancelReplied deletes a message whose thread got a reply without posting
+// it, and tells the author. The author is told whatever their receipt
+// setting, since a cancelled message is one they expected to be posted.
+func (s *Scheduler) cancelReplied(msg *types.ScheduledMessage) {
+	if !s.begin() {
+		s.logger.Warn("Scheduler stopped, not cancelling message", "message_id", msg.ID)
+		return
+	}
+	defer s.active.Done()
+	s.logger.Info("Cancelling message, its thread got a reply", "message_id", msg.ID, "user_id", msg.UserID, "root_id", msg.RootID)
+	s.MessageUnscheduled(msg.ID)
+	if err := s.deleteSchedule(msg); err != nil {
+		return
+	}
+	channelLink := s.linker.MakeChannelLink(s.linker.GetInfoOrUnknown(msg.ChannelID))
+	post := &model.Post{
+		Message: formatter.FormatCancelledReplied(channelLink, msg.MessageContent),
+	}
+	if msg.Followup != "" {
+		post.Message = formatter.FormatFollowupAnswered(channelLink)
+	}
+	if err := s.poster.DM(s.botID, msg.UserID, post); err != nil {
+		s.logger.Error("Failed to send DM about cancelled message", "message_id", msg.ID, "user_id", msg.UserID, "error", err)
+	} else {
+		s.logger.Debug("Successfully sent cancelled message DM", "message_id", msg.ID, "user_id", msg.UserID)
+	}
+}
+
+// remind sends a follow-up that is due by DM to its author, with a link to
+// the thread, instead of posting it, and removes it from storage.
+func (s *Scheduler) remind(msg *types.ScheduledMessage) error {
+	if !s.begin() {
+		s.logger.Warn("Scheduler stopped, not sending follow-up reminder", "message_id", msg.ID)
+		return errors.New("scheduler is stopped")
+	}
+	defer s.active.Done()
+	s.logger.Info("Sending follow-up reminder", "message_id", msg.ID, "user_id", msg.UserID, "root_id", msg.RootID)
+	s.MessageUnscheduled(msg.ID)
+	if err := s.deleteSchedule(msg); err != nil {
+		return err
+	}
+	channelLink := s.linker.MakeChannelLink(s.linker.GetInfoOrUnknown(msg.ChannelID))
+	post := &model.Post{
+		Message: formatter.FormatFollowupReminder(s.siteURL()+constants.ReceiptPermalinkPath+msg.RootID, channelLink, msg.MessageContent),
+	}
+	if err := s.poster.DM(s.botID, msg.UserID, post); err != nil {
+		s.logger.Error("Failed to send follow-up reminder DM", "message_id", msg.ID, "user_id", msg.UserID, "error", err)
+		return fmt.Errorf("failed to send follow-up reminder: %w", err)
+	}
+	s.logger.Debug("Successfully sent follow-up reminder DM", "message_id", msg.ID, "user_id", msg.UserID)
+	return nil
+}
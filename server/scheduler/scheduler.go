@@ -3,89 +3,311 @@ package scheduler
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/clock"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/formatter"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/placeholder"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
 	"github.com/mattermost/mattermost/server/public/model"
 )
 
-// Scheduler delivers scheduled messages on a timed loop.
+// Scheduler delivers scheduled messages when they are due. It keeps a queue
+// of upcoming messages, updated as they are saved and deleted, and wakes at
+// the earliest one. A periodic full scan of the store resyncs the queue with
+// changes made elsewhere, such as on other cluster nodes.
 type Scheduler struct {
-	logger ports.Logger
-	poster ports.PostService
-	store  ports.Store
-	linker ports.ChannelService
-	botID  string
-	clock  ports.Clock
-	ctx    context.Context
-	cancel context.CancelFunc
-	mu     sync.Mutex
+	logger  ports.Logger
+	poster  ports.PostService
+	store   ports.Store
+	linker  ports.ChannelService
+	users   ports.UserService
+	files   ports.FileService
+	config  ports.ConfigService
+	botID   string
+	clock   ports.TimerClock
+	metrics ports.Metrics
+	ctx     context.Context
+	cancel  context.CancelFunc
+	// mu serializes delivery passes and guards limiter and receipts.
+	mu      sync.Mutex
+	limiter *rateLimiter
+	// receipts holds the deliveries made in the current pass, by author, to
+	// be sent as one DM each once the pass is done.
+	receipts map[string][]deliveryReceipt
+	// startMu orders new work against cancellation: once Stop has held it,
+	// nothing more is added to active.
+	startMu     sync.Mutex
+	active      sync.WaitGroup
+	stopTimeout time.Duration
+	policyMu    sync.RWMutex
+	policy      types.DeliveryPolicy
+	// tickMu guards lastTick and nextTick so status reads never wait on a
+	// running tick.
+	tickMu   sync.RWMutex
+	lastTick time.Time
+	nextTick time.Time
+	// queueMu guards queue. wake tells the run loop the queue changed.
+	queueMu sync.Mutex
+	queue   *queue
+	wake    chan struct{}
 }
 
 // New builds a Scheduler with the provided dependencies.
-func New(logger ports.Logger, poster ports.PostService, store ports.Store, linker ports.ChannelService, botID string, clk ports.Clock) *Scheduler {
+func New(logger ports.Logger, poster ports.PostService, store ports.Store, linker ports.ChannelService, users ports.UserService, files ports.FileService, config ports.ConfigService, botID string, clk ports.TimerClock, metrics ports.Metrics) *Scheduler {
 	logger.Debug("Creating new scheduler instance")
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Scheduler{
-		logger: logger,
-		poster: poster,
-		store:  store,
-		linker: linker,
-		botID:  botID,
-		clock:  clk,
-		ctx:    ctx,
-		cancel: cancel,
+		logger:   logger,
+		poster:   poster,
+		store:    store,
+		linker:   linker,
+		users:    users,
+		files:    files,
+		config:   config,
+		botID:    botID,
+		clock:    clk,
+		metrics:  metrics,
+		ctx:      ctx,
+		cancel:   cancel,
+		limiter:  newRateLimiter(),
+		receipts: map[string][]deliveryReceipt{},
+		queue:    newQueue(),
+		wake:     make(chan struct{}, 1),
+
+		stopTimeout: constants.SchedulerStopTimeoutSeconds * time.Second,
+		policy: types.DeliveryPolicy{
+			LatePolicy:    constants.LatePolicySend,
+			LateThreshold: constants.DefaultLateThresholdMinutes * time.Minute,
+			Attribution:   constants.AttributionUser,
+		},
 	}
 }
 
+// SetPolicy replaces the delivery settings, taking effect from the next
+// delivery.
+func (s *Scheduler) SetPolicy(policy types.DeliveryPolicy) {
+	s.logger.Debug("Setting scheduler delivery policy", "late_policy", policy.LatePolicy, "late_threshold", policy.LateThreshold, "channel_rate_limit", policy.ChannelRateLimit, "global_rate_limit", policy.GlobalRateLimit, "attribution", policy.Attribution, "scheduled_indicator", policy.ScheduledIndicator)
+	s.policyMu.Lock()
+	defer s.policyMu.Unlock()
+	s.policy = policy
+}
+
+// Policy returns the delivery settings in effect.
+func (s *Scheduler) Policy() types.DeliveryPolicy {
+	s.policyMu.RLock()
+	defer s.policyMu.RUnlock()
+	return s.policy
+}
+
 // Start begins the scheduling loop.
 func (s *Scheduler) Start() {
 	s.logger.Info("Scheduler starting")
-	go s.run()
+	if !s.begin() {
+		s.logger.Warn("Scheduler already stopped, not starting")
+		return
+	}
+	go func() {
+		defer s.active.Done()
+		s.run()
+	}()
 }
 
-// Stop halts the scheduling loop.
-func (s *Scheduler) Stop() {
+// Stop halts the scheduling loop. It waits for the current tick and any
+// SendNow calls in progress to finish, so a message is never left deleted
+// but unposted, and returns an error if they take longer than the timeout.
+// No new deliveries start once Stop is called.
+func (s *Scheduler) Stop() error {
 	s.logger.Info("Scheduler stopping")
+	s.startMu.Lock()
 	s.cancel()
-	s.logger.Info("Scheduler stopped")
+	s.startMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.active.Wait()
+		close(done)
+	}()
+	timer := s.clock.NewTimer(s.stopTimeout)
+	defer stopTimer(timer)
+	select {
+	case <-done:
+		s.logger.Info("Scheduler stopped")
+		return nil
+	case <-timer.C():
+		s.logger.Error("Timed out waiting for in-flight deliveries to finish", "timeout", s.stopTimeout)
+		return fmt.Errorf("timed out after %s waiting for in-flight deliveries", s.stopTimeout)
+	}
+}
+
+// begin registers new work unless the scheduler has been stopped. Callers
+// that get true must call s.active.Done when finished.
+func (s *Scheduler) begin() bool {
+	s.startMu.Lock()
+	defer s.startMu.Unlock()
+	if s.ctx.Err() != nil {
+		return false
+	}
+	s.active.Add(1)
+	return true
+}
+
+// LastTick returns when the scheduler last checked for due messages, or the
+// zero time if it has not run yet.
+func (s *Scheduler) LastTick() time.Time {
+	s.tickMu.RLock()
+	defer s.tickMu.RUnlock()
+	return s.lastTick
+}
+
+// NextTick returns when the scheduler will next check for due messages, or
+// the zero time if it is not running.
+func (s *Scheduler) NextTick() time.Time {
+	s.tickMu.RLock()
+	defer s.tickMu.RUnlock()
+	return s.nextTick
+}
+
+// MessageScheduled queues a saved or edited message for delivery.
+func (s *Scheduler) MessageScheduled(msg *types.ScheduledMessage) {
+	s.logger.Debug("Queueing scheduled message", "message_id", msg.ID, "post_at", msg.PostAt)
+	s.queueMu.Lock()
+	s.queue.upsert(msg)
+	s.queueMu.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// MessageUnscheduled drops a deleted message from the queue.
+func (s *Scheduler) MessageUnscheduled(msgID string) {
+	s.logger.Debug("Dequeueing scheduled message", "message_id", msgID)
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+	s.queue.remove(msgID)
+}
+
+func (s *Scheduler) setNextTick(t time.Time) {
+	s.tickMu.Lock()
+	defer s.tickMu.Unlock()
+	s.nextTick = t
+}
+
+func (s *Scheduler) setLastTick(t time.Time) {
+	s.tickMu.Lock()
+	defer s.tickMu.Unlock()
+	s.lastTick = t
 }
 
 func (s *Scheduler) run() {
 	s.logger.Debug("Scheduler run loop started")
 	defer s.logger.Info("Scheduler run loop exited")
+	defer s.setNextTick(time.Time{})
 
+	s.processDueMessages()
+	nextResync := s.nextResync()
 	for {
 		now := s.clock.Now()
-		nextMinute := now.Truncate(time.Minute).Add(time.Minute)
-		duration := nextMinute.Sub(now)
-
-		if duration <= 0 {
-			duration += time.Minute
-			nextMinute = nextMinute.Add(time.Minute)
+		wakeAt := nextResync
+		s.queueMu.Lock()
+		if postAt, ok := s.queue.next(); ok && postAt.Before(wakeAt) {
+			wakeAt = postAt
 		}
+		s.queueMu.Unlock()
+		duration := max(wakeAt.Sub(now), 0)
 
-		s.logger.Debug("Scheduler waiting for next minute", "wait_duration", duration, "target_time", nextMinute)
-		timer := time.NewTimer(duration)
+		s.logger.Debug("Scheduler waiting for next delivery or resync", "wait_duration", duration, "target_time", wakeAt)
+		s.setNextTick(wakeAt)
+		timer := s.clock.NewTimer(duration)
 
 		select {
 		case <-s.ctx.Done():
 			s.logger.Debug("Scheduler context done, stopping timer and exiting run loop")
-			if !timer.Stop() {
-				select {
-				case <-timer.C:
-				default:
-				}
-			}
+			stopTimer(timer)
 			return
-		case t := <-timer.C:
+		case <-s.wake:
+			s.logger.Debug("Scheduler queue changed, recalculating wait")
+			stopTimer(timer)
+		case t := <-timer.C():
 			s.logger.Debug("Scheduler received timer tick", "time", t)
+			if s.clock.Now().Before(nextResync) {
+				s.processQueue()
+				continue
+			}
 			s.processDueMessages()
+			nextResync = s.nextResync()
+		}
+	}
+}
+
+func stopTimer(timer clock.Timer) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C():
+		default:
+		}
+	}
+}
+
+func (s *Scheduler) nextResync() time.Time {
+	interval := constants.SchedulerResyncIntervalMinutes * time.Minute
+	return s.clock.Now().Truncate(interval).Add(interval)
+}
+
+// processQueue delivers queued messages that are due. Each one is read again
+// from the store first, since it may have been edited or sent elsewhere.
+func (s *Scheduler) processQueue() {
+	s.logger.Debug("Processing queued messages")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now().UTC()
+	s.setLastTick(now)
+	defer func() {
+		s.metrics.ObserveTickDuration(s.clock.Now().Sub(now))
+	}()
+
+	s.queueMu.Lock()
+	ids := s.queue.popDue(now)
+	s.queueMu.Unlock()
+
+	for i, id := range ids {
+		if s.ctx.Err() != nil {
+			s.logger.Info("Scheduler stopping, leaving queued messages for the next start", "remaining", len(ids)-i)
+			break
 		}
+		msg, err := s.store.GetScheduledMessage(id)
+		if err != nil {
+			s.logger.Debug("Queued message is no longer available, skipping", "message_id", id, "error", err)
+			continue
+		}
+		if warningPending(msg, now) {
+			msg = s.warn(msg, now)
+		}
+		if msg.DueAt().Unix() > now.Unix() {
+			s.logger.Debug("Queued message is not due yet, requeueing", "message_id", id, "due_at", msg.DueAt())
+			s.queueMu.Lock()
+			s.queue.upsert(msg)
+			s.queueMu.Unlock()
+			continue
+		}
+		s.handleDueMessage(msg, now)
 	}
+	s.sendReceipts()
+
+	s.queueMu.Lock()
+	pending := s.queue.Len()
+	s.queueMu.Unlock()
+	s.metrics.SetPendingMessages(pending)
+	s.logger.Debug("Finished processing queued messages", "processed", len(ids), "pending", pending)
 }
 
 func (s *Scheduler) processDueMessages() {
@@ -100,6 +322,14 @@ func (s *Scheduler) processDueMessages() {
 	now := s.clock.Now().UTC()
 	nowUnix := now.Unix()
 	s.logger.Debug("Current time for due check", "time_utc", now, "time_unix", nowUnix)
+	s.setLastTick(now)
+	defer func() {
+		s.metrics.ObserveTickDuration(s.clock.Now().Sub(now))
+	}()
+
+	s.queueMu.Lock()
+	mark := s.queue.mark()
+	s.queueMu.Unlock()
 
 	messages, err := s.getAllScheduledMessages()
 	if err != nil {
@@ -109,18 +339,39 @@ func (s *Scheduler) processDueMessages() {
 	s.logger.Debug("Retrieved scheduled messages", "count", len(messages))
 
 	processedCount := 0
-	skippedCount := 0
+	var due, upcoming []*types.ScheduledMessage
 	for _, msg := range messages {
-		if msg.PostAt.Unix() > nowUnix {
+		if msg.DueAt().Unix() > nowUnix {
 			// s.logger.Debug("Skipping message, not due yet", "message_id", msg.ID, "post_at_unix", msg.PostAt.Unix(), "now_unix", nowUnix)
-			skippedCount++
+			if warningPending(msg, now) {
+				msg = s.warn(msg, now)
+			}
+			upcoming = append(upcoming, msg)
+			continue
+		}
+		due = append(due, msg)
+	}
+	// Messages are posted one at a time in the order they were scheduled, so
+	// multi-part messages to the same channel arrive in sequence.
+	sort.Slice(due, func(i, j int) bool { return due[i].DeliversBefore(due[j]) })
+	for _, msg := range due {
+		if s.ctx.Err() != nil {
+			s.logger.Debug("Scheduler stopping, leaving due message for the next start", "message_id", msg.ID)
 			continue
 		}
 		s.logger.Debug("Message is due, processing", "message_id", msg.ID, "post_at_unix", msg.PostAt.Unix(), "now_unix", nowUnix)
-		s.handleDueMessage(msg)
+		s.handleDueMessage(msg, now)
 		processedCount++
 	}
-	s.logger.Debug("Finished processing potential messages", "processed", processedCount, "skipped_not_due", skippedCount, "total_candidates", len(messages))
+	s.sendReceipts()
+	skippedCount := len(upcoming)
+	s.queueMu.Lock()
+	s.queue.sync(upcoming, mark)
+	// The queue also holds messages deferred during this pass.
+	pending := s.queue.Len()
+	s.queueMu.Unlock()
+	s.metrics.SetPendingMessages(pending)
+	s.logger.Debug("Finished processing potential messages", "processed", processedCount, "skipped_not_due", skippedCount, "pending", pending, "total_candidates", len(messages))
 }
 
 func (s *Scheduler) getAllScheduledMessages() ([]*types.ScheduledMessage, error) {
@@ -132,25 +383,189 @@ func (s *Scheduler) getAllScheduledMessages() ([]*types.ScheduledMessage, error)
 	return messages, err
 }
 
-func (s *Scheduler) handleDueMessage(msg *types.ScheduledMessage) {
+// handleDueMessage sends a due message, applying the late policy to messages
+// that are further past their time than the threshold, such as those missed
+// while the plugin was down. Messages scheduled "unless replied" are
+// cancelled if their thread got a reply, follow-up reminders go to their
+// author by DM, and messages over a rate limit are deferred.
+func (s *Scheduler) handleDueMessage(msg *types.ScheduledMessage, now time.Time) {
 	s.logger.Debug("Handling due message", "message_id", msg.ID, "user_id", msg.UserID, "channel_id", msg.ChannelID)
-	_ = s.SendNow(msg)
+	policy := s.Policy()
+	late := now.Sub(msg.PostAt)
+	isLate := late > policy.LateThreshold && policy.LatePolicy != constants.LatePolicySend
+	// Messages the scheduler deferred itself were already judged on time.
+	if isLate && policy.LatePolicy == constants.LatePolicySkip && msg.DeferredUntil.IsZero() {
+		s.skipLateMessage(msg, late)
+		return
+	}
+	if s.threadReplied(msg) {
+		s.cancelReplied(msg)
+		return
+	}
+	if msg.Followup == constants.FollowupDM {
+		_ = s.remind(msg)
+		return
+	}
+	if until, ok := s.limiter.reserve(msg.Destinations(), now, policy.ChannelRateLimit, policy.GlobalRateLimit); !ok {
+		s.deferMessage(msg, until)
+		return
+	}
+	if !isLate {
+		s.deliver(msg)
+		return
+	}
+	switch policy.LatePolicy {
+	case constants.LatePolicyAnnotate:
+		s.logger.Info("Posting late message with a delay note", "message_id", msg.ID, "late", late)
+		loc := s.location(msg)
+		annotated := *msg
+		annotated.MessageContent = msg.MessageContent + "\n\n" + formatter.FormatDelayedNote(msg.PostAt.In(loc), now.In(loc))
+		s.deliver(&annotated)
+	case constants.LatePolicySkip:
+		s.logger.Debug("Sending deferred message despite skip policy", "message_id", msg.ID, "late", late)
+		s.deliver(msg)
+	default:
+		s.logger.Warn("Unknown late policy, sending message", "message_id", msg.ID, "late_policy", policy.LatePolicy)
+		s.deliver(msg)
+	}
+}
+
+// deferMessage holds a message over a rate limit back until the limit
+// allows it. Its destinations are held too, so later messages to them do
+// not overtake it. The new time is stored so the author sees it in their
+// list.
+func (s *Scheduler) deferMessage(msg *types.ScheduledMessage, until time.Time) {
+	// Due times are compared to the second.
+	if frac := until.Sub(until.Truncate(time.Second)); frac > 0 {
+		until = until.Add(time.Second - frac)
+	}
+	s.logger.Info("Rate limit reached, deferring message", "message_id", msg.ID, "user_id", msg.UserID, "destinations", msg.Destinations(), "deferred_until", until)
+	s.metrics.IncDeferred()
+	s.limiter.block(msg.Destinations(), until)
+	deferred, err := s.store.DeferScheduledMessage(msg.ID, until)
+	if err != nil {
+		// Retry from memory; the next resync finds it due again anyway.
+		s.logger.Error("Failed to store deferred time for message", "message_id", msg.ID, "error", err)
+	} else if !deferred {
+		s.logger.Debug("Message changed or was deleted while deferring, leaving it to the store", "message_id", msg.ID)
+		return
+	}
+	requeued := *msg
+	requeued.DeferredUntil = until
+	s.queueMu.Lock()
+	s.queue.upsert(&requeued)
+	s.queueMu.Unlock()
+}
+
+// skipLateMessage deletes a late message without posting it and tells the
+// author.
+func (s *Scheduler) skipLateMessage(msg *types.ScheduledMessage, late time.Duration) {
+	if !s.begin() {
+		s.logger.Warn("Scheduler stopped, not skipping message", "message_id", msg.ID)
+		return
+	}
+	defer s.active.Done()
+	s.logger.Info("Skipping late message", "message_id", msg.ID, "user_id", msg.UserID, "late", late)
+	s.MessageUnscheduled(msg.ID)
+	if err := s.deleteSchedule(msg); err != nil {
+		return
+	}
+	channelInfo := s.linker.MakeChannelLink(s.linker.GetInfoOrUnknown(msg.ChannelID))
+	post := &model.Post{
+		Message: formatter.FormatSkippedLateMessage(channelInfo, msg.PostAt.In(s.location(msg)), late, msg.MessageContent),
+	}
+	if dmErr := s.poster.DM(s.botID, msg.UserID, post); dmErr != nil {
+		s.logger.Error("Failed to send DM about skipped late message", "message_id", msg.ID, "user_id", msg.UserID, "dm_error", dmErr)
+	} else {
+		s.logger.Debug("Successfully sent skipped late message DM", "message_id", msg.ID, "user_id", msg.UserID)
+	}
+}
+
+// location returns the author's time zone for msg, falling back to UTC.
+func (s *Scheduler) location(msg *types.ScheduledMessage) *time.Location {
+	loc, err := time.LoadLocation(msg.Timezone)
+	if err != nil {
+		s.logger.Warn("Failed to load message timezone, falling back to UTC", "message_id", msg.ID, "timezone", msg.Timezone, "error", err)
+		return time.UTC
+	}
+	return loc
 }
 
 // SendNow delivers a scheduled message immediately and removes it from storage.
+// Follow-up reminders are sent to their author by DM.
 func (s *Scheduler) SendNow(msg *types.ScheduledMessage) error {
-	s.logger.Debug("Sending scheduled message now", "message_id", msg.ID, "user_id", msg.UserID, "channel_id", msg.ChannelID)
+	if msg.Followup == constants.FollowupDM {
+		return s.remind(msg)
+	}
+	_, err := s.send(msg)
+	return err
+}
+
+// deliver sends a due message and holds its receipt for the end of the
+// pass. Callers must hold mu.
+func (s *Scheduler) deliver(msg *types.ScheduledMessage) {
+	results, _ := s.send(msg)
+	if msg.Receipt == constants.ReceiptOff {
+		return
+	}
+	var posted []types.DeliveryResult
+	for _, result := range results {
+		if result.Err == nil {
+			posted = append(posted, result)
+		}
+	}
+	if len(posted) == 0 {
+		return
+	}
+	s.receipts[msg.UserID] = append(s.receipts[msg.UserID], deliveryReceipt{
+		msg:         msg,
+		posted:      posted,
+		deliveredAt: s.clock.Now(),
+	})
+}
+
+// send posts msg to its destinations after removing it from storage, and
+// tells the author about any that failed.
+func (s *Scheduler) send(msg *types.ScheduledMessage) ([]types.DeliveryResult, error) {
+	s.logger.Debug("Sending scheduled message now", "message_id", msg.ID, "user_id", msg.UserID, "destinations", msg.Destinations())
+	if !s.begin() {
+		s.logger.Warn("Scheduler stopped, not sending message", "message_id", msg.ID)
+		return nil, errors.New("scheduler is stopped")
+	}
+	defer s.active.Done()
+	s.MessageUnscheduled(msg.ID)
 	if err := s.deleteSchedule(msg); err != nil {
 		s.logger.Error("Halting processing for message due to delete failure", "message_id", msg.ID)
-		return err
+		return nil, err
+	}
+	results := s.postMessage(msg)
+	failed := failedDeliveries(results)
+	s.recordDelivery(msg, results, failed)
+	if len(failed) == 0 {
+		s.logger.Info("Successfully posted scheduled message", "message_id", msg.ID, "user_id", msg.UserID, "destinations", msg.Destinations(), "post_at", msg.PostAt)
+		return results, nil
 	}
-	if err := s.postMessage(msg); err != nil {
-		s.logger.Warn("Message posting failed, attempting to DM user", "message_id", msg.ID, "user_id", msg.UserID, "error", err)
-		s.dmUserOnFailedMessage(msg, err)
-		return err
+	if len(results) == 1 {
+		s.logger.Warn("Message posting failed, attempting to DM user", "message_id", msg.ID, "user_id", msg.UserID, "error", failed[0].Err)
+		s.dmUserOnFailedMessage(msg, failed[0].Err)
+		return results, failed[0].Err
+	}
+	s.logger.Warn("Message posting failed for some destinations, attempting to DM user", "message_id", msg.ID, "user_id", msg.UserID, "failed", len(failed), "total", len(results))
+	s.dmUserOnPartialFailure(msg, results, failed)
+	return results, fmt.Errorf("failed to post to %d of %d destinations", len(failed), len(results))
+}
+
+func (s *Scheduler) recordDelivery(msg *types.ScheduledMessage, results, failed []types.DeliveryResult) {
+	for range len(results) - len(failed) {
+		s.metrics.IncDelivered()
+	}
+	for range failed {
+		s.metrics.IncDeliveryFailed()
+	}
+	// Messages sent early from the list are not late; only due deliveries count.
+	if lateness := s.clock.Now().Sub(msg.PostAt); len(failed) < len(results) && lateness >= 0 {
+		s.metrics.ObserveLateness(lateness)
 	}
-	s.logger.Info("Successfully posted scheduled message", "message_id", msg.ID, "user_id", msg.UserID, "channel_id", msg.ChannelID, "post_at", msg.PostAt)
-	return nil
 }
 
 func (s *Scheduler) deleteSchedule(msg *types.ScheduledMessage) error {
@@ -163,20 +578,136 @@ func (s *Scheduler) deleteSchedule(msg *types.ScheduledMessage) error {
 	return err
 }
 
-func (s *Scheduler) postMessage(msg *types.ScheduledMessage) error {
-	s.logger.Debug("Attempting to post scheduled message", "message_id", msg.ID, "user_id", msg.UserID, "channel_id", msg.ChannelID)
+func (s *Scheduler) postMessage(msg *types.ScheduledMessage) []types.DeliveryResult {
+	destinations := msg.Destinations()
+	results := make([]types.DeliveryResult, 0, len(destinations))
+	for _, channelID := range destinations {
+		results = append(results, s.postToChannel(msg, channelID))
+	}
+	return results
+}
+
+func (s *Scheduler) postToChannel(msg *types.ScheduledMessage, channelID string) types.DeliveryResult {
+	s.logger.Debug("Attempting to post scheduled message", "message_id", msg.ID, "user_id", msg.UserID, "channel_id", channelID)
 	post := &model.Post{
-		ChannelId: msg.ChannelID,
+		ChannelId: channelID,
 		RootId:    msg.RootID,
-		Message:   msg.MessageContent,
+		Message:   s.expandTemplate(msg, channelID),
 		UserId:    msg.UserID,
 	}
+	if msg.ForwardedPostID != "" {
+		post.Message += "\n\n" + formatter.FormatForwardedFrom(s.siteURL()+constants.ReceiptPermalinkPath+msg.ForwardedPostID)
+	}
+	tagScheduledPost(post, msg)
+	if s.postsAsBot(msg, channelID) {
+		s.logger.Debug("Posting scheduled message as the bot on behalf of the author", "message_id", msg.ID, "user_id", msg.UserID, "channel_id", channelID)
+		post.UserId = s.botID
+		post.Message += "\n\n" + formatter.FormatAttributionFooter(s.authorName(msg.UserID))
+		post.AddProp(constants.PropOnBehalfOfUserID, msg.UserID)
+	} else if s.Policy().ScheduledIndicator {
+		// The attribution footer already says a bot post was scheduled.
+		post.Message += "\n\n" + formatter.FormatScheduledIndicator()
+	}
+	if len(msg.FileIDs) > 0 {
+		post.FileIds = s.copyFiles(msg, post.UserId)
+	}
 	postErr := s.poster.CreatePost(post)
 	if postErr != nil {
-		s.logger.Error("Failed to post scheduled message via PostService", "message_id", msg.ID, "user_id", msg.UserID, "channel_id", msg.ChannelID, "error", postErr)
+		s.logger.Error("Failed to post scheduled message via PostService", "message_id", msg.ID, "user_id", msg.UserID, "channel_id", channelID, "error", postErr)
+		return types.DeliveryResult{ChannelID: channelID, Err: postErr}
 	}
-	s.logger.Debug("Successfully created post via PostService", "message_id", msg.ID, "user_id", msg.UserID, "channel_id", msg.ChannelID)
-	return postErr
+	s.logger.Debug("Successfully created post via PostService", "message_id", msg.ID, "user_id", msg.UserID, "channel_id", channelID, "post_id", post.Id)
+	return types.DeliveryResult{ChannelID: channelID, PostID: post.Id}
+}
+
+// tagScheduledPost records in the post's props that it came from msg, so
+// integrations and admins can find and count scheduled posts.
+func tagScheduledPost(post *model.Post, msg *types.ScheduledMessage) {
+	post.AddProp(constants.PropFromScheduledMessage, true)
+	post.AddProp(constants.PropScheduledMessageID, msg.ID)
+	post.AddProp(constants.PropScheduledPostAt, msg.PostAt.UnixMilli())
+	if msg.RecurrenceID != "" {
+		post.AddProp(constants.PropScheduledRecurrenceID, msg.RecurrenceID)
+		post.AddProp(constants.PropScheduledOccurrence, msg.OccurrenceNumber())
+	}
+}
+
+// postsAsBot reports whether msg is posted to channelID as the bot, going by
+// the message's own setting or else the admin default. Direct and group
+// messages are always posted as the author, as the bot is not part of the
+// conversation.
+func (s *Scheduler) postsAsBot(msg *types.ScheduledMessage, channelID string) bool {
+	attribution := msg.Attribution
+	if attribution == "" {
+		attribution = s.Policy().Attribution
+	}
+	if attribution != constants.AttributionBot {
+		return false
+	}
+	switch s.linker.GetInfoOrUnknown(channelID).ChannelType {
+	case model.ChannelTypeDirect, model.ChannelTypeGroup:
+		s.logger.Debug("Not posting as the bot in a direct or group message", "message_id", msg.ID, "channel_id", channelID)
+		return false
+	}
+	return true
+}
+
+// copyFiles copies the attachments of a forwarded message for a new post by
+// userID, since each file can only be attached to one post. The message is
+// posted without them if they cannot be copied, e.g. because the original
+// files were deleted.
+func (s *Scheduler) copyFiles(msg *types.ScheduledMessage, userID string) model.StringArray {
+	fileIDs, err := s.files.CopyInfos(msg.FileIDs, userID)
+	if err != nil {
+		s.logger.Warn("Failed to copy forwarded attachments, posting without them", "message_id", msg.ID, "user_id", userID, "file_ids", msg.FileIDs, "error", err)
+		return nil
+	}
+	s.logger.Debug("Copied forwarded attachments", "message_id", msg.ID, "user_id", userID, "file_ids", fileIDs)
+	return fileIDs
+}
+
+func (s *Scheduler) expandTemplate(msg *types.ScheduledMessage, channelID string) string {
+	template, literal := msg.MessageContent, ""
+	switch {
+	case msg.Literal:
+		return msg.MessageContent
+	case msg.ForwardedPostID != "":
+		// Only the comment is a template; the quoted post is posted as written.
+		split := min(max(msg.CommentBytes, 0), len(msg.MessageContent))
+		template, literal = msg.MessageContent[:split], msg.MessageContent[split:]
+	}
+	if !placeholder.Has(template) {
+		return msg.MessageContent
+	}
+	s.logger.Debug("Expanding template variables", "message_id", msg.ID, "channel_id", channelID, "timezone", msg.Timezone)
+	localTime := msg.PostAt.In(s.location(msg))
+	return placeholder.Expand(template, map[string]string{
+		placeholder.VarDate:       localTime.Format(constants.TemplateDateLayout),
+		placeholder.VarWeekday:    localTime.Weekday().String(),
+		placeholder.VarTime:       localTime.Format(constants.TemplateTimeLayout),
+		placeholder.VarChannel:    s.linker.GetInfoOrUnknown(channelID).ChannelLink,
+		placeholder.VarAuthor:     s.authorName(msg.UserID),
+		placeholder.VarOccurrence: strconv.Itoa(msg.OccurrenceNumber()),
+	}) + literal
+}
+
+func (s *Scheduler) authorName(userID string) string {
+	user, err := s.users.Get(userID)
+	if err != nil {
+		s.logger.Warn("Failed to get author name", "user_id", userID, "error", err)
+		return constants.UnknownUserPlaceholder
+	}
+	return "@" + user.Username
+}
+
+func failedDeliveries(results []types.DeliveryResult) []types.DeliveryResult {
+	var failed []types.DeliveryResult
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
 }
 
 func (s *Scheduler) dmUserOnFailedMessage(msg *types.ScheduledMessage, postErr error) {
@@ -193,3 +724,21 @@ func (s *Scheduler) dmUserOnFailedMessage(msg *types.ScheduledMessage, postErr e
 		s.logger.Debug("Successfully sent DM alert to user", "message_id", msg.ID, "user_id", msg.UserID)
 	}
 }
+
+func (s *Scheduler) dmUserOnPartialFailure(msg *types.ScheduledMessage, results, failed []types.DeliveryResult) {
+	s.logger.Debug("Attempting to DM user about partially failed message", "message_id", msg.ID, "user_id", msg.UserID, "failed", len(failed), "total", len(results))
+	failures := make([]string, 0, len(failed))
+	for _, result := range failed {
+		channelInfo := s.linker.MakeChannelLink(s.linker.GetInfoOrUnknown(result.ChannelID))
+		failures = append(failures, fmt.Sprintf("%s (%v)", channelInfo, result.Err))
+	}
+	message := formatter.FormatSchedulerPartialFailure(len(results)-len(failed), len(results), failures, msg.MessageContent)
+	post := &model.Post{
+		Message: message,
+	}
+	if dmErr := s.poster.DM(s.botID, msg.UserID, post); dmErr != nil {
+		s.logger.Error("Failed to send DM alert to user about partially failed scheduled message", "message_id", msg.ID, "user_id", msg.UserID, "dm_error", dmErr)
+	} else {
+		s.logger.Debug("Successfully sent partial failure DM alert to user", "message_id", msg.ID, "user_id", msg.UserID)
+	}
+}
@@ -2,7 +2,6 @@ package scheduler
 
 import (
 	"errors"
-	"sync"
 	"testing"
 	"time"
 
@@ -11,6 +10,8 @@ import (
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/testutil"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/encryption"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/formatter"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/store"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
 	"github.com/mattermost/mattermost/server/public/model"
@@ -27,9 +28,9 @@ func TestProcessDueMessages_PostSuccess(t *testing.T) {
 	mockKV := mock.NewMockKVService(ctrl)
 	mockChannel := mock.NewMockChannelService(ctrl)
 
-	st := store.NewKVStore(testutil.FakeLogger{}, mockKV, mm.NewListMatchingService(), constants.MaxUserMessages)
-	clk := testutil.FakeClock{NowTime: time.Now().UTC()}
-	s := New(testutil.FakeLogger{}, mockPoster, st, mockChannel, "bot", clk)
+	st := store.NewKVStore(testutil.FakeLogger{}, mockKV, mm.NewListMatchingService(), constants.MaxUserMessages, encryption.NewBox(nil))
+	clk := testutil.NewManualClock(time.Now().UTC())
+	s := New(testutil.FakeLogger{}, mockPoster, st, mockChannel, mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
 
 	now := clk.Now()
 	msg := &types.ScheduledMessage{
@@ -48,12 +49,8 @@ func TestProcessDueMessages_PostSuccess(t *testing.T) {
 	mockKV.EXPECT().Get(userIndexKey, gomock.Any()).SetArg(1, []string{msg.ID}).Return(nil)
 	mockKV.EXPECT().Set(userIndexKey, gomock.Eq([]string{})).Return(true, nil)
 	mockKV.EXPECT().Delete(msgKey).Return(nil)
-	mockPoster.EXPECT().CreatePost(gomock.Eq(&model.Post{
-		ChannelId: msg.ChannelID,
-		RootId:    msg.RootID,
-		Message:   msg.MessageContent,
-		UserId:    msg.UserID,
-	})).Return(nil)
+	mockPoster.EXPECT().CreatePost(scheduledPost(msg, msg.ChannelID, msg.MessageContent, msg.UserID)).Return(nil)
+	mockKV.EXPECT().Get(constants.ReceiptPreferencePrefix+msg.UserID, gomock.Any()).Return(nil)
 
 	s.processDueMessages()
 }
@@ -66,9 +63,9 @@ func TestProcessDueMessages_PostFailure(t *testing.T) {
 	mockKV := mock.NewMockKVService(ctrl)
 	mockChannel := mock.NewMockChannelService(ctrl)
 
-	st := store.NewKVStore(testutil.FakeLogger{}, mockKV, mm.NewListMatchingService(), constants.MaxUserMessages)
-	clk := testutil.FakeClock{NowTime: time.Now().UTC()}
-	s := New(testutil.FakeLogger{}, mockPoster, st, mockChannel, "bot", clk)
+	st := store.NewKVStore(testutil.FakeLogger{}, mockKV, mm.NewListMatchingService(), constants.MaxUserMessages, encryption.NewBox(nil))
+	clk := testutil.NewManualClock(time.Now().UTC())
+	s := New(testutil.FakeLogger{}, mockPoster, st, mockChannel, mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
 
 	now := clk.Now()
 	msg := &types.ScheduledMessage{
@@ -105,9 +102,9 @@ func TestProcessDueMessages_NotDueYet(t *testing.T) {
 	mockKV := mock.NewMockKVService(ctrl)
 	mockChannel := mock.NewMockChannelService(ctrl)
 
-	st := store.NewKVStore(testutil.FakeLogger{}, mockKV, mm.NewListMatchingService(), constants.MaxUserMessages)
-	clk := testutil.FakeClock{NowTime: time.Now().UTC()}
-	s := New(testutil.FakeLogger{}, mockPoster, st, mockChannel, "bot", clk)
+	st := store.NewKVStore(testutil.FakeLogger{}, mockKV, mm.NewListMatchingService(), constants.MaxUserMessages, encryption.NewBox(nil))
+	clk := testutil.NewManualClock(time.Now().UTC())
+	s := New(testutil.FakeLogger{}, mockPoster, st, mockChannel, mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
 
 	now := clk.Now()
 	msg := &types.ScheduledMessage{
@@ -134,9 +131,9 @@ func TestProcessDueMessages_ListError(t *testing.T) {
 	mockKV := mock.NewMockKVService(ctrl)
 	mockChannel := mock.NewMockChannelService(ctrl)
 
-	st := store.NewKVStore(testutil.FakeLogger{}, mockKV, mm.NewListMatchingService(), constants.MaxUserMessages)
-	clk := testutil.FakeClock{NowTime: time.Now().UTC()}
-	s := New(testutil.FakeLogger{}, mockPoster, st, mockChannel, "bot", clk)
+	st := store.NewKVStore(testutil.FakeLogger{}, mockKV, mm.NewListMatchingService(), constants.MaxUserMessages, encryption.NewBox(nil))
+	clk := testutil.NewManualClock(time.Now().UTC())
+	s := New(testutil.FakeLogger{}, mockPoster, st, mockChannel, mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
 
 	mockKV.EXPECT().ListKeys(0, constants.MaxFetchScheduledMessages, gomock.Any()).Return(nil, errors.New("boom"))
 
@@ -151,9 +148,9 @@ func TestScheduler_StartAndStop(t *testing.T) {
 	mockKV := mock.NewMockKVService(ctrl)
 	mockChannel := mock.NewMockChannelService(ctrl)
 
-	st := store.NewKVStore(testutil.FakeLogger{}, mockKV, mm.NewListMatchingService(), constants.MaxUserMessages)
-	clk := testutil.FakeClock{NowTime: time.Date(2023, 1, 1, 10, 30, 59, 950*1000*1000, time.UTC)}
-	s := New(testutil.FakeLogger{}, mockPoster, st, mockChannel, "bot", clk)
+	st := store.NewKVStore(testutil.FakeLogger{}, mockKV, mm.NewListMatchingService(), constants.MaxUserMessages, encryption.NewBox(nil))
+	clk := testutil.NewManualClock(time.Date(2023, 1, 1, 10, 30, 59, 950*1000*1000, time.UTC))
+	s := New(testutil.FakeLogger{}, mockPoster, st, mockChannel, mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
 
 	now := clk.Now()
 	msg := &types.ScheduledMessage{
@@ -167,23 +164,114 @@ func TestScheduler_StartAndStop(t *testing.T) {
 	msgKey := testutil.SchedKey(msg.ID)
 	userIndexKey := testutil.IndexKey(msg.UserID)
 
-	mockKV.EXPECT().ListKeys(0, constants.MaxFetchScheduledMessages, gomock.Any()).Return([]string{msgKey}, nil).MinTimes(1)
-	mockKV.EXPECT().Get(msgKey, gomock.Any()).SetArg(1, *msg).Return(nil).MinTimes(1)
-	mockKV.EXPECT().Get(userIndexKey, gomock.Any()).SetArg(1, []string{msg.ID}).Return(nil).MinTimes(1)
-	mockKV.EXPECT().Set(userIndexKey, gomock.Eq([]string{})).Return(true, nil).MinTimes(1)
-	mockKV.EXPECT().Delete(msgKey).Return(nil).MinTimes(1)
-	mockPoster.EXPECT().CreatePost(gomock.Any()).Return(nil).MinTimes(1)
+	// Overdue messages are delivered by the catch-up scan on start.
+	mockKV.EXPECT().ListKeys(0, constants.MaxFetchScheduledMessages, gomock.Any()).Return([]string{msgKey}, nil)
+	mockKV.EXPECT().Get(msgKey, gomock.Any()).SetArg(1, *msg).Return(nil)
+	mockKV.EXPECT().Get(userIndexKey, gomock.Any()).SetArg(1, []string{msg.ID}).Return(nil)
+	mockKV.EXPECT().Set(userIndexKey, gomock.Eq([]string{})).Return(true, nil)
+	mockKV.EXPECT().Delete(msgKey).Return(nil)
+	mockKV.EXPECT().Get(constants.ReceiptPreferencePrefix+msg.UserID, gomock.Any()).Return(nil)
+	mockPoster.EXPECT().CreatePost(gomock.Any()).Return(nil)
+
+	stop := startScheduler(t, s)
+	waitUntilWaiting(t, s, clk, time.Date(2023, 1, 1, 10, 35, 0, 0, time.UTC))
+	stop()
+}
 
-	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		s.run()
-	}()
+// startScheduler starts the scheduler and returns a function that stops it.
+func startScheduler(t *testing.T, s *Scheduler) func() {
+	t.Helper()
+	s.Start()
+	return func() {
+		require.NoError(t, s.Stop())
+	}
+}
 
-	time.Sleep(100 * time.Millisecond)
-	s.Stop()
-	wg.Wait()
+// waitUntilWaiting blocks until the scheduler is waiting on its timer for at.
+func waitUntilWaiting(t *testing.T, s *Scheduler, clk *testutil.ManualClock, at time.Time) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return clk.Waiters() == 1 && s.NextTick().Equal(at)
+	}, time.Second, time.Millisecond)
+}
+
+func TestRun_DeliversQueuedMessageOnTime(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock.NewMockStore(ctrl)
+	mockPoster := mock.NewMockPostService(ctrl)
+	clk := testutil.NewManualClock(time.Date(2024, 1, 15, 10, 30, 20, 0, time.UTC))
+	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mock.NewMockChannelService(ctrl), mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
+	msg := &types.ScheduledMessage{ID: "m1", UserID: "user", ChannelID: "chan", PostAt: clk.Now().Add(17 * time.Second), MessageContent: "hi"}
+	resync := time.Date(2024, 1, 15, 10, 35, 0, 0, time.UTC)
+
+	mockStore.EXPECT().ListScheduledMessages().Return(nil, nil)
+	stop := startScheduler(t, s)
+	defer stop()
+	waitUntilWaiting(t, s, clk, resync)
+
+	s.MessageScheduled(msg)
+	waitUntilWaiting(t, s, clk, msg.PostAt)
+
+	// One second early: nothing is delivered and the timer is unchanged.
+	clk.Advance(16 * time.Second)
+	waitUntilWaiting(t, s, clk, msg.PostAt)
+
+	delivered := make(chan struct{})
+	mockStore.EXPECT().GetScheduledMessage("m1").Return(msg, nil)
+	mockStore.EXPECT().DeleteScheduledMessage("user", "m1").Return(nil)
+	mockStore.EXPECT().GetReceiptPreference("user").Return(false, nil)
+	mockPoster.EXPECT().CreatePost(gomock.Any()).DoAndReturn(func(*model.Post) error {
+		close(delivered)
+		return nil
+	})
+	clk.Advance(time.Second)
+	<-delivered
+	waitUntilWaiting(t, s, clk, resync)
+	assert.Equal(t, msg.PostAt, s.LastTick())
+}
+
+func TestRun_ResyncsOnInterval(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock.NewMockStore(ctrl)
+	clk := testutil.NewManualClock(time.Date(2024, 1, 15, 10, 30, 20, 0, time.UTC))
+	s := New(testutil.FakeLogger{}, mock.NewMockPostService(ctrl), mockStore, mock.NewMockChannelService(ctrl), mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
+	// Saved on another cluster node, so only the resync finds it.
+	remote := &types.ScheduledMessage{ID: "remote", PostAt: time.Date(2024, 1, 15, 10, 37, 0, 0, time.UTC)}
+
+	mockStore.EXPECT().ListScheduledMessages().Return(nil, nil)
+	stop := startScheduler(t, s)
+	defer stop()
+	waitUntilWaiting(t, s, clk, time.Date(2024, 1, 15, 10, 35, 0, 0, time.UTC))
+
+	mockStore.EXPECT().ListScheduledMessages().Return([]*types.ScheduledMessage{remote}, nil)
+	clk.Advance(4*time.Minute + 40*time.Second)
+	waitUntilWaiting(t, s, clk, remote.PostAt)
+}
+
+func TestRun_StopsWhileWaiting(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock.NewMockStore(ctrl)
+	clk := testutil.NewManualClock(time.Date(2024, 1, 15, 10, 30, 20, 0, time.UTC))
+	s := New(testutil.FakeLogger{}, mock.NewMockPostService(ctrl), mockStore, mock.NewMockChannelService(ctrl), mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
+
+	mockStore.EXPECT().ListScheduledMessages().Return(nil, nil)
+	stop := startScheduler(t, s)
+	waitUntilWaiting(t, s, clk, time.Date(2024, 1, 15, 10, 35, 0, 0, time.UTC))
+	s.MessageScheduled(&types.ScheduledMessage{ID: "m1", PostAt: clk.Now().Add(time.Minute)})
+	waitUntilWaiting(t, s, clk, clk.Now().Add(time.Minute))
+
+	stop()
+
+	assert.Zero(t, clk.Waiters())
+	assert.True(t, s.NextTick().IsZero())
+	// The loop has exited, so passing the due time touches nothing.
+	clk.Advance(time.Hour)
 }
 
 func TestProcessDueMessages_LoadMessageError(t *testing.T) {
@@ -194,9 +282,9 @@ func TestProcessDueMessages_LoadMessageError(t *testing.T) {
 	mockKV := mock.NewMockKVService(ctrl)
 	mockChannel := mock.NewMockChannelService(ctrl)
 
-	st := store.NewKVStore(testutil.FakeLogger{}, mockKV, mm.NewListMatchingService(), constants.MaxUserMessages)
-	clk := testutil.FakeClock{NowTime: time.Now().UTC()}
-	s := New(testutil.FakeLogger{}, mockPoster, st, mockChannel, "bot", clk)
+	st := store.NewKVStore(testutil.FakeLogger{}, mockKV, mm.NewListMatchingService(), constants.MaxUserMessages, encryption.NewBox(nil))
+	clk := testutil.NewManualClock(time.Now().UTC())
+	s := New(testutil.FakeLogger{}, mockPoster, st, mockChannel, mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
 
 	msgID := "uuid-5"
 	msgKey := testutil.SchedKey(msgID)
@@ -220,9 +308,9 @@ func TestProcessDueMessages_DeleteScheduleError(t *testing.T) {
 	mockKV := mock.NewMockKVService(ctrl)
 	mockChannel := mock.NewMockChannelService(ctrl)
 
-	st := store.NewKVStore(testutil.FakeLogger{}, mockKV, mm.NewListMatchingService(), constants.MaxUserMessages)
-	clk := testutil.FakeClock{NowTime: time.Now().UTC()}
-	s := New(testutil.FakeLogger{}, mockPoster, st, mockChannel, "bot", clk)
+	st := store.NewKVStore(testutil.FakeLogger{}, mockKV, mm.NewListMatchingService(), constants.MaxUserMessages, encryption.NewBox(nil))
+	clk := testutil.NewManualClock(time.Now().UTC())
+	s := New(testutil.FakeLogger{}, mockPoster, st, mockChannel, mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
 
 	now := clk.Now()
 	msg := &types.ScheduledMessage{
@@ -246,9 +334,9 @@ func TestProcessDueMessages_DMError(t *testing.T) {
 	mockKV := mock.NewMockKVService(ctrl)
 	mockChannel := mock.NewMockChannelService(ctrl)
 
-	st := store.NewKVStore(testutil.FakeLogger{}, mockKV, mm.NewListMatchingService(), constants.MaxUserMessages)
-	clk := testutil.FakeClock{NowTime: time.Now().UTC()}
-	s := New(testutil.FakeLogger{}, mockPoster, st, mockChannel, "bot", clk)
+	st := store.NewKVStore(testutil.FakeLogger{}, mockKV, mm.NewListMatchingService(), constants.MaxUserMessages, encryption.NewBox(nil))
+	clk := testutil.NewManualClock(time.Now().UTC())
+	s := New(testutil.FakeLogger{}, mockPoster, st, mockChannel, mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
 
 	now := clk.Now()
 	msg := &types.ScheduledMessage{
@@ -282,9 +370,9 @@ func TestProcessDueMessages_EmptyIDMap(t *testing.T) {
 	mockKV := mock.NewMockKVService(ctrl)
 	mockChannel := mock.NewMockChannelService(ctrl)
 
-	st := store.NewKVStore(testutil.FakeLogger{}, mockKV, mm.NewListMatchingService(), constants.MaxUserMessages)
-	clk := testutil.FakeClock{NowTime: time.Now().UTC()}
-	s := New(testutil.FakeLogger{}, mockPoster, st, mockChannel, "bot", clk)
+	st := store.NewKVStore(testutil.FakeLogger{}, mockKV, mm.NewListMatchingService(), constants.MaxUserMessages, encryption.NewBox(nil))
+	clk := testutil.NewManualClock(time.Now().UTC())
+	s := New(testutil.FakeLogger{}, mockPoster, st, mockChannel, mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
 
 	mockKV.EXPECT().ListKeys(0, constants.MaxFetchScheduledMessages, gomock.Any()).Return([]string{}, nil)
 
@@ -299,8 +387,8 @@ func TestSendNow_Success(t *testing.T) {
 	mockStore := mock.NewMockStore(ctrl)
 	mockChannel := mock.NewMockChannelService(ctrl)
 
-	clk := testutil.FakeClock{NowTime: time.Now().UTC()}
-	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mockChannel, "bot", clk)
+	clk := testutil.NewManualClock(time.Now().UTC())
+	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mockChannel, mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
 
 	msg := &types.ScheduledMessage{
 		ID:             "uuid-send-1",
@@ -313,12 +401,7 @@ func TestSendNow_Success(t *testing.T) {
 	}
 
 	mockStore.EXPECT().DeleteScheduledMessage(msg.UserID, msg.ID).Return(nil)
-	mockPoster.EXPECT().CreatePost(gomock.Eq(&model.Post{
-		ChannelId: msg.ChannelID,
-		RootId:    msg.RootID,
-		Message:   msg.MessageContent,
-		UserId:    msg.UserID,
-	})).Return(nil)
+	mockPoster.EXPECT().CreatePost(scheduledPost(msg, msg.ChannelID, msg.MessageContent, msg.UserID)).Return(nil)
 
 	err := s.SendNow(msg)
 
@@ -333,8 +416,8 @@ func TestSendNow_DeleteError(t *testing.T) {
 	mockStore := mock.NewMockStore(ctrl)
 	mockChannel := mock.NewMockChannelService(ctrl)
 
-	clk := testutil.FakeClock{NowTime: time.Now().UTC()}
-	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mockChannel, "bot", clk)
+	clk := testutil.NewManualClock(time.Now().UTC())
+	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mockChannel, mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
 
 	msg := &types.ScheduledMessage{
 		ID:             "uuid-send-2",
@@ -363,8 +446,8 @@ func TestSendNow_PostError(t *testing.T) {
 	mockStore := mock.NewMockStore(ctrl)
 	mockChannel := mock.NewMockChannelService(ctrl)
 
-	clk := testutil.FakeClock{NowTime: time.Now().UTC()}
-	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mockChannel, "bot", clk)
+	clk := testutil.NewManualClock(time.Now().UTC())
+	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mockChannel, mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
 
 	msg := &types.ScheduledMessage{
 		ID:             "uuid-send-3",
@@ -389,3 +472,1005 @@ func TestSendNow_PostError(t *testing.T) {
 	require.Error(t, err)
 	assert.EqualError(t, err, postErr.Error())
 }
+
+func TestSendNow_MultipleDestinations_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPoster := mock.NewMockPostService(ctrl)
+	mockStore := mock.NewMockStore(ctrl)
+	mockChannel := mock.NewMockChannelService(ctrl)
+
+	clk := testutil.NewManualClock(time.Now().UTC())
+	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mockChannel, mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
+
+	msg := &types.ScheduledMessage{
+		ID:             "uuid-send-4",
+		UserID:         "user",
+		ChannelID:      "chan1",
+		ChannelIDs:     []string{"chan1", "chan2"},
+		PostAt:         clk.Now(),
+		MessageContent: "hi",
+		Timezone:       "UTC",
+	}
+
+	mockStore.EXPECT().DeleteScheduledMessage(msg.UserID, msg.ID).Return(nil)
+	gomock.InOrder(
+		mockPoster.EXPECT().CreatePost(scheduledPost(msg, "chan1", "hi", "user")).Return(nil),
+		mockPoster.EXPECT().CreatePost(scheduledPost(msg, "chan2", "hi", "user")).Return(nil),
+	)
+
+	err := s.SendNow(msg)
+
+	require.NoError(t, err)
+}
+
+func TestSendNow_MultipleDestinations_PartialFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPoster := mock.NewMockPostService(ctrl)
+	mockStore := mock.NewMockStore(ctrl)
+	mockChannel := mock.NewMockChannelService(ctrl)
+
+	clk := testutil.NewManualClock(time.Now().UTC())
+	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mockChannel, mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
+
+	msg := &types.ScheduledMessage{
+		ID:             "uuid-send-5",
+		UserID:         "user",
+		ChannelID:      "chan1",
+		ChannelIDs:     []string{"chan1", "chan2", "chan3"},
+		PostAt:         clk.Now(),
+		MessageContent: "hi",
+		Timezone:       "UTC",
+	}
+	postErr := errors.New("archived")
+	channelInfo := &ports.ChannelInfo{ChannelID: "chan2", ChannelLink: "~chan2"}
+
+	mockStore.EXPECT().DeleteScheduledMessage(msg.UserID, msg.ID).Return(nil)
+	mockPoster.EXPECT().CreatePost(gomock.Any()).DoAndReturn(func(post *model.Post) error {
+		if post.ChannelId == "chan2" {
+			return postErr
+		}
+		return nil
+	}).Times(3)
+	mockChannel.EXPECT().GetInfoOrUnknown("chan2").Return(channelInfo)
+	mockChannel.EXPECT().MakeChannelLink(channelInfo).Return("in channel: ~chan2")
+	mockPoster.EXPECT().DM("bot", msg.UserID, gomock.Any()).DoAndReturn(func(_, _ string, post *model.Post) error {
+		assert.Contains(t, post.Message, "posted to 2 of 3 destinations")
+		assert.Contains(t, post.Message, "in channel: ~chan2 (archived)")
+		return nil
+	})
+
+	err := s.SendNow(msg)
+
+	require.Error(t, err)
+	assert.EqualError(t, err, "failed to post to 1 of 3 destinations")
+}
+
+func TestSendNow_ExpandsTemplateVariables(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPoster := mock.NewMockPostService(ctrl)
+	mockStore := mock.NewMockStore(ctrl)
+	mockChannel := mock.NewMockChannelService(ctrl)
+	mockUsers := mock.NewMockUserService(ctrl)
+
+	clk := testutil.NewManualClock(time.Now().UTC())
+	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mockChannel, mockUsers, mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
+
+	msg := &types.ScheduledMessage{
+		ID:             "uuid-template-1",
+		UserID:         "user",
+		ChannelID:      "chan",
+		PostAt:         time.Date(2024, 1, 15, 14, 30, 0, 0, time.UTC),
+		MessageContent: "{{weekday}} {{date}} at {{time}} in {{channel}} by {{author}} (#{{occurrence}}) {{ unknown }}",
+		Timezone:       "America/New_York",
+		Occurrence:     3,
+	}
+
+	mockStore.EXPECT().DeleteScheduledMessage(msg.UserID, msg.ID).Return(nil)
+	mockChannel.EXPECT().GetInfoOrUnknown("chan").Return(&ports.ChannelInfo{ChannelID: "chan", ChannelLink: "~town-square"})
+	mockUsers.EXPECT().Get("user").Return(&model.User{Username: "alice"}, nil)
+	mockPoster.EXPECT().CreatePost(scheduledPost(msg, msg.ChannelID, "Monday Jan 15, 2024 at 9:30 AM in ~town-square by @alice (#3) {{ unknown }}", msg.UserID)).Return(nil)
+
+	err := s.SendNow(msg)
+
+	require.NoError(t, err)
+}
+
+func TestSendNow_LiteralContentIsNotExpanded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPoster := mock.NewMockPostService(ctrl)
+	mockStore := mock.NewMockStore(ctrl)
+	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mock.NewMockChannelService(ctrl), mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", testutil.NewManualClock(time.Now().UTC()), testutil.FakeMetrics{})
+
+	msg := &types.ScheduledMessage{ID: "m1", UserID: "user", ChannelID: "chan", PostAt: time.Now(), MessageContent: ":calendar: **Ask {{author}}** starts in 15 minutes.", Timezone: "UTC", Literal: true}
+
+	mockStore.EXPECT().DeleteScheduledMessage(msg.UserID, msg.ID).Return(nil)
+	mockPoster.EXPECT().CreatePost(scheduledPost(msg, msg.ChannelID, msg.MessageContent, msg.UserID)).Return(nil)
+
+	require.NoError(t, s.SendNow(msg))
+}
+
+func TestSendNow_TemplateAuthorLookupFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPoster := mock.NewMockPostService(ctrl)
+	mockStore := mock.NewMockStore(ctrl)
+	mockChannel := mock.NewMockChannelService(ctrl)
+	mockUsers := mock.NewMockUserService(ctrl)
+
+	clk := testutil.NewManualClock(time.Now().UTC())
+	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mockChannel, mockUsers, mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
+
+	msg := &types.ScheduledMessage{
+		ID:             "uuid-template-2",
+		UserID:         "user",
+		ChannelID:      "chan",
+		PostAt:         clk.Now(),
+		MessageContent: "from {{author}}, occurrence {{occurrence}}",
+		Timezone:       "UTC",
+	}
+
+	mockStore.EXPECT().DeleteScheduledMessage(msg.UserID, msg.ID).Return(nil)
+	mockChannel.EXPECT().GetInfoOrUnknown("chan").Return(&ports.ChannelInfo{ChannelLink: constants.UnknownChannelPlaceholder})
+	mockUsers.EXPECT().Get("user").Return(nil, errors.New("boom"))
+	mockPoster.EXPECT().CreatePost(scheduledPost(msg, msg.ChannelID, "from "+constants.UnknownUserPlaceholder+", occurrence 1", msg.UserID)).Return(nil)
+
+	err := s.SendNow(msg)
+
+	require.NoError(t, err)
+}
+
+func TestProcessDueMessages_RecordsMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPoster := mock.NewMockPostService(ctrl)
+	mockStore := mock.NewMockStore(ctrl)
+	mockMetrics := mock.NewMockMetrics(ctrl)
+
+	clk := testutil.NewManualClock(time.Now().UTC())
+	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mock.NewMockChannelService(ctrl), mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, mockMetrics)
+
+	due := &types.ScheduledMessage{ID: "due", UserID: "user", ChannelID: "chan", PostAt: clk.Now().Add(-90 * time.Second), MessageContent: "hi"}
+	later := &types.ScheduledMessage{ID: "later", UserID: "user", ChannelID: "chan", PostAt: clk.Now().Add(time.Hour), MessageContent: "hi"}
+
+	mockStore.EXPECT().ListScheduledMessages().Return([]*types.ScheduledMessage{due, later}, nil)
+	mockStore.EXPECT().DeleteScheduledMessage(due.UserID, due.ID).Return(nil)
+	mockPoster.EXPECT().CreatePost(gomock.Any()).Return(nil)
+	mockStore.EXPECT().GetReceiptPreference(due.UserID).Return(false, nil)
+	mockMetrics.EXPECT().IncDelivered()
+	mockMetrics.EXPECT().ObserveLateness(90 * time.Second)
+	mockMetrics.EXPECT().SetPendingMessages(1)
+	mockMetrics.EXPECT().ObserveTickDuration(time.Duration(0))
+
+	s.processDueMessages()
+}
+
+func TestProcessDueMessages_BatchesReceipts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPoster := mock.NewMockPostService(ctrl)
+	mockStore := mock.NewMockStore(ctrl)
+	mockChannel := mock.NewMockChannelService(ctrl)
+	mockConfig := mock.NewMockConfigService(ctrl)
+	clk := testutil.NewManualClock(time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC))
+	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mockChannel, mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mockConfig, "bot", clk, testutil.FakeMetrics{})
+
+	onTime := &types.ScheduledMessage{ID: "on-time", UserID: "alice", ChannelIDs: []string{"c1", "c2"}, PostAt: clk.Now(), Sequence: 1, MessageContent: "a"}
+	late := &types.ScheduledMessage{ID: "late", UserID: "alice", ChannelID: "c1", PostAt: clk.Now().Add(-5 * time.Minute), Sequence: 2, MessageContent: "b"}
+	quiet := &types.ScheduledMessage{ID: "quiet", UserID: "alice", ChannelID: "c1", PostAt: clk.Now(), Sequence: 3, MessageContent: "c", Receipt: constants.ReceiptOff}
+	failed := &types.ScheduledMessage{ID: "failed", UserID: "bob", ChannelID: "gone", PostAt: clk.Now(), Sequence: 4, MessageContent: "d", Receipt: constants.ReceiptOn}
+	asked := &types.ScheduledMessage{ID: "asked", UserID: "carol", ChannelID: "c2", PostAt: clk.Now(), Sequence: 5, MessageContent: "e", Receipt: constants.ReceiptOn}
+	info := func(channelID string) *ports.ChannelInfo {
+		return &ports.ChannelInfo{ChannelID: channelID, ChannelLink: "~" + channelID}
+	}
+
+	mockStore.EXPECT().ListScheduledMessages().Return([]*types.ScheduledMessage{onTime, late, quiet, failed, asked}, nil)
+	mockStore.EXPECT().DeleteScheduledMessage(gomock.Any(), gomock.Any()).Return(nil).Times(5)
+	mockPoster.EXPECT().CreatePost(gomock.Any()).DoAndReturn(func(post *model.Post) error {
+		if post.ChannelId == "gone" {
+			return errors.New("channel archived")
+		}
+		post.Id = post.GetProp(constants.PropScheduledMessageID).(string) + "-" + post.ChannelId
+		return nil
+	}).Times(6)
+	mockChannel.EXPECT().GetInfoOrUnknown(gomock.Any()).DoAndReturn(info).AnyTimes()
+	mockChannel.EXPECT().MakeChannelLink(gomock.Any()).DoAndReturn(func(i *ports.ChannelInfo) string {
+		return "in channel: " + i.ChannelLink
+	}).AnyTimes()
+	mockPoster.EXPECT().DM("bot", "bob", gomock.Any()).Return(nil)
+	mockStore.EXPECT().GetReceiptPreference("alice").Return(true, nil)
+	mockConfig.EXPECT().GetConfig().Return(&model.Config{ServiceSettings: model.ServiceSettings{SiteURL: model.NewPointer("https://mm.example.com/")}})
+	mockPoster.EXPECT().DM("bot", "alice", &model.Post{Message: formatter.FormatDeliveryReceipt([]string{
+		formatter.FormatReceiptEntry("https://mm.example.com/_redirect/pl/late-c1", "in channel: ~c1", 5*time.Minute),
+		formatter.FormatReceiptEntry("https://mm.example.com/_redirect/pl/on-time-c1", "in channel: ~c1", 0),
+		formatter.FormatReceiptEntry("https://mm.example.com/_redirect/pl/on-time-c2", "in channel: ~c2", 0),
+	})}).Return(nil)
+	mockPoster.EXPECT().DM("bot", "carol", &model.Post{Message: formatter.FormatDeliveryReceipt([]string{
+		formatter.FormatReceiptEntry("https://mm.example.com/_redirect/pl/asked-c2", "in channel: ~c2", 0),
+	})}).Return(nil)
+
+	s.processDueMessages()
+	assert.Empty(t, s.receipts)
+}
+
+func TestProcessDueMessages_PostsInScheduledOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPoster := mock.NewMockPostService(ctrl)
+	mockStore := mock.NewMockStore(ctrl)
+	clk := testutil.NewManualClock(time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC))
+	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mock.NewMockChannelService(ctrl), mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
+
+	part := func(id string, seq int64, postAt time.Time) *types.ScheduledMessage {
+		return &types.ScheduledMessage{ID: id, UserID: "user", ChannelID: "chan", PostAt: postAt, Sequence: seq, MessageContent: id}
+	}
+	earlier := part("earlier", 9, clk.Now().Add(-time.Minute))
+	first := part("z-first", 1, clk.Now())
+	second := part("a-second", 2, clk.Now())
+	third := part("m-third", 3, clk.Now())
+
+	mockStore.EXPECT().ListScheduledMessages().Return([]*types.ScheduledMessage{third, first, earlier, second}, nil)
+	mockStore.EXPECT().DeleteScheduledMessage("user", gomock.Any()).Return(nil).Times(4)
+	mockStore.EXPECT().GetReceiptPreference("user").Return(false, nil)
+	var posted []string
+	mockPoster.EXPECT().CreatePost(gomock.Any()).DoAndReturn(func(post *model.Post) error {
+		posted = append(posted, post.Message)
+		return nil
+	}).Times(4)
+
+	s.processDueMessages()
+
+	assert.Equal(t, []string{"earlier", "z-first", "a-second", "m-third"}, posted)
+}
+
+func TestProcessDueMessages_ListErrorRecordsTickDuration(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock.NewMockStore(ctrl)
+	mockMetrics := mock.NewMockMetrics(ctrl)
+
+	clk := testutil.NewManualClock(time.Now().UTC())
+	s := New(testutil.FakeLogger{}, mock.NewMockPostService(ctrl), mockStore, mock.NewMockChannelService(ctrl), mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, mockMetrics)
+
+	mockStore.EXPECT().ListScheduledMessages().Return(nil, errors.New("kv down"))
+	mockMetrics.EXPECT().ObserveTickDuration(gomock.Any())
+
+	s.processDueMessages()
+}
+
+func TestSendNow_FailureRecordsMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPoster := mock.NewMockPostService(ctrl)
+	mockStore := mock.NewMockStore(ctrl)
+	mockChannel := mock.NewMockChannelService(ctrl)
+	mockMetrics := mock.NewMockMetrics(ctrl)
+
+	clk := testutil.NewManualClock(time.Now().UTC())
+	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mockChannel, mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, mockMetrics)
+
+	msg := &types.ScheduledMessage{ID: "uuid-m1", UserID: "user", ChannelID: "chan", PostAt: clk.Now().Add(-time.Minute), MessageContent: "hi"}
+	channelInfo := &ports.ChannelInfo{ChannelID: msg.ChannelID, ChannelLink: "~chan"}
+
+	mockStore.EXPECT().DeleteScheduledMessage(msg.UserID, msg.ID).Return(nil)
+	mockPoster.EXPECT().CreatePost(gomock.Any()).Return(errors.New("fail"))
+	mockChannel.EXPECT().GetInfoOrUnknown(msg.ChannelID).Return(channelInfo)
+	mockChannel.EXPECT().MakeChannelLink(channelInfo).Return("in channel: ~chan")
+	mockPoster.EXPECT().DM("bot", msg.UserID, gomock.Any()).Return(nil)
+	mockMetrics.EXPECT().IncDeliveryFailed()
+
+	err := s.SendNow(msg)
+
+	require.Error(t, err)
+}
+
+func TestSendNow_PartialFailureRecordsMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPoster := mock.NewMockPostService(ctrl)
+	mockStore := mock.NewMockStore(ctrl)
+	mockChannel := mock.NewMockChannelService(ctrl)
+	mockMetrics := mock.NewMockMetrics(ctrl)
+
+	clk := testutil.NewManualClock(time.Now().UTC())
+	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mockChannel, mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, mockMetrics)
+
+	msg := &types.ScheduledMessage{
+		ID:             "uuid-m2",
+		UserID:         "user",
+		ChannelID:      "chan1",
+		ChannelIDs:     []string{"chan1", "chan2"},
+		PostAt:         clk.Now(),
+		MessageContent: "hi",
+	}
+	channelInfo := &ports.ChannelInfo{ChannelID: "chan2", ChannelLink: "~chan2"}
+
+	mockStore.EXPECT().DeleteScheduledMessage(msg.UserID, msg.ID).Return(nil)
+	mockPoster.EXPECT().CreatePost(gomock.Any()).DoAndReturn(func(post *model.Post) error {
+		if post.ChannelId == "chan2" {
+			return errors.New("archived")
+		}
+		return nil
+	}).Times(2)
+	mockChannel.EXPECT().GetInfoOrUnknown("chan2").Return(channelInfo)
+	mockChannel.EXPECT().MakeChannelLink(channelInfo).Return("in channel: ~chan2")
+	mockPoster.EXPECT().DM("bot", msg.UserID, gomock.Any()).Return(nil)
+	mockMetrics.EXPECT().IncDelivered()
+	mockMetrics.EXPECT().IncDeliveryFailed()
+	mockMetrics.EXPECT().ObserveLateness(time.Duration(0))
+
+	err := s.SendNow(msg)
+
+	require.Error(t, err)
+}
+
+func TestScheduler_TickTimes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock.NewMockStore(ctrl)
+	clk := testutil.NewManualClock(time.Date(2024, 1, 15, 10, 30, 20, 0, time.UTC))
+	s := New(testutil.FakeLogger{}, mock.NewMockPostService(ctrl), mockStore, mock.NewMockChannelService(ctrl), mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
+
+	assert.True(t, s.LastTick().IsZero())
+	assert.True(t, s.NextTick().IsZero())
+
+	mockStore.EXPECT().ListScheduledMessages().Return(nil, nil).Times(2)
+	s.processDueMessages()
+	assert.Equal(t, clk.Now(), s.LastTick())
+
+	stop := startScheduler(t, s)
+	// With nothing queued the scheduler sleeps until the next resync.
+	waitUntilWaiting(t, s, clk, time.Date(2024, 1, 15, 10, 35, 0, 0, time.UTC))
+
+	postAt := time.Date(2024, 1, 15, 10, 32, 15, 0, time.UTC)
+	s.MessageScheduled(&types.ScheduledMessage{ID: "m1", PostAt: postAt})
+	waitUntilWaiting(t, s, clk, postAt)
+
+	stop()
+	assert.True(t, s.NextTick().IsZero())
+}
+
+func TestProcessQueue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock.NewMockStore(ctrl)
+	mockPoster := mock.NewMockPostService(ctrl)
+	clk := testutil.NewManualClock(time.Date(2024, 1, 15, 10, 30, 20, 0, time.UTC))
+	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mock.NewMockChannelService(ctrl), mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
+
+	due := &types.ScheduledMessage{ID: "due", UserID: "u", ChannelID: "c", PostAt: clk.Now(), MessageContent: "hi"}
+	edited := &types.ScheduledMessage{ID: "edited", UserID: "u", ChannelID: "c", PostAt: clk.Now().Add(time.Hour)}
+	later := &types.ScheduledMessage{ID: "later", PostAt: clk.Now().Add(time.Second)}
+	s.MessageScheduled(due)
+	s.MessageScheduled(&types.ScheduledMessage{ID: "edited", PostAt: clk.Now().Add(-time.Second)})
+	s.MessageScheduled(&types.ScheduledMessage{ID: "sent", PostAt: clk.Now().Add(-time.Minute)})
+	s.MessageScheduled(later)
+
+	mockStore.EXPECT().GetScheduledMessage("due").Return(due, nil)
+	mockStore.EXPECT().GetScheduledMessage("edited").Return(edited, nil)
+	mockStore.EXPECT().GetScheduledMessage("sent").Return(nil, types.ErrMessageNotFound)
+	mockStore.EXPECT().DeleteScheduledMessage("u", "due").Return(nil)
+	mockStore.EXPECT().GetReceiptPreference("u").Return(false, nil)
+	mockPoster.EXPECT().CreatePost(gomock.Any()).Return(nil)
+
+	s.processQueue()
+
+	assert.Equal(t, clk.Now(), s.LastTick())
+	next, ok := s.queue.next()
+	require.True(t, ok)
+	assert.Equal(t, later.PostAt, next)
+	assert.Equal(t, 2, s.queue.Len())
+}
+
+func TestProcessDueMessages_RebuildsQueue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock.NewMockStore(ctrl)
+	clk := testutil.NewManualClock(time.Date(2024, 1, 15, 10, 30, 20, 0, time.UTC))
+	s := New(testutil.FakeLogger{}, mock.NewMockPostService(ctrl), mockStore, mock.NewMockChannelService(ctrl), mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
+
+	upcoming := &types.ScheduledMessage{ID: "upcoming", PostAt: clk.Now().Add(time.Hour)}
+	s.MessageScheduled(&types.ScheduledMessage{ID: "stale", PostAt: clk.Now().Add(time.Minute)})
+	mockStore.EXPECT().ListScheduledMessages().DoAndReturn(func() ([]*types.ScheduledMessage, error) {
+		// Saved while the store was being read.
+		s.MessageScheduled(&types.ScheduledMessage{ID: "saving", PostAt: clk.Now().Add(2 * time.Minute)})
+		return []*types.ScheduledMessage{upcoming}, nil
+	})
+
+	s.processDueMessages()
+
+	assert.Equal(t, []string{"saving", "upcoming"}, s.queue.popDue(clk.Now().Add(24*time.Hour)))
+}
+
+func TestStop_WaitsForInFlightDelivery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock.NewMockStore(ctrl)
+	mockPoster := mock.NewMockPostService(ctrl)
+	clk := testutil.NewManualClock(time.Date(2024, 1, 15, 10, 30, 20, 0, time.UTC))
+	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mock.NewMockChannelService(ctrl), mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
+	first := &types.ScheduledMessage{ID: "first", UserID: "user", ChannelID: "chan", PostAt: clk.Now().Add(-time.Minute)}
+	second := &types.ScheduledMessage{ID: "second", UserID: "user", ChannelID: "chan", PostAt: clk.Now().Add(-time.Minute)}
+
+	posting := make(chan struct{})
+	release := make(chan struct{})
+	mockStore.EXPECT().ListScheduledMessages().Return([]*types.ScheduledMessage{first, second}, nil)
+	// Only the first message is sent: the second would start after Stop.
+	mockStore.EXPECT().DeleteScheduledMessage("user", "first").Return(nil)
+	mockStore.EXPECT().GetReceiptPreference("user").Return(false, nil)
+	mockPoster.EXPECT().CreatePost(gomock.Any()).DoAndReturn(func(*model.Post) error {
+		close(posting)
+		<-release
+		return nil
+	})
+
+	s.Start()
+	<-posting
+	stopped := make(chan error)
+	go func() { stopped <- s.Stop() }()
+	require.Eventually(t, func() bool { return s.ctx.Err() != nil }, time.Second, time.Millisecond)
+
+	select {
+	case <-stopped:
+		t.Fatal("Stop returned while a delivery was in flight")
+	default:
+	}
+	close(release)
+	require.NoError(t, <-stopped)
+}
+
+func TestStop_TimesOut(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock.NewMockStore(ctrl)
+	mockPoster := mock.NewMockPostService(ctrl)
+	clk := testutil.NewManualClock(time.Date(2024, 1, 15, 10, 30, 20, 0, time.UTC))
+	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mock.NewMockChannelService(ctrl), mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
+	msg := &types.ScheduledMessage{ID: "m1", UserID: "user", ChannelID: "chan", PostAt: clk.Now()}
+
+	posting := make(chan struct{})
+	release := make(chan struct{})
+	mockStore.EXPECT().DeleteScheduledMessage("user", "m1").Return(nil)
+	mockPoster.EXPECT().CreatePost(gomock.Any()).DoAndReturn(func(*model.Post) error {
+		close(posting)
+		<-release
+		return nil
+	})
+	sent := make(chan error)
+	go func() { sent <- s.SendNow(msg) }()
+	<-posting
+
+	stopped := make(chan error)
+	go func() { stopped <- s.Stop() }()
+	// Wait for Stop's timeout timer, then let it expire.
+	require.Eventually(t, func() bool { return clk.Waiters() == 1 }, time.Second, time.Millisecond)
+	clk.Advance(s.stopTimeout)
+
+	require.EqualError(t, <-stopped, "timed out after 30s waiting for in-flight deliveries")
+	close(release)
+	require.NoError(t, <-sent)
+}
+
+func TestSendNow_AfterStop(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clk := testutil.NewManualClock(time.Date(2024, 1, 15, 10, 30, 20, 0, time.UTC))
+	s := New(testutil.FakeLogger{}, mock.NewMockPostService(ctrl), mock.NewMockStore(ctrl), mock.NewMockChannelService(ctrl), mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
+
+	require.NoError(t, s.Stop())
+
+	err := s.SendNow(&types.ScheduledMessage{ID: "m1", UserID: "user"})
+	require.EqualError(t, err, "scheduler is stopped")
+	// A stopped scheduler cannot be restarted.
+	s.Start()
+	assert.Zero(t, clk.Waiters())
+}
+
+func TestHandleDueMessage_LatePolicy(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	newMsg := func(late time.Duration) *types.ScheduledMessage {
+		return &types.ScheduledMessage{ID: "m1", UserID: "user", ChannelID: "chan", PostAt: now.Add(-late), MessageContent: "hi", Timezone: "America/New_York"}
+	}
+	setup := func(t *testing.T, latePolicy string) (*Scheduler, *mock.MockStore, *mock.MockPostService, *mock.MockChannelService) {
+		ctrl := gomock.NewController(t)
+		mockStore := mock.NewMockStore(ctrl)
+		mockPoster := mock.NewMockPostService(ctrl)
+		mockChannel := mock.NewMockChannelService(ctrl)
+		s := New(testutil.FakeLogger{}, mockPoster, mockStore, mockChannel, mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", testutil.NewManualClock(now), testutil.FakeMetrics{})
+		s.SetPolicy(types.DeliveryPolicy{LatePolicy: latePolicy, LateThreshold: 15 * time.Minute})
+		return s, mockStore, mockPoster, mockChannel
+	}
+
+	t.Run("within threshold posts as is", func(t *testing.T) {
+		s, mockStore, mockPoster, _ := setup(t, constants.LatePolicySkip)
+		msg := newMsg(15 * time.Minute)
+		mockStore.EXPECT().DeleteScheduledMessage("user", "m1").Return(nil)
+		mockPoster.EXPECT().CreatePost(scheduledPost(msg, "chan", "hi", "user")).Return(nil)
+
+		s.handleDueMessage(msg, now)
+	})
+	t.Run("send posts late messages as is", func(t *testing.T) {
+		s, mockStore, mockPoster, _ := setup(t, constants.LatePolicySend)
+		msg := newMsg(3 * time.Hour)
+		mockStore.EXPECT().DeleteScheduledMessage("user", "m1").Return(nil)
+		mockPoster.EXPECT().CreatePost(scheduledPost(msg, "chan", "hi", "user")).Return(nil)
+
+		s.handleDueMessage(msg, now)
+	})
+	t.Run("annotate adds a delay note", func(t *testing.T) {
+		s, mockStore, mockPoster, _ := setup(t, constants.LatePolicyAnnotate)
+		msg := newMsg(3 * time.Hour)
+		mockStore.EXPECT().DeleteScheduledMessage("user", "m1").Return(nil)
+		mockPoster.EXPECT().CreatePost(scheduledPost(msg, "chan", "hi\n\n_(delayed from 4:00 AM)_", "user")).Return(nil)
+
+		s.handleDueMessage(msg, now)
+
+		assert.Equal(t, "hi", msg.MessageContent)
+	})
+	t.Run("skip deletes and notifies the author", func(t *testing.T) {
+		s, mockStore, mockPoster, mockChannel := setup(t, constants.LatePolicySkip)
+		mockStore.EXPECT().DeleteScheduledMessage("user", "m1").Return(nil)
+		mockChannel.EXPECT().GetInfoOrUnknown("chan").Return(&ports.ChannelInfo{ChannelID: "chan"})
+		mockChannel.EXPECT().MakeChannelLink(gomock.Any()).Return("in channel: ~town-square")
+		mockPoster.EXPECT().DM("bot", "user", &model.Post{
+			Message: formatter.FormatSkippedLateMessage("in channel: ~town-square", now.Add(-3*time.Hour).In(testutil.MustLoadLocation(t, "America/New_York")), 3*time.Hour, "hi"),
+		}).Return(nil)
+
+		s.handleDueMessage(newMsg(3*time.Hour), now)
+	})
+	t.Run("skip sends messages the scheduler deferred", func(t *testing.T) {
+		s, mockStore, mockPoster, _ := setup(t, constants.LatePolicySkip)
+		msg := newMsg(3 * time.Hour)
+		msg.DeferredUntil = now
+		mockStore.EXPECT().DeleteScheduledMessage("user", "m1").Return(nil)
+		mockPoster.EXPECT().CreatePost(scheduledPost(msg, "chan", "hi", "user")).Return(nil)
+
+		s.handleDueMessage(msg, now)
+	})
+}
+
+func TestHandleDueMessage_UnlessReplied(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	scheduledAt := now.Add(-24 * time.Hour)
+	newMsg := func() *types.ScheduledMessage {
+		return &types.ScheduledMessage{ID: "m1", UserID: "user", ChannelID: "chan", RootID: "root", PostAt: now, MessageContent: "any update?", Timezone: "UTC", CancelIfRepliedAfter: scheduledAt}
+	}
+	thread := func(posts ...*model.Post) *model.PostList {
+		list := model.NewPostList()
+		list.AddPost(&model.Post{Id: "root", UserId: "user", CreateAt: scheduledAt.Add(-time.Hour).UnixMilli()})
+		for _, post := range posts {
+			list.AddPost(post)
+		}
+		return list
+	}
+	setup := func(t *testing.T) (*Scheduler, *mock.MockStore, *mock.MockPostService, *mock.MockChannelService) {
+		ctrl := gomock.NewController(t)
+		mockStore := mock.NewMockStore(ctrl)
+		mockPoster := mock.NewMockPostService(ctrl)
+		mockChannel := mock.NewMockChannelService(ctrl)
+		s := New(testutil.FakeLogger{}, mockPoster, mockStore, mockChannel, mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", testutil.NewManualClock(now), testutil.FakeMetrics{})
+		s.SetPolicy(types.DeliveryPolicy{LatePolicy: constants.LatePolicySend})
+		return s, mockStore, mockPoster, mockChannel
+	}
+
+	t.Run("posts when only the author and their scheduled posts replied", func(t *testing.T) {
+		s, mockStore, mockPoster, _ := setup(t)
+		msg := newMsg()
+		onBehalf := &model.Post{Id: "p2", UserId: "bot", CreateAt: now.Add(-time.Hour).UnixMilli()}
+		onBehalf.AddProp(constants.PropOnBehalfOfUserID, "user")
+		mockPoster.EXPECT().GetPostThread("root").Return(thread(
+			&model.Post{Id: "p1", UserId: "user", CreateAt: now.Add(-2 * time.Hour).UnixMilli()},
+			onBehalf,
+			&model.Post{Id: "p3", UserId: "other", CreateAt: now.Add(-time.Hour).UnixMilli(), Type: model.PostTypeJoinChannel},
+			&model.Post{Id: "p4", UserId: "other", CreateAt: scheduledAt.Add(-time.Minute).UnixMilli()},
+		), nil)
+		mockStore.EXPECT().DeleteScheduledMessage("user", "m1").Return(nil)
+		mockPoster.EXPECT().CreatePost(scheduledPost(msg, "chan", "any update?", "user")).Return(nil)
+
+		s.handleDueMessage(msg, now)
+	})
+	t.Run("cancels and tells the author when someone replied", func(t *testing.T) {
+		s, mockStore, mockPoster, mockChannel := setup(t)
+		msg := newMsg()
+		msg.Receipt = constants.ReceiptOn
+		mockPoster.EXPECT().GetPostThread("root").Return(thread(&model.Post{Id: "p1", UserId: "other", CreateAt: now.Add(-time.Hour).UnixMilli()}), nil)
+		mockStore.EXPECT().DeleteScheduledMessage("user", "m1").Return(nil)
+		mockChannel.EXPECT().GetInfoOrUnknown("chan").Return(&ports.ChannelInfo{ChannelID: "chan"})
+		mockChannel.EXPECT().MakeChannelLink(gomock.Any()).Return("in channel: ~town-square")
+		mockPoster.EXPECT().DM("bot", "user", &model.Post{Message: formatter.FormatCancelledReplied("in channel: ~town-square", "any update?")}).Return(nil)
+
+		s.handleDueMessage(msg, now)
+	})
+	t.Run("tells the author without receipts", func(t *testing.T) {
+		s, mockStore, mockPoster, mockChannel := setup(t)
+		mockPoster.EXPECT().GetPostThread("root").Return(thread(&model.Post{Id: "p1", UserId: "other", CreateAt: now.Add(-time.Hour).UnixMilli()}), nil)
+		mockStore.EXPECT().DeleteScheduledMessage("user", "m1").Return(nil)
+		mockChannel.EXPECT().GetInfoOrUnknown("chan").Return(&ports.ChannelInfo{ChannelID: "chan"})
+		mockChannel.EXPECT().MakeChannelLink(gomock.Any()).Return("in channel: ~town-square")
+		mockPoster.EXPECT().DM("bot", "user", &model.Post{Message: formatter.FormatCancelledReplied("in channel: ~town-square", "any update?")}).Return(nil)
+
+		s.handleDueMessage(newMsg(), now)
+	})
+	t.Run("posts when the thread cannot be read", func(t *testing.T) {
+		s, mockStore, mockPoster, _ := setup(t)
+		msg := newMsg()
+		mockPoster.EXPECT().GetPostThread("root").Return(nil, errors.New("boom"))
+		mockStore.EXPECT().DeleteScheduledMessage("user", "m1").Return(nil)
+		mockPoster.EXPECT().CreatePost(scheduledPost(msg, "chan", "any update?", "user")).Return(nil)
+
+		s.handleDueMessage(msg, now)
+	})
+}
+
+func TestHandleDueMessage_Followup(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	asked := now.Add(-48 * time.Hour)
+	newMsg := func() *types.ScheduledMessage {
+		return &types.ScheduledMessage{ID: "f1", UserID: "user", ChannelID: "chan", RootID: "root", PostAt: now, MessageContent: constants.FollowupDefaultNote, Timezone: "UTC", CancelIfRepliedAfter: asked, ReplyFrom: []string{"bob"}, Followup: constants.FollowupDM}
+	}
+	setup := func(t *testing.T) (*Scheduler, *mock.MockStore, *mock.MockPostService, *mock.MockChannelService, *mock.MockConfigService) {
+		ctrl := gomock.NewController(t)
+		mockStore := mock.NewMockStore(ctrl)
+		mockPoster := mock.NewMockPostService(ctrl)
+		mockChannel := mock.NewMockChannelService(ctrl)
+		mockConfig := mock.NewMockConfigService(ctrl)
+		s := New(testutil.FakeLogger{}, mockPoster, mockStore, mockChannel, mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mockConfig, "bot", testutil.NewManualClock(now), testutil.FakeMetrics{})
+		s.SetPolicy(types.DeliveryPolicy{LatePolicy: constants.LatePolicySend})
+		return s, mockStore, mockPoster, mockChannel, mockConfig
+	}
+
+	t.Run("reminds the author when only others replied", func(t *testing.T) {
+		s, mockStore, mockPoster, mockChannel, mockConfig := setup(t)
+		thread := model.NewPostList()
+		thread.AddPost(&model.Post{Id: "p1", UserId: "carol", CreateAt: now.Add(-time.Hour).UnixMilli()})
+		mockPoster.EXPECT().GetPostThread("root").Return(thread, nil)
+		mockStore.EXPECT().DeleteScheduledMessage("user", "f1").Return(nil)
+		mockChannel.EXPECT().GetInfoOrUnknown("chan").Return(&ports.ChannelInfo{ChannelID: "chan"})
+		mockChannel.EXPECT().MakeChannelLink(gomock.Any()).Return("in channel: ~town-square")
+		mockConfig.EXPECT().GetConfig().Return(&model.Config{ServiceSettings: model.ServiceSettings{SiteURL: model.NewPointer("https://mm.example.com")}})
+		mockPoster.EXPECT().DM("bot", "user", &model.Post{
+			Message: formatter.FormatFollowupReminder("https://mm.example.com/_redirect/pl/root", "in channel: ~town-square", constants.FollowupDefaultNote),
+		}).Return(nil)
+
+		s.handleDueMessage(newMsg(), now)
+	})
+	t.Run("cancels when a watched user replied on their own or through the bot", func(t *testing.T) {
+		s, mockStore, mockPoster, mockChannel, _ := setup(t)
+		msg := newMsg()
+		msg.Receipt = constants.ReceiptOff
+		reply := &model.Post{Id: "p1", UserId: "bot", CreateAt: now.Add(-time.Hour).UnixMilli()}
+		reply.AddProp(constants.PropOnBehalfOfUserID, "bob")
+		thread := model.NewPostList()
+		thread.AddPost(reply)
+		mockPoster.EXPECT().GetPostThread("root").Return(thread, nil)
+		mockStore.EXPECT().DeleteScheduledMessage("user", "f1").Return(nil)
+		mockChannel.EXPECT().GetInfoOrUnknown("chan").Return(&ports.ChannelInfo{ChannelID: "chan"})
+		mockChannel.EXPECT().MakeChannelLink(gomock.Any()).Return("in channel: ~town-square")
+		mockPoster.EXPECT().DM("bot", "user", &model.Post{Message: formatter.FormatFollowupAnswered("in channel: ~town-square")}).Return(nil)
+
+		s.handleDueMessage(msg, now)
+	})
+}
+
+func TestSendNow_FollowupReminder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock.NewMockStore(ctrl)
+	mockPoster := mock.NewMockPostService(ctrl)
+	mockChannel := mock.NewMockChannelService(ctrl)
+	mockConfig := mock.NewMockConfigService(ctrl)
+	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mockChannel, mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mockConfig, "bot", testutil.NewManualClock(time.Now()), testutil.FakeMetrics{})
+	msg := &types.ScheduledMessage{ID: "f1", UserID: "user", ChannelID: "chan", RootID: "root", MessageContent: "ping", Followup: constants.FollowupDM}
+
+	mockStore.EXPECT().DeleteScheduledMessage("user", "f1").Return(nil)
+	mockChannel.EXPECT().GetInfoOrUnknown("chan").Return(&ports.ChannelInfo{ChannelID: "chan"})
+	mockChannel.EXPECT().MakeChannelLink(gomock.Any()).Return("in channel: ~town-square")
+	mockConfig.EXPECT().GetConfig().Return(&model.Config{ServiceSettings: model.ServiceSettings{SiteURL: model.NewPointer("https://mm.example.com")}})
+	mockPoster.EXPECT().DM("bot", "user", gomock.Any()).Return(errors.New("dm failed"))
+
+	err := s.SendNow(msg)
+
+	assert.EqualError(t, err, "failed to send follow-up reminder: dm failed")
+}
+
+func TestHandleDueMessage_RateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock.NewMockStore(ctrl)
+	mockPoster := mock.NewMockPostService(ctrl)
+	mockMetrics := mock.NewMockMetrics(ctrl)
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mock.NewMockChannelService(ctrl), mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", testutil.NewManualClock(now), mockMetrics)
+	s.SetPolicy(types.DeliveryPolicy{LatePolicy: constants.LatePolicySend, ChannelRateLimit: 1})
+
+	msg := func(id string, channelIDs ...string) *types.ScheduledMessage {
+		return &types.ScheduledMessage{ID: id, UserID: "user", ChannelIDs: channelIDs, PostAt: now, MessageContent: id}
+	}
+	retryAt := now.Add(time.Minute)
+
+	mockStore.EXPECT().DeleteScheduledMessage("user", gomock.Any()).Return(nil).Times(2)
+	var posted []string
+	mockPoster.EXPECT().CreatePost(gomock.Any()).DoAndReturn(func(post *model.Post) error {
+		posted = append(posted, post.Message)
+		return nil
+	}).Times(2)
+	mockMetrics.EXPECT().IncDelivered().Times(2)
+	mockMetrics.EXPECT().ObserveLateness(time.Duration(0)).Times(2)
+	mockMetrics.EXPECT().IncDeferred().Times(3)
+	mockStore.EXPECT().DeferScheduledMessage("crosspost", retryAt).Return(true, nil)
+	mockStore.EXPECT().DeferScheduledMessage("second", retryAt).Return(true, nil)
+	// Deleted while it was being deferred, so it is not requeued.
+	mockStore.EXPECT().DeferScheduledMessage("other-second", retryAt).Return(false, nil)
+
+	for _, m := range []*types.ScheduledMessage{
+		msg("first", "a"),
+		msg("crosspost", "a", "b"),
+		// b is free, but the crosspost to it is waiting.
+		msg("second", "b"),
+		msg("other", "c"),
+		msg("other-second", "c"),
+	} {
+		s.handleDueMessage(m, now)
+	}
+
+	assert.Equal(t, []string{"first", "other"}, posted)
+	assert.Equal(t, 2, s.queue.Len())
+	next, _ := s.queue.next()
+	assert.Equal(t, retryAt, next)
+	assert.Equal(t, []string{"crosspost", "second"}, s.queue.popDue(retryAt))
+}
+
+func TestProcessDueMessages_WaitsForDeferredMessages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock.NewMockStore(ctrl)
+	clk := testutil.NewManualClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	s := New(testutil.FakeLogger{}, mock.NewMockPostService(ctrl), mockStore, mock.NewMockChannelService(ctrl), mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
+
+	deferred := &types.ScheduledMessage{ID: "m1", UserID: "user", ChannelID: "chan", PostAt: clk.Now().Add(-time.Minute), DeferredUntil: clk.Now().Add(30 * time.Second)}
+	mockStore.EXPECT().ListScheduledMessages().Return([]*types.ScheduledMessage{deferred}, nil)
+
+	s.processDueMessages()
+
+	next, ok := s.queue.next()
+	require.True(t, ok)
+	assert.Equal(t, deferred.DeferredUntil, next)
+}
+
+func TestSendNow_Attribution(t *testing.T) {
+	setup := func(t *testing.T, defaultAttribution string) (*Scheduler, *mock.MockStore, *mock.MockPostService, *mock.MockChannelService, *mock.MockUserService) {
+		ctrl := gomock.NewController(t)
+		mockStore := mock.NewMockStore(ctrl)
+		mockPoster := mock.NewMockPostService(ctrl)
+		mockChannel := mock.NewMockChannelService(ctrl)
+		mockUsers := mock.NewMockUserService(ctrl)
+		s := New(testutil.FakeLogger{}, mockPoster, mockStore, mockChannel, mockUsers, mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", testutil.NewManualClock(time.Now()), testutil.FakeMetrics{})
+		s.SetPolicy(types.DeliveryPolicy{LatePolicy: constants.LatePolicySend, Attribution: defaultAttribution})
+		mockStore.EXPECT().DeleteScheduledMessage("user", "m1").Return(nil)
+		return s, mockStore, mockPoster, mockChannel, mockUsers
+	}
+	newMsg := func(attribution string) *types.ScheduledMessage {
+		return &types.ScheduledMessage{ID: "m1", UserID: "user", ChannelID: "chan", PostAt: time.Now(), MessageContent: "hi", Attribution: attribution}
+	}
+
+	t.Run("admin default posts as the bot", func(t *testing.T) {
+		s, _, mockPoster, mockChannel, mockUsers := setup(t, constants.AttributionBot)
+		mockChannel.EXPECT().GetInfoOrUnknown("chan").Return(&ports.ChannelInfo{ChannelID: "chan", ChannelType: model.ChannelTypeOpen})
+		mockUsers.EXPECT().Get("user").Return(&model.User{Username: "alice"}, nil)
+		msg := newMsg("")
+		expected := scheduledPost(msg, "chan", "hi\n\n_Scheduled by @alice_", "bot")
+		expected.AddProp(constants.PropOnBehalfOfUserID, "user")
+		mockPoster.EXPECT().CreatePost(expected).Return(nil)
+
+		require.NoError(t, s.SendNow(msg))
+	})
+	t.Run("unknown author in the footer", func(t *testing.T) {
+		s, _, mockPoster, mockChannel, mockUsers := setup(t, constants.AttributionBot)
+		mockChannel.EXPECT().GetInfoOrUnknown("chan").Return(&ports.ChannelInfo{ChannelID: "chan", ChannelType: model.ChannelTypeOpen})
+		mockUsers.EXPECT().Get("user").Return(nil, errors.New("boom"))
+		msg := newMsg("")
+		expected := scheduledPost(msg, "chan", "hi\n\n_Scheduled by "+constants.UnknownUserPlaceholder+"_", "bot")
+		expected.AddProp(constants.PropOnBehalfOfUserID, "user")
+		mockPoster.EXPECT().CreatePost(expected).Return(nil)
+
+		require.NoError(t, s.SendNow(msg))
+	})
+	t.Run("message setting overrides the default", func(t *testing.T) {
+		s, _, mockPoster, _, _ := setup(t, constants.AttributionBot)
+		msg := newMsg(constants.AttributionUser)
+		mockPoster.EXPECT().CreatePost(scheduledPost(msg, "chan", "hi", "user")).Return(nil)
+
+		require.NoError(t, s.SendNow(msg))
+	})
+	t.Run("direct messages post as the author", func(t *testing.T) {
+		s, _, mockPoster, mockChannel, _ := setup(t, constants.AttributionUser)
+		mockChannel.EXPECT().GetInfoOrUnknown("chan").Return(&ports.ChannelInfo{ChannelID: "chan", ChannelType: model.ChannelTypeDirect})
+		msg := newMsg(constants.AttributionBot)
+		mockPoster.EXPECT().CreatePost(scheduledPost(msg, "chan", "hi", "user")).Return(nil)
+
+		require.NoError(t, s.SendNow(msg))
+	})
+}
+
+func TestSendNow_Forwarded(t *testing.T) {
+	setup := func(t *testing.T) (*Scheduler, *mock.MockPostService, *mock.MockFileService, *mock.MockUserService, *mock.MockChannelService) {
+		ctrl := gomock.NewController(t)
+		mockStore := mock.NewMockStore(ctrl)
+		mockPoster := mock.NewMockPostService(ctrl)
+		mockChannel := mock.NewMockChannelService(ctrl)
+		mockUsers := mock.NewMockUserService(ctrl)
+		mockFiles := mock.NewMockFileService(ctrl)
+		mockConfig := mock.NewMockConfigService(ctrl)
+		s := New(testutil.FakeLogger{}, mockPoster, mockStore, mockChannel, mockUsers, mockFiles, mockConfig, "bot", testutil.NewManualClock(time.Now()), testutil.FakeMetrics{})
+		s.SetPolicy(types.DeliveryPolicy{LatePolicy: constants.LatePolicySend, Attribution: constants.AttributionBot})
+		mockStore.EXPECT().DeleteScheduledMessage("user", "m1").Return(nil)
+		mockChannel.EXPECT().GetInfoOrUnknown("chan").Return(&ports.ChannelInfo{ChannelID: "chan", ChannelType: model.ChannelTypeOpen})
+		mockConfig.EXPECT().GetConfig().Return(&model.Config{ServiceSettings: model.ServiceSettings{SiteURL: model.NewPointer("https://mm.example.com/")}})
+		return s, mockPoster, mockFiles, mockUsers, mockChannel
+	}
+	newMsg := func() *types.ScheduledMessage {
+		return &types.ScheduledMessage{ID: "m1", UserID: "user", ChannelID: "chan", PostAt: time.Now(), MessageContent: "> hi", ForwardedPostID: "orig", FileIDs: []string{"f1", "f2"}}
+	}
+
+	t.Run("links the original and copies its files for the poster", func(t *testing.T) {
+		s, mockPoster, mockFiles, mockUsers, _ := setup(t)
+		msg := newMsg()
+		mockUsers.EXPECT().Get("user").Return(&model.User{Username: "alice"}, nil)
+		mockFiles.EXPECT().CopyInfos([]string{"f1", "f2"}, "bot").Return([]string{"c1", "c2"}, nil)
+		expected := scheduledPost(msg, "chan", "> hi\n\n_Forwarded from [this post](https://mm.example.com/_redirect/pl/orig)_\n\n_Scheduled by @alice_", "bot")
+		expected.AddProp(constants.PropOnBehalfOfUserID, "user")
+		expected.FileIds = model.StringArray{"c1", "c2"}
+		mockPoster.EXPECT().CreatePost(expected).Return(nil)
+
+		require.NoError(t, s.SendNow(msg))
+	})
+	t.Run("posts without files that cannot be copied", func(t *testing.T) {
+		s, mockPoster, mockFiles, mockUsers, _ := setup(t)
+		mockUsers.EXPECT().Get("user").Return(&model.User{Username: "alice"}, nil)
+		mockFiles.EXPECT().CopyInfos([]string{"f1", "f2"}, "bot").Return(nil, errors.New("file not found"))
+		mockPoster.EXPECT().CreatePost(gomock.Any()).DoAndReturn(func(post *model.Post) error {
+			assert.Empty(t, post.FileIds)
+			return nil
+		})
+
+		require.NoError(t, s.SendNow(newMsg()))
+	})
+	t.Run("expands the comment but not the quoted post", func(t *testing.T) {
+		s, mockPoster, mockFiles, mockUsers, mockChannel := setup(t)
+		msg := newMsg()
+		msg.MessageContent = "From {{author}}:\n\n> **@bob**\n> ask {{author}}"
+		msg.CommentBytes = len("From {{author}}:")
+		mockUsers.EXPECT().Get("user").Return(&model.User{Username: "alice"}, nil).Times(2)
+		mockChannel.EXPECT().GetInfoOrUnknown("chan").Return(&ports.ChannelInfo{ChannelID: "chan", ChannelType: model.ChannelTypeOpen})
+		mockFiles.EXPECT().CopyInfos([]string{"f1", "f2"}, "bot").Return([]string{"c1", "c2"}, nil)
+		mockPoster.EXPECT().CreatePost(gomock.Any()).DoAndReturn(func(post *model.Post) error {
+			assert.Equal(t, "From @alice:\n\n> **@bob**\n> ask {{author}}\n\n_Forwarded from [this post](https://mm.example.com/_redirect/pl/orig)_\n\n_Scheduled by @alice_", post.Message)
+			return nil
+		})
+
+		require.NoError(t, s.SendNow(msg))
+	})
+}
+
+func TestSendNow_TagsPost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock.NewMockStore(ctrl)
+	mockPoster := mock.NewMockPostService(ctrl)
+	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mock.NewMockChannelService(ctrl), mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", testutil.NewManualClock(time.Now()), testutil.FakeMetrics{})
+	s.SetPolicy(types.DeliveryPolicy{LatePolicy: constants.LatePolicySend, Attribution: constants.AttributionUser, ScheduledIndicator: true})
+
+	postAt := time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)
+	msg := &types.ScheduledMessage{ID: "m1", UserID: "user", ChannelID: "chan", PostAt: postAt, MessageContent: "standup", RecurrenceID: "event-uid", Occurrence: 3}
+	mockStore.EXPECT().DeleteScheduledMessage("user", "m1").Return(nil)
+	mockPoster.EXPECT().CreatePost(gomock.Any()).DoAndReturn(func(post *model.Post) error {
+		assert.Equal(t, "standup\n\n_Scheduled message_", post.Message)
+		assert.Equal(t, model.StringInterface{
+			constants.PropFromScheduledMessage:  true,
+			constants.PropScheduledMessageID:    "m1",
+			constants.PropScheduledPostAt:       postAt.UnixMilli(),
+			constants.PropScheduledRecurrenceID: "event-uid",
+			constants.PropScheduledOccurrence:   3,
+		}, post.GetProps())
+		return nil
+	})
+
+	require.NoError(t, s.SendNow(msg))
+}
+
+// scheduledPost builds the post expected for msg, tagged as scheduled.
+func scheduledPost(msg *types.ScheduledMessage, channelID, message, userID string) *model.Post {
+	post := &model.Post{ChannelId: channelID, RootId: msg.RootID, Message: message, UserId: userID}
+	post.AddProp(constants.PropFromScheduledMessage, true)
+	post.AddProp(constants.PropScheduledMessageID, msg.ID)
+	post.AddProp(constants.PropScheduledPostAt, msg.PostAt.UnixMilli())
+	return post
+}
+
+func TestProcessQueue_SendsWarning(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock.NewMockStore(ctrl)
+	mockPoster := mock.NewMockPostService(ctrl)
+	mockChannel := mock.NewMockChannelService(ctrl)
+	clk := testutil.NewManualClock(time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC))
+	s := New(testutil.FakeLogger{}, mockPoster, mockStore, mockChannel, mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
+
+	msg := &types.ScheduledMessage{ID: "m1", UserID: "u", ChannelID: "c", PostAt: clk.Now().Add(10 * time.Minute), WarnBefore: 15 * time.Minute, MessageContent: "hi", Timezone: "UTC"}
+	s.MessageScheduled(msg)
+	info := &ports.ChannelInfo{ChannelID: "c"}
+
+	mockStore.EXPECT().GetScheduledMessage("m1").Return(msg, nil)
+	mockStore.EXPECT().MarkScheduledMessageWarned("m1", clk.Now()).Return(true, nil)
+	mockChannel.EXPECT().GetInfoOrUnknown("c").Return(info)
+	mockChannel.EXPECT().MakeChannelLink(info).Return("in channel: ~town-square")
+	var dm *model.Post
+	mockPoster.EXPECT().DM("bot", "u", gomock.Any()).DoAndReturn(func(_, _ string, post *model.Post) error {
+		dm = post
+		return nil
+	})
+
+	s.processQueue()
+
+	require.NotNil(t, dm)
+	assert.Equal(t, formatter.FormatWarning(msg.PostAt, "in channel: ~town-square"), dm.Message)
+	attachments, ok := dm.Props["attachments"].([]*model.MessageAttachment)
+	require.True(t, ok)
+	require.Len(t, attachments, 1)
+	assert.Equal(t, "hi", attachments[0].Text)
+	require.Len(t, attachments[0].Actions, 3)
+	for _, action := range attachments[0].Actions {
+		assert.Equal(t, constants.ActionSourceWarning, action.Integration.Context["source"])
+		assert.Equal(t, "m1", action.Integration.Context["id"])
+	}
+	assert.Equal(t, "/plugins/"+constants.PluginID+"/api/v1/snooze", attachments[0].Actions[1].Integration.URL)
+
+	next, ok := s.queue.next()
+	require.True(t, ok)
+	assert.Equal(t, msg.PostAt, next, "requeued for its post time once warned")
+}
+
+func TestProcessDueMessages_WarningAlreadySent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock.NewMockStore(ctrl)
+	clk := testutil.NewManualClock(time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC))
+	s := New(testutil.FakeLogger{}, mock.NewMockPostService(ctrl), mockStore, mock.NewMockChannelService(ctrl), mock.NewMockUserService(ctrl), mock.NewMockFileService(ctrl), mock.NewMockConfigService(ctrl), "bot", clk, testutil.FakeMetrics{})
+
+	msg := &types.ScheduledMessage{ID: "m1", UserID: "u", ChannelID: "c", PostAt: clk.Now().Add(10 * time.Minute), WarnBefore: 15 * time.Minute}
+	mockStore.EXPECT().ListScheduledMessages().Return([]*types.ScheduledMessage{msg}, nil)
+	// Another node sent the heads-up first.
+	mockStore.EXPECT().MarkScheduledMessageWarned("m1", clk.Now()).Return(false, nil)
+
+	s.processDueMessages()
+
+	next, ok := s.queue.next()
+	require.True(t, ok)
+	assert.Equal(t, msg.PostAt, next)
+}
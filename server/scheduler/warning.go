@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/formatter"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// warningPending reports whether msg's heads-up is due at now but has not
+// been sent, and msg itself is not yet due.
+func warningPending(msg *types.ScheduledMessage, now time.Time) bool {
+	warnAt, ok := msg.WarnAt()
+	return ok && warnAt.Unix() <= now.Unix() && msg.DueAt().Unix() > now.Unix()
+}
+
+// warn marks msg's heads-up as sent and DMs the author a preview with
+// buttons to send, snooze or cancel it. The store decides which node sends
+// it, so each heads-up goes out once. It returns msg as warned, so the queue
+// waits for its post time even if the store could not be updated; the next
+// resync retries a heads-up that was never recorded.
+func (s *Scheduler) warn(msg *types.ScheduledMessage, now time.Time) *types.ScheduledMessage {
+	warned := *msg
+	warned.WarnedAt = now
+	marked, err := s.store.MarkScheduledMessageWarned(msg.ID, now)
+	if err != nil {
+		s.logger.Error("Failed to mark message as warned, not sending heads-up", "message_id", msg.ID, "error", err)
+		return &warned
+	}
+	if !marked {
+		s.logger.Debug("Heads-up already sent or message changed, skipping", "message_id", msg.ID)
+		return &warned
+	}
+	s.logger.Info("Sending heads-up before scheduled message", "message_id", msg.ID, "user_id", msg.UserID, "post_at", msg.PostAt)
+	links := make([]string, 0, len(msg.Destinations()))
+	for _, channelID := range msg.Destinations() {
+		links = append(links, s.linker.MakeChannelLink(s.linker.GetInfoOrUnknown(channelID)))
+	}
+	post := &model.Post{
+		Message: formatter.FormatWarning(msg.PostAt.In(s.location(msg)), formatter.FormatDestinationList(links)),
+		Props: model.StringInterface{
+			"attachments": []*model.MessageAttachment{{
+				Text: formatter.FormatPreview(msg.MessageContent, constants.WarningPreviewRunes),
+				Actions: []*model.PostAction{
+					warningAction("send", "Send now", "", msg.ID),
+					warningAction("snooze", "Snooze", "", msg.ID),
+					warningAction("delete", "Cancel", "danger", msg.ID),
+				},
+			}},
+		},
+	}
+	if err := s.poster.DM(s.botID, msg.UserID, post); err != nil {
+		s.logger.Error("Failed to send heads-up DM", "message_id", msg.ID, "user_id", msg.UserID, "error", err)
+	} else {
+		s.logger.Debug("Successfully sent heads-up DM", "message_id", msg.ID, "user_id", msg.UserID)
+	}
+	return &warned
+}
+
+// warningAction builds a heads-up button that calls the plugin's API for
+// action on the message.
+func warningAction(action, name, style, msgID string) *model.PostAction {
+	return &model.PostAction{
+		Id:    action,
+		Name:  name,
+		Style: style,
+		Integration: &model.PostActionIntegration{
+			URL: fmt.Sprintf("/plugins/%s/api/v1/%s", constants.PluginID, action),
+			Context: map[string]any{
+				"action": action,
+				"id":     msgID,
+				"source": constants.ActionSourceWarning,
+			},
+		},
+	}
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+// InstrumentedKV wraps a KVService and counts failed operations.
+type InstrumentedKV struct {
+	kv      ports.KVService
+	metrics ports.Metrics
+}
+
+// NewInstrumentedKV wraps kv so its errors are recorded in metrics.
+func NewInstrumentedKV(kv ports.KVService, metrics ports.Metrics) *InstrumentedKV {
+	return &InstrumentedKV{kv: kv, metrics: metrics}
+}
+
+// Get reads a value, counting failures.
+func (i *InstrumentedKV) Get(key string, val any) error {
+	err := i.kv.Get(key, val)
+	i.record("get", err)
+	return err
+}
+
+// Set writes a value, counting failures.
+func (i *InstrumentedKV) Set(key string, val any, opts ...pluginapi.KVSetOption) (bool, error) {
+	ok, err := i.kv.Set(key, val, opts...)
+	i.record("set", err)
+	return ok, err
+}
+
+// Delete removes a value, counting failures.
+func (i *InstrumentedKV) Delete(key string) error {
+	err := i.kv.Delete(key)
+	i.record("delete", err)
+	return err
+}
+
+// ListKeys lists keys, counting failures.
+func (i *InstrumentedKV) ListKeys(page, perPage int, opts ...pluginapi.ListKeysOption) ([]string, error) {
+	keys, err := i.kv.ListKeys(page, perPage, opts...)
+	i.record("list_keys", err)
+	return keys, err
+}
+
+func (i *InstrumentedKV) record(op string, err error) {
+	if err != nil {
+		i.metrics.IncKVError(op)
+	}
+}
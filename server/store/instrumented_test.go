@@ -0,0 +1,60 @@
+package store
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/adapters/mock"
+	"go.uber.org/mock/gomock"
+)
+
+func TestInstrumentedKV_RecordsErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	metricsMock := mock.NewMockMetrics(ctrl)
+	kv := NewInstrumentedKV(kvMock, metricsMock)
+	boom := errors.New("boom")
+
+	kvMock.EXPECT().Get("k", gomock.Any()).Return(boom)
+	kvMock.EXPECT().Set("k", "v").Return(false, boom)
+	kvMock.EXPECT().Delete("k").Return(boom)
+	kvMock.EXPECT().ListKeys(0, 10).Return(nil, boom)
+	metricsMock.EXPECT().IncKVError("get")
+	metricsMock.EXPECT().IncKVError("set")
+	metricsMock.EXPECT().IncKVError("delete")
+	metricsMock.EXPECT().IncKVError("list_keys")
+
+	var out string
+	if err := kv.Get("k", &out); !errors.Is(err, boom) {
+		t.Fatalf("Get() error = %v, want %v", err, boom)
+	}
+	if _, err := kv.Set("k", "v"); !errors.Is(err, boom) {
+		t.Fatalf("Set() error = %v, want %v", err, boom)
+	}
+	if err := kv.Delete("k"); !errors.Is(err, boom) {
+		t.Fatalf("Delete() error = %v, want %v", err, boom)
+	}
+	if _, err := kv.ListKeys(0, 10); !errors.Is(err, boom) {
+		t.Fatalf("ListKeys() error = %v, want %v", err, boom)
+	}
+}
+
+func TestInstrumentedKV_PassesThroughSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	kv := NewInstrumentedKV(kvMock, mock.NewMockMetrics(ctrl))
+
+	kvMock.EXPECT().Set("k", "v").Return(true, nil)
+	kvMock.EXPECT().ListKeys(0, 10).Return([]string{"a"}, nil)
+
+	if ok, err := kv.Set("k", "v"); err != nil || !ok {
+		t.Fatalf("Set() = %v, %v", ok, err)
+	}
+	if keys, err := kv.ListKeys(0, 10); err != nil || len(keys) != 1 {
+		t.Fatalf("ListKeys() = %v, %v", keys, err)
+	}
+}
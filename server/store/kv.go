@@ -2,14 +2,16 @@
 package store
 
 import (
-	"errors"
 	"fmt"
 	"slices"
+	"strings"
+	"time"
 
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
 	"github.com/google/uuid"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
 )
 
 type kvStore struct {
@@ -17,12 +19,14 @@ type kvStore struct {
 	kv                  ports.KVService
 	listMatchingService ports.ListMatchingService
 	maxUserMessages     int
+	cipher              ports.ContentCipher
 }
 
-// NewKVStore constructs a KV-backed Store.
-func NewKVStore(logger ports.Logger, kv ports.KVService, listMatchingService ports.ListMatchingService, maxUserMessages int) ports.Store {
+// NewKVStore constructs a KV-backed Store. Message content is sealed with
+// cipher before it is written and opened again when it is read.
+func NewKVStore(logger ports.Logger, kv ports.KVService, listMatchingService ports.ListMatchingService, maxUserMessages int, cipher ports.ContentCipher) ports.Store {
 	logger.Debug("Creating new KVStore instance")
-	return &kvStore{logger: logger, kv: kv, listMatchingService: listMatchingService, maxUserMessages: maxUserMessages}
+	return &kvStore{logger: logger, kv: kv, listMatchingService: listMatchingService, maxUserMessages: maxUserMessages, cipher: cipher}
 }
 
 func (s *kvStore) SaveScheduledMessage(userID string, msg *types.ScheduledMessage) error {
@@ -36,7 +40,16 @@ func (s *kvStore) SaveScheduledMessage(userID string, msg *types.ScheduledMessag
 	}
 	s.logger.Debug("Successfully added message ID to user index", "user_id", userID, "message_id", msg.ID)
 
-	s.logger.Debug("Saving scheduled message data", "message_id", msg.ID)
+	if msg.Sequence == 0 {
+		sequence, sequenceErr := s.nextSequence()
+		if sequenceErr != nil {
+			s.logger.Error("Failed to reserve sequence number for scheduled message", "message_id", msg.ID, "error", sequenceErr)
+			return fmt.Errorf("failed to reserve sequence number: %w", sequenceErr)
+		}
+		msg.Sequence = sequence
+	}
+
+	s.logger.Debug("Saving scheduled message data", "message_id", msg.ID, "sequence", msg.Sequence)
 	_, saveMessageErr := s.saveNewScheduledMessage(msg)
 	if saveMessageErr != nil {
 		s.logger.Error("Failed to save scheduled message data", "message_id", msg.ID, "error", saveMessageErr)
@@ -79,6 +92,17 @@ func (s *kvStore) CleanupMessageFromUserIndex(userID string, msgID string) error
 	return nil
 }
 
+func (s *kvStore) RestoreMessageToUserIndex(userID string, msgID string) error {
+	s.logger.Debug("Attempting to restore message ID to user index", "user_id", userID, "message_id", msgID)
+	_, addIndexErr := s.addUserMessageToIndex(userID, msgID)
+	if addIndexErr != nil {
+		s.logger.Error("Failed to add message ID to user index during restore", "user_id", userID, "message_id", msgID, "error", addIndexErr)
+		return fmt.Errorf("failed restore add to user index: %w", addIndexErr)
+	}
+	s.logger.Debug("Successfully restored message ID to user index (or it was already there)", "user_id", userID, "message_id", msgID)
+	return nil
+}
+
 func (s *kvStore) GetScheduledMessage(msgID string) (*types.ScheduledMessage, error) {
 	s.logger.Debug("Attempting to get scheduled message", "message_id", msgID)
 	var msg types.ScheduledMessage
@@ -91,37 +115,58 @@ func (s *kvStore) GetScheduledMessage(msgID string) (*types.ScheduledMessage, er
 	}
 	if msg.ID == "" {
 		s.logger.Debug("message not found (possibly already sent)", "message_id", msgID, "key", key)
-		return nil, errors.New("message not found (possibly already sent)")
+		return nil, types.ErrMessageNotFound
+	}
+	if err := checkSchemaVersion(&msg); err != nil {
+		s.logger.Error("Scheduled message was written by a newer plugin version", "message_id", msgID, "schema_version", msg.SchemaVersion)
+		return nil, err
+	}
+	if err := s.decryptContent(&msg); err != nil {
+		s.logger.Error("Failed to decrypt scheduled message content", "message_id", msgID, "error", err)
+		return nil, fmt.Errorf("failed to decrypt message %s: %w", msgID, err)
 	}
 	s.logger.Debug("Successfully retrieved scheduled message", "message_id", msgID, "key", key)
 	return &msg, nil
 }
 
 func (s *kvStore) ListScheduledMessages() ([]*types.ScheduledMessage, error) {
+	messages, _, err := s.ScanScheduledMessages()
+	return messages, err
+}
+
+func (s *kvStore) ScanScheduledMessages() ([]*types.ScheduledMessage, []string, error) {
 	s.logger.Debug("Attempting to list all scheduled messages")
 	var messages []*types.ScheduledMessage
-	prefix := constants.SchedPrefix
-	s.logger.Debug("Calling KV ListKeys", "prefix", prefix, "page", constants.DefaultPage, "perPage", constants.MaxFetchScheduledMessages)
-	keys, err := s.kv.ListKeys(constants.DefaultPage, constants.MaxFetchScheduledMessages, s.listMatchingService.WithPrefix(prefix))
+	var unreadable []string
+	keys, err := s.listAllKeys(constants.SchedPrefix)
 	if err != nil {
-		s.logger.Error("Failed to list keys from KV store", "prefix", prefix, "error", err)
-		return nil, fmt.Errorf("kv.ListKeys failed for prefix %s: %w", prefix, err)
+		return nil, nil, err
 	}
-	s.logger.Debug("Successfully listed keys", "prefix", prefix, "count", len(keys))
 
-	getFailedCount := 0
 	for _, key := range keys {
 		var msg types.ScheduledMessage
 		getErr := s.kv.Get(key, &msg)
 		if getErr != nil {
 			s.logger.Warn("Failed to get individual scheduled message during list operation", "key", key, "error", getErr)
-			getFailedCount++
+			unreadable = append(unreadable, strings.TrimPrefix(key, constants.SchedPrefix))
+			continue
+		}
+		if versionErr := checkSchemaVersion(&msg); versionErr != nil {
+			s.logger.Warn("Skipping scheduled message written by a newer plugin version", "key", key, "schema_version", msg.SchemaVersion)
+			unreadable = append(unreadable, strings.TrimPrefix(key, constants.SchedPrefix))
+			continue
+		}
+		if decryptErr := s.decryptContent(&msg); decryptErr != nil {
+			// Usually the key it was sealed with was dropped from the
+			// configuration, so it can no longer be sent.
+			s.logger.Error("Failed to decrypt individual scheduled message during list operation", "key", key, "key_id", msg.Encrypted.KeyID, "error", decryptErr)
+			unreadable = append(unreadable, strings.TrimPrefix(key, constants.SchedPrefix))
 			continue
 		}
 		messages = append(messages, &msg)
 	}
-	s.logger.Debug("Finished processing keys for ListScheduledMessages", "total_keys", len(keys), "successful_gets", len(messages), "failed_gets", getFailedCount)
-	return messages, nil
+	s.logger.Debug("Finished processing keys for ListScheduledMessages", "total_keys", len(keys), "successful_gets", len(messages), "failed_gets", len(unreadable))
+	return messages, unreadable, nil
 }
 
 func (s *kvStore) ListUserMessageIDs(userID string) ([]string, error) {
@@ -144,6 +189,436 @@ func (s *kvStore) GenerateMessageID() string {
 	return id
 }
 
+func (s *kvStore) SaveTemplate(userID, name, content string) error {
+	s.logger.Debug("Attempting to save template", "user_id", userID, "template", name)
+	var limitErr error
+	_, err := s.modifyUserTemplates(userID, func(templates map[string]string) (map[string]string, bool) {
+		if templates == nil {
+			templates = make(map[string]string)
+		}
+		old, exists := templates[name]
+		if !exists && len(templates) >= constants.MaxUserTemplates {
+			limitErr = fmt.Errorf("cannot save more than %d templates, delete one first", constants.MaxUserTemplates)
+			return templates, false
+		}
+		size := templatesSize(templates) - len(old) + len(content)
+		if !exists {
+			size += len(name)
+		}
+		if size > constants.MaxUserTemplatesBytes {
+			limitErr = fmt.Errorf("your templates would take %.2f KB, the limit is %.2f KB, delete one first", float64(size)/1024, float64(constants.MaxUserTemplatesBytes)/1024)
+			return templates, false
+		}
+		templates[name] = content
+		return templates, true
+	})
+	if err != nil {
+		s.logger.Error("Failed to save template", "user_id", userID, "template", name, "error", err)
+		return fmt.Errorf("failed to save template %s: %w", name, err)
+	}
+	if limitErr != nil {
+		s.logger.Warn("User template limit reached", "user_id", userID, "template", name, "error", limitErr)
+		return limitErr
+	}
+	s.logger.Info("Successfully saved template", "user_id", userID, "template", name)
+	return nil
+}
+
+func (s *kvStore) GetTemplate(userID, name string) (string, error) {
+	s.logger.Debug("Attempting to get template", "user_id", userID, "template", name)
+	templates, err := s.ListTemplates(userID)
+	if err != nil {
+		return "", err
+	}
+	content, ok := templates[name]
+	if !ok {
+		s.logger.Debug("Template not found", "user_id", userID, "template", name)
+		return "", fmt.Errorf("template %s not found", name)
+	}
+	s.logger.Debug("Successfully retrieved template", "user_id", userID, "template", name)
+	return content, nil
+}
+
+func (s *kvStore) ListTemplates(userID string) (map[string]string, error) {
+	s.logger.Debug("Attempting to list templates", "user_id", userID)
+	var templates map[string]string
+	key := templateKey(userID)
+	s.logger.Debug("Calling KV Get for user templates", "key", key)
+	if err := s.kv.Get(key, &templates); err != nil {
+		s.logger.Error("Failed to get user templates from KV store", "key", key, "error", err)
+		return nil, fmt.Errorf("kv.Get failed for template key %s: %w", key, err)
+	}
+	s.logger.Debug("Successfully retrieved user templates", "user_id", userID, "key", key, "count", len(templates))
+	return templates, nil
+}
+
+func (s *kvStore) DeleteTemplate(userID, name string) error {
+	s.logger.Debug("Attempting to delete template", "user_id", userID, "template", name)
+	found := false
+	_, err := s.modifyUserTemplates(userID, func(templates map[string]string) (map[string]string, bool) {
+		if _, found = templates[name]; !found {
+			return templates, false
+		}
+		delete(templates, name)
+		return templates, true
+	})
+	if err != nil {
+		s.logger.Error("Failed to delete template", "user_id", userID, "template", name, "error", err)
+		return fmt.Errorf("failed to delete template %s: %w", name, err)
+	}
+	if !found {
+		s.logger.Debug("Template not found for deletion", "user_id", userID, "template", name)
+		return fmt.Errorf("template %s not found", name)
+	}
+	s.logger.Info("Successfully deleted template", "user_id", userID, "template", name)
+	return nil
+}
+
+func (s *kvStore) GetCalendarToken(userID string) (string, error) {
+	s.logger.Debug("Attempting to get calendar token", "user_id", userID)
+	var token string
+	key := userCalendarTokenKey(userID)
+	if err := s.kv.Get(key, &token); err != nil {
+		s.logger.Error("Failed to get calendar token from KV store", "key", key, "error", err)
+		return "", fmt.Errorf("kv.Get failed for key %s: %w", key, err)
+	}
+	s.logger.Debug("Retrieved calendar token", "user_id", userID, "has_token", token != "")
+	return token, nil
+}
+
+func (s *kvStore) GetCalendarTokenOwner(token string) (string, error) {
+	s.logger.Debug("Attempting to look up calendar token owner")
+	var userID string
+	if err := s.kv.Get(calendarTokenKey(token), &userID); err != nil {
+		s.logger.Error("Failed to get calendar token owner from KV store", "error", err)
+		return "", fmt.Errorf("kv.Get failed for calendar token: %w", err)
+	}
+	s.logger.Debug("Looked up calendar token owner", "user_id", userID)
+	return userID, nil
+}
+
+func (s *kvStore) SetCalendarToken(userID, token string) error {
+	s.logger.Debug("Attempting to set calendar token", "user_id", userID)
+	if err := s.DeleteCalendarToken(userID); err != nil {
+		return err
+	}
+	if _, err := s.kv.Set(calendarTokenKey(token), userID); err != nil {
+		s.logger.Error("Failed to save calendar token owner", "user_id", userID, "error", err)
+		return fmt.Errorf("kv.Set failed for calendar token: %w", err)
+	}
+	key := userCalendarTokenKey(userID)
+	if _, err := s.kv.Set(key, token); err != nil {
+		s.logger.Error("Failed to save user calendar token", "key", key, "error", err)
+		return fmt.Errorf("kv.Set failed for key %s: %w", key, err)
+	}
+	s.logger.Info("Successfully set calendar token", "user_id", userID)
+	return nil
+}
+
+func (s *kvStore) DeleteCalendarToken(userID string) error {
+	s.logger.Debug("Attempting to delete calendar token", "user_id", userID)
+	token, err := s.GetCalendarToken(userID)
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		s.logger.Debug("No calendar token to delete", "user_id", userID)
+		return nil
+	}
+	if err := s.kv.Delete(calendarTokenKey(token)); err != nil {
+		s.logger.Error("Failed to delete calendar token owner", "user_id", userID, "error", err)
+		return fmt.Errorf("kv.Delete failed for calendar token: %w", err)
+	}
+	key := userCalendarTokenKey(userID)
+	if err := s.kv.Delete(key); err != nil {
+		s.logger.Error("Failed to delete user calendar token", "key", key, "error", err)
+		return fmt.Errorf("kv.Delete failed for key %s: %w", key, err)
+	}
+	s.logger.Info("Successfully deleted calendar token", "user_id", userID)
+	return nil
+}
+
+func (s *kvStore) GetReceiptPreference(userID string) (bool, error) {
+	s.logger.Debug("Attempting to get receipt preference", "user_id", userID)
+	var enabled bool
+	key := receiptPreferenceKey(userID)
+	if err := s.kv.Get(key, &enabled); err != nil {
+		s.logger.Error("Failed to get receipt preference from KV store", "key", key, "error", err)
+		return false, fmt.Errorf("kv.Get failed for key %s: %w", key, err)
+	}
+	s.logger.Debug("Retrieved receipt preference", "user_id", userID, "enabled", enabled)
+	return enabled, nil
+}
+
+func (s *kvStore) SetReceiptPreference(userID string, enabled bool) error {
+	s.logger.Debug("Attempting to set receipt preference", "user_id", userID, "enabled", enabled)
+	key := receiptPreferenceKey(userID)
+	if !enabled {
+		// Off is the default, so there is nothing to keep.
+		if err := s.kv.Delete(key); err != nil {
+			s.logger.Error("Failed to delete receipt preference", "key", key, "error", err)
+			return fmt.Errorf("kv.Delete failed for key %s: %w", key, err)
+		}
+		s.logger.Info("Turned receipts off", "user_id", userID)
+		return nil
+	}
+	if _, err := s.kv.Set(key, true); err != nil {
+		s.logger.Error("Failed to save receipt preference", "key", key, "error", err)
+		return fmt.Errorf("kv.Set failed for key %s: %w", key, err)
+	}
+	s.logger.Info("Turned receipts on", "user_id", userID)
+	return nil
+}
+
+func (s *kvStore) ListUserIndexes() (map[string][]string, error) {
+	s.logger.Debug("Attempting to list all user message indexes")
+	prefix := constants.UserIndexPrefix
+	keys, err := s.listAllKeys(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make(map[string][]string, len(keys))
+	for _, key := range keys {
+		var ids []string
+		if err := s.kv.Get(key, &ids); err != nil {
+			s.logger.Error("Failed to get user message index from KV store", "key", key, "error", err)
+			return nil, fmt.Errorf("kv.Get failed for user index key %s: %w", key, err)
+		}
+		indexes[strings.TrimPrefix(key, prefix)] = ids
+	}
+	s.logger.Debug("Finished listing user message indexes", "count", len(indexes))
+	return indexes, nil
+}
+
+func (s *kvStore) ReencryptMessages() (int, error) {
+	keyID := s.cipher.KeyID()
+	s.logger.Debug("Attempting to reencrypt scheduled messages", "key_id", keyID)
+	keys, err := s.listAllKeys(constants.SchedPrefix)
+	if err != nil {
+		return 0, err
+	}
+
+	rewritten, failed := 0, 0
+	for _, key := range keys {
+		changed, err := s.reencryptMessage(key, keyID)
+		if err != nil {
+			s.logger.Error("Failed to reencrypt scheduled message", "key", key, "error", err)
+			failed++
+			continue
+		}
+		if changed {
+			rewritten++
+		}
+	}
+	s.logger.Info("Finished reencrypting scheduled messages", "key_id", keyID, "total_keys", len(keys), "rewritten", rewritten, "failed", failed)
+	if failed > 0 {
+		return rewritten, fmt.Errorf("failed to reencrypt %d of %d scheduled messages", failed, len(keys))
+	}
+	if _, err := s.kv.Set(constants.ReencryptedKeyIDKey, keyID); err != nil {
+		s.logger.Error("Failed to record reencrypted key ID", "key_id", keyID, "error", err)
+		return rewritten, fmt.Errorf("kv.Set failed for key %s: %w", constants.ReencryptedKeyIDKey, err)
+	}
+	return rewritten, nil
+}
+
+func (s *kvStore) NeedsReencryption() (bool, error) {
+	// A nil ID means no reencryption has completed yet, which is different
+	// from one that completed with encryption disabled.
+	var done *string
+	if err := s.kv.Get(constants.ReencryptedKeyIDKey, &done); err != nil {
+		s.logger.Error("Failed to get reencrypted key ID", "error", err)
+		return false, fmt.Errorf("kv.Get failed for key %s: %w", constants.ReencryptedKeyIDKey, err)
+	}
+	keyID := s.cipher.KeyID()
+	needed := done == nil || *done != keyID
+	s.logger.Debug("Checked whether stored messages need reencryption", "key_id", keyID, "needed", needed)
+	return needed, nil
+}
+
+// listAllKeys lists every key with prefix, reading as many pages as needed.
+func (s *kvStore) listAllKeys(prefix string) ([]string, error) {
+	var keys []string
+	for page := constants.DefaultPage; ; page++ {
+		s.logger.Debug("Calling KV ListKeys", "prefix", prefix, "page", page, "perPage", constants.MaxFetchScheduledMessages)
+		batch, err := s.kv.ListKeys(page, constants.MaxFetchScheduledMessages, s.listMatchingService.WithPrefix(prefix))
+		if err != nil {
+			s.logger.Error("Failed to list keys from KV store", "prefix", prefix, "page", page, "error", err)
+			return nil, fmt.Errorf("kv.ListKeys failed for prefix %s: %w", prefix, err)
+		}
+		keys = append(keys, batch...)
+		if len(batch) < constants.MaxFetchScheduledMessages {
+			s.logger.Debug("Successfully listed keys", "prefix", prefix, "count", len(keys))
+			return keys, nil
+		}
+	}
+}
+
+// reencryptMessage brings one record in line with the current key. The write
+// only succeeds if the record is unchanged since it was read, so a message
+// the scheduler sends and deletes meanwhile is not written back.
+func (s *kvStore) reencryptMessage(key, keyID string) (bool, error) {
+	var stored types.ScheduledMessage
+	if err := s.kv.Get(key, &stored); err != nil {
+		return false, fmt.Errorf("kv.Get failed for key %s: %w", key, err)
+	}
+	if stored.ID == "" || checkSchemaVersion(&stored) != nil {
+		return false, nil
+	}
+	updated := stored
+	switch {
+	case stored.Encrypted == nil && keyID == "":
+		return false, nil
+	case stored.Encrypted == nil:
+		enc, err := s.cipher.Encrypt(stored.MessageContent)
+		if err != nil {
+			return false, err
+		}
+		updated.MessageContent = ""
+		updated.Encrypted = enc
+	case stored.Encrypted.KeyID == keyID:
+		return false, nil
+	case keyID == "":
+		content, err := s.cipher.Decrypt(stored.Encrypted)
+		if err != nil {
+			return false, err
+		}
+		updated.MessageContent = content
+		updated.Encrypted = nil
+	default:
+		enc, err := s.cipher.Rewrap(stored.Encrypted)
+		if err != nil {
+			return false, err
+		}
+		updated.Encrypted = enc
+	}
+	stampSchemaVersion(&updated)
+	set, err := s.kv.Set(key, &updated, pluginapi.SetAtomic(&stored))
+	if err != nil {
+		return false, fmt.Errorf("kv.Set failed for key %s: %w", key, err)
+	}
+	if !set {
+		s.logger.Debug("Scheduled message changed during reencryption, skipping", "key", key)
+	}
+	return set, nil
+}
+
+// DeferScheduledMessage records that a rate limit held the message back
+// until the given time. Like reencryption, the write only succeeds if the
+// record is unchanged since it was read, so a message edited or deleted
+// meanwhile is left alone; false is returned in that case.
+func (s *kvStore) DeferScheduledMessage(msgID string, until time.Time) (bool, error) {
+	s.logger.Debug("Attempting to defer scheduled message", "message_id", msgID, "deferred_until", until)
+	return s.updateScheduledMessage(msgID, "defer", func(msg *types.ScheduledMessage) bool {
+		msg.DeferredUntil = until
+		return true
+	})
+}
+
+func (s *kvStore) MarkScheduledMessageWarned(msgID string, at time.Time) (bool, error) {
+	s.logger.Debug("Attempting to mark scheduled message warned", "message_id", msgID, "warned_at", at)
+	return s.updateScheduledMessage(msgID, "mark warned", func(msg *types.ScheduledMessage) bool {
+		// Another server may have sent the heads-up already.
+		if !msg.WarnedAt.IsZero() || msg.WarnBefore <= 0 {
+			return false
+		}
+		msg.WarnedAt = at
+		return true
+	})
+}
+
+func (s *kvStore) SnoozeScheduledMessage(msgID string, postAt time.Time) (bool, error) {
+	s.logger.Debug("Attempting to snooze scheduled message", "message_id", msgID, "post_at", postAt)
+	return s.updateScheduledMessage(msgID, "snooze", func(msg *types.ScheduledMessage) bool {
+		msg.PostAt = postAt
+		msg.DeferredUntil = time.Time{}
+		msg.WarnedAt = time.Time{}
+		return true
+	})
+}
+
+// updateScheduledMessage applies update to the stored record of msgID and
+// writes it back only if the record has not changed since it was read, so
+// an update never resurrects a message that was sent or deleted meanwhile.
+// update returns false to leave the record alone. The record is updated as
+// stored, so its content stays sealed.
+func (s *kvStore) updateScheduledMessage(msgID, action string, update func(*types.ScheduledMessage) bool) (bool, error) {
+	key := schedKey(msgID)
+	var stored types.ScheduledMessage
+	if err := s.kv.Get(key, &stored); err != nil {
+		s.logger.Error("Failed to get scheduled message from KV store", "key", key, "error", err)
+		return false, fmt.Errorf("kv.Get failed for key %s: %w", key, err)
+	}
+	if stored.ID == "" {
+		s.logger.Debug("Scheduled message to update no longer exists", "message_id", msgID, "action", action)
+		return false, nil
+	}
+	updated := stored
+	if !update(&updated) {
+		s.logger.Debug("Scheduled message does not need updating", "message_id", msgID, "action", action)
+		return false, nil
+	}
+	stampSchemaVersion(&updated)
+	set, err := s.kv.Set(key, &updated, pluginapi.SetAtomic(&stored))
+	if err != nil {
+		s.logger.Error("Failed to save updated scheduled message", "key", key, "action", action, "error", err)
+		return false, fmt.Errorf("kv.Set failed for key %s: %w", key, err)
+	}
+	if !set {
+		s.logger.Debug("Scheduled message changed before it could be updated", "message_id", msgID, "action", action)
+		return false, nil
+	}
+	s.logger.Debug("Successfully updated scheduled message", "message_id", msgID, "action", action)
+	return true, nil
+}
+
+func (s *kvStore) encryptContent(msg *types.ScheduledMessage) (*types.ScheduledMessage, error) {
+	enc, err := s.cipher.Encrypt(msg.MessageContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt message %s: %w", msg.ID, err)
+	}
+	if enc == nil {
+		return msg, nil
+	}
+	record := *msg
+	record.MessageContent = ""
+	record.Encrypted = enc
+	return &record, nil
+}
+
+// stampSchemaVersion sets the oldest layout version that can read record.
+// Only encrypted content needs version 2, so plain records stay readable by
+// version 1 if the plugin is downgraded.
+func stampSchemaVersion(record *types.ScheduledMessage) {
+	if record.Encrypted != nil {
+		record.SchemaVersion = constants.ScheduledMessageSchemaVersion
+		return
+	}
+	record.SchemaVersion = constants.PlainMessageSchemaVersion
+}
+
+// checkSchemaVersion refuses records laid out by a newer plugin version, which
+// may hold fields this version would misread or drop on write.
+func checkSchemaVersion(msg *types.ScheduledMessage) error {
+	if msg.SchemaVersion > constants.ScheduledMessageSchemaVersion {
+		return fmt.Errorf("message %s has schema version %d, newer than the supported version %d", msg.ID, msg.SchemaVersion, constants.ScheduledMessageSchemaVersion)
+	}
+	return nil
+}
+
+func (s *kvStore) decryptContent(msg *types.ScheduledMessage) error {
+	if msg.Encrypted == nil {
+		return nil
+	}
+	content, err := s.cipher.Decrypt(msg.Encrypted)
+	if err != nil {
+		return err
+	}
+	msg.MessageContent = content
+	msg.Encrypted = nil
+	return nil
+}
+
 func (s *kvStore) removeUserMessageFromIndex(userID, msgID string) (bool, error) {
 	s.logger.Debug("Calling modifyUserIndex to remove message ID", "user_id", userID, "message_id", msgID)
 	return s.modifyUserIndex(userID, func(ids []string) ([]string, bool) {
@@ -169,10 +644,47 @@ func (s *kvStore) addUserMessageToIndex(userID, msgID string) (bool, error) {
 	})
 }
 
+// nextSequence reserves the next scheduled message sequence number. The
+// counter is shared by all cluster nodes, so it is incremented with a
+// compare-and-set and retried if another save got there first.
+func (s *kvStore) nextSequence() (int64, error) {
+	key := constants.SequenceKey
+	for attempt := 1; attempt <= constants.SequenceMaxAttempts; attempt++ {
+		var current *int64
+		if err := s.kv.Get(key, &current); err != nil {
+			return 0, fmt.Errorf("kv.Get failed for key %s: %w", key, err)
+		}
+		// A nil old value makes the write succeed only if the key is unset.
+		var old any
+		next := int64(1)
+		if current != nil {
+			old = *current
+			next = *current + 1
+		}
+		set, err := s.kv.Set(key, next, pluginapi.SetAtomic(old))
+		if err != nil {
+			return 0, fmt.Errorf("kv.Set failed for key %s: %w", key, err)
+		}
+		if set {
+			s.logger.Debug("Reserved scheduled message sequence number", "sequence", next, "attempt", attempt)
+			return next, nil
+		}
+		s.logger.Debug("Sequence number was taken concurrently, retrying", "attempt", attempt)
+	}
+	return 0, fmt.Errorf("sequence number still contended after %d attempts", constants.SequenceMaxAttempts)
+}
+
 func (s *kvStore) saveNewScheduledMessage(msg *types.ScheduledMessage) (bool, error) {
 	key := schedKey(msg.ID)
-	s.logger.Debug("Calling KV Set to save scheduled message", "key", key, "message_id", msg.ID)
-	set, err := s.kv.Set(key, msg)
+	record, err := s.encryptContent(msg)
+	if err != nil {
+		s.logger.Error("Failed to encrypt scheduled message content", "message_id", msg.ID, "error", err)
+		return false, err
+	}
+	stampSchemaVersion(record)
+	msg.SchemaVersion = record.SchemaVersion
+	s.logger.Debug("Calling KV Set to save scheduled message", "key", key, "message_id", msg.ID, "encrypted", record.Encrypted != nil)
+	set, err := s.kv.Set(key, record)
 	if err != nil {
 		s.logger.Error("Failed to set scheduled message in KV store", "key", key, "message_id", msg.ID, "error", err)
 		return false, fmt.Errorf("kv.Set failed for key %s: %w", key, err)
@@ -225,6 +737,44 @@ func (s *kvStore) modifyUserIndex(
 	return set, nil
 }
 
+func (s *kvStore) modifyUserTemplates(
+	userID string,
+	fn func(map[string]string) (map[string]string, bool),
+) (bool, error) {
+	key := templateKey(userID)
+	s.logger.Debug("Modifying user templates", "user_id", userID, "key", key)
+
+	var templates map[string]string
+	if err := s.kv.Get(key, &templates); err != nil {
+		s.logger.Warn("Failed to get user templates", "key", key, "error", err)
+		return false, fmt.Errorf("kv.Get failed for template key %s: %w", key, err)
+	}
+
+	newTemplates, modified := fn(templates)
+	if !modified {
+		s.logger.Debug("Template modification function indicated no changes needed", "key", key)
+		return false, nil
+	}
+
+	s.logger.Debug("Templates were modified, calling KV Set to save them", "key", key, "new_count", len(newTemplates))
+	set, err := s.kv.Set(key, newTemplates)
+	if err != nil {
+		s.logger.Error("Failed to set user templates in KV store", "key", key, "error", err)
+		return false, fmt.Errorf("kv.Set failed for template key %s: %w", key, err)
+	}
+	s.logger.Debug("Successfully updated user templates in KV store", "key", key, "set_result", set)
+	return set, nil
+}
+
+// templatesSize returns the bytes counted against MaxUserTemplatesBytes.
+func templatesSize(templates map[string]string) int {
+	size := 0
+	for name, content := range templates {
+		size += len(name) + len(content)
+	}
+	return size
+}
+
 func schedKey(id string) string {
 	return fmt.Sprintf("%s%s", constants.SchedPrefix, id)
 }
@@ -232,3 +782,19 @@ func schedKey(id string) string {
 func indexKey(userID string) string {
 	return fmt.Sprintf("%s%s", constants.UserIndexPrefix, userID)
 }
+
+func templateKey(userID string) string {
+	return fmt.Sprintf("%s%s", constants.TemplatePrefix, userID)
+}
+
+func calendarTokenKey(token string) string {
+	return fmt.Sprintf("%s%s", constants.CalendarTokenPrefix, token)
+}
+
+func userCalendarTokenKey(userID string) string {
+	return fmt.Sprintf("%s%s", constants.UserCalendarTokenPrefix, userID)
+}
+
+func receiptPreferenceKey(userID string) string {
+	return fmt.Sprintf("%s%s", constants.ReceiptPreferencePrefix, userID)
+}
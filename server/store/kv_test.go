@@ -3,12 +3,14 @@ package store
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/adapters/mock"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/testutil"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/constants"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/encryption"
 	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
 	"github.com/google/uuid"
 	"github.com/mattermost/mattermost/server/public/pluginapi"
@@ -41,7 +43,7 @@ func TestSaveScheduledMessage_Success(t *testing.T) {
 	listFake := &fakeListMatching{}
 	logger := testutil.FakeLogger{}
 
-	store := NewKVStore(logger, kvMock, listFake, constants.MaxUserMessages)
+	store := NewKVStore(logger, kvMock, listFake, constants.MaxUserMessages, encryption.NewBox(nil))
 
 	userID := "user"
 	msgID := uuid.NewString()
@@ -53,6 +55,8 @@ func TestSaveScheduledMessage_Success(t *testing.T) {
 	gomock.InOrder(
 		kvMock.EXPECT().Get(indexKey, gomock.Any()).Return(nil),
 		kvMock.EXPECT().Set(indexKey, gomock.Any()).Return(true, nil),
+		kvMock.EXPECT().Get(constants.SequenceKey, gomock.Any()).Return(nil),
+		kvMock.EXPECT().Set(constants.SequenceKey, int64(1), gomock.Any()).Return(true, nil),
 		kvMock.EXPECT().Set(schedKey, msg).Return(true, nil),
 	)
 
@@ -60,6 +64,13 @@ func TestSaveScheduledMessage_Success(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	// Plain records keep the version older plugin versions can read.
+	if msg.SchemaVersion != constants.PlainMessageSchemaVersion {
+		t.Fatalf("expected schema version %d, got %d", constants.PlainMessageSchemaVersion, msg.SchemaVersion)
+	}
+	if msg.Sequence != 1 {
+		t.Fatalf("expected sequence 1, got %d", msg.Sequence)
+	}
 }
 
 func TestSaveScheduledMessage_SaveError(t *testing.T) {
@@ -69,7 +80,7 @@ func TestSaveScheduledMessage_SaveError(t *testing.T) {
 	kvMock := mock.NewMockKVService(ctrl)
 	listFake := &fakeListMatching{}
 	logger := testutil.FakeLogger{}
-	store := NewKVStore(logger, kvMock, listFake, constants.MaxUserMessages)
+	store := NewKVStore(logger, kvMock, listFake, constants.MaxUserMessages, encryption.NewBox(nil))
 
 	userID := "user"
 	msgID := uuid.NewString()
@@ -81,6 +92,8 @@ func TestSaveScheduledMessage_SaveError(t *testing.T) {
 	gomock.InOrder(
 		kvMock.EXPECT().Get(indexKey, gomock.Any()).Return(nil),
 		kvMock.EXPECT().Set(indexKey, gomock.Any()).Return(true, nil),
+		kvMock.EXPECT().Get(constants.SequenceKey, gomock.Any()).Return(nil),
+		kvMock.EXPECT().Set(constants.SequenceKey, int64(1), gomock.Any()).Return(true, nil),
 		kvMock.EXPECT().Set(schedKey, msg).Return(false, fmt.Errorf("save failed")),
 	)
 
@@ -97,7 +110,7 @@ func TestDeleteScheduledMessage_Success(t *testing.T) {
 	kvMock := mock.NewMockKVService(ctrl)
 	listFake := &fakeListMatching{}
 	logger := testutil.FakeLogger{}
-	store := NewKVStore(logger, kvMock, listFake, constants.MaxUserMessages)
+	store := NewKVStore(logger, kvMock, listFake, constants.MaxUserMessages, encryption.NewBox(nil))
 
 	userID := "user"
 	msgID := uuid.NewString()
@@ -130,7 +143,7 @@ func TestGetScheduledMessage_NotFound(t *testing.T) {
 	kvMock := mock.NewMockKVService(ctrl)
 	listFake := &fakeListMatching{}
 	logger := testutil.FakeLogger{}
-	store := NewKVStore(logger, kvMock, listFake, constants.MaxUserMessages)
+	store := NewKVStore(logger, kvMock, listFake, constants.MaxUserMessages, encryption.NewBox(nil))
 
 	msgID := uuid.NewString()
 	schedKey := testutil.SchedKey(msgID)
@@ -150,7 +163,7 @@ func TestListScheduledMessages_SkipBadKeys(t *testing.T) {
 	kvMock := mock.NewMockKVService(ctrl)
 	listFake := &fakeListMatching{}
 	logger := testutil.FakeLogger{}
-	store := NewKVStore(logger, kvMock, listFake, constants.MaxUserMessages)
+	store := NewKVStore(logger, kvMock, listFake, constants.MaxUserMessages, encryption.NewBox(nil))
 
 	msgID1 := uuid.NewString()
 	msgID2 := uuid.NewString()
@@ -192,7 +205,7 @@ func TestSaveScheduledMessage_GetError(t *testing.T) {
 	defer ctrl.Finish()
 	kvMock := mock.NewMockKVService(ctrl)
 	listFake := &fakeListMatching{}
-	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages, encryption.NewBox(nil))
 	userID := "user"
 	msgID := uuid.NewString()
 	msg := sampleMessage(msgID, userID, time.Now())
@@ -209,7 +222,7 @@ func TestSaveScheduledMessage_IndexSetError(t *testing.T) {
 	defer ctrl.Finish()
 	kvMock := mock.NewMockKVService(ctrl)
 	listFake := &fakeListMatching{}
-	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages, encryption.NewBox(nil))
 	userID := "user"
 	msgID := uuid.NewString()
 	msg := sampleMessage(msgID, userID, time.Now())
@@ -227,7 +240,7 @@ func TestDeleteScheduledMessage_DeleteError(t *testing.T) {
 	defer ctrl.Finish()
 	kvMock := mock.NewMockKVService(ctrl)
 	listFake := &fakeListMatching{}
-	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages, encryption.NewBox(nil))
 	userID := "user"
 	msgID := uuid.NewString()
 	schedKey := testutil.SchedKey(msgID)
@@ -243,7 +256,7 @@ func TestDeleteScheduledMessage_GetIndexError(t *testing.T) {
 	defer ctrl.Finish()
 	kvMock := mock.NewMockKVService(ctrl)
 	listFake := &fakeListMatching{}
-	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages, encryption.NewBox(nil))
 	userID := "user"
 	msgID := uuid.NewString()
 	schedKey := testutil.SchedKey(msgID)
@@ -263,7 +276,7 @@ func TestDeleteScheduledMessage_SetIndexError(t *testing.T) {
 	defer ctrl.Finish()
 	kvMock := mock.NewMockKVService(ctrl)
 	listFake := &fakeListMatching{}
-	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages, encryption.NewBox(nil))
 	userID := "user"
 	msgID := uuid.NewString()
 	schedKey := testutil.SchedKey(msgID)
@@ -290,7 +303,7 @@ func TestCleanupMessageFromUserIndex_Happy(t *testing.T) {
 	defer ctrl.Finish()
 	kvMock := mock.NewMockKVService(ctrl)
 	listFake := &fakeListMatching{}
-	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages, encryption.NewBox(nil))
 	userID := "user"
 	msgID := uuid.NewString()
 	indexKey := testutil.IndexKey(userID)
@@ -314,7 +327,7 @@ func TestCleanupMessageFromUserIndex_MissingID(t *testing.T) {
 	defer ctrl.Finish()
 	kvMock := mock.NewMockKVService(ctrl)
 	listFake := &fakeListMatching{}
-	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages, encryption.NewBox(nil))
 	userID := "user"
 	msgID := uuid.NewString()
 	indexKey := testutil.IndexKey(userID)
@@ -335,7 +348,7 @@ func TestCleanupMessageFromUserIndex_GetError(t *testing.T) {
 	defer ctrl.Finish()
 	kvMock := mock.NewMockKVService(ctrl)
 	listFake := &fakeListMatching{}
-	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages, encryption.NewBox(nil))
 	userID := "user"
 	msgID := uuid.NewString()
 	indexKey := testutil.IndexKey(userID)
@@ -350,7 +363,7 @@ func TestGetScheduledMessage_Success(t *testing.T) {
 	defer ctrl.Finish()
 	kvMock := mock.NewMockKVService(ctrl)
 	listFake := &fakeListMatching{}
-	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages, encryption.NewBox(nil))
 	msgID := uuid.NewString()
 	schedKey := testutil.SchedKey(msgID)
 	want := sampleMessage(msgID, "u", time.Unix(55, 0))
@@ -375,7 +388,7 @@ func TestListScheduledMessages_Happy(t *testing.T) {
 	defer ctrl.Finish()
 	kvMock := mock.NewMockKVService(ctrl)
 	listFake := &fakeListMatching{}
-	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages, encryption.NewBox(nil))
 	msgID := uuid.NewString()
 	key := testutil.SchedKey(msgID)
 	prefixOpt := pluginapi.WithPrefix(constants.SchedPrefix)
@@ -402,7 +415,7 @@ func TestListScheduledMessages_ListKeysError(t *testing.T) {
 	defer ctrl.Finish()
 	kvMock := mock.NewMockKVService(ctrl)
 	listFake := &fakeListMatching{}
-	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages, encryption.NewBox(nil))
 	prefixOpt := pluginapi.WithPrefix(constants.SchedPrefix)
 	kvMock.EXPECT().ListKeys(0, constants.MaxFetchScheduledMessages, gomock.AssignableToTypeOf(prefixOpt)).Return(nil, fmt.Errorf("list error"))
 	_, err := store.ListScheduledMessages()
@@ -416,7 +429,7 @@ func TestListUserMessageIDs_Success(t *testing.T) {
 	defer ctrl.Finish()
 	kvMock := mock.NewMockKVService(ctrl)
 	listFake := &fakeListMatching{}
-	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages, encryption.NewBox(nil))
 	userID := "user"
 	indexKey := testutil.IndexKey(userID)
 	kvMock.EXPECT().Get(indexKey, gomock.Any()).DoAndReturn(
@@ -441,7 +454,7 @@ func TestListUserMessageIDs_GetError(t *testing.T) {
 	defer ctrl.Finish()
 	kvMock := mock.NewMockKVService(ctrl)
 	listFake := &fakeListMatching{}
-	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages, encryption.NewBox(nil))
 	userID := "user"
 	indexKey := testutil.IndexKey(userID)
 	kvMock.EXPECT().Get(indexKey, gomock.Any()).Return(fmt.Errorf("get err"))
@@ -452,7 +465,7 @@ func TestListUserMessageIDs_GetError(t *testing.T) {
 }
 
 func TestGenerateMessageID_Unique(t *testing.T) {
-	store := NewKVStore(testutil.FakeLogger{}, nil, nil, 0)
+	store := NewKVStore(testutil.FakeLogger{}, nil, nil, 0, encryption.NewBox(nil))
 	id1 := store.GenerateMessageID()
 	id2 := store.GenerateMessageID()
 	if id1 == "" || id2 == "" || id1 == id2 {
@@ -465,7 +478,7 @@ func TestSaveScheduledMessage_DuplicateIDNoIndexWrite(t *testing.T) {
 
 	kvMock := mock.NewMockKVService(ctrl)
 	listFake := &fakeListMatching{}
-	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages, encryption.NewBox(nil))
 
 	userID := "user"
 	msgID := uuid.NewString()
@@ -482,9 +495,1017 @@ func TestSaveScheduledMessage_DuplicateIDNoIndexWrite(t *testing.T) {
 		},
 	)
 
+	expectFirstSequence(kvMock)
 	kvMock.EXPECT().Set(schedKey, msg).Return(true, nil)
 
 	if err := store.SaveScheduledMessage(userID, msg); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestSaveTemplate_AddsToExistingTemplates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+
+	key := testutil.TemplateKey("user")
+	kvMock.EXPECT().Get(key, gomock.Any()).SetArg(1, map[string]string{"handoff": "on-call notes"}).Return(nil)
+	kvMock.EXPECT().Set(key, map[string]string{"handoff": "on-call notes", "release": "checklist"}).Return(true, nil)
+
+	if err := store.SaveTemplate("user", "release", "checklist"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSaveTemplate_FirstTemplate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+
+	key := testutil.TemplateKey("user")
+	kvMock.EXPECT().Get(key, gomock.Any()).Return(nil)
+	kvMock.EXPECT().Set(key, map[string]string{"release": "checklist"}).Return(true, nil)
+
+	if err := store.SaveTemplate("user", "release", "checklist"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSaveTemplate_SetError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+
+	key := testutil.TemplateKey("user")
+	kvMock.EXPECT().Get(key, gomock.Any()).Return(nil)
+	kvMock.EXPECT().Set(key, gomock.Any()).Return(false, fmt.Errorf("boom"))
+
+	if err := store.SaveTemplate("user", "release", "checklist"); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestSaveTemplate_Limits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+
+	full := make(map[string]string, constants.MaxUserTemplates)
+	for i := range constants.MaxUserTemplates {
+		full[fmt.Sprintf("t%d", i)] = "body"
+	}
+	key := testutil.TemplateKey("user")
+	kvMock.EXPECT().Get(key, gomock.Any()).SetArg(1, full).Return(nil).Times(2)
+	kvMock.EXPECT().Set(key, gomock.Any()).Return(true, nil)
+
+	err := store.SaveTemplate("user", "another", "checklist")
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("cannot save more than %d templates", constants.MaxUserTemplates)) {
+		t.Fatalf("expected template limit error, got %v", err)
+	}
+	if err := store.SaveTemplate("user", "t0", "updated"); err != nil {
+		t.Fatalf("expected replacing a template at the limit to succeed, got %v", err)
+	}
+}
+
+func TestSaveTemplate_MapSizeLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+
+	body := strings.Repeat("a", constants.MaxMessageBytes)
+	existing := make(map[string]string)
+	for i := 0; templatesSize(existing)+len(body)+2 <= constants.MaxUserTemplatesBytes; i++ {
+		existing[fmt.Sprintf("t%d", i)] = body
+	}
+	key := testutil.TemplateKey("user")
+	kvMock.EXPECT().Get(key, gomock.Any()).SetArg(1, existing).Return(nil).Times(2)
+	kvMock.EXPECT().Set(key, gomock.Any()).Return(true, nil)
+
+	err := store.SaveTemplate("user", "big", body)
+	if err == nil || !strings.Contains(err.Error(), "the limit is 1024.00 KB") {
+		t.Fatalf("expected templates size error, got %v", err)
+	}
+	if err := store.SaveTemplate("user", "t0", strings.Repeat("b", constants.MaxMessageBytes)); err != nil {
+		t.Fatalf("expected replacing a template of the same size to succeed, got %v", err)
+	}
+}
+
+func TestGetTemplate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+
+	key := testutil.TemplateKey("user")
+	kvMock.EXPECT().Get(key, gomock.Any()).SetArg(1, map[string]string{"release": "checklist"}).Return(nil).Times(2)
+
+	content, err := store.GetTemplate("user", "release")
+	if err != nil || content != "checklist" {
+		t.Fatalf("unexpected result: %q, %v", content, err)
+	}
+	if _, err := store.GetTemplate("user", "missing"); err == nil {
+		t.Fatalf("expected not found error")
+	}
+}
+
+func TestListTemplates_GetError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+
+	kvMock.EXPECT().Get(testutil.TemplateKey("user"), gomock.Any()).Return(fmt.Errorf("boom"))
+
+	if _, err := store.ListTemplates("user"); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestDeleteTemplate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+
+	key := testutil.TemplateKey("user")
+	kvMock.EXPECT().Get(key, gomock.Any()).SetArg(1, map[string]string{"release": "checklist", "handoff": "notes"}).Return(nil)
+	kvMock.EXPECT().Set(key, map[string]string{"handoff": "notes"}).Return(true, nil)
+
+	if err := store.DeleteTemplate("user", "release"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDeleteTemplate_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+
+	kvMock.EXPECT().Get(testutil.TemplateKey("user"), gomock.Any()).SetArg(1, map[string]string{"handoff": "notes"}).Return(nil)
+
+	if err := store.DeleteTemplate("user", "release"); err == nil {
+		t.Fatalf("expected not found error")
+	}
+}
+
+func TestSetCalendarToken_ReplacesExistingToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+
+	userKey := constants.UserCalendarTokenPrefix + "user"
+	gomock.InOrder(
+		kvMock.EXPECT().Get(userKey, gomock.Any()).SetArg(1, "old").Return(nil),
+		kvMock.EXPECT().Delete(constants.CalendarTokenPrefix+"old").Return(nil),
+		kvMock.EXPECT().Delete(userKey).Return(nil),
+		kvMock.EXPECT().Set(constants.CalendarTokenPrefix+"new", "user").Return(true, nil),
+		kvMock.EXPECT().Set(userKey, "new").Return(true, nil),
+	)
+
+	if err := store.SetCalendarToken("user", "new"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSetCalendarToken_FirstToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+
+	userKey := constants.UserCalendarTokenPrefix + "user"
+	kvMock.EXPECT().Get(userKey, gomock.Any()).Return(nil)
+	kvMock.EXPECT().Set(constants.CalendarTokenPrefix+"new", "user").Return(true, nil)
+	kvMock.EXPECT().Set(userKey, "new").Return(true, nil)
+
+	if err := store.SetCalendarToken("user", "new"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDeleteCalendarToken_GetError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+
+	kvMock.EXPECT().Get(constants.UserCalendarTokenPrefix+"user", gomock.Any()).Return(fmt.Errorf("boom"))
+
+	if err := store.DeleteCalendarToken("user"); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestGetCalendarTokenOwner(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+
+	kvMock.EXPECT().Get(constants.CalendarTokenPrefix+"tok", gomock.Any()).SetArg(1, "user").Return(nil)
+
+	owner, err := store.GetCalendarTokenOwner("tok")
+	if err != nil || owner != "user" {
+		t.Fatalf("unexpected result: %q, %v", owner, err)
+	}
+}
+
+func TestGetReceiptPreference(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+
+	kvMock.EXPECT().Get(constants.ReceiptPreferencePrefix+"on", gomock.Any()).SetArg(1, true).Return(nil)
+	kvMock.EXPECT().Get(constants.ReceiptPreferencePrefix+"unset", gomock.Any()).Return(nil)
+
+	if enabled, err := store.GetReceiptPreference("on"); err != nil || !enabled {
+		t.Fatalf("unexpected result: %v, %v", enabled, err)
+	}
+	if enabled, err := store.GetReceiptPreference("unset"); err != nil || enabled {
+		t.Fatalf("unexpected result for unset preference: %v, %v", enabled, err)
+	}
+}
+
+func TestSetReceiptPreference(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+
+	key := constants.ReceiptPreferencePrefix + "user"
+	gomock.InOrder(
+		kvMock.EXPECT().Set(key, true).Return(true, nil),
+		kvMock.EXPECT().Delete(key).Return(nil),
+	)
+
+	if err := store.SetReceiptPreference("user", true); err != nil {
+		t.Fatalf("unexpected error turning receipts on: %v", err)
+	}
+	if err := store.SetReceiptPreference("user", false); err != nil {
+		t.Fatalf("unexpected error turning receipts off: %v", err)
+	}
+}
+
+func TestListUserIndexes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	listFake := &fakeListMatching{}
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, listFake, constants.MaxUserMessages, encryption.NewBox(nil))
+
+	prefixOpt := pluginapi.WithPrefix(constants.UserIndexPrefix)
+	kvMock.EXPECT().ListKeys(0, constants.MaxFetchScheduledMessages, gomock.AssignableToTypeOf(prefixOpt)).
+		Return([]string{testutil.IndexKey("u1"), testutil.IndexKey("u2")}, nil)
+	kvMock.EXPECT().Get(testutil.IndexKey("u1"), gomock.Any()).SetArg(1, []string{"a", "b"}).Return(nil)
+	kvMock.EXPECT().Get(testutil.IndexKey("u2"), gomock.Any()).SetArg(1, []string{}).Return(nil)
+
+	got, err := store.ListUserIndexes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string][]string{"u1": {"a", "b"}, "u2": {}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("mismatch: expected %v got %v", want, got)
+	}
+	if listFake.prefixCalled != constants.UserIndexPrefix {
+		t.Fatalf("unexpected prefix: %s", listFake.prefixCalled)
+	}
+}
+
+func TestListUserIndexes_GetError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+
+	kvMock.EXPECT().ListKeys(0, constants.MaxFetchScheduledMessages, gomock.Any()).Return([]string{testutil.IndexKey("u1")}, nil)
+	kvMock.EXPECT().Get(testutil.IndexKey("u1"), gomock.Any()).Return(fmt.Errorf("boom"))
+
+	if _, err := store.ListUserIndexes(); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestListUserIndexes_ReadsEveryPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+
+	firstPage := make([]string, constants.MaxFetchScheduledMessages)
+	for i := range firstPage {
+		firstPage[i] = testutil.IndexKey(fmt.Sprintf("u%d", i))
+	}
+	kvMock.EXPECT().ListKeys(0, constants.MaxFetchScheduledMessages, gomock.Any()).Return(firstPage, nil)
+	kvMock.EXPECT().ListKeys(1, constants.MaxFetchScheduledMessages, gomock.Any()).Return([]string{testutil.IndexKey("last")}, nil)
+	kvMock.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil).Times(constants.MaxFetchScheduledMessages + 1)
+
+	got, err := store.ListUserIndexes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != constants.MaxFetchScheduledMessages+1 {
+		t.Fatalf("expected %d indexes, got %d", constants.MaxFetchScheduledMessages+1, len(got))
+	}
+	if _, ok := got["last"]; !ok {
+		t.Fatalf("expected the index on the second page to be listed")
+	}
+}
+
+func TestRestoreMessageToUserIndex(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+
+	kvMock.EXPECT().Get(testutil.IndexKey("u1"), gomock.Any()).SetArg(1, []string{"a"}).Return(nil)
+	kvMock.EXPECT().Set(testutil.IndexKey("u1"), []string{"a", "b"}).Return(true, nil)
+
+	if err := store.RestoreMessageToUserIndex("u1", "b"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRestoreMessageToUserIndex_AlreadyIndexed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+
+	kvMock.EXPECT().Get(testutil.IndexKey("u1"), gomock.Any()).SetArg(1, []string{"b"}).Return(nil)
+
+	if err := store.RestoreMessageToUserIndex("u1", "b"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func testBox(t *testing.T, current string, previous ...string) *encryption.Box {
+	t.Helper()
+	keyring, err := encryption.NewKeyring(current, previous)
+	if err != nil {
+		t.Fatalf("unexpected keyring error: %v", err)
+	}
+	return encryption.NewBox(keyring)
+}
+
+var (
+	testOldSecret = strings.Repeat("o", 32)
+	testNewSecret = strings.Repeat("n", 32)
+)
+
+func storeMessage(v any, msg types.ScheduledMessage) {
+	*v.(*types.ScheduledMessage) = msg
+}
+
+func isAtomic(opts []pluginapi.KVSetOption) bool {
+	var o pluginapi.KVSetOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o.Atomic
+}
+
+func TestSaveScheduledMessage_EncryptsContent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	box := testBox(t, testNewSecret)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, box)
+	msg := sampleMessage("m1", "user", time.Unix(100, 0))
+
+	var saved *types.ScheduledMessage
+	kvMock.EXPECT().Get(testutil.IndexKey("user"), gomock.Any()).Return(nil)
+	kvMock.EXPECT().Set(testutil.IndexKey("user"), gomock.Any()).Return(true, nil)
+	expectFirstSequence(kvMock)
+	kvMock.EXPECT().Set(testutil.SchedKey("m1"), gomock.Any()).DoAndReturn(
+		func(_ string, v any, _ ...pluginapi.KVSetOption) (bool, error) {
+			saved = v.(*types.ScheduledMessage)
+			return true, nil
+		},
+	)
+
+	if err := store.SaveScheduledMessage("user", msg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if saved.MessageContent != "" || saved.Encrypted == nil || saved.Encrypted.KeyID != box.KeyID() {
+		t.Fatalf("expected sealed content, got %+v", saved)
+	}
+	if saved.SchemaVersion != constants.ScheduledMessageSchemaVersion {
+		t.Fatalf("expected schema version %d, got %d", constants.ScheduledMessageSchemaVersion, saved.SchemaVersion)
+	}
+	if msg.MessageContent != "hello" || msg.Encrypted != nil {
+		t.Fatalf("caller's message was modified: %+v", msg)
+	}
+}
+
+func TestGetScheduledMessage_DecryptsContent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	box := testBox(t, testNewSecret)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, box)
+	enc, err := box.Encrypt("hello")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stored := *sampleMessage("m1", "u", time.Unix(55, 0))
+	stored.MessageContent = ""
+	stored.Encrypted = enc
+
+	kvMock.EXPECT().Get(testutil.SchedKey("m1"), gomock.Any()).DoAndReturn(func(_ string, v any) error {
+		storeMessage(v, stored)
+		return nil
+	})
+
+	got, err := store.GetScheduledMessage("m1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := sampleMessage("m1", "u", time.Unix(55, 0)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("mismatch got %+v want %+v", got, want)
+	}
+}
+
+func TestGetScheduledMessage_RefusesNewerSchemaVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, testBox(t, ""))
+	stored := *sampleMessage("m1", "u", time.Unix(55, 0))
+	stored.SchemaVersion = constants.ScheduledMessageSchemaVersion + 1
+
+	kvMock.EXPECT().Get(testutil.SchedKey("m1"), gomock.Any()).DoAndReturn(func(_ string, v any) error {
+		storeMessage(v, stored)
+		return nil
+	})
+
+	_, err := store.GetScheduledMessage("m1")
+	want := fmt.Sprintf("message m1 has schema version %d, newer than the supported version %d", stored.SchemaVersion, constants.ScheduledMessageSchemaVersion)
+	if err == nil || err.Error() != want {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestListScheduledMessages_SkipsUndecryptable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	enc, err := testBox(t, testOldSecret).Encrypt("secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The key the message was sealed with is no longer configured.
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, testBox(t, testNewSecret))
+	sealed := *sampleMessage("m1", "u", time.Unix(1, 0))
+	sealed.MessageContent = ""
+	sealed.Encrypted = enc
+	plain := *sampleMessage("m2", "u", time.Unix(2, 0))
+
+	kvMock.EXPECT().ListKeys(0, constants.MaxFetchScheduledMessages, gomock.Any()).Return([]string{testutil.SchedKey("m1"), testutil.SchedKey("m2")}, nil)
+	kvMock.EXPECT().Get(testutil.SchedKey("m1"), gomock.Any()).DoAndReturn(func(_ string, v any) error {
+		storeMessage(v, sealed)
+		return nil
+	})
+	kvMock.EXPECT().Get(testutil.SchedKey("m2"), gomock.Any()).DoAndReturn(func(_ string, v any) error {
+		storeMessage(v, plain)
+		return nil
+	})
+
+	got, err := store.ListScheduledMessages()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []*types.ScheduledMessage{&plain}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("mismatch got %+v want %+v", got, want)
+	}
+}
+
+func TestScanScheduledMessages_ReturnsUnreadableIDs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	enc, err := testBox(t, testOldSecret).Encrypt("secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, testBox(t, testNewSecret))
+	sealed := *sampleMessage("sealed", "u", time.Unix(1, 0))
+	sealed.MessageContent = ""
+	sealed.Encrypted = enc
+	newer := *sampleMessage("newer", "u", time.Unix(2, 0))
+	newer.SchemaVersion = constants.ScheduledMessageSchemaVersion + 1
+	plain := *sampleMessage("plain", "u", time.Unix(3, 0))
+
+	kvMock.EXPECT().ListKeys(0, constants.MaxFetchScheduledMessages, gomock.Any()).
+		Return([]string{testutil.SchedKey("sealed"), testutil.SchedKey("newer"), testutil.SchedKey("broken"), testutil.SchedKey("plain")}, nil)
+	kvMock.EXPECT().Get(testutil.SchedKey("sealed"), gomock.Any()).DoAndReturn(func(_ string, v any) error {
+		storeMessage(v, sealed)
+		return nil
+	})
+	kvMock.EXPECT().Get(testutil.SchedKey("newer"), gomock.Any()).DoAndReturn(func(_ string, v any) error {
+		storeMessage(v, newer)
+		return nil
+	})
+	kvMock.EXPECT().Get(testutil.SchedKey("broken"), gomock.Any()).Return(fmt.Errorf("corrupt"))
+	kvMock.EXPECT().Get(testutil.SchedKey("plain"), gomock.Any()).DoAndReturn(func(_ string, v any) error {
+		storeMessage(v, plain)
+		return nil
+	})
+
+	got, unreadable, err := store.ScanScheduledMessages()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []*types.ScheduledMessage{&plain}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("mismatch got %+v want %+v", got, want)
+	}
+	if want := []string{"sealed", "newer", "broken"}; !reflect.DeepEqual(unreadable, want) {
+		t.Fatalf("unreadable mismatch got %v want %v", unreadable, want)
+	}
+}
+
+func TestReencryptMessages(t *testing.T) {
+	oldBox := testBox(t, testOldSecret)
+	oldEnc, err := oldBox.Encrypt("rotated")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	box := testBox(t, testNewSecret, testOldSecret)
+	currentEnc, err := box.Encrypt("current")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	plain := *sampleMessage("plain", "u", time.Unix(1, 0))
+	old := *sampleMessage("old", "u", time.Unix(2, 0))
+	old.MessageContent, old.Encrypted = "", oldEnc
+	current := *sampleMessage("current", "u", time.Unix(3, 0))
+	current.MessageContent, current.Encrypted = "", currentEnc
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, box)
+
+	records := map[string]types.ScheduledMessage{"plain": plain, "old": old, "current": current}
+	kvMock.EXPECT().ListKeys(0, constants.MaxFetchScheduledMessages, gomock.Any()).Return(
+		[]string{testutil.SchedKey("plain"), testutil.SchedKey("old"), testutil.SchedKey("current"), testutil.SchedKey("sent")}, nil)
+	for id, msg := range records {
+		kvMock.EXPECT().Get(testutil.SchedKey(id), gomock.Any()).DoAndReturn(func(_ string, v any) error {
+			storeMessage(v, msg)
+			return nil
+		})
+	}
+	// Sent and deleted after the keys were listed.
+	kvMock.EXPECT().Get(testutil.SchedKey("sent"), gomock.Any()).Return(nil)
+
+	kvMock.EXPECT().Set(constants.ReencryptedKeyIDKey, box.KeyID()).Return(true, nil)
+	written := map[string]*types.ScheduledMessage{}
+	kvMock.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).DoAndReturn(
+		func(key string, v any, opts ...pluginapi.KVSetOption) (bool, error) {
+			if !isAtomic(opts) {
+				t.Fatalf("expected atomic write for %s", key)
+			}
+			written[key] = v.(*types.ScheduledMessage)
+			return true, nil
+		},
+	)
+
+	rewritten, err := store.ReencryptMessages()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rewritten != 2 {
+		t.Fatalf("expected 2 rewritten, got %d", rewritten)
+	}
+	for _, id := range []string{"plain", "old"} {
+		got := written[testutil.SchedKey(id)]
+		if got == nil || got.MessageContent != "" || got.Encrypted.KeyID != box.KeyID() {
+			t.Fatalf("expected %s sealed with the current key, got %+v", id, got)
+		}
+		if got.SchemaVersion != constants.ScheduledMessageSchemaVersion {
+			t.Fatalf("expected %s stamped with schema version %d, got %d", id, constants.ScheduledMessageSchemaVersion, got.SchemaVersion)
+		}
+	}
+	if content, _ := box.Decrypt(written[testutil.SchedKey("old")].Encrypted); content != "rotated" {
+		t.Fatalf("rewrapped content mismatch: %q", content)
+	}
+}
+
+func TestNeedsReencryption(t *testing.T) {
+	box := testBox(t, testNewSecret)
+	cases := []struct {
+		name string
+		done any
+		want bool
+	}{
+		{"never reencrypted", nil, true},
+		{"reencrypted with an older key", "old-key-id", true},
+		{"reencrypted while disabled", "", true},
+		{"reencrypted with the current key", box.KeyID(), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			kvMock := mock.NewMockKVService(ctrl)
+			store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, box)
+
+			kvMock.EXPECT().Get(constants.ReencryptedKeyIDKey, gomock.Any()).DoAndReturn(func(_ string, v any) error {
+				if id, ok := tc.done.(string); ok {
+					*v.(**string) = &id
+				}
+				return nil
+			})
+
+			got, err := store.NeedsReencryption()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestReencryptMessages_ReadsEveryPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, testBox(t, testNewSecret))
+
+	firstPage := make([]string, constants.MaxFetchScheduledMessages)
+	for i := range firstPage {
+		firstPage[i] = testutil.SchedKey(fmt.Sprintf("sent%d", i))
+	}
+	kvMock.EXPECT().ListKeys(0, constants.MaxFetchScheduledMessages, gomock.Any()).Return(firstPage, nil)
+	kvMock.EXPECT().ListKeys(1, constants.MaxFetchScheduledMessages, gomock.Any()).Return([]string{testutil.SchedKey("last")}, nil)
+	// Everything on the first page was sent meanwhile.
+	kvMock.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil).Times(constants.MaxFetchScheduledMessages)
+	kvMock.EXPECT().Get(testutil.SchedKey("last"), gomock.Any()).DoAndReturn(func(_ string, v any) error {
+		storeMessage(v, *sampleMessage("last", "u", time.Unix(1, 0)))
+		return nil
+	})
+	kvMock.EXPECT().Set(testutil.SchedKey("last"), gomock.Any(), gomock.Any()).Return(true, nil)
+	kvMock.EXPECT().Set(constants.ReencryptedKeyIDKey, gomock.Any()).Return(true, nil)
+
+	rewritten, err := store.ReencryptMessages()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rewritten != 1 {
+		t.Fatalf("expected 1 rewritten, got %d", rewritten)
+	}
+}
+
+func TestReencryptMessages_DecryptsWhenDisabled(t *testing.T) {
+	enc, err := testBox(t, testOldSecret).Encrypt("hello")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sealed := *sampleMessage("m1", "u", time.Unix(1, 0))
+	sealed.MessageContent, sealed.Encrypted = "", enc
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, testBox(t, "", testOldSecret))
+
+	kvMock.EXPECT().ListKeys(0, constants.MaxFetchScheduledMessages, gomock.Any()).Return([]string{testutil.SchedKey("m1")}, nil)
+	kvMock.EXPECT().Get(testutil.SchedKey("m1"), gomock.Any()).DoAndReturn(func(_ string, v any) error {
+		storeMessage(v, sealed)
+		return nil
+	})
+	want := sampleMessage("m1", "u", time.Unix(1, 0))
+	want.SchemaVersion = constants.PlainMessageSchemaVersion
+	kvMock.EXPECT().Set(testutil.SchedKey("m1"), want, gomock.Any()).Return(true, nil)
+	kvMock.EXPECT().Set(constants.ReencryptedKeyIDKey, "").Return(true, nil)
+
+	if _, err := store.ReencryptMessages(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestReencryptMessages_SkipsChangedRecords(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, testBox(t, testNewSecret))
+
+	kvMock.EXPECT().ListKeys(0, constants.MaxFetchScheduledMessages, gomock.Any()).Return([]string{testutil.SchedKey("m1")}, nil)
+	kvMock.EXPECT().Get(testutil.SchedKey("m1"), gomock.Any()).DoAndReturn(func(_ string, v any) error {
+		storeMessage(v, *sampleMessage("m1", "u", time.Unix(1, 0)))
+		return nil
+	})
+	// The compare-and-set fails because the message changed since it was read.
+	kvMock.EXPECT().Set(testutil.SchedKey("m1"), gomock.Any(), gomock.Any()).Return(false, nil)
+	kvMock.EXPECT().Set(constants.ReencryptedKeyIDKey, gomock.Any()).Return(true, nil)
+
+	rewritten, err := store.ReencryptMessages()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rewritten != 0 {
+		t.Fatalf("expected 0 rewritten, got %d", rewritten)
+	}
+}
+
+func TestReencryptMessages_SkipsNewerSchemaVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, testBox(t, testNewSecret))
+	newer := *sampleMessage("m1", "u", time.Unix(1, 0))
+	newer.SchemaVersion = constants.ScheduledMessageSchemaVersion + 1
+
+	kvMock.EXPECT().ListKeys(0, constants.MaxFetchScheduledMessages, gomock.Any()).Return([]string{testutil.SchedKey("m1")}, nil)
+	kvMock.EXPECT().Get(testutil.SchedKey("m1"), gomock.Any()).DoAndReturn(func(_ string, v any) error {
+		storeMessage(v, newer)
+		return nil
+	})
+	kvMock.EXPECT().Set(constants.ReencryptedKeyIDKey, gomock.Any()).Return(true, nil)
+
+	rewritten, err := store.ReencryptMessages()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rewritten != 0 {
+		t.Fatalf("expected 0 rewritten, got %d", rewritten)
+	}
+}
+
+func TestReencryptMessages_ReportsFailures(t *testing.T) {
+	enc, err := testBox(t, testOldSecret).Encrypt("hello")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sealed := *sampleMessage("m1", "u", time.Unix(1, 0))
+	sealed.MessageContent, sealed.Encrypted = "", enc
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	kvMock := mock.NewMockKVService(ctrl)
+	// The old key was dropped before the message was reencrypted.
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, testBox(t, testNewSecret))
+
+	kvMock.EXPECT().ListKeys(0, constants.MaxFetchScheduledMessages, gomock.Any()).Return([]string{testutil.SchedKey("m1")}, nil)
+	kvMock.EXPECT().Get(testutil.SchedKey("m1"), gomock.Any()).DoAndReturn(func(_ string, v any) error {
+		storeMessage(v, sealed)
+		return nil
+	})
+
+	_, err = store.ReencryptMessages()
+	if err == nil || err.Error() != "failed to reencrypt 1 of 1 scheduled messages" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// expectFirstSequence expects the first sequence number to be reserved.
+func expectFirstSequence(kvMock *mock.MockKVService) {
+	kvMock.EXPECT().Get(constants.SequenceKey, gomock.Any()).Return(nil)
+	kvMock.EXPECT().Set(constants.SequenceKey, int64(1), gomock.Any()).Return(true, nil)
+}
+
+func TestSaveScheduledMessage_RetriesContendedSequence(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+	msg := sampleMessage("m1", "user", time.Unix(100, 0))
+
+	kvMock.EXPECT().Get(testutil.IndexKey("user"), gomock.Any()).Return(nil)
+	kvMock.EXPECT().Set(testutil.IndexKey("user"), gomock.Any()).Return(true, nil)
+	gomock.InOrder(
+		kvMock.EXPECT().Get(constants.SequenceKey, gomock.Any()).SetArg(1, ptrTo(int64(41))).Return(nil),
+		// Another node reserved 42 first.
+		kvMock.EXPECT().Set(constants.SequenceKey, int64(42), gomock.Any()).Return(false, nil),
+		kvMock.EXPECT().Get(constants.SequenceKey, gomock.Any()).SetArg(1, ptrTo(int64(42))).Return(nil),
+		kvMock.EXPECT().Set(constants.SequenceKey, int64(43), gomock.Any()).DoAndReturn(
+			func(_ string, _ any, opts ...pluginapi.KVSetOption) (bool, error) {
+				if !isAtomic(opts) {
+					t.Fatalf("expected atomic sequence write")
+				}
+				return true, nil
+			},
+		),
+	)
+	kvMock.EXPECT().Set(testutil.SchedKey("m1"), msg).Return(true, nil)
+
+	if err := store.SaveScheduledMessage("user", msg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if msg.Sequence != 43 {
+		t.Fatalf("expected sequence 43, got %d", msg.Sequence)
+	}
+}
+
+func TestSaveScheduledMessage_KeepsExistingSequence(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+	msg := sampleMessage("m1", "user", time.Unix(100, 0))
+	msg.Sequence = 7
+
+	kvMock.EXPECT().Get(testutil.IndexKey("user"), gomock.Any()).SetArg(1, []string{"m1"}).Return(nil)
+	kvMock.EXPECT().Set(testutil.SchedKey("m1"), msg).Return(true, nil)
+
+	if err := store.SaveScheduledMessage("user", msg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSaveScheduledMessage_SequenceExhausted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+
+	kvMock.EXPECT().Get(testutil.IndexKey("user"), gomock.Any()).Return(nil)
+	kvMock.EXPECT().Set(testutil.IndexKey("user"), gomock.Any()).Return(true, nil)
+	kvMock.EXPECT().Get(constants.SequenceKey, gomock.Any()).Return(nil).Times(constants.SequenceMaxAttempts)
+	kvMock.EXPECT().Set(constants.SequenceKey, int64(1), gomock.Any()).Return(false, nil).Times(constants.SequenceMaxAttempts)
+
+	err := store.SaveScheduledMessage("user", sampleMessage("m1", "user", time.Unix(100, 0)))
+	if err == nil || err.Error() != "failed to reserve sequence number: sequence number still contended after 10 attempts" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func ptrTo[T any](v T) *T { return &v }
+
+func TestDeferScheduledMessage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+	until := time.Unix(160, 0).UTC()
+	// A plain record stamped version 2 before only encrypted records needed it.
+	stored := *sampleMessage("m1", "u", time.Unix(100, 0))
+	stored.SchemaVersion = constants.ScheduledMessageSchemaVersion
+
+	kvMock.EXPECT().Get(testutil.SchedKey("m1"), gomock.Any()).DoAndReturn(func(_ string, v any) error {
+		storeMessage(v, stored)
+		return nil
+	})
+	kvMock.EXPECT().Set(testutil.SchedKey("m1"), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ string, v any, opts ...pluginapi.KVSetOption) (bool, error) {
+			if !isAtomic(opts) {
+				t.Fatalf("expected atomic write")
+			}
+			if got := v.(*types.ScheduledMessage).DeferredUntil; !got.Equal(until) {
+				t.Fatalf("expected deferred until %v, got %v", until, got)
+			}
+			if got := v.(*types.ScheduledMessage).SchemaVersion; got != constants.PlainMessageSchemaVersion {
+				t.Fatalf("expected schema version %d, got %d", constants.PlainMessageSchemaVersion, got)
+			}
+			return true, nil
+		},
+	)
+
+	deferred, err := store.DeferScheduledMessage("m1", until)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !deferred {
+		t.Fatalf("expected message to be deferred")
+	}
+}
+
+func TestDeferScheduledMessage_SkipsMissingAndChangedRecords(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+
+	kvMock.EXPECT().Get(testutil.SchedKey("gone"), gomock.Any()).Return(nil)
+	kvMock.EXPECT().Get(testutil.SchedKey("m1"), gomock.Any()).DoAndReturn(func(_ string, v any) error {
+		storeMessage(v, *sampleMessage("m1", "u", time.Unix(100, 0)))
+		return nil
+	})
+	// The compare-and-set fails because the message was edited since it was read.
+	kvMock.EXPECT().Set(testutil.SchedKey("m1"), gomock.Any(), gomock.Any()).Return(false, nil)
+
+	for _, id := range []string{"gone", "m1"} {
+		deferred, err := store.DeferScheduledMessage(id, time.Unix(160, 0))
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", id, err)
+		}
+		if deferred {
+			t.Fatalf("expected %s not to be deferred", id)
+		}
+	}
+}
+
+func TestMarkScheduledMessageWarned(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+	at := time.Unix(40, 0).UTC()
+
+	pending := *sampleMessage("m1", "u", time.Unix(100, 0))
+	pending.WarnBefore = time.Minute
+	warned := pending
+	warned.ID = "m2"
+	warned.WarnedAt = time.Unix(30, 0).UTC()
+
+	kvMock.EXPECT().Get(testutil.SchedKey("m1"), gomock.Any()).DoAndReturn(func(_ string, v any) error {
+		storeMessage(v, pending)
+		return nil
+	})
+	kvMock.EXPECT().Set(testutil.SchedKey("m1"), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ string, v any, opts ...pluginapi.KVSetOption) (bool, error) {
+			if !isAtomic(opts) {
+				t.Fatalf("expected atomic write")
+			}
+			if got := v.(*types.ScheduledMessage).WarnedAt; !got.Equal(at) {
+				t.Fatalf("expected warned at %v, got %v", at, got)
+			}
+			return true, nil
+		},
+	)
+	// A heads-up another server already sent is not sent again.
+	kvMock.EXPECT().Get(testutil.SchedKey("m2"), gomock.Any()).DoAndReturn(func(_ string, v any) error {
+		storeMessage(v, warned)
+		return nil
+	})
+
+	if marked, err := store.MarkScheduledMessageWarned("m1", at); err != nil || !marked {
+		t.Fatalf("unexpected result: %v, %v", marked, err)
+	}
+	if marked, err := store.MarkScheduledMessageWarned("m2", at); err != nil || marked {
+		t.Fatalf("expected already warned message to be left alone, got %v, %v", marked, err)
+	}
+}
+
+func TestSnoozeScheduledMessage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	kvMock := mock.NewMockKVService(ctrl)
+	store := NewKVStore(testutil.FakeLogger{}, kvMock, &fakeListMatching{}, constants.MaxUserMessages, encryption.NewBox(nil))
+	postAt := time.Unix(700, 0).UTC()
+
+	stored := *sampleMessage("m1", "u", time.Unix(100, 0))
+	stored.WarnBefore = 10 * time.Minute
+	stored.WarnedAt = time.Unix(40, 0).UTC()
+	stored.DeferredUntil = time.Unix(160, 0).UTC()
+
+	kvMock.EXPECT().Get(testutil.SchedKey("m1"), gomock.Any()).DoAndReturn(func(_ string, v any) error {
+		storeMessage(v, stored)
+		return nil
+	})
+	kvMock.EXPECT().Set(testutil.SchedKey("m1"), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ string, v any, opts ...pluginapi.KVSetOption) (bool, error) {
+			if !isAtomic(opts) {
+				t.Fatalf("expected atomic write")
+			}
+			got := v.(*types.ScheduledMessage)
+			if !got.PostAt.Equal(postAt) || !got.WarnedAt.IsZero() || !got.DeferredUntil.IsZero() {
+				t.Fatalf("unexpected snoozed record: %+v", got)
+			}
+			return true, nil
+		},
+	)
+
+	if snoozed, err := store.SnoozeScheduledMessage("m1", postAt); err != nil || !snoozed {
+		t.Fatalf("unexpected result: %v, %v", snoozed, err)
+	}
+}
@@ -0,0 +1,36 @@
+package store
+
+import (
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/internal/ports"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+)
+
+// ObservedStore wraps a Store and reports saved and deleted scheduled
+// messages to an observer.
+type ObservedStore struct {
+	ports.Store
+	observer ports.MessageObserver
+}
+
+// NewObservedStore wraps st so successful saves and deletes reach observer.
+func NewObservedStore(st ports.Store, observer ports.MessageObserver) *ObservedStore {
+	return &ObservedStore{Store: st, observer: observer}
+}
+
+// SaveScheduledMessage saves msg and reports it once stored.
+func (o *ObservedStore) SaveScheduledMessage(userID string, msg *types.ScheduledMessage) error {
+	if err := o.Store.SaveScheduledMessage(userID, msg); err != nil {
+		return err
+	}
+	o.observer.MessageScheduled(msg)
+	return nil
+}
+
+// DeleteScheduledMessage deletes a message and reports it once removed.
+func (o *ObservedStore) DeleteScheduledMessage(userID string, msgID string) error {
+	if err := o.Store.DeleteScheduledMessage(userID, msgID); err != nil {
+		return err
+	}
+	o.observer.MessageUnscheduled(msgID)
+	return nil
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/adapters/mock"
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	"go.uber.org/mock/gomock"
+)
+
+func TestObservedStore_ReportsChanges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inner := mock.NewMockStore(ctrl)
+	observer := mock.NewMockMessageObserver(ctrl)
+	st := NewObservedStore(inner, observer)
+	msg := &types.ScheduledMessage{ID: "m1"}
+
+	inner.EXPECT().SaveScheduledMessage("u", msg).Return(nil)
+	observer.EXPECT().MessageScheduled(msg)
+	inner.EXPECT().DeleteScheduledMessage("u", "m1").Return(nil)
+	observer.EXPECT().MessageUnscheduled("m1")
+
+	if err := st.SaveScheduledMessage("u", msg); err != nil {
+		t.Fatalf("SaveScheduledMessage() error = %v", err)
+	}
+	if err := st.DeleteScheduledMessage("u", "m1"); err != nil {
+		t.Fatalf("DeleteScheduledMessage() error = %v", err)
+	}
+}
+
+func TestObservedStore_SkipsFailedChanges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inner := mock.NewMockStore(ctrl)
+	st := NewObservedStore(inner, mock.NewMockMessageObserver(ctrl))
+	boom := errors.New("boom")
+
+	inner.EXPECT().SaveScheduledMessage("u", gomock.Any()).Return(boom)
+	inner.EXPECT().DeleteScheduledMessage("u", "m1").Return(boom)
+
+	if err := st.SaveScheduledMessage("u", &types.ScheduledMessage{ID: "m1"}); !errors.Is(err, boom) {
+		t.Fatalf("SaveScheduledMessage() error = %v, want %v", err, boom)
+	}
+	if err := st.DeleteScheduledMessage("u", "m1"); !errors.Is(err, boom) {
+		t.Fatalf("DeleteScheduledMessage() error = %v, want %v", err, boom)
+	}
+}
@@ -0,0 +1,236 @@
+// Package transfer encodes and decodes scheduled messages for export and import.
+package transfer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+)
+
+const (
+	// FormatJSON is the JSON export format.
+	FormatJSON = "json"
+	// FormatCSV is the CSV export format.
+	FormatCSV = "csv"
+)
+
+// csvHeader lists the CSV columns in order. Multiple destinations are
+// space separated in the channel_ids column. New columns are only ever
+// appended, so files exported before they were added still import.
+var csvHeader = []string{"id", "channel_id", "channel_ids", "root_id", "post_at", "timezone", "occurrence", "message_content", "recurrence_id", "attribution", "receipt", "warn_before", "cancel_if_replied_after", "reply_from", "followup", "forwarded_post_id", "file_ids", "comment_bytes", "literal"}
+
+// csvRequiredColumns is how many leading csvHeader columns a file must have.
+const csvRequiredColumns = 8
+
+// Row is one decoded record. Err is set when the record could not be decoded.
+type Row struct {
+	Number  int
+	Message *types.ScheduledMessage
+	Err     error
+}
+
+// IsSupported reports whether format is a known export format.
+func IsSupported(format string) bool {
+	return format == FormatJSON || format == FormatCSV
+}
+
+// Encode serializes messages in the given format.
+func Encode(format string, msgs []*types.ScheduledMessage) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(msgs, "", "  ")
+	case FormatCSV:
+		return encodeCSV(msgs)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// Decode parses an export file. A returned error means the whole file is
+// unreadable; problems with individual records are reported on their Row.
+func Decode(format string, data []byte) ([]Row, error) {
+	switch format {
+	case FormatJSON:
+		return decodeJSON(data)
+	case FormatCSV:
+		return decodeCSV(data)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+func encodeCSV(msgs []*types.ScheduledMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	for _, m := range msgs {
+		warnBefore := ""
+		if m.WarnBefore > 0 {
+			warnBefore = m.WarnBefore.String()
+		}
+		cancelIfRepliedAfter := ""
+		if !m.CancelIfRepliedAfter.IsZero() {
+			cancelIfRepliedAfter = m.CancelIfRepliedAfter.UTC().Format(time.RFC3339Nano)
+		}
+		record := []string{
+			m.ID,
+			m.ChannelID,
+			strings.Join(m.ChannelIDs, " "),
+			m.RootID,
+			m.PostAt.UTC().Format(time.RFC3339),
+			m.Timezone,
+			strconv.Itoa(m.Occurrence),
+			m.MessageContent,
+			m.RecurrenceID,
+			m.Attribution,
+			m.Receipt,
+			warnBefore,
+			cancelIfRepliedAfter,
+			strings.Join(m.ReplyFrom, " "),
+			m.Followup,
+			m.ForwardedPostID,
+			strings.Join(m.FileIDs, " "),
+			strconv.Itoa(m.CommentBytes),
+			strconv.FormatBool(m.Literal),
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeJSON(data []byte) ([]Row, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON export: %w", err)
+	}
+	rows := make([]Row, 0, len(raw))
+	for i, r := range raw {
+		var msg types.ScheduledMessage
+		row := Row{Number: i + 1}
+		if err := json.Unmarshal(r, &msg); err != nil {
+			row.Err = fmt.Errorf("invalid record: %w", err)
+		} else {
+			row.Message = &msg
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func decodeCSV(data []byte) ([]Row, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV export: %w", err)
+	}
+	if len(header) < csvRequiredColumns || len(header) > len(csvHeader) || !slices.Equal(header, csvHeader[:len(header)]) {
+		return nil, fmt.Errorf("invalid CSV export: header must be %s", strings.Join(csvHeader, ","))
+	}
+	var rows []Row
+	for number := 1; ; number++ {
+		record, readErr := r.Read()
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil {
+			return nil, fmt.Errorf("invalid CSV export: %w", readErr)
+		}
+		row := Row{Number: number}
+		if len(record) != len(header) {
+			row.Err = fmt.Errorf("expected %d columns, got %d", len(header), len(record))
+		} else {
+			// Columns missing from older exports are read as empty.
+			record = append(record, make([]string, len(csvHeader)-len(header))...)
+			row.Message, row.Err = decodeCSVRecord(record)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// decodeCSVRecord decodes a record with one value for each csvHeader column.
+func decodeCSVRecord(record []string) (*types.ScheduledMessage, error) {
+	postAt, err := time.Parse(time.RFC3339, record[4])
+	if err != nil {
+		return nil, fmt.Errorf("invalid post_at %q: %w", record[4], err)
+	}
+	occurrence := 0
+	if record[6] != "" {
+		if occurrence, err = strconv.Atoi(record[6]); err != nil {
+			return nil, fmt.Errorf("invalid occurrence %q", record[6])
+		}
+	}
+	var warnBefore time.Duration
+	if record[11] != "" {
+		if warnBefore, err = time.ParseDuration(record[11]); err != nil {
+			return nil, fmt.Errorf("invalid warn_before %q", record[11])
+		}
+	}
+	var cancelIfRepliedAfter time.Time
+	if record[12] != "" {
+		if cancelIfRepliedAfter, err = time.Parse(time.RFC3339Nano, record[12]); err != nil {
+			return nil, fmt.Errorf("invalid cancel_if_replied_after %q: %w", record[12], err)
+		}
+	}
+	commentBytes := 0
+	if record[17] != "" {
+		if commentBytes, err = strconv.Atoi(record[17]); err != nil {
+			return nil, fmt.Errorf("invalid comment_bytes %q", record[17])
+		}
+	}
+	literal := false
+	if record[18] != "" {
+		if literal, err = strconv.ParseBool(record[18]); err != nil {
+			return nil, fmt.Errorf("invalid literal %q", record[18])
+		}
+	}
+	return &types.ScheduledMessage{
+		ID:                   record[0],
+		ChannelID:            record[1],
+		ChannelIDs:           strings.Fields(record[2]),
+		RootID:               record[3],
+		PostAt:               postAt,
+		Timezone:             record[5],
+		Occurrence:           occurrence,
+		MessageContent:       record[7],
+		RecurrenceID:         record[8],
+		Attribution:          record[9],
+		Receipt:              record[10],
+		WarnBefore:           warnBefore,
+		CancelIfRepliedAfter: cancelIfRepliedAfter,
+		ReplyFrom:            splitList(record[13]),
+		Followup:             record[14],
+		ForwardedPostID:      record[15],
+		FileIDs:              splitList(record[16]),
+		CommentBytes:         commentBytes,
+		Literal:              literal,
+	}, nil
+}
+
+// splitList splits a space-separated CSV cell, returning nil for an empty cell
+// so that decoded messages match their JSON counterparts.
+func splitList(cell string) []string {
+	fields := strings.Fields(cell)
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
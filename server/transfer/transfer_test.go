@@ -0,0 +1,142 @@
+package transfer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apartmentlines/mattermost-plugin-poor-mans-scheduled-messages/server/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleMessages() []*types.ScheduledMessage {
+	return []*types.ScheduledMessage{
+		{
+			ID:                   "id1",
+			UserID:               "user",
+			ChannelID:            "chan1",
+			RootID:               "root1",
+			CancelIfRepliedAfter: time.Date(2030, 1, 1, 8, 0, 0, 123456789, time.UTC),
+			ReplyFrom:            []string{"bob", "carol"},
+			Followup:             "bump",
+			ForwardedPostID:      "post-1",
+			FileIDs:              []string{"file-1", "file-2"},
+			CommentBytes:         2,
+			Literal:              true,
+			PostAt:               time.Date(2030, 1, 2, 15, 4, 0, 0, time.UTC),
+			MessageContent:       "hello, \"world\"\nsecond line",
+			Timezone:             "America/New_York",
+		},
+		{
+			ID:             "id2",
+			UserID:         "user",
+			ChannelID:      "chan1",
+			ChannelIDs:     []string{"chan1", "chan2"},
+			PostAt:         time.Date(2030, 2, 3, 9, 0, 0, 0, time.UTC),
+			MessageContent: "cross-post",
+			Timezone:       "UTC",
+			Occurrence:     2,
+			RecurrenceID:   "event-uid",
+			Attribution:    "bot",
+			Receipt:        "off",
+			WarnBefore:     90 * time.Minute,
+		},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []string{FormatJSON, FormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			msgs := sampleMessages()
+			data, err := Encode(format, msgs)
+			require.NoError(t, err)
+
+			rows, err := Decode(format, data)
+			require.NoError(t, err)
+			require.Len(t, rows, len(msgs))
+			for i, row := range rows {
+				require.NoError(t, row.Err)
+				assert.Equal(t, i+1, row.Number)
+				assert.Equal(t, msgs[i].ID, row.Message.ID)
+				assert.Equal(t, msgs[i].ChannelID, row.Message.ChannelID)
+				assert.Equal(t, msgs[i].Destinations(), row.Message.Destinations())
+				assert.Equal(t, msgs[i].RootID, row.Message.RootID)
+				assert.True(t, msgs[i].PostAt.Equal(row.Message.PostAt))
+				assert.Equal(t, msgs[i].MessageContent, row.Message.MessageContent)
+				assert.Equal(t, msgs[i].Timezone, row.Message.Timezone)
+				assert.Equal(t, msgs[i].Occurrence, row.Message.Occurrence)
+				assert.Equal(t, msgs[i].RecurrenceID, row.Message.RecurrenceID)
+				assert.Equal(t, msgs[i].Attribution, row.Message.Attribution)
+				assert.Equal(t, msgs[i].Receipt, row.Message.Receipt)
+				assert.Equal(t, msgs[i].WarnBefore, row.Message.WarnBefore)
+				assert.True(t, msgs[i].CancelIfRepliedAfter.Equal(row.Message.CancelIfRepliedAfter))
+				assert.Equal(t, msgs[i].ReplyFrom, row.Message.ReplyFrom)
+				assert.Equal(t, msgs[i].Followup, row.Message.Followup)
+				assert.Equal(t, msgs[i].ForwardedPostID, row.Message.ForwardedPostID)
+				assert.Equal(t, msgs[i].FileIDs, row.Message.FileIDs)
+				assert.Equal(t, msgs[i].CommentBytes, row.Message.CommentBytes)
+				assert.Equal(t, msgs[i].Literal, row.Message.Literal)
+			}
+		})
+	}
+}
+
+func TestDecodeJSON_InvalidRecord(t *testing.T) {
+	rows, err := Decode(FormatJSON, []byte(`[{"id":"ok","post_at":"2030-01-01T00:00:00Z"},{"post_at":"tomorrow"}]`))
+
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.NoError(t, rows[0].Err)
+	assert.Error(t, rows[1].Err)
+	assert.Nil(t, rows[1].Message)
+}
+
+func TestDecodeJSON_NotAnArray(t *testing.T) {
+	_, err := Decode(FormatJSON, []byte(`{"id":"x"}`))
+
+	assert.ErrorContains(t, err, "invalid JSON export")
+}
+
+func TestDecodeCSV_InvalidRecords(t *testing.T) {
+	data := strings.Join([]string{
+		strings.Join(csvHeader[:csvRequiredColumns], ","),
+		"id1,chan1,,,not-a-time,UTC,,hi",
+		"id2,chan1",
+		"id3,chan1,,,2030-01-01T00:00:00Z,UTC,x,hi",
+	}, "\n")
+
+	rows, err := Decode(FormatCSV, []byte(data))
+
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.ErrorContains(t, rows[0].Err, "invalid post_at")
+	assert.ErrorContains(t, rows[1].Err, fmt.Sprintf("expected %d columns", csvRequiredColumns))
+	assert.ErrorContains(t, rows[2].Err, "invalid occurrence")
+}
+
+func TestDecodeCSV_OlderHeader(t *testing.T) {
+	data := strings.Join(csvHeader[:csvRequiredColumns], ",") + "\nid1,chan1,,,2030-01-01T00:00:00Z,UTC,,hi\n"
+
+	rows, err := Decode(FormatCSV, []byte(data))
+
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.NoError(t, rows[0].Err)
+	assert.Equal(t, "hi", rows[0].Message.MessageContent)
+}
+
+func TestDecodeCSV_BadHeader(t *testing.T) {
+	_, err := Decode(FormatCSV, []byte("a,b,c\n1,2,3\n"))
+
+	assert.ErrorContains(t, err, "header must be")
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	assert.False(t, IsSupported("xml"))
+	_, err := Encode("xml", nil)
+	assert.Error(t, err)
+	_, err = Decode("xml", nil)
+	assert.Error(t, err)
+}
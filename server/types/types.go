@@ -1,15 +1,239 @@
 //revive:disable:var-naming // Package name is conventional for shared types.
 package types
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// ErrMessageNotFound is returned when a scheduled message does not exist,
+// usually because it has already been sent or deleted.
+var ErrMessageNotFound = errors.New("message not found (possibly already sent)")
 
 // ScheduledMessage represents a message scheduled for future delivery.
 type ScheduledMessage struct {
 	ID             string    `json:"id"`
 	UserID         string    `json:"user_id"`
 	ChannelID      string    `json:"channel_id"`
+	ChannelIDs     []string  `json:"channel_ids,omitempty"`
 	RootID         string    `json:"root_id,omitempty"`
 	PostAt         time.Time `json:"post_at"`
 	MessageContent string    `json:"message_content"`
 	Timezone       string    `json:"timezone"`
+	Occurrence     int       `json:"occurrence,omitempty"`
+	// RecurrenceID identifies the series a recurring message belongs to,
+	// such as the calendar event UID of an imported reminder.
+	RecurrenceID string `json:"recurrence_id,omitempty"`
+	// Literal marks MessageContent taken from elsewhere, such as a calendar
+	// event title, that is posted as written rather than as a template.
+	Literal bool `json:"literal,omitempty"`
+	// Attribution is who the message is posted as, one of the
+	// constants.Attribution values. Empty uses the admin default.
+	Attribution string `json:"attribution,omitempty"`
+	// Receipt is whether the author is sent a receipt once the message is
+	// posted, one of the constants.Receipt values. Empty uses the author's
+	// preference.
+	Receipt string `json:"receipt,omitempty"`
+	// WarnBefore is how long before PostAt the author is sent a heads-up
+	// they can cancel, snooze or send from. Zero sends none.
+	WarnBefore time.Duration `json:"warn_before,omitempty"`
+	// WarnedAt is when the heads-up was sent.
+	WarnedAt time.Time `json:"warned_at,omitzero"`
+	// CancelIfRepliedAfter is set for thread replies scheduled "unless
+	// replied": the message is cancelled if anyone else posted in the
+	// thread after this time.
+	CancelIfRepliedAfter time.Time `json:"cancel_if_replied_after,omitzero"`
+	// ReplyFrom limits the replies that cancel the message to these users.
+	// Empty counts a reply from anyone but the author.
+	ReplyFrom []string `json:"reply_from,omitempty"`
+	// Followup marks a follow-up for the thread, one of the
+	// constants.Followup values. Empty for ordinary messages.
+	Followup string `json:"followup,omitempty"`
+	// ForwardedPostID is the post a forwarded message quotes. A link to it
+	// is added when the message is posted.
+	ForwardedPostID string `json:"forwarded_post_id,omitempty"`
+	// CommentBytes is the length of the author's comment at the start of a
+	// forwarded message's MessageContent. Only the comment is a template;
+	// the quoted post after it is posted as written.
+	CommentBytes int `json:"comment_bytes,omitempty"`
+	// FileIDs are the attachments of a forwarded post, copied onto each
+	// post the message is sent as.
+	FileIDs []string `json:"file_ids,omitempty"`
+	// Sequence orders messages by when they were scheduled, so messages
+	// due at the same time are posted in that order. Records written
+	// before sequencing have 0.
+	Sequence int64 `json:"sequence,omitempty"`
+	// DeferredUntil is set when a rate limit held the message back past its
+	// PostAt; it is next tried at this time.
+	DeferredUntil time.Time `json:"deferred_until,omitzero"`
+	// SchemaVersion is the record layout version, see
+	// constants.ScheduledMessageSchemaVersion. Records written before
+	// versioning have 0.
+	SchemaVersion int `json:"schema_version,omitempty"`
+	// Encrypted holds MessageContent sealed at rest. The store fills in
+	// MessageContent on read, so only the store sees this field set.
+	Encrypted *EncryptedContent `json:"encrypted,omitempty"`
+}
+
+// EncryptedContent is message content sealed with a per-message data key,
+// which is itself sealed with the configured key named by KeyID.
+type EncryptedContent struct {
+	KeyID      string `json:"key_id"`
+	WrappedKey []byte `json:"wrapped_key"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Destinations returns every channel the message is delivered to. Records
+// written before cross-posting existed only carry ChannelID.
+func (m *ScheduledMessage) Destinations() []string {
+	if len(m.ChannelIDs) > 0 {
+		return m.ChannelIDs
+	}
+	return []string{m.ChannelID}
+}
+
+// DueAt returns when the message is next tried: its PostAt, or the time a
+// rate limit deferred it to.
+func (m *ScheduledMessage) DueAt() time.Time {
+	if m.DeferredUntil.After(m.PostAt) {
+		return m.DeferredUntil
+	}
+	return m.PostAt
+}
+
+// WarnAt returns when the author's heads-up is due, if one is still to be
+// sent.
+func (m *ScheduledMessage) WarnAt() (time.Time, bool) {
+	if m.WarnBefore <= 0 || !m.WarnedAt.IsZero() {
+		return time.Time{}, false
+	}
+	return m.PostAt.Add(-m.WarnBefore), true
+}
+
+// NextActionAt returns when the scheduler next has something to do for the
+// message: send its heads-up, or deliver it.
+func (m *ScheduledMessage) NextActionAt() time.Time {
+	if warnAt, ok := m.WarnAt(); ok && warnAt.Before(m.DueAt()) {
+		return warnAt
+	}
+	return m.DueAt()
+}
+
+// OccurrenceNumber returns the 1-based position of the message within its
+// series. One-off messages are always the first occurrence.
+func (m *ScheduledMessage) OccurrenceNumber() int {
+	if m.Occurrence < 1 {
+		return 1
+	}
+	return m.Occurrence
+}
+
+// DeliveryResult records the outcome of posting a message to one destination.
+type DeliveryResult struct {
+	ChannelID string
+	PostID    string
+	Err       error
+}
+
+// ImportRowResult reports the outcome of importing one row of an export file.
+type ImportRowResult struct {
+	Row       int    `json:"row"`
+	MessageID string `json:"message_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ImportReport summarizes an import of scheduled messages.
+type ImportReport struct {
+	Imported int               `json:"imported"`
+	Rejected int               `json:"rejected"`
+	Rows     []ImportRowResult `json:"rows"`
+}
+
+// AdminStatus reports the health of the scheduler and its stored data.
+// UnreadableMessages counts stored messages that cannot be read, such as ones
+// sealed with an encryption key that is no longer configured; they are never
+// sent.
+type AdminStatus struct {
+	BotID                string        `json:"bot_id"`
+	LastTick             time.Time     `json:"last_tick"`
+	NextTick             time.Time     `json:"next_tick"`
+	ScheduledMessages    int           `json:"scheduled_messages"`
+	DueMessages          int           `json:"due_messages"`
+	UnreadableMessages   int           `json:"unreadable_messages"`
+	OrphanedIndexEntries int           `json:"orphaned_index_entries"`
+	MissingIndexEntries  int           `json:"missing_index_entries"`
+	Settings             AdminSettings `json:"settings"`
+}
+
+// AdminSettings lists the limits the plugin is running with.
+type AdminSettings struct {
+	MaxUserMessages           int `json:"max_user_messages"`
+	MaxMessageBytes           int `json:"max_message_bytes"`
+	MaxImportBytes            int `json:"max_import_bytes"`
+	MaxFetchScheduledMessages int `json:"max_fetch_scheduled_messages"`
+	// EncryptionEnabled is whether new content is sealed at rest, and
+	// EncryptionKeyID the fingerprint of the key it is sealed with.
+	EncryptionEnabled bool   `json:"encryption_enabled"`
+	EncryptionKeyID   string `json:"encryption_key_id,omitempty"`
+	// LatePolicy applies to messages more than LateThresholdMinutes late.
+	LatePolicy           string `json:"late_policy"`
+	LateThresholdMinutes int    `json:"late_threshold_minutes"`
+	// MaxChannelPostsPerMinute and MaxPostsPerMinute are the posting rate
+	// limits. Zero means no limit.
+	MaxChannelPostsPerMinute int `json:"max_channel_posts_per_minute"`
+	MaxPostsPerMinute        int `json:"max_posts_per_minute"`
+	// DefaultAttribution is who messages are posted as unless their author
+	// chose, one of the constants.Attribution values.
+	DefaultAttribution string `json:"default_attribution"`
+	// ShowScheduledIndicator is whether posted messages are marked as
+	// scheduled.
+	ShowScheduledIndicator bool `json:"show_scheduled_indicator"`
+}
+
+// ConsistencyIssue is a mismatch between a stored message and its owner's
+// index. Kind is one of the constants.ConsistencyIssue values.
+type ConsistencyIssue struct {
+	Kind      string `json:"kind"`
+	UserID    string `json:"user_id"`
+	MessageID string `json:"message_id"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ConsistencyReport summarizes a consistency scan and the repairs it made.
+type ConsistencyReport struct {
+	Messages int                `json:"messages"`
+	Indexes  int                `json:"indexes"`
+	Repaired []ConsistencyIssue `json:"repaired"`
+	Failed   []ConsistencyIssue `json:"failed"`
+}
+
+// DeliveryPolicy holds the scheduler settings from the plugin configuration.
+type DeliveryPolicy struct {
+	// LatePolicy is one of the constants.LatePolicy values. It applies to
+	// messages more than LateThreshold past their PostAt.
+	LatePolicy    string
+	LateThreshold time.Duration
+	// ChannelRateLimit and GlobalRateLimit cap the posts per minute to one
+	// channel and overall. Zero means no limit.
+	ChannelRateLimit int
+	GlobalRateLimit  int
+	// Attribution is who messages without their own setting are posted
+	// as, one of the constants.Attribution values.
+	Attribution string
+	// ScheduledIndicator adds a note to posted messages saying they were
+	// scheduled.
+	ScheduledIndicator bool
+}
+
+// DeliversBefore reports whether m is posted before other when both are
+// due: earlier DueAt first, to the second, then the order they were
+// scheduled in.
+func (m *ScheduledMessage) DeliversBefore(other *ScheduledMessage) bool {
+	if m.DueAt().Unix() != other.DueAt().Unix() {
+		return m.DueAt().Unix() < other.DueAt().Unix()
+	}
+	if m.Sequence != other.Sequence {
+		return m.Sequence < other.Sequence
+	}
+	return m.ID < other.ID
 }
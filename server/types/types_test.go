@@ -58,3 +58,60 @@ func TestScheduledMessageJSONMissingRootIDDecodesAsEmpty(t *testing.T) {
 		t.Fatalf("expected legacy record to have no root ID, got %q", decoded.RootID)
 	}
 }
+
+func TestScheduledMessageDestinations(t *testing.T) {
+	t.Run("legacy single channel", func(t *testing.T) {
+		msg := ScheduledMessage{ChannelID: "channel1"}
+		got := msg.Destinations()
+		if len(got) != 1 || got[0] != "channel1" {
+			t.Fatalf("expected [channel1], got %v", got)
+		}
+	})
+
+	t.Run("multiple channels", func(t *testing.T) {
+		msg := ScheduledMessage{ChannelID: "channel1", ChannelIDs: []string{"channel1", "channel2"}}
+		got := msg.Destinations()
+		if len(got) != 2 || got[0] != "channel1" || got[1] != "channel2" {
+			t.Fatalf("expected [channel1 channel2], got %v", got)
+		}
+	})
+}
+
+func TestScheduledMessageDeliversBefore(t *testing.T) {
+	at := time.Unix(1700000000, 0).UTC()
+	tests := []struct {
+		name string
+		a, b ScheduledMessage
+		want bool
+	}{
+		{"earlier post time", ScheduledMessage{ID: "b", PostAt: at, Sequence: 2}, ScheduledMessage{ID: "a", PostAt: at.Add(time.Second), Sequence: 1}, true},
+		{"lower sequence", ScheduledMessage{ID: "b", PostAt: at, Sequence: 1}, ScheduledMessage{ID: "a", PostAt: at, Sequence: 2}, true},
+		{"same second", ScheduledMessage{ID: "b", PostAt: at.Add(900 * time.Millisecond), Sequence: 1}, ScheduledMessage{ID: "a", PostAt: at, Sequence: 2}, true},
+		{"ID breaks ties", ScheduledMessage{ID: "a", PostAt: at}, ScheduledMessage{ID: "b", PostAt: at}, true},
+		{"deferred waits", ScheduledMessage{ID: "a", PostAt: at, Sequence: 1, DeferredUntil: at.Add(time.Minute)}, ScheduledMessage{ID: "b", PostAt: at.Add(time.Second), Sequence: 2}, false},
+		{"later sequence", ScheduledMessage{ID: "a", PostAt: at, Sequence: 2}, ScheduledMessage{ID: "b", PostAt: at, Sequence: 1}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.a.DeliversBefore(&tt.b); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestScheduledMessageNextActionAt(t *testing.T) {
+	at := time.Unix(1700000000, 0).UTC()
+	tests := []struct {
+		name string
+		msg  ScheduledMessage
+		want time.Time
+	}{
+		{"no heads-up", ScheduledMessage{PostAt: at}, at},
+		{"heads-up pending", ScheduledMessage{PostAt: at, WarnBefore: 10 * time.Minute}, at.Add(-10 * time.Minute)},
+		{"heads-up sent", ScheduledMessage{PostAt: at, WarnBefore: 10 * time.Minute, WarnedAt: at.Add(-10 * time.Minute)}, at},
+	}
+	for _, tt := range tests {
+		if got := tt.msg.NextActionAt(); !got.Equal(tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}